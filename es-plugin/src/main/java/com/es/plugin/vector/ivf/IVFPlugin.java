@@ -1,19 +1,45 @@
 package com.es.plugin.vector.ivf;
 
+import org.elasticsearch.action.ActionRequest;
+import org.elasticsearch.action.ActionResponse;
+import org.elasticsearch.cluster.metadata.IndexNameExpressionResolver;
+import org.elasticsearch.cluster.node.DiscoveryNodes;
+import org.elasticsearch.cluster.service.ClusterService;
+import org.elasticsearch.common.io.stream.NamedWriteableRegistry;
+import org.elasticsearch.common.settings.ClusterSettings;
+import org.elasticsearch.common.settings.IndexScopedSettings;
+import org.elasticsearch.common.settings.Settings;
+import org.elasticsearch.common.settings.SettingsFilter;
+import org.elasticsearch.features.NodeFeature;
+import org.elasticsearch.plugins.ActionPlugin;
 import org.elasticsearch.plugins.Plugin;
 import org.elasticsearch.plugins.SearchPlugin;
 import org.elasticsearch.common.settings.Setting;
 import org.elasticsearch.index.mapper.Mapper;
 import org.elasticsearch.plugins.MapperPlugin;
 import org.elasticsearch.plugins.ScriptPlugin;
+import org.elasticsearch.rest.RestController;
+import org.elasticsearch.rest.RestHandler;
 
 import java.util.Collection;
 import java.util.Collections;
 import java.util.List;
 import java.util.Map;
 import java.util.function.Function;
+import java.util.function.Predicate;
+import java.util.function.Supplier;
 
-public class IVFPlugin extends Plugin implements MapperPlugin, SearchPlugin {
+public class IVFPlugin extends Plugin implements ActionPlugin, MapperPlugin, SearchPlugin {
+
+    private ClusterService clusterService;
+
+    @Override
+    public Collection<?> createComponents(PluginServices services) {
+        clusterService = services.clusterService();
+        // Trained IVF indexes are saved next to the shards of this node
+        IVFQueryBuilder.setStorageDirectory(services.environment().dataFiles()[0].resolve("ivf"));
+        return Collections.emptyList();
+    }
 
     @Override
     public Map<String, Mapper.TypeParser> getMappers() {
@@ -27,6 +53,25 @@ public class IVFPlugin extends Plugin implements MapperPlugin, SearchPlugin {
         );
     }
 
+    @Override
+    public List<RestHandler> getRestHandlers(Settings settings,
+                                             NamedWriteableRegistry namedWriteableRegistry,
+                                             RestController restController,
+                                             ClusterSettings clusterSettings,
+                                             IndexScopedSettings indexScopedSettings,
+                                             SettingsFilter settingsFilter,
+                                             IndexNameExpressionResolver indexNameExpressionResolver,
+                                             Supplier<DiscoveryNodes> nodesInCluster,
+                                             Predicate<NodeFeature> clusterSupportsFeature) {
+        return List.of(new RestTrainIVFIndexAction(),
+            new RestIVFIndexStatsAction(clusterService, indexNameExpressionResolver));
+    }
+
+    @Override
+    public List<ActionHandler<? extends ActionRequest, ? extends ActionResponse>> getActions() {
+        return List.of(new ActionHandler<>(TrainIVFIndexAction.INSTANCE, TransportTrainIVFIndexAction.class));
+    }
+
     @Override
     public List<Setting<?>> getSettings() {
         return Collections.emptyList();
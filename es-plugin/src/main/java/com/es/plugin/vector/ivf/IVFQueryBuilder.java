@@ -14,6 +14,8 @@ import org.elasticsearch.index.query.AbstractQueryBuilder;
 import org.elasticsearch.index.query.SearchExecutionContext;
 
 import java.io.IOException;
+import java.nio.file.Files;
+import java.nio.file.Path;
 import java.util.Objects;
 import java.util.List;
 import java.util.Map;
@@ -22,9 +24,12 @@ import java.util.concurrent.ConcurrentHashMap;
 public class IVFQueryBuilder extends AbstractQueryBuilder<IVFQueryBuilder> {
     public static final String NAME = "ann";
 
-    // Cache for IVF indexes (index_name -> IVF index)
+    // Cache for IVF indexes (concrete index name + "_" + field -> IVF index)
     private static final Map<String, InvertedFileIndex> indexCache = new ConcurrentHashMap<>();
 
+    // Directory the IVF indexes of this node are saved in, set by IVFPlugin from the node's data path
+    private static volatile Path storageDirectory;
+
     private String field;
     private float[] vector;
     private String algorithm = "ivf";
@@ -105,9 +110,13 @@ public class IVFQueryBuilder extends AbstractQueryBuilder<IVFQueryBuilder> {
 
     @Override
     protected Query doToQuery(SearchExecutionContext context) throws IOException {
-        // Get or create IVF index for this field
-        String indexName = context.index().getName() + "_" + field;
-        InvertedFileIndex ivfIndex = getOrCreateIndex(indexName, context);
+        // The IVF index is keyed by the concrete index, so searches through an alias find it too
+        String indexKey = indexKey(context.index().getName(), field);
+        InvertedFileIndex ivfIndex = getIndexIfPresent(indexKey);
+        if (ivfIndex == null || !ivfIndex.isTrained()) {
+            throw new IllegalArgumentException("field [" + field + "] of index [" + context.index().getName()
+                + "] has no trained IVF index on this node, call _ivf/train first");
+        }
 
         // Perform IVF search
         List<InvertedFileIndex.SearchResult> results = ivfIndex.search(vector, k, nprobe);
@@ -130,64 +139,73 @@ public class IVFQueryBuilder extends AbstractQueryBuilder<IVFQueryBuilder> {
     }
 
     /**
-     * Get or create IVF index for the field.
-     * This is a simplified implementation - in production, you'd want to:
-     * 1. Load index from persistent storage
-     * 2. Build index incrementally as documents are added
-     * 3. Handle index updates and deletions
+     * Set the directory IVF indexes are saved in, normally the ivf directory under the node's data path.
      */
-    private InvertedFileIndex getOrCreateIndex(String indexName, SearchExecutionContext context) throws IOException {
-        return indexCache.computeIfAbsent(indexName, key -> {
-            try {
-                // Try to load existing index from file
-                String indexPath = getIndexPath(indexName);
-                java.io.File indexFile = new java.io.File(indexPath);
-
-                if (indexFile.exists()) {
-                    System.out.println("Loading existing IVF index from: " + indexPath);
-                    return InvertedFileIndex.load(indexPath);
-                } else {
-                    System.out.println("Creating new IVF index: " + indexName);
-                    // Create new index with default parameters
-                    // In production, these should come from index settings
-                    InvertedFileIndex newIndex = new InvertedFileIndex(100, vector.length, "l2");
-
-                    // Index needs to be trained before use
-                    // This is a placeholder - in production, training should happen
-                    // when enough documents are indexed
-                    System.out.println("Warning: IVF index not trained yet. Returning empty results.");
-
-                    return newIndex;
-                }
-            } catch (Exception e) {
-                throw new RuntimeException("Failed to load/create IVF index", e);
-            }
-        });
+    public static void setStorageDirectory(Path directory) {
+        storageDirectory = directory;
+    }
+
+    /**
+     * Key of the IVF index of a vector field. Training, search, stats and indexing all
+     * use the concrete index name, never an alias.
+     */
+    public static String indexKey(String concreteIndex, String field) {
+        return concreteIndex + "_" + field;
     }
 
     /**
-     * Get file path for storing IVF index.
+     * Get the file path an IVF index is saved to.
      */
-    private String getIndexPath(String indexName) {
-        // In production, this should use Elasticsearch's data directory
-        String dataDir = System.getProperty("es.path.data", "/tmp/es-ivf-indexes");
-        return dataDir + "/" + indexName + ".ivf";
+    private static Path indexPath(String indexKey) {
+        Path directory = storageDirectory;
+        if (directory == null) {
+            throw new IllegalStateException("IVF storage directory is not configured");
+        }
+        return directory.resolve(indexKey + ".ivf");
+    }
+
+    /**
+     * Return the IVF index with the given key on this node, loading a saved index
+     * from disk if it is not cached yet.
+     *
+     * @return The index, or null if there is no IVF index with this key on this node
+     */
+    public static InvertedFileIndex getIndexIfPresent(String indexKey) throws IOException {
+        InvertedFileIndex index = indexCache.get(indexKey);
+        if (index != null) {
+            return index;
+        }
+
+        Path indexFile = indexPath(indexKey);
+        if (!Files.exists(indexFile)) {
+            return null;
+        }
+        try {
+            return indexCache.computeIfAbsent(indexKey, key -> {
+                try {
+                    return InvertedFileIndex.load(indexFile.toString());
+                } catch (IOException | ClassNotFoundException e) {
+                    throw new RuntimeException(e);
+                }
+            });
+        } catch (RuntimeException e) {
+            throw new IOException("Failed to load IVF index " + indexKey, e.getCause());
+        }
     }
 
     /**
      * Static method to manually add vectors to an index.
      * This should be called during document indexing.
      */
-    public static void addVectorToIndex(String indexName, String docId, float[] vector, Map<String, Object> metadata) {
+    public static void addVectorToIndex(String indexKey, String docId, float[] vector, Map<String, Object> metadata) {
         try {
-            InvertedFileIndex index = indexCache.get(indexName);
+            InvertedFileIndex index = indexCache.get(indexKey);
             if (index != null && index.isTrained()) {
                 index.addVector(docId, vector, metadata);
 
                 // Periodically save index to disk
                 if (index.size() % 1000 == 0) {
-                    String indexPath = "/tmp/es-ivf-indexes/" + indexName + ".ivf";
-                    index.save(indexPath);
+                    index.save(indexPath(indexKey).toString());
                 }
             }
         } catch (Exception e) {
@@ -199,23 +217,42 @@ public class IVFQueryBuilder extends AbstractQueryBuilder<IVFQueryBuilder> {
      * Static method to train an index with vectors.
      * Should be called when index is created or when sufficient vectors are available.
      */
-    public static void trainIndex(String indexName, float[][] trainingVectors, int dimension, String metricType) {
+    public static void trainIndex(String indexKey, float[][] trainingVectors, int dimension, String metricType) {
         try {
-            InvertedFileIndex index = new InvertedFileIndex(100, dimension, metricType);
-            index.train(trainingVectors);
-            indexCache.put(indexName, index);
-
-            // Save trained index
-            String indexPath = "/tmp/es-ivf-indexes/" + indexName + ".ivf";
-            new java.io.File("/tmp/es-ivf-indexes").mkdirs();
-            index.save(indexPath);
-
-            System.out.println("IVF index trained and saved: " + indexName);
+            trainIndex(indexKey, trainingVectors, 100, 100, dimension, metricType);
         } catch (Exception e) {
             System.err.println("Failed to train IVF index: " + e.getMessage());
         }
     }
 
+    /**
+     * Train an index with the given number of clusters and iterations, replace the cached
+     * index and save it. Errors are thrown to the caller. The inverted lists stay empty,
+     * use {@link TransportTrainIVFIndexAction} to also add the vectors of the index.
+     *
+     * @return The trained index
+     */
+    public static InvertedFileIndex trainIndex(String indexKey, float[][] trainingVectors, int nlist,
+                                               int maxIterations, int dimension, String metricType) throws IOException {
+        InvertedFileIndex index = new InvertedFileIndex(nlist, dimension, metricType);
+        index.train(trainingVectors, maxIterations);
+        publishIndex(indexKey, index);
+        return index;
+    }
+
+    /**
+     * Save a trained index and replace the cached index with the same key, so searches
+     * never see a partly filled index.
+     */
+    public static void publishIndex(String indexKey, InvertedFileIndex index) throws IOException {
+        Path indexFile = indexPath(indexKey);
+        Files.createDirectories(indexFile.getParent());
+        index.save(indexFile.toString());
+        indexCache.put(indexKey, index);
+
+        System.out.println("IVF index trained and saved: " + indexKey);
+    }
+
     @Override
     protected boolean doEquals(IVFQueryBuilder other) {
         return Objects.equals(field, other.field) &&
@@ -79,13 +79,23 @@ public class InvertedFileIndex implements Serializable {
      * @param trainingVectors Vectors to use for training
      */
     public void train(float[][] trainingVectors) {
+        train(trainingVectors, 100);
+    }
+
+    /**
+     * Train the index using KMeans clustering with a bounded number of iterations.
+     *
+     * @param trainingVectors Vectors to use for training
+     * @param maxIterations Maximum number of KMeans iterations
+     */
+    public void train(float[][] trainingVectors, int maxIterations) {
         if (trainingVectors == null || trainingVectors.length == 0) {
             throw new IllegalArgumentException("Training vectors cannot be empty");
         }
 
         System.out.println("Training IVF index with " + trainingVectors.length + " vectors...");
 
-        SimpleKMeansTrainer trainer = new SimpleKMeansTrainer(nlist);
+        SimpleKMeansTrainer trainer = new SimpleKMeansTrainer(nlist, maxIterations);
         this.centroids = trainer.train(trainingVectors);
         this.isTrained = true;
 
@@ -317,6 +327,34 @@ public class InvertedFileIndex implements Serializable {
         }
     }
 
+    /**
+     * Sum of squared L2 distances from each vector to its nearest centroid.
+     *
+     * @param vectors Vectors to measure, usually the training sample
+     */
+    public double inertia(float[][] vectors) {
+        if (!isTrained) {
+            throw new IllegalStateException("Index must be trained before measuring inertia");
+        }
+
+        double inertia = 0;
+        for (float[] vector : vectors) {
+            float minDistance = Float.MAX_VALUE;
+            for (float[] centroid : centroids) {
+                minDistance = Math.min(minDistance, VectorSimilarity.l2Distance(vector, centroid));
+            }
+            inertia += (double) minDistance * minDistance;
+        }
+        return inertia;
+    }
+
+    /**
+     * Get number of trained centroids.
+     */
+    public int centroidCount() {
+        return centroids == null ? 0 : centroids.length;
+    }
+
     /**
      * Check if index is trained.
      */
@@ -0,0 +1,69 @@
+package com.es.plugin.vector.ivf;
+
+import org.elasticsearch.action.support.IndicesOptions;
+import org.elasticsearch.client.internal.node.NodeClient;
+import org.elasticsearch.cluster.metadata.IndexNameExpressionResolver;
+import org.elasticsearch.cluster.service.ClusterService;
+import org.elasticsearch.rest.BaseRestHandler;
+import org.elasticsearch.rest.RestRequest;
+import org.elasticsearch.rest.RestResponse;
+import org.elasticsearch.rest.RestStatus;
+import org.elasticsearch.xcontent.XContentBuilder;
+
+import java.io.IOException;
+import java.util.Collections;
+import java.util.List;
+
+import static org.elasticsearch.rest.RestRequest.Method.GET;
+
+/**
+ * REST endpoint GET /{index}/_ivf/stats?field=vector.
+ * Returns the statistics of the IVF index of the field held by the node that receives the request,
+ * or 404 if the index has no IVF index on that node. An alias is resolved to its concrete index.
+ *
+ * Response: {"nlist": 100, "dimension": 128, "metricType": "l2", "isTrained": true,
+ *            "totalVectors": 10000, "minClusterSize": 50, "maxClusterSize": 150, "avgClusterSize": 100.0}
+ */
+public class RestIVFIndexStatsAction extends BaseRestHandler {
+
+    private final ClusterService clusterService;
+    private final IndexNameExpressionResolver indexNameExpressionResolver;
+
+    public RestIVFIndexStatsAction(ClusterService clusterService, IndexNameExpressionResolver indexNameExpressionResolver) {
+        this.clusterService = clusterService;
+        this.indexNameExpressionResolver = indexNameExpressionResolver;
+    }
+
+    @Override
+    public String getName() {
+        return "ivf_stats_action";
+    }
+
+    @Override
+    public List<Route> routes() {
+        return Collections.singletonList(new Route(GET, "/{index}/_ivf/stats"));
+    }
+
+    @Override
+    protected RestChannelConsumer prepareRequest(RestRequest request, NodeClient client) throws IOException {
+        String indexName = request.param("index");
+        String field = request.param("field", "vector");
+        String concreteIndex = indexNameExpressionResolver.concreteIndexNames(clusterService.state(),
+            IndicesOptions.strictSingleIndexNoExpandForbidClosed(), indexName)[0];
+        InvertedFileIndex index = IVFQueryBuilder.getIndexIfPresent(IVFQueryBuilder.indexKey(concreteIndex, field));
+
+        return channel -> {
+            XContentBuilder builder = channel.newBuilder();
+            if (index == null) {
+                builder.startObject()
+                    .field("error", "no IVF index for field [" + field + "] of [" + concreteIndex + "]")
+                    .field("status", RestStatus.NOT_FOUND.getStatus())
+                    .endObject();
+                channel.sendResponse(new RestResponse(RestStatus.NOT_FOUND, builder));
+                return;
+            }
+            builder.map(index.getStats());
+            channel.sendResponse(new RestResponse(RestStatus.OK, builder));
+        };
+    }
+}
@@ -0,0 +1,66 @@
+package com.es.plugin.vector.ivf;
+
+import org.elasticsearch.client.internal.node.NodeClient;
+import org.elasticsearch.common.xcontent.XContentHelper;
+import org.elasticsearch.rest.BaseRestHandler;
+import org.elasticsearch.rest.RestRequest;
+import org.elasticsearch.rest.action.RestToXContentListener;
+
+import java.io.IOException;
+import java.util.Collections;
+import java.util.List;
+import java.util.Map;
+
+import static org.elasticsearch.rest.RestRequest.Method.POST;
+
+/**
+ * REST endpoint POST /{index}/_ivf/train.
+ * Parses the body and runs {@link TrainIVFIndexAction}, which samples the field's vectors, trains
+ * the IVF centroids, fills the inverted lists with the vectors of the index and replaces the cached
+ * index of this node under the concrete index name, so an alias can be trained too.
+ *
+ * Body: {"field": "vector", "nlist": 100, "metric": "l2", "sample_size": 5000, "max_iterations": 100}
+ */
+public class RestTrainIVFIndexAction extends BaseRestHandler {
+
+    // Samples are fetched in a single search, so they are bounded by index.max_result_window
+    private static final int MAX_SAMPLE_SIZE = 10000;
+
+    @Override
+    public String getName() {
+        return "ivf_train_action";
+    }
+
+    @Override
+    public List<Route> routes() {
+        return Collections.singletonList(new Route(POST, "/{index}/_ivf/train"));
+    }
+
+    @Override
+    protected RestChannelConsumer prepareRequest(RestRequest request, NodeClient client) throws IOException {
+        String indexName = request.param("index");
+        Map<String, Object> body = request.hasContent()
+            ? XContentHelper.convertToMap(request.requiredContent(), false, request.getXContentType()).v2()
+            : Collections.emptyMap();
+
+        String field = stringParam(body, "field", "vector");
+        String metric = stringParam(body, "metric", "l2");
+        int nlist = intParam(body, "nlist", 100);
+        int maxIterations = intParam(body, "max_iterations", 100);
+        int sampleSize = Math.min(intParam(body, "sample_size", nlist * 50), MAX_SAMPLE_SIZE);
+
+        TrainIVFIndexAction.Request trainRequest = new TrainIVFIndexAction.Request(indexName, field, nlist, metric,
+            sampleSize, maxIterations);
+        return channel -> client.execute(TrainIVFIndexAction.INSTANCE, trainRequest, new RestToXContentListener<>(channel));
+    }
+
+    private static String stringParam(Map<String, Object> body, String name, String defaultValue) {
+        Object value = body.get(name);
+        return value == null ? defaultValue : value.toString();
+    }
+
+    private static int intParam(Map<String, Object> body, String name, int defaultValue) {
+        Object value = body.get(name);
+        return value instanceof Number number ? number.intValue() : defaultValue;
+    }
+}
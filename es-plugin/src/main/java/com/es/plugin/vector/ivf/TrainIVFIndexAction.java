@@ -32,14 +32,23 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
         private String fieldName;
         private int nlist;
         private String metric;
+        private int sampleSize;
+        private int maxIterations = 100;
 
         public Request() {}
 
         public Request(String indexName, String fieldName, int nlist, String metric) {
+            this(indexName, fieldName, nlist, metric, nlist * 50, 100);
+        }
+
+        public Request(String indexName, String fieldName, int nlist, String metric,
+                       int sampleSize, int maxIterations) {
             this.indexName = indexName;
             this.fieldName = fieldName;
             this.nlist = nlist;
             this.metric = metric;
+            this.sampleSize = sampleSize;
+            this.maxIterations = maxIterations;
         }
 
         public Request(StreamInput in) throws IOException {
@@ -48,6 +57,8 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
             this.fieldName = in.readString();
             this.nlist = in.readInt();
             this.metric = in.readString();
+            this.sampleSize = in.readInt();
+            this.maxIterations = in.readInt();
         }
 
         @Override
@@ -57,6 +68,8 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
             out.writeString(fieldName);
             out.writeInt(nlist);
             out.writeString(metric);
+            out.writeInt(sampleSize);
+            out.writeInt(maxIterations);
         }
 
         @Override
@@ -71,6 +84,16 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
                 e.addValidationError("fieldName is required");
                 return e;
             }
+            if (nlist <= 0 || maxIterations <= 0) {
+                ActionRequestValidationException e = new ActionRequestValidationException();
+                e.addValidationError("nlist and max_iterations must be positive");
+                return e;
+            }
+            if (sampleSize < nlist) {
+                ActionRequestValidationException e = new ActionRequestValidationException();
+                e.addValidationError("sample_size " + sampleSize + " is smaller than nlist " + nlist);
+                return e;
+            }
             return null;
         }
 
@@ -89,6 +112,14 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
         public String getMetric() {
             return metric;
         }
+
+        public int getSampleSize() {
+            return sampleSize;
+        }
+
+        public int getMaxIterations() {
+            return maxIterations;
+        }
     }
 
     /**
@@ -99,14 +130,25 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
         private String message;
         private int vectorCount;
         private int nlist;
+        private int centroidCount;
+        private double inertia;
+        private long trainingTimeMs;
 
         public Response() {}
 
         public Response(boolean success, String message, int vectorCount, int nlist) {
+            this(success, message, vectorCount, nlist, 0, 0, 0);
+        }
+
+        public Response(boolean success, String message, int vectorCount, int nlist,
+                        int centroidCount, double inertia, long trainingTimeMs) {
             this.success = success;
             this.message = message;
             this.vectorCount = vectorCount;
             this.nlist = nlist;
+            this.centroidCount = centroidCount;
+            this.inertia = inertia;
+            this.trainingTimeMs = trainingTimeMs;
         }
 
         public Response(StreamInput in) throws IOException {
@@ -115,6 +157,9 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
             this.message = in.readString();
             this.vectorCount = in.readInt();
             this.nlist = in.readInt();
+            this.centroidCount = in.readInt();
+            this.inertia = in.readDouble();
+            this.trainingTimeMs = in.readLong();
         }
 
         @Override
@@ -123,6 +168,9 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
             out.writeString(message);
             out.writeInt(vectorCount);
             out.writeInt(nlist);
+            out.writeInt(centroidCount);
+            out.writeDouble(inertia);
+            out.writeLong(trainingTimeMs);
         }
 
         @Override
@@ -132,6 +180,9 @@ public class TrainIVFIndexAction extends ActionType<TrainIVFIndexAction.Response
             builder.field("message", message);
             builder.field("vector_count", vectorCount);
             builder.field("nlist", nlist);
+            builder.field("centroid_count", centroidCount);
+            builder.field("inertia", inertia);
+            builder.field("training_time_ms", trainingTimeMs);
             builder.endObject();
             return builder;
         }
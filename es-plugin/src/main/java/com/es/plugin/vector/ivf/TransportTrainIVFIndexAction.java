@@ -0,0 +1,178 @@
+package com.es.plugin.vector.ivf;
+
+import org.elasticsearch.action.ActionListener;
+import org.elasticsearch.action.ActionRunnable;
+import org.elasticsearch.action.search.ClearScrollRequest;
+import org.elasticsearch.action.search.SearchRequest;
+import org.elasticsearch.action.search.SearchResponse;
+import org.elasticsearch.action.search.SearchScrollRequest;
+import org.elasticsearch.action.support.ActionFilters;
+import org.elasticsearch.action.support.HandledTransportAction;
+import org.elasticsearch.action.support.IndicesOptions;
+import org.elasticsearch.client.internal.node.NodeClient;
+import org.elasticsearch.cluster.metadata.IndexNameExpressionResolver;
+import org.elasticsearch.cluster.service.ClusterService;
+import org.elasticsearch.common.inject.Inject;
+import org.elasticsearch.common.util.concurrent.EsExecutors;
+import org.elasticsearch.core.TimeValue;
+import org.elasticsearch.index.query.QueryBuilders;
+import org.elasticsearch.index.query.functionscore.RandomScoreFunctionBuilder;
+import org.elasticsearch.search.SearchHit;
+import org.elasticsearch.search.builder.SearchSourceBuilder;
+import org.elasticsearch.tasks.Task;
+import org.elasticsearch.threadpool.ThreadPool;
+import org.elasticsearch.transport.TransportService;
+
+import java.io.IOException;
+import java.util.ArrayList;
+import java.util.List;
+
+/**
+ * Transport action behind POST /{index}/_ivf/train.
+ * Resolves the index to its concrete index, samples vectors of the field with a random_score
+ * query, trains the centroids with KMeans, adds every vector of the index to the inverted lists
+ * and replaces the IVF index cached on this node. Training runs on the generic thread pool.
+ */
+public class TransportTrainIVFIndexAction extends HandledTransportAction<TrainIVFIndexAction.Request, TrainIVFIndexAction.Response> {
+
+    // Page size of the scroll that fills the inverted lists
+    private static final int SCROLL_SIZE = 1000;
+    private static final TimeValue SCROLL_KEEP_ALIVE = TimeValue.timeValueMinutes(5);
+
+    private final ClusterService clusterService;
+    private final IndexNameExpressionResolver indexNameExpressionResolver;
+    private final NodeClient client;
+    private final ThreadPool threadPool;
+
+    @Inject
+    public TransportTrainIVFIndexAction(TransportService transportService, ActionFilters actionFilters,
+                                        ClusterService clusterService,
+                                        IndexNameExpressionResolver indexNameExpressionResolver,
+                                        NodeClient client) {
+        super(TrainIVFIndexAction.NAME, transportService, actionFilters, TrainIVFIndexAction.Request::new,
+            EsExecutors.DIRECT_EXECUTOR_SERVICE);
+        this.clusterService = clusterService;
+        this.indexNameExpressionResolver = indexNameExpressionResolver;
+        this.client = client;
+        this.threadPool = transportService.getThreadPool();
+    }
+
+    @Override
+    protected void doExecute(Task task, TrainIVFIndexAction.Request request,
+                             ActionListener<TrainIVFIndexAction.Response> listener) {
+        // KMeans is CPU bound and the searches below block, keep both off the transport thread
+        threadPool.generic().execute(ActionRunnable.supply(listener, () -> train(request)));
+    }
+
+    private TrainIVFIndexAction.Response train(TrainIVFIndexAction.Request request) throws IOException {
+        // An alias created by a reindex resolves to its single backing index
+        String concreteIndex = indexNameExpressionResolver.concreteIndexNames(clusterService.state(),
+            IndicesOptions.strictSingleIndexNoExpandForbidClosed(), request.getIndexName())[0];
+        String field = request.getFieldName();
+        int nlist = request.getNlist();
+
+        float[][] vectors = sampleVectors(concreteIndex, field, request.getSampleSize());
+        if (vectors.length < nlist) {
+            throw new IllegalArgumentException(vectors.length + " vectors are not enough to train nlist=" + nlist);
+        }
+
+        long start = System.currentTimeMillis();
+        InvertedFileIndex index = new InvertedFileIndex(nlist, vectors[0].length, request.getMetric());
+        index.train(vectors, request.getMaxIterations());
+        int added = addIndexVectors(index, concreteIndex, field, vectors[0].length);
+        IVFQueryBuilder.publishIndex(IVFQueryBuilder.indexKey(concreteIndex, field), index);
+        long elapsed = System.currentTimeMillis() - start;
+
+        return new TrainIVFIndexAction.Response(true,
+            "IVF index of [" + concreteIndex + "] trained successfully with " + added + " indexed vectors",
+            vectors.length, nlist, index.centroidCount(), index.inertia(vectors), elapsed);
+    }
+
+    /**
+     * Fetch a random sample of the field's vectors in a single search, so the sample size
+     * is bounded by index.max_result_window.
+     */
+    private float[][] sampleVectors(String concreteIndex, String field, int sampleSize) {
+        SearchRequest searchRequest = new SearchRequest(concreteIndex).source(new SearchSourceBuilder()
+            .size(sampleSize)
+            .fetchSource(field, null)
+            .query(QueryBuilders.functionScoreQuery(
+                QueryBuilders.existsQuery(field),
+                new RandomScoreFunctionBuilder().seed(42).setField("_seq_no"))));
+
+        SearchResponse response = client.search(searchRequest).actionGet();
+        try {
+            List<float[]> vectors = new ArrayList<>();
+            for (SearchHit hit : response.getHits().getHits()) {
+                float[] vector = readVector(hit, field);
+                if (vector != null) {
+                    vectors.add(vector);
+                }
+            }
+            return vectors.toArray(new float[0][]);
+        } finally {
+            response.decRef();
+        }
+    }
+
+    /**
+     * Scroll through every document of the index and add its vector to the inverted lists.
+     * Vectors with another dimension are skipped.
+     *
+     * @return The number of vectors added
+     */
+    private int addIndexVectors(InvertedFileIndex index, String concreteIndex, String field, int dimension) {
+        SearchRequest searchRequest = new SearchRequest(concreteIndex)
+            .scroll(SCROLL_KEEP_ALIVE)
+            .source(new SearchSourceBuilder()
+                .size(SCROLL_SIZE)
+                .fetchSource(field, null)
+                .query(QueryBuilders.existsQuery(field))
+                .sort("_doc"));
+
+        int added = 0;
+        String scrollId = null;
+        SearchResponse response = client.search(searchRequest).actionGet();
+        try {
+            while (true) {
+                scrollId = response.getScrollId();
+                SearchHit[] hits = response.getHits().getHits();
+                if (hits.length == 0) {
+                    return added;
+                }
+                for (SearchHit hit : hits) {
+                    float[] vector = readVector(hit, field);
+                    if (vector != null && vector.length == dimension) {
+                        index.addVector(hit.getId(), vector, null);
+                        added++;
+                    }
+                }
+
+                response.decRef();
+                response = null;
+                response = client.searchScroll(new SearchScrollRequest(scrollId).scroll(SCROLL_KEEP_ALIVE)).actionGet();
+            }
+        } finally {
+            if (response != null) {
+                response.decRef();
+            }
+            if (scrollId != null) {
+                ClearScrollRequest clearScroll = new ClearScrollRequest();
+                clearScroll.addScrollId(scrollId);
+                client.clearScroll(clearScroll).actionGet();
+            }
+        }
+    }
+
+    private static float[] readVector(SearchHit hit, String field) {
+        Object value = hit.getSourceAsMap().get(field);
+        if (!(value instanceof List<?> list) || list.isEmpty()) {
+            return null;
+        }
+        float[] vector = new float[list.size()];
+        for (int i = 0; i < vector.length; i++) {
+            vector[i] = ((Number) list.get(i)).floatValue();
+        }
+        return vector;
+    }
+}
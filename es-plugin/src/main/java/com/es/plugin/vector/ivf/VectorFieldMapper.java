@@ -183,7 +183,7 @@ public class VectorFieldMapper extends ParametrizedFieldMapper {
 
             // Add vector to IVF index
             try {
-                String indexName = context.index().getName() + "_" + fieldType().name();
+                String indexName = IVFQueryBuilder.indexKey(context.index().getName(), fieldType().name());
                 String docId = context.id();
 
                 // Extract metadata from source
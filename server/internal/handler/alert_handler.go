@@ -0,0 +1,370 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type AlertHandler struct {
+	alertService *service.AlertService
+}
+
+func NewAlertHandler(alertService *service.AlertService) *AlertHandler {
+	return &AlertHandler{
+		alertService: alertService,
+	}
+}
+
+// ListAlerts lists alerts
+// ListAlerts 列出告警
+// @Summary List alerts
+// @Description List alerts, newest first. Without state only pending and firing alerts are returned.
+// @Tags alerts
+// @Produce json
+// @Param tenant_org_id query string false "Tenant organization"
+// @Param namespace query string false "Cluster namespace"
+// @Param state query string false "pending, firing, resolved or all"
+// @Success 200 {array} model.Alert
+// @Failure 400 {string} string "Bad Request"
+// @Router /alerts [get]
+func (h *AlertHandler) ListAlerts(c *gin.Context) {
+	alerts, err := h.alertService.ListAlerts(c.Query("tenant_org_id"), c.Query("namespace"), c.Query("state"))
+	if err != nil {
+		writeAlertError(c, err, "alert")
+		return
+	}
+
+	c.JSON(http.StatusOK, alerts)
+}
+
+// CreateRule creates an alert rule
+// CreateRule 创建告警规则
+// @Summary Create an alert rule
+// @Description Create a threshold, rate-of-change or absence rule for one tenant, or a global rule when tenant_org_id is empty
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param rule body model.AlertRuleRequest true "Rule definition"
+// @Success 200 {object} model.AlertRule
+// @Failure 400 {string} string "Bad Request"
+// @Router /alerts/rules [post]
+func (h *AlertHandler) CreateRule(c *gin.Context) {
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertService.CreateRule(req)
+	if err != nil {
+		writeAlertError(c, err, "rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// ListRules lists alert rules
+// ListRules 列出告警规则
+// @Summary List alert rules
+// @Description List the rules of a tenant together with the global rules, or every rule without tenant_org_id
+// @Tags alerts
+// @Produce json
+// @Param tenant_org_id query string false "Tenant organization"
+// @Success 200 {array} model.AlertRule
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /alerts/rules [get]
+func (h *AlertHandler) ListRules(c *gin.Context) {
+	rules, err := h.alertService.ListRules(c.Query("tenant_org_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// GetRule gets an alert rule
+// GetRule 获取告警规则
+// @Summary Get an alert rule
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} model.AlertRule
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/rules/{id} [get]
+func (h *AlertHandler) GetRule(c *gin.Context) {
+	rule, err := h.alertService.GetRule(c.Param("id"))
+	if err != nil {
+		writeAlertError(c, err, "rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// UpdateRule updates an alert rule
+// UpdateRule 更新告警规则
+// @Summary Update an alert rule
+// @Description Replace the definition of an alert rule
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Param rule body model.AlertRuleRequest true "Rule definition"
+// @Success 200 {object} model.AlertRule
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/rules/{id} [put]
+func (h *AlertHandler) UpdateRule(c *gin.Context) {
+	var req model.AlertRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rule, err := h.alertService.UpdateRule(c.Param("id"), req)
+	if err != nil {
+		writeAlertError(c, err, "rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, rule)
+}
+
+// DeleteRule deletes an alert rule
+// DeleteRule 删除告警规则
+// @Summary Delete an alert rule
+// @Description Delete an alert rule together with its alerts
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/rules/{id} [delete]
+func (h *AlertHandler) DeleteRule(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.alertService.DeleteRule(id); err != nil {
+		writeAlertError(c, err, "rule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert rule deleted successfully",
+		"id":      id,
+		"status":  "deleted",
+	})
+}
+
+// CreateChannel creates a notification channel
+// CreateChannel 创建通知渠道
+// @Summary Create a notification channel
+// @Description Create a webhook, Slack-compatible or SMTP channel for one tenant, or a global channel when tenant_org_id is empty
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param channel body model.AlertChannelRequest true "Channel definition"
+// @Success 200 {object} model.AlertChannel
+// @Failure 400 {string} string "Bad Request"
+// @Router /alerts/channels [post]
+func (h *AlertHandler) CreateChannel(c *gin.Context) {
+	var req model.AlertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.alertService.CreateChannel(req)
+	if err != nil {
+		writeAlertError(c, err, "channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// ListChannels lists notification channels
+// ListChannels 列出通知渠道
+// @Summary List notification channels
+// @Description List the channels of a tenant together with the global channels; passwords and header values are hidden
+// @Tags alerts
+// @Produce json
+// @Param tenant_org_id query string false "Tenant organization"
+// @Success 200 {array} model.AlertChannel
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /alerts/channels [get]
+func (h *AlertHandler) ListChannels(c *gin.Context) {
+	channels, err := h.alertService.ListChannels(c.Query("tenant_org_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, channels)
+}
+
+// UpdateChannel updates a notification channel
+// UpdateChannel 更新通知渠道
+// @Summary Update a notification channel
+// @Description Replace the definition of a channel; an empty password keeps the stored one
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Param channel body model.AlertChannelRequest true "Channel definition"
+// @Success 200 {object} model.AlertChannel
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/channels/{id} [put]
+func (h *AlertHandler) UpdateChannel(c *gin.Context) {
+	var req model.AlertChannelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	channel, err := h.alertService.UpdateChannel(c.Param("id"), req)
+	if err != nil {
+		writeAlertError(c, err, "channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, channel)
+}
+
+// DeleteChannel deletes a notification channel
+// DeleteChannel 删除通知渠道
+// @Summary Delete a notification channel
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/channels/{id} [delete]
+func (h *AlertHandler) DeleteChannel(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.alertService.DeleteChannel(id); err != nil {
+		writeAlertError(c, err, "channel")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alert channel deleted successfully",
+		"id":      id,
+		"status":  "deleted",
+	})
+}
+
+// TestChannel sends a test notification
+// TestChannel 发送测试通知
+// @Summary Test a notification channel
+// @Description Send a test notification through a channel
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Channel ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Failure 502 {string} string "Bad Gateway"
+// @Router /alerts/channels/{id}/test [post]
+func (h *AlertHandler) TestChannel(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.alertService.TestChannel(c.Request.Context(), id); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			writeAlertError(c, err, "channel")
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Test notification sent",
+		"id":      id,
+	})
+}
+
+// CreateSilence creates an alert silence
+// CreateSilence 创建告警静默
+// @Summary Create an alert silence
+// @Description Suppress notifications of alerts matching the tenant, rule, namespace and severity until ends_at or for duration seconds
+// @Tags alerts
+// @Accept json
+// @Produce json
+// @Param silence body model.AlertSilenceRequest true "Silence definition"
+// @Success 200 {object} model.AlertSilence
+// @Failure 400 {string} string "Bad Request"
+// @Router /alerts/silences [post]
+func (h *AlertHandler) CreateSilence(c *gin.Context) {
+	var req model.AlertSilenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	silence, err := h.alertService.CreateSilence(req)
+	if err != nil {
+		writeAlertError(c, err, "silence")
+		return
+	}
+
+	c.JSON(http.StatusOK, silence)
+}
+
+// ListSilences lists alert silences
+// ListSilences 列出告警静默
+// @Summary List alert silences
+// @Description List the silences that have not ended yet
+// @Tags alerts
+// @Produce json
+// @Param tenant_org_id query string false "Tenant organization"
+// @Success 200 {array} model.AlertSilence
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /alerts/silences [get]
+func (h *AlertHandler) ListSilences(c *gin.Context) {
+	silences, err := h.alertService.ListSilences(c.Query("tenant_org_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, silences)
+}
+
+// ExpireSilence ends an alert silence
+// ExpireSilence 结束告警静默
+// @Summary Expire an alert silence
+// @Description End a silence immediately
+// @Tags alerts
+// @Produce json
+// @Param id path string true "Silence ID"
+// @Success 200 {object} model.AlertSilence
+// @Failure 404 {string} string "Not Found"
+// @Router /alerts/silences/{id} [delete]
+func (h *AlertHandler) ExpireSilence(c *gin.Context) {
+	silence, err := h.alertService.ExpireSilence(c.Param("id"))
+	if err != nil {
+		writeAlertError(c, err, "silence")
+		return
+	}
+
+	c.JSON(http.StatusOK, silence)
+}
+
+// writeAlertError maps alert service errors to HTTP responses
+// writeAlertError 将告警服务错误映射为 HTTP 响应
+func writeAlertError(c *gin.Context, err error, kind string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " not found"})
+	case errors.Is(err, service.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,157 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type AliasHandler struct {
+	aliasService *service.AliasService
+}
+
+func NewAliasHandler(aliasService *service.AliasService) *AliasHandler {
+	return &AliasHandler{
+		aliasService: aliasService,
+	}
+}
+
+// CreateAlias creates an index alias
+// CreateAlias 创建索引别名
+// @Summary Create an index alias
+// @Description Create an alias pointing at one or more physical indices
+// @Tags aliases
+// @Accept json
+// @Produce json
+// @Param alias body model.AliasRequest true "Alias definition"
+// @Success 200 {object} model.IndexAlias
+// @Failure 400 {string} string "Bad Request"
+// @Failure 409 {string} string "Conflict"
+// @Router /vectors/aliases [post]
+func (h *AliasHandler) CreateAlias(c *gin.Context) {
+	var req model.AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := h.aliasService.CreateAlias(req)
+	if err != nil {
+		writeAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// ListAliases lists index aliases
+// ListAliases 列出索引别名
+// @Summary List index aliases
+// @Description List all aliases managed through the alias API
+// @Tags aliases
+// @Produce json
+// @Success 200 {array} model.IndexAlias
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /vectors/aliases [get]
+func (h *AliasHandler) ListAliases(c *gin.Context) {
+	aliases, err := h.aliasService.ListAliases()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, aliases)
+}
+
+// GetAlias gets an index alias
+// GetAlias 获取索引别名
+// @Summary Get an index alias
+// @Description Get an alias and the indices it currently points to
+// @Tags aliases
+// @Produce json
+// @Param alias path string true "Alias name"
+// @Success 200 {object} model.IndexAlias
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/aliases/{alias} [get]
+func (h *AliasHandler) GetAlias(c *gin.Context) {
+	alias, err := h.aliasService.GetAlias(c.Param("alias"))
+	if err != nil {
+		writeAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// UpdateAlias repoints an index alias
+// UpdateAlias 重新指向索引别名
+// @Summary Update an index alias
+// @Description Atomically repoint an alias at a new set of indices
+// @Tags aliases
+// @Accept json
+// @Produce json
+// @Param alias path string true "Alias name"
+// @Param request body model.AliasRequest true "New alias definition"
+// @Success 200 {object} model.IndexAlias
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/aliases/{alias} [put]
+func (h *AliasHandler) UpdateAlias(c *gin.Context) {
+	var req model.AliasRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	alias, err := h.aliasService.UpdateAlias(c.Param("alias"), req)
+	if err != nil {
+		writeAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, alias)
+}
+
+// DeleteAlias deletes an index alias
+// DeleteAlias 删除索引别名
+// @Summary Delete an index alias
+// @Description Remove an alias from all of its indices; the indices themselves are kept
+// @Tags aliases
+// @Produce json
+// @Param alias path string true "Alias name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/aliases/{alias} [delete]
+func (h *AliasHandler) DeleteAlias(c *gin.Context) {
+	name := c.Param("alias")
+	if err := h.aliasService.DeleteAlias(name); err != nil {
+		writeAliasError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Alias deleted successfully",
+		"alias":   name,
+		"status":  "deleted",
+	})
+}
+
+// writeAliasError maps alias service errors to HTTP responses
+// writeAliasError 将别名服务错误映射为 HTTP 响应
+func writeAliasError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "alias not found"})
+	case errors.Is(err, service.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrAliasConflict):
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,417 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type AutoscalerHandler struct {
+	autoscalerService *service.AutoscalerService
+}
+
+func NewAutoscalerHandler(autoscalerService *service.AutoscalerService) *AutoscalerHandler {
+	return &AutoscalerHandler{
+		autoscalerService: autoscalerService,
+	}
+}
+
+// GetConfig gets the global autoscaler configuration
+// GetConfig 获取全局自动扩缩容配置
+// @Summary Get the autoscaler configuration
+// @Description Get the global mode, metric targets, tolerance, scaling factors, replica limits and cooldowns used when no policy sets them, and the predictive scaling settings
+// @Tags autoscaler
+// @Produce json
+// @Success 200 {object} model.AutoscalerConfig
+// @Router /autoscaler/config [get]
+func (h *AutoscalerHandler) GetConfig(c *gin.Context) {
+	c.JSON(http.StatusOK, h.autoscalerService.GetConfig())
+}
+
+// UpdateConfig updates the global autoscaler configuration
+// UpdateConfig 更新全局自动扩缩容配置
+// @Summary Update the autoscaler configuration
+// @Description Replace the global autoscaler configuration; it takes effect on the next evaluation and survives restarts
+// @Tags autoscaler
+// @Accept json
+// @Produce json
+// @Param config body model.AutoscalerConfig true "Autoscaler configuration"
+// @Success 200 {object} model.AutoscalerConfig
+// @Failure 400 {string} string "Bad Request"
+// @Router /autoscaler/config [put]
+func (h *AutoscalerHandler) UpdateConfig(c *gin.Context) {
+	var config model.AutoscalerConfig
+	if err := c.ShouldBindJSON(&config); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	updated, err := h.autoscalerService.UpdateConfig(config)
+	if err != nil {
+		writeAutoscalerError(c, err, "config")
+		return
+	}
+
+	c.JSON(http.StatusOK, updated)
+}
+
+// CreatePolicy creates a scaling policy
+// CreatePolicy 创建扩缩容策略
+// @Summary Create a scaling policy
+// @Description Create the scaling policy of a cluster, or of every cluster of a user when namespace is empty. A cluster policy takes precedence over its user's policy. Mode off skips the cluster, recommend only records decisions and auto scales.
+// @Tags autoscaler
+// @Accept json
+// @Produce json
+// @Param policy body model.ScalingPolicyRequest true "Policy definition"
+// @Success 200 {object} model.ScalingPolicy
+// @Failure 400 {string} string "Bad Request"
+// @Router /autoscaler/policies [post]
+func (h *AutoscalerHandler) CreatePolicy(c *gin.Context) {
+	var req model.ScalingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.autoscalerService.CreatePolicy(req)
+	if err != nil {
+		writeAutoscalerError(c, err, "policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// ListPolicies lists scaling policies
+// ListPolicies 列出扩缩容策略
+// @Summary List scaling policies
+// @Tags autoscaler
+// @Produce json
+// @Param user_id query string false "User"
+// @Param namespace query string false "Cluster namespace"
+// @Success 200 {array} model.ScalingPolicy
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /autoscaler/policies [get]
+func (h *AutoscalerHandler) ListPolicies(c *gin.Context) {
+	policies, err := h.autoscalerService.ListPolicies(c.Query("user_id"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, policies)
+}
+
+// GetPolicy gets a scaling policy
+// GetPolicy 获取扩缩容策略
+// @Summary Get a scaling policy
+// @Tags autoscaler
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} model.ScalingPolicy
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/policies/{id} [get]
+func (h *AutoscalerHandler) GetPolicy(c *gin.Context) {
+	policy, err := h.autoscalerService.GetPolicy(c.Param("id"))
+	if err != nil {
+		writeAutoscalerError(c, err, "policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// UpdatePolicy updates a scaling policy
+// UpdatePolicy 更新扩缩容策略
+// @Summary Update a scaling policy
+// @Description Replace the definition of a scaling policy
+// @Tags autoscaler
+// @Accept json
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Param policy body model.ScalingPolicyRequest true "Policy definition"
+// @Success 200 {object} model.ScalingPolicy
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/policies/{id} [put]
+func (h *AutoscalerHandler) UpdatePolicy(c *gin.Context) {
+	var req model.ScalingPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.autoscalerService.UpdatePolicy(c.Param("id"), req)
+	if err != nil {
+		writeAutoscalerError(c, err, "policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, policy)
+}
+
+// DeletePolicy deletes a scaling policy
+// DeletePolicy 删除扩缩容策略
+// @Summary Delete a scaling policy
+// @Description Delete a scaling policy; its clusters fall back to their user's policy or the global configuration
+// @Tags autoscaler
+// @Produce json
+// @Param id path string true "Policy ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/policies/{id} [delete]
+func (h *AutoscalerHandler) DeletePolicy(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.autoscalerService.DeletePolicy(id); err != nil {
+		writeAutoscalerError(c, err, "policy")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scaling policy deleted successfully",
+		"id":      id,
+		"status":  "deleted",
+	})
+}
+
+// ListDecisions lists the autoscaler decisions of a cluster
+// ListDecisions 列出集群的自动扩缩容决策
+// @Summary List autoscaler decisions
+// @Description List the recorded evaluations of a cluster, newest first, with their inputs, trend adjustments, policy, outcome and reason. Decisions are recorded in recommend and auto mode.
+// @Tags autoscaler
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Param from query string false "Range start, RFC3339 or unix seconds (default: to - 24h)"
+// @Param to query string false "Range end, RFC3339 or unix seconds (default: now)"
+// @Param outcome query string false "error, disabled, hold, cooldown, quota_exceeded, scale_up or scale_down"
+// @Param limit query int false "Maximum number of decisions (default and max: 1000)"
+// @Success 200 {array} model.AutoscalerDecision
+// @Failure 400 {string} string "Bad Request"
+// @Router /clusters/{namespace}/autoscaler/decisions [get]
+func (h *AutoscalerHandler) ListDecisions(c *gin.Context) {
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", err)})
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-24 * time.Hour)
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", err)})
+			return
+		}
+		from = parsed
+	}
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", err)})
+			return
+		}
+		limit = parsed
+	}
+
+	decisions, err := h.autoscalerService.ListDecisions(c.Param("namespace"), c.Query("outcome"), from, to, limit)
+	if err != nil {
+		writeAutoscalerError(c, err, "decision")
+		return
+	}
+
+	c.JSON(http.StatusOK, decisions)
+}
+
+// GetForecast gets the load forecast of a cluster
+// GetForecast 获取集群的负载预测
+// @Summary Get the load forecast
+// @Description Get the hourly p95 load forecast of a cluster over the configured horizon, learned with Holt-Winters from the daily and, after two weeks of history, weekly seasonality of its metrics history, together with the error of the forecasts of the last week against the actual load. With predictive scaling on, the autoscaler provisions for the forecast peak ahead of time.
+// @Tags autoscaler
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Success 200 {object} model.LoadForecastReport
+// @Failure 404 {string} string "Not Found"
+// @Router /clusters/{namespace}/autoscaler/forecast [get]
+func (h *AutoscalerHandler) GetForecast(c *gin.Context) {
+	report, err := h.autoscalerService.GetForecast(c.Param("namespace"))
+	if err != nil {
+		writeAutoscalerError(c, err, "cluster")
+		return
+	}
+
+	c.JSON(http.StatusOK, report)
+}
+
+// CreateSchedule creates a scaling schedule
+// CreateSchedule 创建定时扩缩容计划
+// @Summary Create a scaling schedule
+// @Description Create a recurring capacity plan of a cluster. Every time the five-field cron expression fires in the time zone, the replicas are kept within [min_replicas, max_replicas] for duration minutes, on top of the metric decision and regardless of the cooldown. Overlapping floors take the highest, ceilings the lowest, and a floor wins over a ceiling.
+// @Tags autoscaler
+// @Accept json
+// @Produce json
+// @Param schedule body model.ScalingScheduleRequest true "Schedule definition"
+// @Success 200 {object} model.ScalingSchedule
+// @Failure 400 {string} string "Bad Request"
+// @Router /autoscaler/schedules [post]
+func (h *AutoscalerHandler) CreateSchedule(c *gin.Context) {
+	var req model.ScalingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.autoscalerService.CreateSchedule(req)
+	if err != nil {
+		writeAutoscalerError(c, err, "schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// ListSchedules lists scaling schedules
+// ListSchedules 列出定时扩缩容计划
+// @Summary List scaling schedules
+// @Tags autoscaler
+// @Produce json
+// @Param namespace query string false "Cluster namespace"
+// @Success 200 {array} model.ScalingSchedule
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /autoscaler/schedules [get]
+func (h *AutoscalerHandler) ListSchedules(c *gin.Context) {
+	schedules, err := h.autoscalerService.ListSchedules(c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, schedules)
+}
+
+// GetSchedule gets a scaling schedule
+// GetSchedule 获取定时扩缩容计划
+// @Summary Get a scaling schedule
+// @Tags autoscaler
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} model.ScalingSchedule
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/schedules/{id} [get]
+func (h *AutoscalerHandler) GetSchedule(c *gin.Context) {
+	schedule, err := h.autoscalerService.GetSchedule(c.Param("id"))
+	if err != nil {
+		writeAutoscalerError(c, err, "schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// UpdateSchedule updates a scaling schedule
+// UpdateSchedule 更新定时扩缩容计划
+// @Summary Update a scaling schedule
+// @Description Replace the definition of a scaling schedule
+// @Tags autoscaler
+// @Accept json
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Param schedule body model.ScalingScheduleRequest true "Schedule definition"
+// @Success 200 {object} model.ScalingSchedule
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/schedules/{id} [put]
+func (h *AutoscalerHandler) UpdateSchedule(c *gin.Context) {
+	var req model.ScalingScheduleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	schedule, err := h.autoscalerService.UpdateSchedule(c.Param("id"), req)
+	if err != nil {
+		writeAutoscalerError(c, err, "schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, schedule)
+}
+
+// DeleteSchedule deletes a scaling schedule
+// DeleteSchedule 删除定时扩缩容计划
+// @Summary Delete a scaling schedule
+// @Tags autoscaler
+// @Produce json
+// @Param id path string true "Schedule ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /autoscaler/schedules/{id} [delete]
+func (h *AutoscalerHandler) DeleteSchedule(c *gin.Context) {
+	id := c.Param("id")
+	if err := h.autoscalerService.DeleteSchedule(id); err != nil {
+		writeAutoscalerError(c, err, "schedule")
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Scaling schedule deleted successfully",
+		"id":      id,
+		"status":  "deleted",
+	})
+}
+
+// ListScheduledChanges lists the upcoming scheduled changes of a cluster
+// ListScheduledChanges 列出集群即将到来的定时变更
+// @Summary List upcoming scheduled changes
+// @Description List when the windows of the enabled schedules of a cluster start and end within the next hours, in order
+// @Tags autoscaler
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Param hours query int false "Hours to look ahead (default: 24, max: 168)"
+// @Success 200 {array} model.ScheduledChange
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /clusters/{namespace}/autoscaler/schedule [get]
+func (h *AutoscalerHandler) ListScheduledChanges(c *gin.Context) {
+	hours := 24
+	if value := c.Query("hours"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid hours: %v", err)})
+			return
+		}
+		hours = parsed
+	}
+
+	changes, err := h.autoscalerService.ListScheduledChanges(c.Param("namespace"), time.Duration(hours)*time.Hour)
+	if err != nil {
+		writeAutoscalerError(c, err, "cluster")
+		return
+	}
+
+	c.JSON(http.StatusOK, changes)
+}
+
+// writeAutoscalerError maps autoscaler service errors to HTTP responses
+// writeAutoscalerError 将自动扩缩容服务错误映射为 HTTP 响应
+func writeAutoscalerError(c *gin.Context, err error, kind string) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": kind + " not found"})
+	case errors.Is(err, service.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type BenchmarkHandler struct {
+	benchmarkService *service.BenchmarkService
+}
+
+func NewBenchmarkHandler(benchmarkService *service.BenchmarkService) *BenchmarkHandler {
+	return &BenchmarkHandler{
+		benchmarkService: benchmarkService,
+	}
+}
+
+// StartBenchmark starts a recall and latency benchmark of a vector index
+// StartBenchmark 启动向量索引的召回率与延迟基准测试
+// @Summary Benchmark a vector index
+// @Description Compute exact ground truth over the whole index with script_score queries and measure recall@k, latency percentiles and QPS at several nprobe or num_candidates values
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.BenchmarkRequest false "Benchmark options"
+// @Success 202 {object} model.BenchmarkRun
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/benchmarks [post]
+func (h *BenchmarkHandler) StartBenchmark(c *gin.Context) {
+	var req model.BenchmarkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	run, err := h.benchmarkService.StartBenchmark(c.Param("index_name"), req)
+	if err != nil {
+		writeBenchmarkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusAccepted, run)
+}
+
+// ListBenchmarks lists the benchmark runs of a vector index
+// ListBenchmarks 列出向量索引的基准测试记录
+// @Summary List benchmark runs
+// @Description List the persisted benchmark runs of an index, newest first
+// @Tags vectors
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Success 200 {array} model.BenchmarkRun
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/benchmarks [get]
+func (h *BenchmarkHandler) ListBenchmarks(c *gin.Context) {
+	runs, err := h.benchmarkService.ListBenchmarks(c.Param("index_name"))
+	if err != nil {
+		writeBenchmarkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, runs)
+}
+
+// GetBenchmark gets a benchmark run of a vector index
+// GetBenchmark 获取向量索引的基准测试记录
+// @Summary Get a benchmark run
+// @Description Get the results of a benchmark run
+// @Tags vectors
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param benchmark_id path string true "Benchmark ID"
+// @Success 200 {object} model.BenchmarkRun
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/benchmarks/{benchmark_id} [get]
+func (h *BenchmarkHandler) GetBenchmark(c *gin.Context) {
+	run, err := h.benchmarkService.GetBenchmark(c.Param("index_name"), c.Param("benchmark_id"))
+	if err != nil {
+		writeBenchmarkError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, run)
+}
+
+// writeBenchmarkError maps benchmark service errors to HTTP responses
+// writeBenchmarkError 将基准测试服务错误映射为 HTTP 响应
+func writeBenchmarkError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": "not found"})
+	case errors.Is(err, service.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type CapacityHandler struct {
+	capacity *service.CapacityService
+}
+
+func NewCapacityHandler(capacity *service.CapacityService) *CapacityHandler {
+	return &CapacityHandler{
+		capacity: capacity,
+	}
+}
+
+// Estimate estimates the resources of a vector workload
+// Estimate 估算向量负载所需的资源
+// @Summary Estimate capacity
+// @Description Estimate heap, off-heap and disk needs and recommend per node CPU, memory and disk
+// @Tags capacity
+// @Accept json
+// @Produce json
+// @Param request body model.CapacityRequest true "Workload description"
+// @Success 200 {object} model.CapacityEstimate
+// @Failure 400 {string} string "Bad Request"
+// @Router /capacity/estimate [post]
+func (h *CapacityHandler) Estimate(c *gin.Context) {
+	var req model.CapacityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	estimate, err := h.capacity.Estimate(req)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, estimate)
+}
@@ -1,16 +1,17 @@
 package handler
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net/http"
 	"os"
-	"os/exec"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 
 	"es-serverless-manager/internal/model"
 	"es-serverless-manager/internal/service"
@@ -19,12 +20,18 @@ import (
 type ClusterHandler struct {
 	metadataService  *service.MetadataService
 	terraformManager *service.TerraformManager
+	capacityService  *service.CapacityService
+	inspector        service.ClusterInspector
+	scaler           *service.ClusterScaler
 }
 
-func NewClusterHandler(metadata *service.MetadataService, terraform *service.TerraformManager) *ClusterHandler {
+func NewClusterHandler(metadata *service.MetadataService, terraform *service.TerraformManager, capacity *service.CapacityService, inspector service.ClusterInspector, scaler *service.ClusterScaler) *ClusterHandler {
 	return &ClusterHandler{
 		metadataService:  metadata,
 		terraformManager: terraform,
+		capacityService:  capacity,
+		inspector:        inspector,
+		scaler:           scaler,
 	}
 }
 
@@ -75,6 +82,35 @@ func (h *ClusterHandler) CreateCluster(c *gin.Context) {
 		}
 	}
 
+	// Size the cluster for its vector workload and reject under-provisioned requests
+	// before any resource is created
+	// 按向量负载规划集群资源，并在创建任何资源之前拒绝资源不足的请求
+	var capacity *model.CapacityEstimate
+	if req.Dimension > 0 && req.VectorCount > 0 {
+		estimate, err := h.capacityService.EstimateForCluster(req)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if estimate.UnderProvisioned && !req.SkipCapacityCheck {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error":    "requested resources are below the capacity estimate, set skip_capacity_check to create anyway",
+				"capacity": estimate,
+			})
+			return
+		}
+		if req.CPURequest == "" {
+			req.CPURequest = estimate.RecommendedCPU
+		}
+		if req.MemRequest == "" {
+			req.MemRequest = estimate.RecommendedMemory
+		}
+		if req.DiskSize == "" {
+			req.DiskSize = estimate.RecommendedDiskSize
+		}
+		capacity = estimate
+	}
+
 	// 构建基于租户组织ID的命名空间（实现多租户隔离）
 	ns := req.Namespace
 	if ns == "" {
@@ -136,6 +172,8 @@ func (h *ClusterHandler) CreateCluster(c *gin.Context) {
 			"vector_count": req.VectorCount,
 			"index_limit":  req.IndexLimit,
 			"gitlab_url":   req.GitlabURL,
+			"quantization": req.Quantization,
+			"engine":       req.Engine,
 		},
 	}
 	err = h.metadataService.SaveDeploymentStatus(deploymentStatus)
@@ -214,11 +252,15 @@ func (h *ClusterHandler) CreateCluster(c *gin.Context) {
 	tenantContainer.SyncTime = time.Now()
 	h.metadataService.SaveTenantContainer(tenantContainer)
 
-	c.JSON(http.StatusOK, gin.H{
+	response := gin.H{
 		"message":   "Cluster creation initiated successfully",
 		"namespace": ns,
 		"status":    "created",
-	})
+	}
+	if capacity != nil {
+		response["capacity"] = capacity
+	}
+	c.JSON(http.StatusOK, response)
 }
 
 // DeleteCluster deletes a cluster
@@ -286,13 +328,16 @@ func (h *ClusterHandler) DeleteCluster(c *gin.Context) {
 // ScaleCluster scales a cluster
 // ScaleCluster 扩缩容集群
 // @Summary Scale a cluster
-// @Description Scale an Elasticsearch cluster
+// @Description Scale an Elasticsearch cluster through Terraform after checking the tenant quota; the same path is used by the autoscaler and every attempt is audited. The Terraform apply runs in the background and its result is recorded in the scale events
 // @Tags clusters
 // @Accept json
 // @Produce json
 // @Param cluster body model.ScaleRequest true "Cluster scaling info"
 // @Success 200 {object} map[string]interface{}
 // @Failure 400 {string} string "Bad Request"
+// @Failure 403 {string} string "Forbidden"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
 // @Failure 500 {string} string "Internal Server Error"
 // @Router /clusters/scale [post]
 func (h *ClusterHandler) ScaleCluster(c *gin.Context) {
@@ -310,80 +355,71 @@ func (h *ClusterHandler) ScaleCluster(c *gin.Context) {
 		}
 	}
 
-	deployment, err := h.metadataService.GetDeploymentStatus(ns)
-	if err != nil {
-		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Deployment not found: %v", err)})
+	if err := h.scaler.Scale(ns, req.Replicas, service.ScaleSourceManual, callerIdentity(c), req.Reason); err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("Deployment not found: %v", err)})
+		case errors.Is(err, service.ErrInvalidRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrQuotaExceeded):
+			c.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrScaleInProgress):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
 		return
 	}
 
-	getString := func(v interface{}) string {
-		if s, ok := v.(string); ok {
-			return s
-		}
-		return ""
-	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Cluster scaling initiated successfully via Terraform",
+		"namespace": ns,
+		"replicas":  req.Replicas,
+		"status":    "scaling",
+	})
+}
 
-	getInt := func(v interface{}) int {
-		if i, ok := v.(int); ok {
-			return i
-		}
-		if f, ok := v.(float64); ok {
-			return int(f)
+// callerIdentity returns who made a request, for the audit trail: the user set by the authenticating
+// proxy in the header named by AUTH_USER_HEADER, or the peer address when no such header is configured
+// callerIdentity 返回请求的发起者，用于审计：由认证代理写入 AUTH_USER_HEADER 所指定请求头中的用户；未配置该请求头时为对端地址
+func callerIdentity(c *gin.Context) string {
+	if header := os.Getenv("AUTH_USER_HEADER"); header != "" {
+		if user := c.GetHeader(header); user != "" {
+			return user
 		}
-		return 0
-	}
-
-	tenantConfig := model.TenantConfig{
-		TenantOrgID:     deployment.TenantOrgID,
-		User:            deployment.User,
-		ServiceName:     deployment.ServiceName,
-		Replicas:        req.Replicas,
-		CPU:             getString(deployment.Details["cpu_request"]),
-		Memory:          getString(deployment.Details["mem_request"]),
-		DiskSize:        getString(deployment.Details["disk_size"]),
-		StorageClass:    "hostpath",
-		GPUCount:        getInt(deployment.Details["gpu_count"]),
-		VectorDimension: getInt(deployment.Details["dimension"]),
-		VectorCount:     getInt(deployment.Details["vector_count"]),
 	}
+	return "api@" + c.RemoteIP()
+}
 
-	if tenantConfig.CPU == "" {
-		tenantConfig.CPU = "500m"
-	}
-	if tenantConfig.Memory == "" {
-		tenantConfig.Memory = "1Gi"
-	}
-	if tenantConfig.DiskSize == "" {
-		tenantConfig.DiskSize = "10Gi"
+// ListScaleEvents lists the scale audit trail of a cluster
+// ListScaleEvents 列出集群的扩缩容审计记录
+// @Summary List scale events
+// @Description List the manual and automatic scale attempts of a cluster, newest first
+// @Tags clusters
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Param limit query int false "Maximum number of events (default and max: 1000)"
+// @Success 200 {array} model.ScaleEvent
+// @Failure 400 {string} string "Bad Request"
+// @Router /clusters/{namespace}/scale-events [get]
+func (h *ClusterHandler) ListScaleEvents(c *gin.Context) {
+	limit := 0
+	if value := c.Query("limit"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid limit: %v", err)})
+			return
+		}
+		limit = parsed
 	}
 
-	// Apply changes via Terraform
-	// 通过 Terraform 应用变更
-	err = h.terraformManager.CreateCluster(tenantConfig)
+	events, err := h.scaler.ListEvents(c.Param("namespace"), limit)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Failed to scale cluster: %v", err)})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
-	deployment.Replicas = req.Replicas
-	deployment.Details["replicas"] = req.Replicas
-	deployment.UpdatedAt = time.Now()
-	deployment.Status = "scaling"
-
-	h.metadataService.SaveDeploymentStatus(deployment)
-
-	if tenantContainer, err := h.metadataService.GetTenantContainer(deployment.User, deployment.ServiceName); err == nil {
-		tenantContainer.Replicas = req.Replicas
-		tenantContainer.SyncTime = time.Now()
-		h.metadataService.SaveTenantContainer(tenantContainer)
-	}
-
-	c.JSON(http.StatusOK, gin.H{
-		"message":   "Cluster scaling initiated successfully via Terraform",
-		"namespace": ns,
-		"replicas":  req.Replicas,
-		"status":    "scaling",
-	})
+	c.JSON(http.StatusOK, events)
 }
 
 // ListClusters lists all clusters
@@ -398,34 +434,30 @@ func (h *ClusterHandler) ScaleCluster(c *gin.Context) {
 func (h *ClusterHandler) ListClusters(c *gin.Context) {
 	deployments, err := h.metadataService.ListDeploymentStatus()
 	if err != nil {
-		// Fallback to kubectl
-		cmd := exec.Command("kubectl", "get", "namespaces", "-l", "es-cluster=true", "-o", "jsonpath={.items[*].metadata.name}")
-		out, err := cmd.CombinedOutput()
+		// Fallback to Kubernetes
+		namespaces, err := h.inspector.ListClusterNamespaces(c.Request.Context())
 		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": string(out)})
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 			return
 		}
 
-		namespaces := strings.Fields(string(out))
 		clusters := make([]model.ClusterStatus, len(namespaces))
 
 		for i, ns := range namespaces {
-			statusCmd := exec.Command("kubectl", "-n", ns, "get", "sts/elasticsearch", "-o", "jsonpath={.status.readyReplicas}/{.spec.replicas}")
-			statusOut, _ := statusCmd.CombinedOutput()
-			status := string(statusOut)
-			if status == "" {
-				status = "unknown"
-			}
-
 			clusters[i] = model.ClusterStatus{
 				Namespace:   ns,
 				User:        "unknown",
 				ServiceName: "unknown",
-				Status:      status,
 				Replicas:    1,
 				CreatedAt:   time.Now(),
 				UpdatedAt:   time.Now(),
 			}
+			sts := h.statefulSet(c.Request.Context(), ns)
+			if sts != nil {
+				clusters[i].Replicas = sts.Replicas
+				clusters[i].ReadyReplicas = sts.ReadyReplicas
+			}
+			clusters[i].Status, clusters[i].StatusReason = service.DeriveClusterStatus(sts, nil, "")
 		}
 		c.JSON(http.StatusOK, clusters)
 		return
@@ -433,31 +465,94 @@ func (h *ClusterHandler) ListClusters(c *gin.Context) {
 
 	clusters := make([]model.ClusterStatus, len(deployments))
 	for i, deployment := range deployments {
-		statusCmd := exec.Command("kubectl", "-n", deployment.Namespace, "get", "sts/elasticsearch", "-o", "jsonpath={.status.readyReplicas}/{.spec.replicas}")
-		statusOut, _ := statusCmd.CombinedOutput()
-		status := string(statusOut)
-		if status == "" {
-			status = "unknown"
-		}
-
-		clusters[i] = model.ClusterStatus{
-			Namespace:   deployment.Namespace,
-			User:        deployment.User,
-			ServiceName: deployment.ServiceName,
-			Status:      status,
-			CPUUsage:    deployment.CPUUsage,
-			MemoryUsage: deployment.MemoryUsage,
-			DiskUsage:   deployment.DiskUsage,
-			QPS:         deployment.QPS,
-			GPUCount:    deployment.GPUCount,
-			Dimension:   deployment.Dimension,
-			VectorCount: deployment.VectorCount,
-			Replicas:    deployment.Replicas,
-			CreatedAt:   deployment.CreatedAt,
-			UpdatedAt:   deployment.UpdatedAt,
-			Details:     deployment.Details,
-		}
+		clusters[i] = h.clusterStatus(c.Request.Context(), deployment)
 	}
 
 	c.JSON(http.StatusOK, clusters)
 }
+
+// GetCluster returns the details of a cluster
+// GetCluster 获取集群详情
+// @Summary Get cluster details
+// @Description Get a cluster with its latest cluster level aggregates and per pod metrics
+// @Tags clusters
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Success 200 {object} model.ClusterDetail
+// @Failure 404 {string} string "Not Found"
+// @Router /clusters/{namespace} [get]
+func (h *ClusterHandler) GetCluster(c *gin.Context) {
+	ns := c.Param("namespace")
+
+	deployment, err := h.metadataService.GetDeploymentStatus(ns)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("cluster %s not found", ns)})
+		return
+	}
+
+	detail := model.ClusterDetail{
+		ClusterStatus: h.clusterStatus(c.Request.Context(), deployment),
+		Pods:          []model.PodMetrics{},
+	}
+	if metrics, err := h.metadataService.GetLatestMetrics(ns); err == nil {
+		detail.Metrics = metrics
+	}
+	if pods, err := h.metadataService.GetLatestPodMetrics(ns); err == nil {
+		detail.Pods = pods
+	}
+
+	c.JSON(http.StatusOK, detail)
+}
+
+// clusterStatus builds the status of a cluster from its deployment, deriving the overall status from
+// the live StatefulSet readiness and the Elasticsearch health recorded by the monitoring loop
+// clusterStatus 根据部署信息构建集群状态，整体状态由实时的 StatefulSet 就绪情况与监控循环记录的 Elasticsearch 健康状态得出
+func (h *ClusterHandler) clusterStatus(ctx context.Context, deployment *model.DeploymentStatus) model.ClusterStatus {
+	status := model.ClusterStatus{
+		Namespace:       deployment.Namespace,
+		User:            deployment.User,
+		ServiceName:     deployment.ServiceName,
+		CPUUsage:        deployment.CPUUsage,
+		MemoryUsage:     deployment.MemoryUsage,
+		DiskUsage:       deployment.DiskUsage,
+		QPS:             deployment.QPS,
+		GPUCount:        deployment.GPUCount,
+		Dimension:       deployment.Dimension,
+		VectorCount:     deployment.VectorCount,
+		Replicas:        deployment.Replicas,
+		CreatedAt:       deployment.CreatedAt,
+		UpdatedAt:       deployment.UpdatedAt,
+		Details:         deployment.Details,
+		ReadyReplicas:   deployment.ReadyReplicas,
+		Load:            deployment.Load,
+		UnhealthyShards: deployment.UnhealthyShards,
+		HealthCheckedAt: deployment.HealthCheckedAt,
+	}
+
+	var health *model.ESClusterHealth
+	if deployment.HealthCheckedAt != nil {
+		esHealth := deployment.ESHealth
+		health = &esHealth
+		status.ESHealth = health
+	}
+
+	sts := h.statefulSet(ctx, deployment.Namespace)
+	if sts != nil {
+		status.ReadyReplicas = sts.ReadyReplicas
+	}
+	status.Status, status.StatusReason = service.DeriveClusterStatus(sts, health, deployment.Status)
+	if status.Status == "running" && deployment.Load != "" {
+		status.Status = deployment.Load
+	}
+	return status
+}
+
+// statefulSet reads the Elasticsearch StatefulSet of a cluster, or nil when it cannot be read
+// statefulSet 读取集群的 Elasticsearch StatefulSet，无法读取时返回 nil
+func (h *ClusterHandler) statefulSet(ctx context.Context, namespace string) *service.StatefulSetStatus {
+	sts, err := h.inspector.GetStatefulSet(ctx, namespace, "elasticsearch")
+	if err != nil {
+		return nil
+	}
+	return sts
+}
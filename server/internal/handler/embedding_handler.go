@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type EmbeddingHandler struct {
+	embedding *service.EmbeddingService
+}
+
+func NewEmbeddingHandler(embedding *service.EmbeddingService) *EmbeddingHandler {
+	return &EmbeddingHandler{
+		embedding: embedding,
+	}
+}
+
+// ListEncoders lists the registered text encoders
+// ListEncoders 列出已注册的文本编码器
+// @Summary List encoders
+// @Description List the server-side text encoders and their dimensions
+// @Tags embeddings
+// @Produce json
+// @Success 200 {array} model.EncoderInfo
+// @Router /embeddings/encoders [get]
+func (h *EmbeddingHandler) ListEncoders(c *gin.Context) {
+	c.JSON(http.StatusOK, h.embedding.ListEncoders())
+}
+
+// Embed encodes texts into vectors
+// Embed 将文本编码为向量
+// @Summary Encode texts
+// @Description Encode texts into vectors with a registered encoder
+// @Tags embeddings
+// @Accept json
+// @Produce json
+// @Param request body model.EmbedRequest true "Encoder and texts"
+// @Success 200 {object} map[string]interface{}
+// @Failure 400 {string} string "Bad Request"
+// @Router /embeddings [post]
+func (h *EmbeddingHandler) Embed(c *gin.Context) {
+	var req model.EmbedRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Encoder == "" || len(req.Texts) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "encoder and texts are required"})
+		return
+	}
+	if len(req.Texts) > maxBatchSearchQueries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "too many texts"})
+		return
+	}
+
+	vectors, err := h.embedding.Embed(c.Request.Context(), req.Encoder, req.Texts)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"encoder": req.Encoder,
+		"vectors": vectors,
+	})
+}
@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"es-serverless-manager/internal/service"
+)
+
+const (
+	// defaultHistoryRange is the range queried when from is omitted
+	// defaultHistoryRange 未指定 from 时查询的时间范围
+	defaultHistoryRange = time.Hour
+	// defaultHistoryPoints is the number of buckets aimed for when step is omitted
+	// defaultHistoryPoints 未指定 step 时期望的桶数
+	defaultHistoryPoints = 200
+	// minHistoryStep matches the collection interval of the monitoring loop
+	// minHistoryStep 与监控循环的采集间隔一致
+	minHistoryStep = 30 * time.Second
+)
+
+type MetricsHandler struct {
+	history *service.MetricsHistoryService
+}
+
+func NewMetricsHandler(history *service.MetricsHistoryService) *MetricsHandler {
+	return &MetricsHandler{
+		history: history,
+	}
+}
+
+// GetMetricsHistory returns the metrics time series of a cluster
+// GetMetricsHistory 获取集群的指标时间序列
+// @Summary Get cluster metrics history
+// @Description Get time series aggregated per bucket (avg, max and p95). Recent ranges are served from raw samples, older ranges from 5 minute or 1 hour rollups.
+// @Tags clusters
+// @Produce json
+// @Param namespace path string true "Cluster namespace"
+// @Param from query string false "Range start, RFC3339 or unix seconds (default: to - 1h)"
+// @Param to query string false "Range end, RFC3339 or unix seconds (default: now)"
+// @Param step query string false "Bucket width, Go duration or seconds (default: range / 200, at least 30s)"
+// @Param series query string false "Comma separated series names (default: all)"
+// @Success 200 {object} model.MetricsHistory
+// @Failure 400 {string} string "Bad Request"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /clusters/{namespace}/metrics [get]
+func (h *MetricsHandler) GetMetricsHistory(c *gin.Context) {
+	ns := c.Param("namespace")
+
+	to := time.Now()
+	if value := c.Query("to"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid to: %v", err)})
+			return
+		}
+		to = parsed
+	}
+	from := to.Add(-defaultHistoryRange)
+	if value := c.Query("from"); value != "" {
+		parsed, err := parseQueryTime(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid from: %v", err)})
+			return
+		}
+		from = parsed
+	}
+
+	step := to.Sub(from) / defaultHistoryPoints
+	if value := c.Query("step"); value != "" {
+		parsed, err := parseQueryDuration(value)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid step: %v", err)})
+			return
+		}
+		step = parsed
+	}
+	if step < minHistoryStep {
+		step = minHistoryStep
+	}
+
+	var series []string
+	if value := c.Query("series"); value != "" {
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				series = append(series, name)
+			}
+		}
+	}
+
+	history, err := h.history.Query(ns, from, to, step, series)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRequest) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
+
+// parseQueryTime accepts RFC3339 timestamps and unix seconds
+// parseQueryTime 接受 RFC3339 时间戳与 Unix 秒数
+func parseQueryTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+// parseQueryDuration accepts Go durations such as "5m" and plain seconds
+// parseQueryDuration 接受 "5m" 等 Go 时长格式以及纯秒数
+func parseQueryDuration(value string) (time.Duration, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Duration(seconds) * time.Second, nil
+	}
+	return time.ParseDuration(value)
+}
@@ -0,0 +1,47 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"es-serverless-manager/internal/service"
+)
+
+type PrometheusHandler struct {
+	exporter *service.PrometheusExporter
+}
+
+func NewPrometheusHandler(exporter *service.PrometheusExporter) *PrometheusHandler {
+	return &PrometheusHandler{
+		exporter: exporter,
+	}
+}
+
+// Metrics exposes the manager and tenant metrics for Prometheus
+// Metrics 为 Prometheus 暴露管理器与租户指标
+// @Summary Prometheus metrics
+// @Description Manager internals (HTTP requests, Terraform runs, autoscaler decisions, monitoring lag) and the latest metrics of every tenant cluster in the Prometheus text format
+// @Tags monitoring
+// @Produce plain
+// @Success 200 {string} string "Prometheus text exposition"
+// @Router /metrics [get]
+func (h *PrometheusHandler) Metrics(c *gin.Context) {
+	h.exporter.ServeHTTP(c.Writer, c.Request)
+}
+
+// Middleware records the count and latency of every request per route template, so
+// path parameters such as namespaces do not create a series each
+// Middleware 按路由模板记录每个请求的次数与延迟，避免命名空间等路径参数各自产生一条序列
+func (h *PrometheusHandler) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		h.exporter.ObserveHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
@@ -0,0 +1,63 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type ReindexHandler struct {
+	reindexService *service.ReindexService
+}
+
+func NewReindexHandler(reindexService *service.ReindexService) *ReindexHandler {
+	return &ReindexHandler{
+		reindexService: reindexService,
+	}
+}
+
+// ReindexVectorIndex rebuilds a vector index into a new version without downtime
+// ReindexVectorIndex 无停机地将向量索引重建为新版本
+// @Summary Reindex a vector index
+// @Description Create a versioned backing index, copy data with _reindex and atomically swap the alias
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.ReindexRequest true "New index definition"
+// @Success 202 {object} model.OperationTask
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Router /vectors/{index_name}/reindex [post]
+func (h *ReindexHandler) ReindexVectorIndex(c *gin.Context) {
+	indexName := c.Param("index_name")
+
+	var req model.ReindexRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	task, err := h.reindexService.StartReindex(indexName, req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "index metadata not found"})
+		case errors.Is(err, service.ErrInvalidRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrIndexBusy):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
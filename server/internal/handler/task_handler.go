@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/service"
+)
+
+type TaskHandler struct {
+	taskService *service.TaskService
+}
+
+func NewTaskHandler(taskService *service.TaskService) *TaskHandler {
+	return &TaskHandler{
+		taskService: taskService,
+	}
+}
+
+// ListTasks lists background operation tasks
+// ListTasks 列出后台操作任务
+// @Summary List background tasks
+// @Description List background operation tasks, optionally filtered by index
+// @Tags tasks
+// @Produce json
+// @Param index_name query string false "Index name"
+// @Success 200 {array} model.OperationTask
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /tasks [get]
+func (h *TaskHandler) ListTasks(c *gin.Context) {
+	tasks, err := h.taskService.ListTasks(c.Query("index_name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, tasks)
+}
+
+// GetTask gets a background operation task
+// GetTask 获取后台操作任务
+// @Summary Get a background task
+// @Description Get the status and progress of a background operation task
+// @Tags tasks
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Success 200 {object} model.OperationTask
+// @Failure 404 {string} string "Not Found"
+// @Router /tasks/{task_id} [get]
+func (h *TaskHandler) GetTask(c *gin.Context) {
+	task, err := h.taskService.GetTask(c.Param("task_id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, task)
+}
+
+// CancelTask cancels a running background operation task
+// CancelTask 取消正在运行的后台操作任务
+// @Summary Cancel a background task
+// @Description Request cancellation of a running background operation task
+// @Tags tasks
+// @Produce json
+// @Param task_id path string true "Task ID"
+// @Success 202 {object} model.OperationTask
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Router /tasks/{task_id}/cancel [post]
+func (h *TaskHandler) CancelTask(c *gin.Context) {
+	task, err := h.taskService.CancelTask(c.Param("task_id"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "task not found"})
+			return
+		}
+		if errors.Is(err, service.ErrTaskFinished) || errors.Is(err, service.ErrTaskNotStarted) {
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
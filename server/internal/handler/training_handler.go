@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"es-serverless-manager/internal/model"
+	"es-serverless-manager/internal/service"
+)
+
+type TrainingHandler struct {
+	trainingService *service.TrainingService
+}
+
+func NewTrainingHandler(trainingService *service.TrainingService) *TrainingHandler {
+	return &TrainingHandler{
+		trainingService: trainingService,
+	}
+}
+
+// TrainIndex starts IVF training for a vector index
+// TrainIndex 启动向量索引的 IVF 训练
+// @Summary Train an IVF index
+// @Description Train the coarse quantizer of an IVF index in the Elasticsearch plugin as a background task
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.TrainRequest false "Training options"
+// @Success 202 {object} model.OperationTask
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 409 {string} string "Conflict"
+// @Router /vectors/{index_name}/train [post]
+func (h *TrainingHandler) TrainIndex(c *gin.Context) {
+	var req model.TrainRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+	}
+
+	task, err := h.trainingService.StartTraining(c.Param("index_name"), req)
+	if err != nil {
+		switch {
+		case errors.Is(err, gorm.ErrRecordNotFound):
+			c.JSON(http.StatusNotFound, gin.H{"error": "index metadata not found"})
+		case errors.Is(err, service.ErrInvalidRequest):
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		case errors.Is(err, service.ErrIndexBusy):
+			c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		default:
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		}
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
+
+// GetTrainingStatus gets the IVF training status of a vector index
+// GetTrainingStatus 获取向量索引的 IVF 训练状态
+// @Summary Get IVF training status
+// @Description Get the stored training results and the latest training task of an index
+// @Tags vectors
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/train [get]
+func (h *TrainingHandler) GetTrainingStatus(c *gin.Context) {
+	status, err := h.trainingService.GetTrainingStatus(c.Param("index_name"))
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": "index metadata not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, status)
+}
@@ -1,6 +1,9 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
+	"log"
 	"net/http"
 	"time"
 
@@ -8,15 +11,36 @@ import (
 	"es-serverless-manager/internal/service"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
+// maxBatchSearchQueries caps the number of query vectors accepted in one batch search
+// maxBatchSearchQueries 单次批量搜索允许的最大查询向量数
+const maxBatchSearchQueries = 10000
+
+// maxBatchSearchSize caps the number of queries a caller can put in one _msearch request
+// maxBatchSearchSize 单个 _msearch 请求允许包含的最大查询数
+const maxBatchSearchSize = 1000
+
+// maxBulkDocuments caps the number of documents accepted in one bulk request
+// maxBulkDocuments 单次批量写入允许的最大文档数
+const maxBulkDocuments = 10000
+
 type VectorHandler struct {
-	esService *service.ESService
+	store           service.VectorStore
+	metadataService *service.MetadataService
+	taskService     *service.TaskService
+	exportService   *service.ExportService
+	embedding       *service.EmbeddingService
 }
 
-func NewVectorHandler(esService *service.ESService) *VectorHandler {
+func NewVectorHandler(store service.VectorStore, metadata *service.MetadataService, tasks *service.TaskService, export *service.ExportService, embedding *service.EmbeddingService) *VectorHandler {
 	return &VectorHandler{
-		esService: esService,
+		store:           store,
+		metadataService: metadata,
+		taskService:     tasks,
+		exportService:   export,
+		embedding:       embedding,
 	}
 }
 
@@ -44,37 +68,94 @@ func (h *VectorHandler) CreateVectorIndex(c *gin.Context) {
 		return
 	}
 
-	// Construct mapping for vector index
-	// 构建向量索引的映射
-	// This is a simplified example. In a real scenario, you'd construct the mapping based on dimension, metric, etc.
-	// 这是一个简化的示例。在实际场景中，您需要根据维度、度量等构建映射。
-	mapping := model.VectorIndexMapping{
-		Properties: map[string]interface{}{
-			"vector": map[string]interface{}{
-				"type":       "dense_vector",
-				"dims":       req.Dimension,
-				"index":      true,
-				"similarity": req.Metric, // e.g., "l2_norm", "cosine", "dot_product"
-			},
-			// Add other fields from request if needed
-			// 如果需要，添加其他字段
-		},
+	// The dimension of an index with a server-side encoder must match the encoder
+	// 使用服务端编码器的索引，其维度必须与编码器一致
+	if req.Encoder != "" {
+		encoder, err := h.embedding.GetEncoder(req.Encoder)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if req.Dimension == 0 {
+			req.Dimension = encoder.Dimension()
+		}
+		if req.Dimension != encoder.Dimension() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("dimension %d does not match encoder %s (%d)", req.Dimension, req.Encoder, encoder.Dimension())})
+			return
+		}
+		if req.TextField == "" {
+			req.TextField = "text"
+		}
 	}
 
-	// Add user defined fields
-	// 添加用户定义的字段
-	for k, v := range req.FieldMapping {
-		mapping.Properties[k] = map[string]interface{}{
-			"type": v,
+	quantization, err := service.NormalizeQuantization(req.Quantization)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	req.Quantization = quantization
+	if req.RescoreOversample != 0 && (req.RescoreOversample < 1 || req.RescoreOversample > 10) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rescore_oversample must be between 1 and 10"})
+		return
+	}
+	if req.RescoreOversample != 0 && quantization == "none" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "rescore_oversample requires int8 quantization"})
+		return
+	}
+
+	// Check tenant quota before creating the index
+	// 创建索引前检查租户配额
+	if req.TenantID != "" {
+		hasQuota, quota, err := h.metadataService.CheckTenantQuota(req.TenantID)
+		if err != nil {
+			log.Printf("Warning: Failed to check tenant quota for tenant %s: %v", req.TenantID, err)
+		} else if !hasQuota {
+			c.JSON(http.StatusForbidden, gin.H{
+				"error": fmt.Sprintf("Tenant quota exceeded. Max indices: %d, Current indices: %d", quota.MaxIndices, quota.CurrentIndices),
+			})
+			return
 		}
 	}
 
-	err := h.esService.CreateVectorIndex(req.IndexName, mapping)
+	// Construct mapping for vector index
+	// 构建向量索引的映射
+	mapping := service.BuildVectorMapping(req)
+
+	err = h.store.CreateVectorIndex(req.IndexName, mapping)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Record index metadata and quota usage
+	// 记录索引元数据和配额使用量
+	metadata := &model.IndexMetadata{
+		ID:        "index_" + req.IndexName,
+		IndexName: req.IndexName,
+		Dimension: req.Dimension,
+		Metric:    req.Metric,
+		IVFParams: model.IVFParams{
+			NList:  req.IVFParams["nlist"],
+			NProbe: req.IVFParams["nprobe"],
+		},
+		CreatedAt:   time.Now(),
+		UpdatedAt:   time.Now(),
+		CreatedBy:   req.TenantID,
+		Status:      "active",
+		StorageSize: "0",
+		Encoder:     req.Encoder,
+		TextField:   req.TextField,
+
+		Quantization:      req.Quantization,
+		RescoreOversample: req.RescoreOversample,
+	}
+	if err := h.metadataService.SaveIndexMetadata(metadata); err != nil {
+		log.Printf("Warning: Failed to save metadata for index %s: %v", req.IndexName, err)
+	}
+	if req.TenantID != "" {
+		h.metadataService.UpdateTenantQuotaUsage(req.TenantID, true, "")
+	}
+
 	response := map[string]interface{}{
 		"message": "Vector index created successfully",
 		"index":   req.IndexName,
@@ -102,19 +183,380 @@ func (h *VectorHandler) IndexDocument(c *gin.Context) {
 	// 可选：通过查询参数或字段指定文档 ID
 	docID := c.Query("id")
 
-	err := h.esService.IndexDocument(indexName, docID, doc)
+	if err := h.embedding.EmbedDocuments(c.Request.Context(), h.indexMetadata(indexName), []map[string]interface{}{doc}); err != nil {
+		writeStoreError(c, err)
+		return
+	}
+
+	result, err := h.store.IndexDocument(indexName, docID, doc)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStoreError(c, err)
 		return
 	}
 
+	docDelta := 0
+	if result.Result == "created" {
+		docDelta = 1
+	}
+	h.recordIndexUsage(result.Index, docDelta)
+
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Document indexed successfully",
 		"index":   indexName,
-		"id":      docID,
+		"id":      result.ID,
+		"result":  result.Result,
 	})
 }
 
+// BulkIndex indexes many documents in one request
+// BulkIndex 在一次请求中写入多个文档
+// @Summary Bulk index documents
+// @Description Index many documents at once, reporting failures per document
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.BulkRequest true "Documents to index"
+// @Success 200 {object} model.BulkResponse
+// @Failure 400 {string} string "Bad Request"
+// @Router /vectors/{index_name}/bulk [post]
+func (h *VectorHandler) BulkIndex(c *gin.Context) {
+	indexName := c.Param("index_name")
+
+	var req model.BulkRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Documents) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "documents is required"})
+		return
+	}
+	if len(req.Documents) > maxBulkDocuments {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many documents: %d (max %d)", len(req.Documents), maxBulkDocuments)})
+		return
+	}
+
+	docs := make([]map[string]interface{}, len(req.Documents))
+	for i, doc := range req.Documents {
+		if doc.Document == nil {
+			req.Documents[i].Document = map[string]interface{}{}
+		}
+		docs[i] = req.Documents[i].Document
+	}
+	if err := h.embedding.EmbedDocuments(c.Request.Context(), h.indexMetadata(indexName), docs); err != nil {
+		writeStoreError(c, err)
+		return
+	}
+
+	result, err := h.store.BulkIndex(indexName, req.Documents)
+	if err != nil {
+		writeStoreError(c, err)
+		return
+	}
+
+	created := 0
+	for _, item := range result.Items {
+		if item.Result == "created" {
+			created++
+		}
+	}
+	h.recordIndexUsage(indexName, created)
+
+	c.JSON(http.StatusOK, result)
+}
+
+// GetDocument retrieves a document by ID
+// GetDocument 根据 ID 获取文档
+// @Summary Get a document
+// @Description Get a document from a vector index by ID
+// @Tags vectors
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param doc_id path string true "Document ID"
+// @Success 200 {object} map[string]interface{}
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/doc/{doc_id} [get]
+func (h *VectorHandler) GetDocument(c *gin.Context) {
+	indexName := c.Param("index_name")
+	docID := c.Param("doc_id")
+
+	doc, err := h.store.GetDocument(indexName, docID)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, doc)
+}
+
+// UpdateDocument partially updates a document
+// UpdateDocument 部分更新文档
+// @Summary Partially update a document
+// @Description Merge the given fields into an existing document
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param doc_id path string true "Document ID"
+// @Param fields body map[string]interface{} true "Fields to update"
+// @Success 200 {object} model.DocumentResult
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/doc/{doc_id} [patch]
+func (h *VectorHandler) UpdateDocument(c *gin.Context) {
+	indexName := c.Param("index_name")
+	docID := c.Param("doc_id")
+
+	var fields map[string]interface{}
+	if err := c.ShouldBindJSON(&fields); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(fields) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no fields to update"})
+		return
+	}
+
+	if err := h.embedding.EmbedDocuments(c.Request.Context(), h.indexMetadata(indexName), []map[string]interface{}{fields}); err != nil {
+		writeStoreError(c, err)
+		return
+	}
+
+	result, err := h.store.UpdateDocument(indexName, docID, fields)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.recordIndexUsage(result.Index, 0)
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteDocument deletes a document by ID
+// DeleteDocument 根据 ID 删除文档
+// @Summary Delete a document
+// @Description Delete a document from a vector index by ID
+// @Tags vectors
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param doc_id path string true "Document ID"
+// @Success 200 {object} model.DocumentResult
+// @Failure 404 {string} string "Not Found"
+// @Router /vectors/{index_name}/doc/{doc_id} [delete]
+func (h *VectorHandler) DeleteDocument(c *gin.Context) {
+	indexName := c.Param("index_name")
+	docID := c.Param("doc_id")
+
+	result, err := h.store.DeleteDocument(indexName, docID)
+	if err != nil {
+		if errors.Is(err, service.ErrDocumentNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	h.recordIndexUsage(result.Index, -1)
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteByQuery deletes all documents matching a query as a tracked task
+// DeleteByQuery 以可跟踪任务的方式删除所有匹配查询的文档
+// @Summary Delete documents by query
+// @Description Start an asynchronous delete-by-query and return the tracking task
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.DeleteByQueryRequest true "Query selecting the documents to delete"
+// @Success 202 {object} model.OperationTask
+// @Failure 400 {string} string "Bad Request"
+// @Router /vectors/{index_name}/delete_by_query [post]
+func (h *VectorHandler) DeleteByQuery(c *gin.Context) {
+	indexName := c.Param("index_name")
+
+	var req model.DeleteByQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if len(req.Query) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query is required"})
+		return
+	}
+
+	// Delete-by-query runs as an Elasticsearch task and has no embedded equivalent
+	// 按查询删除以 Elasticsearch 任务运行，内嵌存储不支持
+	esService, ok := h.store.(*service.ESService)
+	if !ok {
+		c.JSON(http.StatusNotImplemented, gin.H{"error": "delete by query requires the Elasticsearch backend"})
+		return
+	}
+
+	task, err := h.taskService.CreateTask("delete_by_query", indexName)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	esTaskID, err := esService.DeleteByQuery(indexName, req.Query)
+	if err != nil {
+		h.taskService.FailTask(task, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error(), "task": task})
+		return
+	}
+
+	// Documents deleted before a failure or cancellation count too, so usage is synced whatever the outcome
+	// 失败或取消前已删除的文档同样计入，因此无论结果如何都同步用量
+	h.taskService.TrackESTask(task, esTaskID, func(response map[string]interface{}) {
+		h.syncIndexUsage(indexName)
+	})
+
+	c.JSON(http.StatusAccepted, task)
+}
+
+// ExportIndex streams the documents of an index as NDJSON
+// ExportIndex 以 NDJSON 流式导出索引中的文档
+// @Summary Export a vector index
+// @Description Stream all matching documents as NDJSON (optionally gzip) using point-in-time and search_after
+// @Tags vectors
+// @Accept json
+// @Produce application/x-ndjson
+// @Param index_name path string true "Index name"
+// @Param request body model.ExportRequest false "Export options"
+// @Success 200 {string} string "NDJSON stream"
+// @Failure 400 {string} string "Bad Request"
+// @Failure 404 {string} string "Not Found"
+// @Failure 500 {string} string "Internal Server Error"
+// @Router /vectors/{index_name}/export [post]
+func (h *VectorHandler) ExportIndex(c *gin.Context) {
+	indexName := c.Param("index_name")
+
+	req, ok := bindExportRequest(c)
+	if !ok {
+		return
+	}
+
+	// Count and open the point-in-time first so that failures still get a proper status
+	// 先统计文档数并打开 point-in-time，使失败时仍能返回正确的状态码
+	cursor, err := h.exportService.OpenExport(indexName, req)
+	if err != nil {
+		writeStoreError(c, err)
+		return
+	}
+	defer cursor.Close()
+
+	fileName := indexName + ".ndjson"
+	contentType := "application/x-ndjson"
+	if req.Gzip {
+		fileName += ".gz"
+		contentType = "application/gzip"
+	}
+	c.Header("Content-Type", contentType)
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", fileName))
+	c.Status(http.StatusOK)
+	c.Writer.WriteHeaderNow()
+
+	// Headers are already sent, so errors can only be logged and the stream cut short
+	// 响应头已发送，出错时只能记录日志并中断流
+	written, err := cursor.WriteTo(c.Request.Context(), c.Writer, nil)
+	if err != nil {
+		log.Printf("Error exporting index %s after %d documents: %v", indexName, written, err)
+		return
+	}
+	log.Printf("Exported %d documents from index %s", written, indexName)
+}
+
+// StartExportJob exports an index to a server-side file as a tracked task
+// StartExportJob 以可跟踪任务的方式将索引导出到服务器端文件
+// @Summary Start an export job
+// @Description Export all matching documents to a server-side NDJSON file as a background task
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.ExportRequest false "Export options"
+// @Success 202 {object} model.OperationTask
+// @Failure 400 {string} string "Bad Request"
+// @Router /vectors/{index_name}/export/jobs [post]
+func (h *VectorHandler) StartExportJob(c *gin.Context) {
+	indexName := c.Param("index_name")
+
+	req, ok := bindExportRequest(c)
+	if !ok {
+		return
+	}
+
+	task, err := h.exportService.StartExportJob(indexName, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, task)
+}
+
+// bindExportRequest reads export options from the optional JSON body
+// bindExportRequest 从可选的 JSON 请求体读取导出选项
+func bindExportRequest(c *gin.Context) (model.ExportRequest, bool) {
+	var req model.ExportRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return req, false
+		}
+	}
+	if c.Query("gzip") == "true" {
+		req.Gzip = true
+	}
+	return req, true
+}
+
+// syncIndexUsage overwrites the recorded usage with the live _stats of every index
+// behind name, which may be an alias
+// syncIndexUsage 使用 name（可能是别名）背后每个索引的实时 _stats 覆盖记录的使用量
+func (h *VectorHandler) syncIndexUsage(name string) {
+	indices, err := h.store.GetAliasIndices(name)
+	if err != nil || len(indices) == 0 {
+		indices = []string{name}
+	}
+
+	for _, indexName := range indices {
+		docCount, storageBytes, err := h.store.GetIndexUsage(indexName)
+		if err != nil {
+			log.Printf("Warning: Failed to get usage for index %s: %v", indexName, err)
+			continue
+		}
+		err = h.metadataService.SetIndexUsage(indexName, docCount, storageBytes)
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("Warning: Failed to update usage for index %s: %v", indexName, err)
+		}
+	}
+}
+
+// recordIndexUsage updates the document count and storage recorded for a physical index
+// recordIndexUsage 更新物理索引记录的文档数和存储大小
+func (h *VectorHandler) recordIndexUsage(indexName string, docDelta int) {
+	storageBytes := int64(-1)
+	if _, size, err := h.store.GetIndexUsage(indexName); err == nil {
+		storageBytes = size
+	}
+
+	err := h.metadataService.UpdateIndexUsage(indexName, docDelta, storageBytes)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Warning: Failed to update usage for index %s: %v", indexName, err)
+	}
+}
+
 // Search performs a vector search
 // Search 执行向量搜索
 func (h *VectorHandler) Search(c *gin.Context) {
@@ -130,15 +572,99 @@ func (h *VectorHandler) Search(c *gin.Context) {
 		return
 	}
 
-	result, err := h.esService.Search(indexName, query)
+	metadata := h.indexMetadata(indexName)
+	if err := h.embedding.EmbedSearch(c.Request.Context(), metadata, query); err != nil {
+		writeStoreError(c, err)
+		return
+	}
+
+	// Quantized indexes rescore with the index default oversample unless the query sets a
+	// rescore_vector; the rescoring happens here since Elasticsearch 8.15 has no rescore_vector
+	// 量化索引在查询未指定 rescore_vector 时使用索引默认的过采样倍数；由于 Elasticsearch 8.15 不支持
+	// rescore_vector，重打分在此完成
+	var rescore *service.KNNRescore
+	if metadata != nil {
+		rescore = service.OversampleKNNQuery(query, metadata.Metric, metadata.RescoreOversample)
+	}
+
+	result, err := h.store.Search(indexName, query)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		writeStoreError(c, err)
 		return
 	}
+	rescore.Apply(result)
 
 	c.JSON(http.StatusOK, result)
 }
 
+// BatchSearch performs many vector searches in one request
+// BatchSearch 在一次请求中执行多个向量搜索
+// @Summary Batch vector search
+// @Description Run many kNN queries through Elasticsearch _msearch with bounded concurrency
+// @Tags vectors
+// @Accept json
+// @Produce json
+// @Param index_name path string true "Index name"
+// @Param request body model.BatchSearchRequest true "Batch search request"
+// @Success 200 {object} model.BatchSearchResponse
+// @Failure 400 {string} string "Bad Request"
+// @Router /vectors/{index_name}/search/batch [post]
+func (h *VectorHandler) BatchSearch(c *gin.Context) {
+	indexName := c.Param("index_name")
+	if indexName == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "index_name is required"})
+		return
+	}
+
+	var req model.BatchSearchRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if req.BatchSize < 0 || req.BatchSize > maxBatchSearchSize {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("batch_size must be between 0 and %d", maxBatchSearchSize)})
+		return
+	}
+	if len(req.Texts) > maxBatchSearchQueries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many texts: %d (max %d)", len(req.Texts), maxBatchSearchQueries)})
+		return
+	}
+	metadata := h.indexMetadata(indexName)
+	if err := h.embedding.EmbedBatchSearch(c.Request.Context(), metadata, &req); err != nil {
+		writeStoreError(c, err)
+		return
+	}
+	if metadata != nil {
+		if req.Oversample == 0 {
+			req.Oversample = metadata.RescoreOversample
+		}
+		req.Metric = metadata.Metric
+	}
+
+	if len(req.Vectors) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "vectors or texts is required"})
+		return
+	}
+	if len(req.Vectors) > maxBatchSearchQueries {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("too many vectors: %d (max %d)", len(req.Vectors), maxBatchSearchQueries)})
+		return
+	}
+
+	// All query vectors must share the same dimension
+	// 所有查询向量必须具有相同的维度
+	dimension := len(req.Vectors[0])
+	for i, vector := range req.Vectors {
+		if len(vector) == 0 || len(vector) != dimension {
+			c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("vector %d has dimension %d, expected %d", i, len(vector), dimension)})
+			return
+		}
+	}
+
+	result := h.store.BatchSearch(indexName, req)
+	c.JSON(http.StatusOK, result)
+}
+
 // GetIndexStats gets index statistics
 // GetIndexStats 获取索引统计信息
 func (h *VectorHandler) GetIndexStats(c *gin.Context) {
@@ -148,12 +674,30 @@ func (h *VectorHandler) GetIndexStats(c *gin.Context) {
 		return
 	}
 
-	stats, err := h.esService.GetIndexStats(indexName)
+	stats, err := h.store.GetIndexStats(indexName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Report the off-heap vector memory implied by the quantization choice
+	// 报告量化方式对应的堆外向量内存估算
+	if metadata := h.indexMetadata(indexName); metadata != nil {
+		docCount, _, err := h.store.GetIndexUsage(indexName)
+		if err != nil {
+			docCount = int64(metadata.DocumentCount)
+		}
+		quantization, _ := service.NormalizeQuantization(metadata.Quantization)
+		stats["vector_memory"] = gin.H{
+			"quantization":       quantization,
+			"dimension":          metadata.Dimension,
+			"vector_count":       docCount,
+			"estimated_bytes":    service.EstimateVectorMemory(docCount, metadata.Dimension, quantization),
+			"unquantized_bytes":  service.EstimateVectorMemory(docCount, metadata.Dimension, "none"),
+			"rescore_oversample": metadata.RescoreOversample,
+		}
+	}
+
 	c.JSON(http.StatusOK, stats)
 }
 
@@ -171,7 +715,7 @@ func (h *VectorHandler) ListVectorIndexes(c *gin.Context) {
 	// For now, we'll just list all indexes from ES
 	// 在实际实现中，您可能需要查询 ES 元数据或将索引元数据存储在自己的数据库中
 	// 目前，我们只是列出 ES 中的所有索引
-	indexNames, err := h.esService.ListIndexes()
+	indexNames, err := h.store.ListIndexes()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -220,16 +764,49 @@ func (h *VectorHandler) DeleteVectorIndex(c *gin.Context) {
 		return
 	}
 
-	err := h.esService.DeleteIndex(req.IndexName)
+	// A reindexed index is an alias, so delete every version behind it, including those kept by reindex
+	// 重建过的索引是一个别名，因此删除其背后的所有版本，包括重建时保留的旧版本
+	deleted, err := service.DeleteIndexVersions(h.store, h.metadataService, req.IndexName)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
+	// Mark metadata as deleted and release quota
+	// 将元数据标记为已删除并释放配额
+	err = h.metadataService.MarkIndexDeleted(req.IndexName)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Warning: Failed to mark index %s as deleted: %v", req.IndexName, err)
+	}
+
 	response := map[string]interface{}{
-		"message": "Vector index deleted successfully",
-		"index":   req.IndexName,
-		"status":  "deleted",
+		"message":         "Vector index deleted successfully",
+		"index":           req.IndexName,
+		"deleted_indices": deleted,
+		"status":          "deleted",
 	}
 	c.JSON(http.StatusOK, response)
 }
+
+// indexMetadata returns the metadata of an index, or nil when the index is not managed
+// indexMetadata 返回索引元数据，索引不受管理时返回 nil
+func (h *VectorHandler) indexMetadata(indexName string) *model.IndexMetadata {
+	metadata, err := h.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil
+	}
+	return metadata
+}
+
+// writeStoreError maps vector store errors to HTTP responses
+// writeStoreError 将向量存储错误映射为 HTTP 响应
+func writeStoreError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrInvalidRequest):
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, service.ErrIndexNotFound):
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -1,6 +1,7 @@
 package model
 
 import (
+	"slices"
 	"time"
 )
 
@@ -22,6 +23,60 @@ type CreateRequest struct {
 	VectorCount int    `json:"vector_count"`  // 向量数量估计
 	IndexLimit  int    `json:"index_limit"`   // 索引数量限制
 	GitlabURL   string `json:"gitlab_url"`    // Gitlab 地址（可选）
+
+	// Sizing inputs for the capacity planner
+	// 容量规划的输入参数
+	Quantization      string `json:"quantization"`        // none, int8
+	Engine            string `json:"engine"`              // hnsw, ivf, flat
+	IndexReplicas     int    `json:"index_replicas"`      // 每个主分片的副本数
+	SkipCapacityCheck bool   `json:"skip_capacity_check"` // 跳过资源不足检查
+}
+
+// CapacityRequest represents the request body for a capacity estimate
+// CapacityRequest 容量估算的请求体
+type CapacityRequest struct {
+	Dimension     int    `json:"dimension"`
+	VectorCount   int64  `json:"vector_count"`
+	Nodes         int    `json:"nodes"`          // ES 节点（Pod）数
+	IndexReplicas int    `json:"index_replicas"` // 每个主分片的副本数
+	Quantization  string `json:"quantization"`   // none, int8
+	Engine        string `json:"engine"`         // hnsw, ivf, flat
+	NList         int    `json:"nlist"`          // IVF 聚类中心数（engine=ivf）
+
+	// Resources to check against the estimate (optional)
+	// 需要与估算结果比对的资源（可选）
+	CPU      string `json:"cpu"`
+	Memory   string `json:"memory"`
+	DiskSize string `json:"disk_size"`
+}
+
+// CapacityEstimate represents the result of a capacity estimate
+// CapacityEstimate 容量估算结果
+type CapacityEstimate struct {
+	Dimension     int    `json:"dimension"`
+	VectorCount   int64  `json:"vector_count"`
+	Nodes         int    `json:"nodes"`
+	IndexReplicas int    `json:"index_replicas"`
+	Quantization  string `json:"quantization"`
+	Engine        string `json:"engine"`
+
+	RawVectorBytes int64 `json:"raw_vector_bytes"` // 单份原始浮点向量大小
+	OffHeapBytes   int64 `json:"off_heap_bytes"`   // 所有副本的向量与图/IVF 堆外内存
+	DiskBytes      int64 `json:"disk_bytes"`       // 所有副本的磁盘占用
+	// Per node requirements
+	// 单节点需求
+	HeapBytesPerNode    int64 `json:"heap_bytes_per_node"`
+	OffHeapBytesPerNode int64 `json:"off_heap_bytes_per_node"`
+	DiskBytesPerNode    int64 `json:"disk_bytes_per_node"`
+
+	// Recommended Kubernetes resources per node
+	// 推荐的单节点 Kubernetes 资源
+	RecommendedCPU      string `json:"recommended_cpu"`
+	RecommendedMemory   string `json:"recommended_memory"`
+	RecommendedDiskSize string `json:"recommended_disk_size"`
+
+	UnderProvisioned bool     `json:"under_provisioned"`
+	Warnings         []string `json:"warnings,omitempty"`
 }
 
 // DeleteRequest represents the request body for deleting a cluster
@@ -35,6 +90,7 @@ type DeleteRequest struct {
 type ScaleRequest struct {
 	Namespace string `json:"namespace"` // 命名空间
 	Replicas  int    `json:"replicas"`  // 目标副本数
+	Reason    string `json:"reason"`    // 原因，记录在审计中
 }
 
 // ClusterStatus represents the status of a cluster
@@ -55,16 +111,32 @@ type ClusterStatus struct {
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 	Details     map[string]interface{} `json:"details"`
+
+	// Health combines the StatefulSet readiness with the Elasticsearch cluster health
+	// Health 结合 StatefulSet 就绪情况与 Elasticsearch 集群健康状态
+	ReadyReplicas   int              `json:"ready_replicas"`
+	Load            string           `json:"load,omitempty"`          // high_load, normal, low_load
+	StatusReason    string           `json:"status_reason,omitempty"` // 状态原因
+	ESHealth        *ESClusterHealth `json:"es_health,omitempty"`
+	UnhealthyShards []ESShard        `json:"unhealthy_shards,omitempty"`
+	HealthCheckedAt *time.Time       `json:"health_checked_at,omitempty"`
 }
 
 // VectorIndexRequest represents the request body for creating a vector index
 // VectorIndexRequest 创建向量索引的请求体
 type VectorIndexRequest struct {
 	IndexName    string            `json:"index_name"`
+	TenantID     string            `json:"tenant_id"` // 所属租户（用于配额统计）
 	Dimension    int               `json:"dimension"`
 	Metric       string            `json:"metric"`     // L2, cosine, dot
 	IVFParams    map[string]int    `json:"ivf_params"` // nlist, nprobe
 	FieldMapping map[string]string `json:"field_mapping"`
+	Encoder      string            `json:"encoder"`      // 服务端文本编码器名称（可选）
+	TextField    string            `json:"text_field"`   // 需要编码的文本字段，默认 text
+	Quantization string            `json:"quantization"` // none, int8
+	// Default oversample factor for rescoring quantized kNN searches
+	// 量化索引 kNN 搜索重打分的默认过采样倍数
+	RescoreOversample float64 `json:"rescore_oversample"`
 }
 
 // VectorIndexStatus represents the status of a vector index
@@ -79,6 +151,170 @@ type VectorIndexStatus struct {
 	CreatedAt     time.Time      `json:"created_at"`
 }
 
+// BatchSearchRequest represents the request body for a batch vector search
+// BatchSearchRequest 批量向量搜索的请求体
+type BatchSearchRequest struct {
+	Field         string                 `json:"field"`          // 向量字段名，默认 vector
+	Vectors       [][]float32            `json:"vectors"`        // 查询向量列表
+	K             int                    `json:"k"`              // 每个查询返回的结果数
+	NumCandidates int                    `json:"num_candidates"` // 每个分片的候选数
+	Filter        map[string]interface{} `json:"filter"`         // 可选过滤条件
+	Source        []string               `json:"_source"`        // 返回的字段
+	BatchSize     int                    `json:"batch_size"`     // 每次 _msearch 请求包含的查询数，最大 1000
+	Concurrency   int                    `json:"concurrency"`    // 并发的 _msearch 请求数
+	Texts         []string               `json:"texts"`          // 查询文本，由索引的编码器转换为向量
+	Oversample    float64                `json:"oversample"`     // 量化索引重打分过采样倍数，为 0 时使用索引默认值
+	Metric        string                 `json:"-"`              // 重打分使用的索引相似度，由索引元数据填充
+}
+
+// BatchSearchResult represents the result of a single query in a batch search
+// BatchSearchResult 批量搜索中单个查询的结果
+type BatchSearchResult struct {
+	Index int                      `json:"index"` // 查询在请求中的位置
+	Took  int                      `json:"took"`
+	Hits  []map[string]interface{} `json:"hits"`
+	Error string                   `json:"error,omitempty"`
+}
+
+// BatchSearchResponse represents the response of a batch vector search
+// BatchSearchResponse 批量向量搜索的响应
+type BatchSearchResponse struct {
+	Took    int64               `json:"took"` // 总耗时（毫秒）
+	Total   int                 `json:"total"`
+	Errors  int                 `json:"errors"`
+	Results []BatchSearchResult `json:"results"`
+}
+
+// DocumentResult represents the result of a single document write
+// DocumentResult 单个文档写操作的结果
+type DocumentResult struct {
+	Index   string `json:"index"`
+	ID      string `json:"id"`
+	Result  string `json:"result"` // created, updated, deleted, noop
+	Version int64  `json:"version"`
+	Error   string `json:"error,omitempty"` // 批量写入中单个文档的错误
+}
+
+// EmbedRequest represents the request body for encoding texts into vectors
+// EmbedRequest 将文本编码为向量的请求体
+type EmbedRequest struct {
+	Encoder string   `json:"encoder"`
+	Texts   []string `json:"texts"`
+}
+
+// EncoderInfo describes a registered text encoder
+// EncoderInfo 已注册文本编码器的描述
+type EncoderInfo struct {
+	Name      string `json:"name"`
+	Dimension int    `json:"dimension"`
+}
+
+// BulkDocument represents a single document in a bulk index request
+// BulkDocument 批量写入请求中的单个文档
+type BulkDocument struct {
+	ID       string                 `json:"id"` // 为空时自动生成
+	Document map[string]interface{} `json:"document"`
+}
+
+// BulkRequest represents the request body for indexing many documents at once
+// BulkRequest 批量写入文档的请求体
+type BulkRequest struct {
+	Documents []BulkDocument `json:"documents"`
+}
+
+// BulkResponse represents the response of a bulk index request
+// BulkResponse 批量写入的响应
+type BulkResponse struct {
+	Took   int64            `json:"took"` // 总耗时（毫秒）
+	Errors int              `json:"errors"`
+	Items  []DocumentResult `json:"items"`
+}
+
+// DeleteByQueryRequest represents the request body for deleting documents by query
+// DeleteByQueryRequest 按查询条件删除文档的请求体
+type DeleteByQueryRequest struct {
+	Query map[string]interface{} `json:"query"` // Elasticsearch 查询条件
+}
+
+// ExportRequest represents the request body for exporting a vector index
+// ExportRequest 导出向量索引的请求体
+type ExportRequest struct {
+	Fields    []string               `json:"fields"`     // 导出的字段，为空时导出全部
+	Query     map[string]interface{} `json:"query"`      // 可选过滤条件
+	Gzip      bool                   `json:"gzip"`       // 是否使用 gzip 压缩
+	BatchSize int                    `json:"batch_size"` // 每页文档数
+}
+
+// ReindexRequest represents the request body for reindexing a vector index into a new version
+// ReindexRequest 将向量索引重建为新版本的请求体
+type ReindexRequest struct {
+	Dimension       int               `json:"dimension"`        // 新维度，为 0 时沿用当前值
+	Metric          string            `json:"metric"`           // 新度量方式，为空时沿用当前值
+	IVFParams       map[string]int    `json:"ivf_params"`       // 新 IVF 参数，为空时沿用当前值
+	FieldMapping    map[string]string `json:"field_mapping"`    // 附加字段映射
+	VectorTransform string            `json:"vector_transform"` // truncate, pad, normalize
+	Script          string            `json:"script"`           // 自定义 painless 脚本（优先于 vector_transform）
+	BlockWrites     bool              `json:"block_writes"`     // 整个复制期间阻止写入旧索引；否则仅在最后的增量复制期间阻止
+	DeleteOld       bool              `json:"delete_old"`       // 切换别名后删除旧索引（逻辑名仍为物理索引时必须开启）
+	Quantization    string            `json:"quantization"`     // 新量化方式，为空时沿用当前值
+}
+
+// AliasRequest represents the request body for creating or updating an index alias
+// AliasRequest 创建或更新索引别名的请求体
+type AliasRequest struct {
+	Alias      string                 `json:"alias"`
+	Indices    []string               `json:"indices"`     // 别名指向的物理索引
+	WriteIndex string                 `json:"write_index"` // 写入目标索引（指向多个索引时需要）
+	Filter     map[string]interface{} `json:"filter"`      // 可选过滤条件
+	TenantID   string                 `json:"tenant_id"`
+}
+
+// TrainRequest represents the request body for training an IVF index
+// TrainRequest 训练 IVF 索引的请求体
+type TrainRequest struct {
+	Field         string `json:"field"`          // 向量字段名，默认 vector
+	NList         int    `json:"nlist"`          // 聚类中心数，为 0 时使用索引配置
+	SampleSize    int    `json:"sample_size"`    // 训练采样向量数
+	MaxIterations int    `json:"max_iterations"` // KMeans 最大迭代次数
+}
+
+// TrainResult represents the result returned by the IVF plugin after training
+// TrainResult IVF 插件训练完成后返回的结果（插件 RestTrainIVFIndexAction 的响应）
+type TrainResult struct {
+	Success        bool    `json:"success"`
+	Message        string  `json:"message"`
+	VectorCount    int     `json:"vector_count"`
+	NList          int     `json:"nlist"`
+	CentroidCount  int     `json:"centroid_count"`
+	Inertia        float64 `json:"inertia"`
+	TrainingTimeMs int64   `json:"training_time_ms"`
+}
+
+// BenchmarkRequest represents the request body for a recall and latency benchmark
+// BenchmarkRequest 召回率与延迟基准测试的请求体
+type BenchmarkRequest struct {
+	Field         string      `json:"field"`          // 向量字段名，默认 vector
+	K             int         `json:"k"`              // recall@k 中的 k
+	Queries       [][]float32 `json:"queries"`        // 查询向量，为空时从样本中抽取
+	QueryCount    int         `json:"query_count"`    // 从样本中抽取的查询数
+	SampleSize    int         `json:"sample_size"`    // 用于抽取查询向量的文档数上限，真实结果始终在整个索引上计算
+	NProbes       []int       `json:"nprobes"`        // 待测试的 nprobe 值（IVF 插件查询）
+	NumCandidates []int       `json:"num_candidates"` // 待测试的 num_candidates 值（ES kNN 查询）
+}
+
+// BenchmarkSetting represents the measurements of one search setting in a benchmark
+// BenchmarkSetting 基准测试中单个搜索参数的测量结果
+type BenchmarkSetting struct {
+	NProbe        int     `json:"nprobe,omitempty"`
+	NumCandidates int     `json:"num_candidates,omitempty"`
+	Recall        float64 `json:"recall"` // recall@k (0-1)
+	P50Ms         float64 `json:"p50_ms"`
+	P95Ms         float64 `json:"p95_ms"`
+	P99Ms         float64 `json:"p99_ms"`
+	QPS           float64 `json:"qps"`
+	Errors        int     `json:"errors"`
+}
+
 // IVFParams represents IVF algorithm parameters
 // IVFParams IVF 算法参数
 type IVFParams struct {
@@ -107,12 +343,91 @@ type IndexMetadata struct {
 	Status        string    `json:"status"` // active, deleted, building
 	DocumentCount int       `json:"document_count"`
 	StorageSize   string    `json:"storage_size"`
+	Version       int       `json:"version"`       // 当前底层索引版本，1 表示原始索引
+	BackingIndex  string    `json:"backing_index"` // 当前底层物理索引（为空时即 IndexName）
+	Encoder       string    `json:"encoder"`       // 服务端文本编码器（为空表示客户端提供向量）
+	TextField     string    `json:"text_field"`    // 由编码器转换为向量的文本字段
+	Quantization  string    `json:"quantization"`  // none, int8
+	// Default rescoring oversample factor applied to kNN searches
+	// kNN 搜索默认使用的重打分过采样倍数
+	RescoreOversample float64 `json:"rescore_oversample"`
+
+	// IVF training results
+	// IVF 训练结果
+	CentroidCount       int        `json:"centroid_count"`
+	TrainingVectorCount int        `json:"training_vector_count"`
+	TrainingTimeMs      int64      `json:"training_time_ms"`
+	TrainingInertia     float64    `json:"training_inertia"`
+	TrainedAt           *time.Time `json:"trained_at,omitempty"`
 }
 
 func (IndexMetadata) TableName() string {
 	return "index_metadata"
 }
 
+// OperationTask represents a long running background operation
+// OperationTask 长时间运行的后台操作任务
+type OperationTask struct {
+	ID         string                 `json:"id" gorm:"primaryKey"`
+	Type       string                 `json:"type" gorm:"index"` // delete_by_query, export, reindex, train, benchmark, ...
+	IndexName  string                 `json:"index_name" gorm:"index"`
+	Status     string                 `json:"status"`     // pending, running, completed, failed, cancelled
+	ESTaskID   string                 `json:"es_task_id"` // Elasticsearch 任务 ID（如有）
+	Total      int64                  `json:"total"`
+	Processed  int64                  `json:"processed"`
+	Progress   float64                `json:"progress"` // Percentage (0-100)
+	Error      string                 `json:"error,omitempty"`
+	Result     map[string]interface{} `json:"result,omitempty" gorm:"serializer:json"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+	FinishedAt *time.Time             `json:"finished_at,omitempty"`
+}
+
+func (OperationTask) TableName() string {
+	return "operation_tasks"
+}
+
+// IndexAlias represents an alias managed through the alias API
+// IndexAlias 通过别名 API 管理的索引别名
+type IndexAlias struct {
+	ID         string                 `json:"id" gorm:"primaryKey"`
+	Alias      string                 `json:"alias" gorm:"uniqueIndex"`
+	Indices    []string               `json:"indices" gorm:"serializer:json"`
+	WriteIndex string                 `json:"write_index"`
+	Filter     map[string]interface{} `json:"filter,omitempty" gorm:"serializer:json"`
+	CreatedBy  string                 `json:"created_by"`
+	CreatedAt  time.Time              `json:"created_at"`
+	UpdatedAt  time.Time              `json:"updated_at"`
+}
+
+func (IndexAlias) TableName() string {
+	return "index_aliases"
+}
+
+// BenchmarkRun represents a persisted recall and latency benchmark of an index
+// BenchmarkRun 持久化的索引召回率与延迟基准测试记录
+type BenchmarkRun struct {
+	ID         string             `json:"id" gorm:"primaryKey"`
+	IndexName  string             `json:"index_name" gorm:"index"`
+	TaskID     string             `json:"task_id"`
+	Field      string             `json:"field"`
+	Metric     string             `json:"metric"`
+	K          int                `json:"k"`
+	NList      int                `json:"nlist"`       // 测试时索引的 nlist
+	Mode       string             `json:"mode"`        // nprobe, num_candidates
+	QueryCount int                `json:"query_count"` // 实际执行的查询数
+	SampleSize int                `json:"sample_size"` // 实际用于抽取查询向量的文档数
+	Status     string             `json:"status"`      // running, completed, failed, cancelled
+	Error      string             `json:"error,omitempty"`
+	Results    []BenchmarkSetting `json:"results" gorm:"serializer:json"`
+	CreatedAt  time.Time          `json:"created_at"`
+	FinishedAt *time.Time         `json:"finished_at,omitempty"`
+}
+
+func (BenchmarkRun) TableName() string {
+	return "benchmark_runs"
+}
+
 // TenantQuota represents tenant quota information
 // TenantQuota 租户配额信息
 type TenantQuota struct {
@@ -122,6 +437,7 @@ type TenantQuota struct {
 	MaxStorage     string    `json:"max_storage"`     // 最大存储空间
 	CurrentIndices int       `json:"current_indices"` // 当前索引数
 	CurrentStorage string    `json:"current_storage"` // 当前存储空间
+	MaxReplicas    int       `json:"max_replicas"`    // 租户所有集群的副本总数上限，0 表示不限制
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
@@ -138,7 +454,7 @@ type DeploymentStatus struct {
 	Namespace   string                 `json:"namespace" gorm:"uniqueIndex"`
 	User        string                 `json:"user" gorm:"index"`
 	ServiceName string                 `json:"service_name"`
-	Status      string                 `json:"status"` // created, running, scaling, deleting, error
+	Status      string                 `json:"status"` // created, running, scaling, degraded, unhealthy, unavailable, stopped, deleting, error or a load level when healthy
 	CPUUsage    float64                `json:"cpu_usage"`
 	MemoryUsage float64                `json:"memory_usage"`
 	DiskUsage   float64                `json:"disk_usage"`
@@ -150,12 +466,46 @@ type DeploymentStatus struct {
 	CreatedAt   time.Time              `json:"created_at"`
 	UpdatedAt   time.Time              `json:"updated_at"`
 	Details     map[string]interface{} `json:"details" gorm:"-"`
+
+	// Health recorded by the monitoring loop
+	// 监控循环记录的健康状态
+	ReadyReplicas   int             `json:"ready_replicas"`
+	Load            string          `json:"load"`          // high_load, normal, low_load
+	StatusReason    string          `json:"status_reason"` // 状态原因
+	ESHealth        ESClusterHealth `json:"es_health" gorm:"embedded;embeddedPrefix:es_"`
+	UnhealthyShards []ESShard       `json:"unhealthy_shards" gorm:"serializer:json"`
+	HealthCheckedAt *time.Time      `json:"health_checked_at"`
+
+	// LastScaledAt is the time of the last manual or automatic scale, used for the autoscaler cooldown
+	// LastScaledAt 最近一次手动或自动扩缩容的时间，用于自动扩缩容冷却期
+	LastScaledAt *time.Time `json:"last_scaled_at"`
 }
 
 func (DeploymentStatus) TableName() string {
 	return "deployment_status"
 }
 
+// ScaleEvent is the audit record of one manual or automatic scale attempt
+// ScaleEvent 一次手动或自动扩缩容尝试的审计记录
+type ScaleEvent struct {
+	ID           string    `json:"id" gorm:"primaryKey"`
+	Namespace    string    `json:"namespace" gorm:"index:idx_scale_event_ns_time"`
+	TenantOrgID  string    `json:"tenant_org_id"`
+	User         string    `json:"user"`
+	Source       string    `json:"source"` // manual, autoscaler
+	Actor        string    `json:"actor"`  // 发起者
+	FromReplicas int       `json:"from_replicas"`
+	ToReplicas   int       `json:"to_replicas"`
+	Reason       string    `json:"reason"`
+	Result       string    `json:"result"` // succeeded, failed, rejected
+	Error        string    `json:"error,omitempty"`
+	CreatedAt    time.Time `json:"created_at" gorm:"index:idx_scale_event_ns_time"`
+}
+
+func (ScaleEvent) TableName() string {
+	return "scale_events"
+}
+
 // TenantContainer represents a tenant's container metadata
 // TenantContainer 租户容器元数据
 type TenantContainer struct {
@@ -201,37 +551,206 @@ type TenantConfig struct {
 // Metrics represents container resource usage metrics
 // Metrics 容器资源使用指标
 type Metrics struct {
-	ID          string    `json:"id" gorm:"primaryKey"`
-	Namespace   string    `json:"namespace" gorm:"index"`
-	CPUUsage    float64   `json:"cpu_usage"`
-	MemoryUsage float64   `json:"memory_usage"`
-	DiskUsage   float64   `json:"disk_usage"`
-	QPS         float64   `json:"qps"`
-	Timestamp   time.Time `json:"timestamp" gorm:"index"`
+	ID        string `json:"id" gorm:"primaryKey"`
+	Namespace string `json:"namespace" gorm:"index"`
+	// Usage percentages averaged over the pods of the cluster
+	// 集群各 Pod 的平均使用率（百分比）
+	CPUUsage    float64 `json:"cpu_usage"`
+	MemoryUsage float64 `json:"memory_usage"`
+	DiskUsage   float64 `json:"disk_usage"`
+	HeapUsage   float64 `json:"heap_usage"`
+	// Usage percentages of the busiest pod
+	// 最繁忙 Pod 的使用率（百分比）
+	CPUUsageMax    float64 `json:"cpu_usage_max"`
+	MemoryUsageMax float64 `json:"memory_usage_max"`
+	DiskUsageMax   float64 `json:"disk_usage_max"`
+	HeapUsageMax   float64 `json:"heap_usage_max"`
+	// Totals over the pods of the cluster
+	// 集群各 Pod 的合计值
+	CPUCores     float64 `json:"cpu_cores"`
+	MemoryBytes  int64   `json:"memory_bytes"`
+	DiskBytes    int64   `json:"disk_bytes"`
+	IndexingRate float64 `json:"indexing_rate"`
+	PodCount     int     `json:"pod_count"`
+	QPS          float64 `json:"qps"`
+	PluginQPS    float64 `json:"plugin_qps"`
+	// Search latency derived from Elasticsearch search time counters
+	// 根据 Elasticsearch 搜索耗时计数器计算的搜索延迟
+	SearchLatencyMs    float64                       `json:"search_latency_ms"`
+	SearchLatencyP95Ms float64                       `json:"search_latency_p95_ms"`
+	SearchLatencyP99Ms float64                       `json:"search_latency_p99_ms"`
+	Indices            map[string]IndexSearchMetrics `json:"indices,omitempty" gorm:"serializer:json"`
+	// Missing lists the scaling metrics (cpu_usage, memory_usage, disk_usage, heap_usage, qps)
+	// that could not be measured; their values are 0 and must not be read as measurements
+	// Missing 列出无法测量的扩缩容指标（cpu_usage、memory_usage、disk_usage、heap_usage、qps），其值为 0，不能视为测量值
+	Missing   []string  `json:"missing,omitempty" gorm:"serializer:json"`
+	Timestamp time.Time `json:"timestamp" gorm:"index"`
+}
+
+// IsMissing reports whether a scaling metric could not be measured
+// IsMissing 判断扩缩容指标是否无法测量
+func (m *Metrics) IsMissing(metric string) bool {
+	return slices.Contains(m.Missing, metric)
+}
+
+// IndexSearchMetrics represents the search rate and latency of one index
+// IndexSearchMetrics 单个索引的搜索速率与延迟
+type IndexSearchMetrics struct {
+	QPS          float64         `json:"qps"`
+	QueryTotal   int64           `json:"query_total"`
+	AvgLatencyMs float64         `json:"avg_latency_ms"`
+	P95LatencyMs float64         `json:"p95_latency_ms"`
+	P99LatencyMs float64         `json:"p99_latency_ms"`
+	Plugin       *IVFPluginStats `json:"plugin,omitempty"`
+}
+
+// IVFPluginStats represents the counters reported by the IVF plugin for an index
+// IVFPluginStats IVF 插件报告的索引计数器
+type IVFPluginStats struct {
+	NList          int     `json:"nlist"`
+	Dimension      int     `json:"dimension"`
+	MetricType     string  `json:"metric_type"`
+	IsTrained      bool    `json:"is_trained"`
+	TotalVectors   int64   `json:"total_vectors"`
+	MinClusterSize int64   `json:"min_cluster_size"`
+	MaxClusterSize int64   `json:"max_cluster_size"`
+	AvgClusterSize float64 `json:"avg_cluster_size"`
 }
 
 func (Metrics) TableName() string {
 	return "monitoring_metrics"
 }
 
+// MetricsRollup represents the metrics of a namespace compacted into one time bucket
+// MetricsRollup 压缩到一个时间桶内的命名空间指标
+type MetricsRollup struct {
+	ID          string                      `json:"id" gorm:"primaryKey"`
+	Namespace   string                      `json:"namespace" gorm:"index"`
+	BucketStart time.Time                   `json:"bucket_start" gorm:"index"`
+	Samples     int                         `json:"samples"`
+	Values      map[string]SeriesBucketStat `json:"values" gorm:"serializer:json"`
+}
+
+// MetricsRollup5m is a 5 minute rollup of monitoring_metrics
+// MetricsRollup5m monitoring_metrics 的 5 分钟汇总
+type MetricsRollup5m struct {
+	MetricsRollup
+}
+
+func (MetricsRollup5m) TableName() string {
+	return "monitoring_metrics_5m"
+}
+
+// MetricsRollup1h is a 1 hour rollup of monitoring_metrics
+// MetricsRollup1h monitoring_metrics 的 1 小时汇总
+type MetricsRollup1h struct {
+	MetricsRollup
+}
+
+func (MetricsRollup1h) TableName() string {
+	return "monitoring_metrics_1h"
+}
+
+// SeriesBucketStat represents the aggregates of one series in one time bucket
+// SeriesBucketStat 单个序列在一个时间桶内的聚合值
+type SeriesBucketStat struct {
+	Avg float64 `json:"avg"`
+	Max float64 `json:"max"`
+	P95 float64 `json:"p95"`
+}
+
+// SeriesPoint represents one bucket of a metrics time series
+// SeriesPoint 指标时间序列中的一个桶
+type SeriesPoint struct {
+	Timestamp time.Time `json:"timestamp"`
+	Samples   int       `json:"samples"`
+	Avg       float64   `json:"avg"`
+	Max       float64   `json:"max"`
+	P95       float64   `json:"p95"`
+}
+
+// MetricsHistory represents the response of a metrics history query
+// MetricsHistory 指标历史查询的响应
+type MetricsHistory struct {
+	Namespace string                   `json:"namespace"`
+	From      time.Time                `json:"from"`
+	To        time.Time                `json:"to"`
+	Step      string                   `json:"step"`
+	Source    string                   `json:"source"` // raw, 5m, 1h
+	Series    map[string][]SeriesPoint `json:"series"`
+}
+
+// PodMetrics represents the resource usage and rates of one Elasticsearch pod
+// PodMetrics 单个 Elasticsearch Pod 的资源使用与速率
+type PodMetrics struct {
+	ID        string `json:"id" gorm:"primaryKey"`
+	Namespace string `json:"namespace" gorm:"index"`
+	PodName   string `json:"pod_name"`
+	// CPU and memory usage relative to the container limit (or request when no limit is set)
+	// CPU 与内存使用率相对于容器 limit（未设置 limit 时相对于 request）
+	CPUCores          float64 `json:"cpu_cores"`
+	CPUUsage          float64 `json:"cpu_usage"`
+	MemoryBytes       int64   `json:"memory_bytes"`
+	MemoryUsage       float64 `json:"memory_usage"`
+	DiskUsedBytes     int64   `json:"disk_used_bytes"`
+	DiskCapacityBytes int64   `json:"disk_capacity_bytes"`
+	DiskUsage         float64 `json:"disk_usage"`
+	HeapUsedBytes     int64   `json:"heap_used_bytes"`
+	HeapMaxBytes      int64   `json:"heap_max_bytes"`
+	HeapUsage         float64 `json:"heap_usage"`
+	SearchRate        float64 `json:"search_rate"`   // 每秒分片级查询数
+	IndexingRate      float64 `json:"indexing_rate"` // 每秒写入文档数
+	// Usage metrics of the pod that could not be measured, as in Metrics.Missing
+	// 该 Pod 无法测量的使用率指标，含义同 Metrics.Missing
+	Missing   []string  `json:"missing,omitempty" gorm:"serializer:json"`
+	Timestamp time.Time `json:"timestamp" gorm:"index"`
+}
+
+func (PodMetrics) TableName() string {
+	return "pod_metrics"
+}
+
+// ClusterDetail represents a cluster with its latest cluster and pod metrics
+// ClusterDetail 集群详情，包含最新的集群指标与 Pod 指标
+type ClusterDetail struct {
+	ClusterStatus
+	Metrics *Metrics     `json:"metrics,omitempty"`
+	Pods    []PodMetrics `json:"pods"`
+}
+
 // ContainerMetrics represents detailed container metrics including startup data
 // ContainerMetrics 详细容器指标（包含启动数据）
 type ContainerMetrics struct {
-	ID               string           `json:"id"`
-	Namespace        string           `json:"namespace"`
-	ContainerName    string           `json:"container_name"`
-	CPUUsage         float64          `json:"cpu_usage"`
-	MemoryUsage      float64          `json:"memory_usage"`
-	DiskUsage        float64          `json:"disk_usage"`
-	QPS              float64          `json:"qps"`
-	StartupCPU       float64          `json:"startup_cpu"`
-	StartupMemory    float64          `json:"startup_memory"`
-	StartupDisk      float64          `json:"startup_disk"`
-	PluginQPS        float64          `json:"plugin_qps"`
-	Timestamp        time.Time        `json:"timestamp"`
-	Status           string           `json:"status"`
-	ResourceLimits   ResourceLimits   `json:"resource_limits"`
-	ResourceRequests ResourceRequests `json:"resource_requests"`
+	ID                 string                        `json:"id"`
+	Namespace          string                        `json:"namespace"`
+	ContainerName      string                        `json:"container_name"`
+	CPUUsage           float64                       `json:"cpu_usage"`
+	MemoryUsage        float64                       `json:"memory_usage"`
+	DiskUsage          float64                       `json:"disk_usage"`
+	QPS                float64                       `json:"qps"`
+	StartupCPU         float64                       `json:"startup_cpu"`
+	StartupMemory      float64                       `json:"startup_memory"`
+	StartupDisk        float64                       `json:"startup_disk"`
+	PluginQPS          float64                       `json:"plugin_qps"`
+	SearchLatencyMs    float64                       `json:"search_latency_ms"`
+	SearchLatencyP95Ms float64                       `json:"search_latency_p95_ms"`
+	SearchLatencyP99Ms float64                       `json:"search_latency_p99_ms"`
+	Indices            map[string]IndexSearchMetrics `json:"indices,omitempty"`
+	HeapUsage          float64                       `json:"heap_usage"`
+	CPUUsageMax        float64                       `json:"cpu_usage_max"`
+	MemoryUsageMax     float64                       `json:"memory_usage_max"`
+	DiskUsageMax       float64                       `json:"disk_usage_max"`
+	HeapUsageMax       float64                       `json:"heap_usage_max"`
+	CPUCores           float64                       `json:"cpu_cores"`
+	MemoryBytes        int64                         `json:"memory_bytes"`
+	DiskBytes          int64                         `json:"disk_bytes"`
+	IndexingRate       float64                       `json:"indexing_rate"`
+	Pods               []PodMetrics                  `json:"pods,omitempty"`
+	Missing            []string                      `json:"missing,omitempty"`
+	Timestamp          time.Time                     `json:"timestamp"`
+	Status             string                        `json:"status"`
+	ResourceLimits     ResourceLimits                `json:"resource_limits"`
+	ResourceRequests   ResourceRequests              `json:"resource_requests"`
 }
 
 // ResourceLimits represents container resource limits
@@ -241,26 +760,24 @@ type ResourceLimits struct {
 }
 
 // AutoscalerConfig holds the configuration for autoscaling
+// AutoscalerConfig 自动扩缩容的全局配置，保存为单行记录以便运行时修改
 type AutoscalerConfig struct {
-	// CPU thresholds
-	HighCPUThreshold float64 `json:"high_cpu_threshold"` // Percentage (0-100)
-	LowCPUThreshold  float64 `json:"low_cpu_threshold"`  // Percentage (0-100)
+	ID string `json:"-" gorm:"primaryKey"`
 
-	// Memory thresholds
-	HighMemoryThreshold float64 `json:"high_memory_threshold"` // Percentage (0-100)
-	LowMemoryThreshold  float64 `json:"low_memory_threshold"`  // Percentage (0-100)
+	// Mode of clusters whose policy sets none: off, recommend or auto
+	// 策略未设置模式时集群使用的模式：off、recommend 或 auto
+	Mode string `json:"mode"`
 
-	// QPS thresholds
-	HighQPSThreshold float64 `json:"high_qps_threshold"`
-	LowQPSThreshold  float64 `json:"low_qps_threshold"`
+	// Targets used by clusters whose policy sets none
+	// 策略未设置目标时使用的各指标目标
+	Targets []MetricTarget `json:"targets" gorm:"serializer:json"`
 
-	// Disk thresholds
-	HighDiskThreshold float64 `json:"high_disk_threshold"` // Percentage (0-100)
-	LowDiskThreshold  float64 `json:"low_disk_threshold"`  // Percentage (0-100)
+	// Relative deviation from a target within which a metric does not cause scaling (e.g., 0.1)
+	Tolerance float64 `json:"tolerance"`
 
-	// Scaling factors
-	ScaleUpFactor   float64 `json:"scale_up_factor"`   // Multiplier for scale up (e.g., 1.5)
-	ScaleDownFactor float64 `json:"scale_down_factor"` // Multiplier for scale down (e.g., 0.5)
+	// Scaling factors bound how far one evaluation may move the replicas
+	ScaleUpFactor   float64 `json:"scale_up_factor"`   // Largest multiplier for one scale up (e.g., 2)
+	ScaleDownFactor float64 `json:"scale_down_factor"` // Smallest multiplier for one scale down (e.g., 0.5)
 
 	// Limits
 	MinReplicas int `json:"min_replicas"`
@@ -270,19 +787,209 @@ type AutoscalerConfig struct {
 	ScaleUpCooldown   int `json:"scale_up_cooldown"`   // Cooldown period after scaling up
 	ScaleDownCooldown int `json:"scale_down_cooldown"` // Cooldown period after scaling down
 
-	// User-specific scaling policies
-	ScalingPolicies map[string]ScalingPolicy `json:"scaling_policies"`
+	// Predictive scaling pre-scales for the load forecast from the metrics history
+	// 预测式扩缩容根据指标历史的负载预测提前扩容
+	Predictive      bool `json:"predictive"`
+	ForecastHorizon int  `json:"forecast_horizon"` // Minutes of forecast load to provision for (e.g., 60)
+
+	UpdatedAt time.Time `json:"updated_at"`
 }
 
-// ScalingPolicy holds user-specific scaling policies
+func (AutoscalerConfig) TableName() string {
+	return "autoscaler_config"
+}
+
+// ScalingPolicy holds the scaling policy of a cluster, or of every cluster of a user when namespace is empty
+// ScalingPolicy 集群的扩缩容策略；namespace 为空时作用于该用户的所有集群
 type ScalingPolicy struct {
-	UserID              string  `json:"user_id"`
-	EnableAutoScaleUp   bool    `json:"enable_auto_scale_up"`
-	EnableAutoScaleDown bool    `json:"enable_auto_scale_down"`
-	ScaleUpThreshold    float64 `json:"scale_up_threshold"`
-	ScaleDownThreshold  float64 `json:"scale_down_threshold"`
-	MaxReplicas         int     `json:"max_replicas"`
-	MinReplicas         int     `json:"min_replicas"`
+	ID                  string         `json:"id" gorm:"primaryKey"`
+	UserID              string         `json:"user_id" gorm:"uniqueIndex:idx_scaling_policy_scope"`
+	Namespace           string         `json:"namespace" gorm:"uniqueIndex:idx_scaling_policy_scope"` // 为空表示用户级策略
+	Mode                string         `json:"mode"`                                                  // off, recommend, auto；为空表示使用全局模式
+	EnableAutoScaleUp   bool           `json:"enable_auto_scale_up"`
+	EnableAutoScaleDown bool           `json:"enable_auto_scale_down"`
+	Targets             []MetricTarget `json:"targets" gorm:"serializer:json"` // 为空表示使用全局目标
+	Tolerance           float64        `json:"tolerance"`                      // 为零表示使用全局容差
+	MaxReplicas         int            `json:"max_replicas"`                   // 为零表示使用全局上限
+	MinReplicas         int            `json:"min_replicas"`                   // 为零表示使用全局下限
+	CreatedAt           time.Time      `json:"created_at"`
+	UpdatedAt           time.Time      `json:"updated_at"`
+}
+
+func (ScalingPolicy) TableName() string {
+	return "autoscaler_policies"
+}
+
+// ScalingPolicyRequest is the request body for creating or updating a scaling policy; empty
+// targets, a zero tolerance and zero replica limits take the global autoscaler configuration
+// ScalingPolicyRequest 创建或更新扩缩容策略的请求体；目标为空、容差与副本上下限为零时取全局自动扩缩容配置
+type ScalingPolicyRequest struct {
+	UserID              string         `json:"user_id"`
+	Namespace           string         `json:"namespace"`
+	Mode                string         `json:"mode"`
+	EnableAutoScaleUp   *bool          `json:"enable_auto_scale_up"`   // 默认 true
+	EnableAutoScaleDown *bool          `json:"enable_auto_scale_down"` // 默认 true
+	Targets             []MetricTarget `json:"targets"`
+	Tolerance           float64        `json:"tolerance"`
+	MaxReplicas         int            `json:"max_replicas"`
+	MinReplicas         int            `json:"min_replicas"`
+}
+
+// ScalingSchedule is a recurring capacity plan of a cluster: every time Cron fires in Timezone, the
+// autoscaler keeps the replicas of the cluster within [MinReplicas, MaxReplicas] for Duration minutes.
+// Schedules win over the scaling policy: they also apply when the policy mode is off or both scale
+// directions are disabled; only recommend mode leaves them unapplied.
+// ScalingSchedule 集群的周期性容量计划：每当 Cron 在 Timezone 时区触发后的 Duration 分钟内，
+// 自动扩缩容将集群副本数保持在 [MinReplicas, MaxReplicas] 之间。定时计划优先于扩缩容策略：
+// 策略模式为 off 或两个扩缩容方向都被禁用时计划同样生效；只有 recommend 模式下不会执行
+type ScalingSchedule struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	Namespace   string    `json:"namespace" gorm:"index"`
+	Name        string    `json:"name"`
+	Cron        string    `json:"cron"`         // 分 时 日 月 周，例如 "0 8 * * 1-5"
+	Duration    int       `json:"duration"`     // 每次触发后生效的分钟数
+	Timezone    string    `json:"timezone"`     // IANA 时区，例如 Asia/Shanghai；为空表示 UTC
+	MinReplicas int       `json:"min_replicas"` // 副本数下限，0 表示不设下限
+	MaxReplicas int       `json:"max_replicas"` // 副本数上限，0 表示不设上限
+	Enabled     bool      `json:"enabled"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (ScalingSchedule) TableName() string {
+	return "autoscaler_schedules"
+}
+
+// ScalingScheduleRequest is the request body for creating or updating a scaling schedule
+// ScalingScheduleRequest 创建或更新定时扩缩容计划的请求体
+type ScalingScheduleRequest struct {
+	Namespace   string `json:"namespace"`
+	Name        string `json:"name"`
+	Cron        string `json:"cron"`
+	Duration    int    `json:"duration"`
+	Timezone    string `json:"timezone"`
+	MinReplicas int    `json:"min_replicas"`
+	MaxReplicas int    `json:"max_replicas"`
+	Enabled     *bool  `json:"enabled"` // 默认 true
+}
+
+// ScheduledChange is an upcoming start or end of a scaling schedule window
+// ScheduledChange 即将到来的定时扩缩容窗口的开始或结束
+type ScheduledChange struct {
+	Time        time.Time `json:"time"`
+	Action      string    `json:"action"` // start, end
+	ScheduleID  string    `json:"schedule_id"`
+	Name        string    `json:"name"`
+	Namespace   string    `json:"namespace"`
+	MinReplicas int       `json:"min_replicas"`
+	MaxReplicas int       `json:"max_replicas"`
+}
+
+// ScheduleOverride is the floor and ceiling the active schedules put on a recommendation
+// ScheduleOverride 生效中的定时计划对扩缩容建议施加的下限与上限
+type ScheduleOverride struct {
+	Schedules   []string `json:"schedules"`    // 生效中的计划 ID
+	MinReplicas int      `json:"min_replicas"` // 0 表示不设下限
+	MaxReplicas int      `json:"max_replicas"` // 0 表示不设上限
+}
+
+// MetricTarget is the target of one scaling metric: a percentage of the busiest pod for
+// cpu_usage, memory_usage, disk_usage and heap_usage, or queries per second per replica for qps
+// MetricTarget 单个扩缩容指标的目标：cpu_usage、memory_usage、disk_usage 与 heap_usage 为最繁忙 Pod 的百分比，qps 为每个副本的每秒查询数
+type MetricTarget struct {
+	Metric string  `json:"metric"`
+	Target float64 `json:"target"`
+}
+
+// ScalingRecommendation is the outcome of evaluating every metric target of a cluster
+// ScalingRecommendation 评估集群全部指标目标的结果
+type ScalingRecommendation struct {
+	CurrentReplicas int                    `json:"current_replicas"`
+	DesiredReplicas int                    `json:"desired_replicas"`
+	DrivingMetric   string                 `json:"driving_metric"` // 决定期望副本数的指标，预测决定时为 forecast，定时计划覆盖时为 schedule
+	Metrics         []MetricRecommendation `json:"metrics"`
+	Forecast        []MetricRecommendation `json:"forecast,omitempty"` // 按预测负载峰值评估的各指标
+	Schedule        *ScheduleOverride      `json:"schedule,omitempty"` // 生效中的定时计划
+	Missing         []string               `json:"missing,omitempty"`  // 未能测量的目标指标，存在时不会缩容
+}
+
+// MetricRecommendation is the replicas one metric asks for
+// MetricRecommendation 单个指标所要求的副本数
+type MetricRecommendation struct {
+	Metric          string  `json:"metric"`
+	Value           float64 `json:"value"`
+	Target          float64 `json:"target"`
+	Ratio           float64 `json:"ratio"`
+	DesiredReplicas int     `json:"desired_replicas"`
+}
+
+// AutoscalerDecision records one autoscaler evaluation of a cluster with its inputs, the trend
+// adjustments, the policy used and the outcome; in recommend mode Applied is always false
+// AutoscalerDecision 记录一次集群自动扩缩容评估的输入、趋势调整、所用策略与结果；recommend 模式下 Applied 始终为 false
+type AutoscalerDecision struct {
+	ID              string                `json:"id" gorm:"primaryKey"`
+	Namespace       string                `json:"namespace" gorm:"index:idx_autoscaler_decision_ns_time"`
+	UserID          string                `json:"user_id"`
+	Mode            string                `json:"mode"` // recommend, auto
+	Timestamp       time.Time             `json:"timestamp" gorm:"index:idx_autoscaler_decision_ns_time"`
+	CurrentReplicas int                   `json:"current_replicas"`
+	DesiredReplicas int                   `json:"desired_replicas"`
+	Outcome         string                `json:"outcome"` // error, disabled, hold, cooldown, quota_exceeded, scale_up, scale_down
+	Applied         bool                  `json:"applied"` // 是否实际执行了扩缩容
+	Reason          string                `json:"reason"`
+	Inputs          map[string]float64    `json:"inputs" gorm:"serializer:json"`   // 各扩缩容指标的原始值
+	Trends          map[string]float64    `json:"trends" gorm:"serializer:json"`   // 各指标的平均变化量
+	Adjusted        map[string]float64    `json:"adjusted" gorm:"serializer:json"` // 趋势调整后的指标值
+	Policy          ScalingPolicy         `json:"policy" gorm:"serializer:json"`
+	Recommendation  ScalingRecommendation `json:"recommendation" gorm:"serializer:json"`
+}
+
+func (AutoscalerDecision) TableName() string {
+	return "autoscaler_decisions"
+}
+
+// LoadForecast is the forecast of one metrics series for one hour of a cluster, made from the history
+// before that hour; Actual is filled in once the hour is complete
+// LoadForecast 集群某一指标序列在某一小时的预测值，由该小时之前的历史得出；该小时结束后填入 Actual
+type LoadForecast struct {
+	ID        string    `json:"id" gorm:"primaryKey"`
+	Namespace string    `json:"namespace" gorm:"index:idx_load_forecast_ns_time"`
+	Series    string    `json:"series"`
+	Time      time.Time `json:"time" gorm:"index:idx_load_forecast_ns_time"` // 预测的小时桶起始时间
+	Predicted float64   `json:"predicted"`
+	Actual    *float64  `json:"actual"` // 该小时的 p95 实际值，未完成时为空
+	CreatedAt time.Time `json:"created_at"`
+}
+
+func (LoadForecast) TableName() string {
+	return "autoscaler_forecasts"
+}
+
+// LoadForecastReport is the current load forecast of a cluster with the accuracy of past forecasts
+// LoadForecastReport 集群当前的负载预测及历史预测的准确度
+type LoadForecastReport struct {
+	Namespace   string                 `json:"namespace"`
+	FittedAt    time.Time              `json:"fitted_at"`   // 模型使用该时刻之前的完整小时拟合
+	Seasonality map[string]string      `json:"seasonality"` // 各序列的季节性：daily 或 daily+weekly
+	Points      []LoadForecastPoint    `json:"points"`
+	Accuracy    []LoadForecastAccuracy `json:"accuracy"`
+}
+
+// LoadForecastPoint is the forecast p95 of one series for one upcoming hour
+// LoadForecastPoint 单个序列在未来某一小时的预测 p95 值
+type LoadForecastPoint struct {
+	Series    string    `json:"series"`
+	Time      time.Time `json:"time"`
+	Predicted float64   `json:"predicted"`
+}
+
+// LoadForecastAccuracy compares the forecasts of one series with the actual load
+// LoadForecastAccuracy 单个序列的预测值与实际负载的对比
+type LoadForecastAccuracy struct {
+	Series  string  `json:"series"`
+	Samples int     `json:"samples"`
+	MAE     float64 `json:"mae"`  // 平均绝对误差
+	MAPE    float64 `json:"mape"` // 平均绝对百分比误差（%），忽略实际值为 0 的小时
 }
 
 // HistoricalMetrics stores historical metrics for trend analysis
@@ -296,3 +1003,177 @@ type ResourceRequests struct {
 	CPU    string `json:"cpu"`
 	Memory string `json:"memory"`
 }
+
+// AlertRule represents an alert rule evaluated against collected metrics and
+// Elasticsearch health; a rule without tenant_org_id is global
+// AlertRule 基于采集指标与 Elasticsearch 健康状态评估的告警规则；未指定 tenant_org_id 的规则为全局规则
+type AlertRule struct {
+	ID             string    `json:"id" gorm:"primaryKey"`
+	TenantOrgID    string    `json:"tenant_org_id" gorm:"index"` // 为空表示全局规则
+	Namespace      string    `json:"namespace"`                  // 为空表示租户（或全部）集群
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Type           string    `json:"type"`      // threshold, rate, absence
+	Metric         string    `json:"metric"`    // 指标历史序列名或 es_status、es_unassigned_shards 等
+	Operator       string    `json:"operator"`  // >, >=, <, <=, ==, !=
+	Threshold      float64   `json:"threshold"` // 阈值；rate 规则为窗口内的变化量
+	Window         int       `json:"window"`    // rate 与 absence 规则的时间窗口（秒）
+	For            int       `json:"for"`       // 条件持续多久后触发（秒）
+	Severity       string    `json:"severity"`  // info, warning, critical
+	Channels       []string  `json:"channels" gorm:"serializer:json"`
+	RepeatInterval int       `json:"repeat_interval"` // 持续触发时重复通知的间隔（秒），0 表示只通知一次
+	Enabled        bool      `json:"enabled"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+func (AlertRule) TableName() string {
+	return "alert_rules"
+}
+
+// AlertRuleRequest represents the request body for creating or updating an alert rule
+// AlertRuleRequest 创建或更新告警规则的请求体
+type AlertRuleRequest struct {
+	TenantOrgID    string   `json:"tenant_org_id"`
+	Namespace      string   `json:"namespace"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Type           string   `json:"type"`
+	Metric         string   `json:"metric"`
+	Operator       string   `json:"operator"`
+	Threshold      float64  `json:"threshold"`
+	Window         int      `json:"window"`
+	For            int      `json:"for"`
+	Severity       string   `json:"severity"`
+	Channels       []string `json:"channels"`
+	RepeatInterval int      `json:"repeat_interval"`
+	Enabled        *bool    `json:"enabled"` // 默认启用
+}
+
+// AlertChannel represents a notification channel; a channel without tenant_org_id is global
+// AlertChannel 告警通知渠道；未指定 tenant_org_id 的渠道为全局渠道
+type AlertChannel struct {
+	ID          string            `json:"id" gorm:"primaryKey"`
+	TenantOrgID string            `json:"tenant_org_id" gorm:"index"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`                                     // webhook, slack, smtp
+	URL         string            `json:"url,omitempty"`                            // webhook 与 slack 的地址
+	Headers     map[string]string `json:"headers,omitempty" gorm:"serializer:json"` // webhook 附加请求头
+	SMTPHost    string            `json:"smtp_host,omitempty"`
+	SMTPPort    int               `json:"smtp_port,omitempty"`
+	Username    string            `json:"username,omitempty"`
+	Password    string            `json:"password,omitempty"` // 响应中会被隐藏
+	From        string            `json:"from,omitempty"`
+	To          []string          `json:"to,omitempty" gorm:"serializer:json"`
+	Enabled     bool              `json:"enabled"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+func (AlertChannel) TableName() string {
+	return "alert_channels"
+}
+
+// AlertChannelRequest represents the request body for creating or updating a notification channel
+// AlertChannelRequest 创建或更新通知渠道的请求体
+type AlertChannelRequest struct {
+	TenantOrgID string            `json:"tenant_org_id"`
+	Name        string            `json:"name"`
+	Type        string            `json:"type"`
+	URL         string            `json:"url"`
+	Headers     map[string]string `json:"headers"`
+	SMTPHost    string            `json:"smtp_host"`
+	SMTPPort    int               `json:"smtp_port"`
+	Username    string            `json:"username"`
+	Password    string            `json:"password"` // 更新时为空表示保留原密码
+	From        string            `json:"from"`
+	To          []string          `json:"to"`
+	Enabled     *bool             `json:"enabled"` // 默认启用
+}
+
+// Alert represents one alert of a rule on a cluster; at most one pending or firing
+// alert exists per rule and namespace
+// Alert 某条规则在某个集群上的告警；每个规则与命名空间最多存在一条 pending 或 firing 告警
+type Alert struct {
+	ID              string     `json:"id" gorm:"primaryKey"`
+	Fingerprint     string     `json:"fingerprint" gorm:"index"` // 规则 ID/命名空间，用于去重
+	RuleID          string     `json:"rule_id" gorm:"index"`
+	RuleName        string     `json:"rule_name"`
+	TenantOrgID     string     `json:"tenant_org_id" gorm:"index"`
+	Namespace       string     `json:"namespace" gorm:"index"`
+	Severity        string     `json:"severity"`
+	State           string     `json:"state" gorm:"index"` // pending, firing, resolved
+	Value           float64    `json:"value"`
+	Message         string     `json:"message"`
+	Silenced        bool       `json:"silenced"`
+	StartsAt        time.Time  `json:"starts_at"`
+	FiredAt         *time.Time `json:"fired_at,omitempty"`
+	ResolvedAt      *time.Time `json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time  `json:"last_evaluated_at"`
+	LastNotifiedAt  *time.Time `json:"last_notified_at,omitempty"`
+	NotifiedState   string     `json:"notified_state,omitempty"` // 最近一次通知时的状态
+	NotifyError     string     `json:"notify_error,omitempty"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+func (Alert) TableName() string {
+	return "alerts"
+}
+
+// AlertSilence suppresses notifications of matching alerts between StartsAt and EndsAt;
+// empty matchers match everything within the tenant
+// AlertSilence 在 StartsAt 与 EndsAt 之间屏蔽匹配告警的通知；空匹配条件匹配租户内的所有告警
+type AlertSilence struct {
+	ID          string    `json:"id" gorm:"primaryKey"`
+	TenantOrgID string    `json:"tenant_org_id" gorm:"index"` // 为空表示全局静默
+	RuleID      string    `json:"rule_id"`
+	Namespace   string    `json:"namespace"`
+	Severity    string    `json:"severity"`
+	Comment     string    `json:"comment"`
+	CreatedBy   string    `json:"created_by"`
+	StartsAt    time.Time `json:"starts_at"`
+	EndsAt      time.Time `json:"ends_at"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (AlertSilence) TableName() string {
+	return "alert_silences"
+}
+
+// AlertSilenceRequest represents the request body for creating an alert silence
+// AlertSilenceRequest 创建告警静默的请求体
+type AlertSilenceRequest struct {
+	TenantOrgID string     `json:"tenant_org_id"`
+	RuleID      string     `json:"rule_id"`
+	Namespace   string     `json:"namespace"`
+	Severity    string     `json:"severity"`
+	Comment     string     `json:"comment"`
+	CreatedBy   string     `json:"created_by"`
+	StartsAt    *time.Time `json:"starts_at"` // 默认为当前时间
+	EndsAt      *time.Time `json:"ends_at"`   // 与 duration 二选一
+	Duration    int        `json:"duration"`  // 静默时长（秒）
+}
+
+// ESClusterHealth represents the _cluster/health response of a tenant cluster
+// ESClusterHealth 租户集群 _cluster/health 的响应
+type ESClusterHealth struct {
+	Status               string `json:"status"` // green, yellow, red, unreachable
+	NumberOfNodes        int    `json:"number_of_nodes"`
+	ActiveShards         int    `json:"active_shards"`
+	RelocatingShards     int    `json:"relocating_shards"`
+	InitializingShards   int    `json:"initializing_shards"`
+	UnassignedShards     int    `json:"unassigned_shards"`
+	NumberOfPendingTasks int    `json:"number_of_pending_tasks"`
+}
+
+// ESShard represents a row of _cat/shards
+// ESShard _cat/shards 的一行
+type ESShard struct {
+	Index            string `json:"index"`
+	Shard            string `json:"shard"`
+	PrimaryOrReplica string `json:"prirep"` // p, r
+	State            string `json:"state"`  // STARTED, RELOCATING, INITIALIZING, UNASSIGNED
+	Node             string `json:"node"`
+	UnassignedReason string `json:"unassigned.reason"`
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// AlertNotification is the payload delivered to notification channels
+// AlertNotification 发送到通知渠道的内容
+type AlertNotification struct {
+	Status      string     `json:"status"` // firing, resolved
+	AlertID     string     `json:"alert_id"`
+	RuleID      string     `json:"rule_id"`
+	RuleName    string     `json:"rule_name"`
+	TenantOrgID string     `json:"tenant_org_id"`
+	Namespace   string     `json:"namespace"`
+	Severity    string     `json:"severity"`
+	Value       float64    `json:"value"`
+	Message     string     `json:"message"`
+	StartsAt    time.Time  `json:"starts_at"`
+	ResolvedAt  *time.Time `json:"resolved_at,omitempty"`
+}
+
+// summary renders a one-line description used by Slack messages and mail subjects
+// summary 生成用于 Slack 消息与邮件主题的单行描述
+func (n AlertNotification) summary() string {
+	return fmt.Sprintf("[%s] %s %s on %s: %s", strings.ToUpper(n.Status), n.Severity, n.RuleName, n.Namespace, n.Message)
+}
+
+// smtpTimeout bounds an SMTP delivery whose context has no deadline
+// smtpTimeout 限制上下文没有截止时间时一次 SMTP 发送的时长
+const smtpTimeout = 30 * time.Second
+
+// AlertNotifier delivers alert notifications to webhook, Slack-compatible and SMTP channels
+// AlertNotifier 将告警通知发送到 Webhook、兼容 Slack 的地址以及 SMTP 渠道
+type AlertNotifier struct {
+	httpClient *http.Client
+}
+
+// NewAlertNotifier creates a new alert notifier
+// NewAlertNotifier 创建一个新的告警通知器
+func NewAlertNotifier() *AlertNotifier {
+	return &AlertNotifier{
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+	}
+}
+
+// Notify sends a notification through one channel
+// Notify 通过一个渠道发送通知
+func (n *AlertNotifier) Notify(ctx context.Context, channel *model.AlertChannel, notification AlertNotification) error {
+	switch channel.Type {
+	case "webhook":
+		return n.postJSON(ctx, channel.URL, channel.Headers, notification)
+	case "slack":
+		return n.postJSON(ctx, channel.URL, nil, map[string]string{"text": notification.summary()})
+	case "smtp":
+		return n.mail(ctx, channel, notification)
+	default:
+		return fmt.Errorf("unknown channel type %s", channel.Type)
+	}
+}
+
+func (n *AlertNotifier) postJSON(ctx context.Context, url string, headers map[string]string, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := n.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("POST %s failed with status %d: %s", url, resp.StatusCode, string(data))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
+
+// mail sends the notification over SMTP like smtp.SendMail, but bounded by ctx: the
+// connection is dialed and used with the context deadline and closed when ctx ends
+// mail 与 smtp.SendMail 一样通过 SMTP 发送通知，但受 ctx 约束：按上下文截止时间建立并使用连接，ctx 结束时关闭连接
+func (n *AlertNotifier) mail(ctx context.Context, channel *model.AlertChannel, notification AlertNotification) error {
+	port := channel.SMTPPort
+	if port == 0 {
+		port = 25
+	}
+	addr := net.JoinHostPort(channel.SMTPHost, strconv.Itoa(port))
+
+	var auth smtp.Auth
+	if channel.Username != "" {
+		auth = smtp.PlainAuth("", channel.Username, channel.Password, channel.SMTPHost)
+	}
+
+	details, err := json.MarshalIndent(notification, "", "  ")
+	if err != nil {
+		return err
+	}
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", channel.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(channel.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.NewReplacer("\r", " ", "\n", " ").Replace(notification.summary()))
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	fmt.Fprintf(&msg, "%s\r\n\r\n%s\r\n", notification.summary(), details)
+
+	for _, address := range append([]string{channel.From}, channel.To...) {
+		if strings.ContainsAny(address, "\r\n") {
+			return fmt.Errorf("invalid mail address %q", address)
+		}
+	}
+
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(smtpTimeout)
+	}
+	dialer := net.Dialer{Deadline: deadline}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return err
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, channel.SMTPHost)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: channel.SMTPHost}); err != nil {
+			return err
+		}
+	}
+	if auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return fmt.Errorf("smtp server %s does not support AUTH", addr)
+		}
+		if err := client.Auth(auth); err != nil {
+			return err
+		}
+	}
+	if err := client.Mail(channel.From); err != nil {
+		return err
+	}
+	for _, to := range channel.To {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg.Bytes()); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
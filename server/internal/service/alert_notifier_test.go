@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestAlertNotifierMailRespectsContext(t *testing.T) {
+	// A server that accepts connections but never greets
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	channel := &model.AlertChannel{Type: "smtp", SMTPHost: host, SMTPPort: portNumber, From: "alerts@example.com", To: []string{"ops@example.com"}}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := NewAlertNotifier().Notify(ctx, channel, AlertNotification{Status: "firing"}); err == nil {
+		t.Fatalf("Notify to a silent SMTP server: want error")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("Notify returned after %s, want it bounded by the context", elapsed)
+	}
+}
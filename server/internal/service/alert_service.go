@@ -0,0 +1,837 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// alertStaleAfter is the age after which the latest sample no longer counts for threshold rules
+	// alertStaleAfter 最新样本超过该时长后不再用于阈值规则
+	alertStaleAfter = 5 * time.Minute
+	// defaultAlertListLimit bounds the alerts returned by the list API
+	// defaultAlertListLimit 告警列表接口返回的最大条数
+	defaultAlertListLimit = 500
+	// redactedSecret replaces secrets in API responses; sent back unchanged, it keeps the stored secret
+	// redactedSecret 在 API 响应中替代密钥；原样回传时保留已存储的密钥
+	redactedSecret = "******"
+)
+
+// alertOperators are the comparison operators of threshold and rate rules
+// alertOperators 阈值与变化率规则可用的比较运算符
+var alertOperators = map[string]func(value, threshold float64) bool{
+	">":  func(v, t float64) bool { return v > t },
+	">=": func(v, t float64) bool { return v >= t },
+	"<":  func(v, t float64) bool { return v < t },
+	"<=": func(v, t float64) bool { return v <= t },
+	"==": func(v, t float64) bool { return v == t },
+	"!=": func(v, t float64) bool { return v != t },
+}
+
+// esHealthSeries are the rule metrics read from _cluster/health; es_status is
+// 0 for green, 1 for yellow and 2 for red
+// esHealthSeries 从 _cluster/health 读取的规则指标；es_status 中 green 为 0、yellow 为 1、red 为 2
+var esHealthSeries = map[string]func(h *model.ESClusterHealth) float64{
+	"es_status": func(h *model.ESClusterHealth) float64 {
+		switch h.Status {
+		case "green":
+			return 0
+		case "yellow":
+			return 1
+		default:
+			return 2
+		}
+	},
+	"es_nodes":               func(h *model.ESClusterHealth) float64 { return float64(h.NumberOfNodes) },
+	"es_unassigned_shards":   func(h *model.ESClusterHealth) float64 { return float64(h.UnassignedShards) },
+	"es_relocating_shards":   func(h *model.ESClusterHealth) float64 { return float64(h.RelocatingShards) },
+	"es_initializing_shards": func(h *model.ESClusterHealth) float64 { return float64(h.InitializingShards) },
+	"es_pending_tasks":       func(h *model.ESClusterHealth) float64 { return float64(h.NumberOfPendingTasks) },
+}
+
+// AlertService manages alert rules, channels and silences and evaluates the rules
+// against collected metrics and Elasticsearch health
+// AlertService 管理告警规则、通知渠道与静默，并基于采集指标与 Elasticsearch 健康状态评估规则
+type AlertService struct {
+	metadataService *MetadataService
+	esStats         *ESStatsCollector
+	notifier        *AlertNotifier
+	interval        time.Duration
+	ticker          *time.Ticker
+	stopChan        chan struct{}
+	startedAt       time.Time
+	// lastHealth is the time of the last successful health read per namespace
+	// lastHealth 每个命名空间最近一次成功读取健康状态的时间
+	lastHealth map[string]time.Time
+	mu         sync.Mutex
+}
+
+// NewAlertService creates a new alert service evaluating rules every interval
+// NewAlertService 创建一个新的告警服务，每隔 interval 评估一次规则
+func NewAlertService(metadataService *MetadataService, esStats *ESStatsCollector, notifier *AlertNotifier, interval time.Duration) *AlertService {
+	return &AlertService{
+		metadataService: metadataService,
+		esStats:         esStats,
+		notifier:        notifier,
+		interval:        interval,
+		stopChan:        make(chan struct{}),
+		lastHealth:      make(map[string]time.Time),
+	}
+}
+
+// Start begins the evaluation loop
+// Start 启动规则评估循环
+func (s *AlertService) Start() {
+	s.startedAt = time.Now()
+	s.ticker = time.NewTicker(s.interval)
+	go func() {
+		for {
+			select {
+			case <-s.ticker.C:
+				s.Evaluate(time.Now())
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the evaluation loop
+// Stop 停止规则评估循环
+func (s *AlertService) Stop() {
+	close(s.stopChan)
+}
+
+// CreateRule creates an alert rule
+// CreateRule 创建告警规则
+func (s *AlertService) CreateRule(req model.AlertRuleRequest) (*model.AlertRule, error) {
+	now := time.Now()
+	rule := &model.AlertRule{
+		ID:        fmt.Sprintf("rule_%d", now.UnixNano()),
+		CreatedAt: now,
+	}
+	if err := s.applyRule(rule, req); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = now
+	if err := s.metadataService.SaveAlertRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// UpdateRule replaces the definition of an alert rule; its alerts are re-evaluated
+// against the new definition on the next cycle
+// UpdateRule 替换告警规则的定义；其告警会在下一个周期按新定义重新评估
+func (s *AlertService) UpdateRule(id string, req model.AlertRuleRequest) (*model.AlertRule, error) {
+	rule, err := s.metadataService.GetAlertRule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.applyRule(rule, req); err != nil {
+		return nil, err
+	}
+	rule.UpdatedAt = time.Now()
+	if err := s.metadataService.SaveAlertRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// GetRule gets an alert rule
+// GetRule 获取告警规则
+func (s *AlertService) GetRule(id string) (*model.AlertRule, error) {
+	return s.metadataService.GetAlertRule(id)
+}
+
+// ListRules lists the rules of a tenant with the global rules, or every rule when tenant is empty
+// ListRules 列出租户的规则及全局规则；租户为空时列出全部规则
+func (s *AlertService) ListRules(tenantOrgID string) ([]*model.AlertRule, error) {
+	return s.metadataService.ListAlertRules(tenantOrgID)
+}
+
+// DeleteRule deletes an alert rule and its alerts
+// DeleteRule 删除告警规则及其告警
+func (s *AlertService) DeleteRule(id string) error {
+	return s.metadataService.DeleteAlertRule(id)
+}
+
+// applyRule validates a rule request and copies it onto rule
+// applyRule 校验规则请求并将其写入 rule
+func (s *AlertService) applyRule(rule *model.AlertRule, req model.AlertRuleRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	_, isSeries := metricsSeries[req.Metric]
+	_, isHealth := esHealthSeries[req.Metric]
+	if !isSeries && !isHealth {
+		return fmt.Errorf("%w: unknown metric %s", ErrInvalidRequest, req.Metric)
+	}
+	switch req.Type {
+	case "threshold", "rate":
+		if _, ok := alertOperators[req.Operator]; !ok {
+			return fmt.Errorf("%w: operator must be one of >, >=, <, <=, ==, !=", ErrInvalidRequest)
+		}
+		if req.Type == "rate" {
+			if !isSeries {
+				return fmt.Errorf("%w: rate rules need a collected metrics series, not %s", ErrInvalidRequest, req.Metric)
+			}
+			if req.Window <= 0 {
+				return fmt.Errorf("%w: window is required for rate rules", ErrInvalidRequest)
+			}
+		}
+	case "absence":
+		if req.Window <= 0 {
+			return fmt.Errorf("%w: window is required for absence rules", ErrInvalidRequest)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule type %s (expected threshold, rate or absence)", ErrInvalidRequest, req.Type)
+	}
+	if req.For < 0 || req.RepeatInterval < 0 {
+		return fmt.Errorf("%w: for and repeat_interval must not be negative", ErrInvalidRequest)
+	}
+	severity := req.Severity
+	if severity == "" {
+		severity = "warning"
+	}
+	if severity != "info" && severity != "warning" && severity != "critical" {
+		return fmt.Errorf("%w: unknown severity %s (expected info, warning or critical)", ErrInvalidRequest, req.Severity)
+	}
+	if req.Namespace != "" && req.TenantOrgID != "" {
+		deployment, err := s.metadataService.GetDeploymentStatus(req.Namespace)
+		if err != nil || deployment.TenantOrgID != req.TenantOrgID {
+			return fmt.Errorf("%w: cluster %s does not belong to tenant %s", ErrInvalidRequest, req.Namespace, req.TenantOrgID)
+		}
+	}
+	for _, id := range req.Channels {
+		channel, err := s.metadataService.GetAlertChannel(id)
+		if err != nil {
+			return fmt.Errorf("%w: channel %s not found", ErrInvalidRequest, id)
+		}
+		if channel.TenantOrgID != "" && channel.TenantOrgID != req.TenantOrgID {
+			return fmt.Errorf("%w: channel %s belongs to another tenant", ErrInvalidRequest, id)
+		}
+	}
+
+	rule.TenantOrgID = req.TenantOrgID
+	rule.Namespace = req.Namespace
+	rule.Name = req.Name
+	rule.Description = req.Description
+	rule.Type = req.Type
+	rule.Metric = req.Metric
+	rule.Operator = req.Operator
+	rule.Threshold = req.Threshold
+	rule.Window = req.Window
+	rule.For = req.For
+	rule.Severity = severity
+	rule.Channels = req.Channels
+	rule.RepeatInterval = req.RepeatInterval
+	rule.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// CreateChannel creates a notification channel
+// CreateChannel 创建通知渠道
+func (s *AlertService) CreateChannel(req model.AlertChannelRequest) (*model.AlertChannel, error) {
+	now := time.Now()
+	channel := &model.AlertChannel{
+		ID:        fmt.Sprintf("channel_%d", now.UnixNano()),
+		CreatedAt: now,
+	}
+	if err := applyChannel(channel, req); err != nil {
+		return nil, err
+	}
+	channel.UpdatedAt = now
+	if err := s.metadataService.SaveAlertChannel(channel); err != nil {
+		return nil, err
+	}
+	return redactChannel(channel), nil
+}
+
+// UpdateChannel replaces the definition of a notification channel
+// UpdateChannel 替换通知渠道的定义
+func (s *AlertService) UpdateChannel(id string, req model.AlertChannelRequest) (*model.AlertChannel, error) {
+	channel, err := s.metadataService.GetAlertChannel(id)
+	if err != nil {
+		return nil, err
+	}
+	req, err = keepRedactedSecrets(channel, req)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyChannel(channel, req); err != nil {
+		return nil, err
+	}
+	channel.UpdatedAt = time.Now()
+	if err := s.metadataService.SaveAlertChannel(channel); err != nil {
+		return nil, err
+	}
+	return redactChannel(channel), nil
+}
+
+// ListChannels lists the channels of a tenant with the global channels, or every channel when tenant is empty
+// ListChannels 列出租户的渠道及全局渠道；租户为空时列出全部渠道
+func (s *AlertService) ListChannels(tenantOrgID string) ([]*model.AlertChannel, error) {
+	channels, err := s.metadataService.ListAlertChannels(tenantOrgID)
+	if err != nil {
+		return nil, err
+	}
+	for i, channel := range channels {
+		channels[i] = redactChannel(channel)
+	}
+	return channels, nil
+}
+
+// DeleteChannel deletes a notification channel
+// DeleteChannel 删除通知渠道
+func (s *AlertService) DeleteChannel(id string) error {
+	return s.metadataService.DeleteAlertChannel(id)
+}
+
+// TestChannel sends a test notification through a channel
+// TestChannel 通过渠道发送一条测试通知
+func (s *AlertService) TestChannel(ctx context.Context, id string) error {
+	channel, err := s.metadataService.GetAlertChannel(id)
+	if err != nil {
+		return err
+	}
+	return s.notifier.Notify(ctx, channel, AlertNotification{
+		Status:      "firing",
+		RuleName:    "test notification",
+		TenantOrgID: channel.TenantOrgID,
+		Severity:    "info",
+		Message:     fmt.Sprintf("test notification for channel %s", channel.Name),
+		StartsAt:    time.Now(),
+	})
+}
+
+// applyChannel validates a channel request and copies it onto channel
+// applyChannel 校验渠道请求并将其写入 channel
+func applyChannel(channel *model.AlertChannel, req model.AlertChannelRequest) error {
+	if req.Name == "" {
+		return fmt.Errorf("%w: name is required", ErrInvalidRequest)
+	}
+	switch req.Type {
+	case "webhook", "slack":
+		parsed, err := url.Parse(req.URL)
+		if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+			return fmt.Errorf("%w: url must be an http or https URL", ErrInvalidRequest)
+		}
+	case "smtp":
+		if req.SMTPHost == "" || req.From == "" || len(req.To) == 0 {
+			return fmt.Errorf("%w: smtp_host, from and to are required for smtp channels", ErrInvalidRequest)
+		}
+	default:
+		return fmt.Errorf("%w: unknown channel type %s (expected webhook, slack or smtp)", ErrInvalidRequest, req.Type)
+	}
+
+	channel.TenantOrgID = req.TenantOrgID
+	channel.Name = req.Name
+	channel.Type = req.Type
+	channel.URL = req.URL
+	channel.Headers = req.Headers
+	channel.SMTPHost = req.SMTPHost
+	channel.SMTPPort = req.SMTPPort
+	channel.Username = req.Username
+	channel.Password = req.Password
+	channel.From = req.From
+	channel.To = req.To
+	channel.Enabled = req.Enabled == nil || *req.Enabled
+	return nil
+}
+
+// keepRedactedSecrets replaces the secrets of an update request that are empty or echo the
+// redacted value returned by the API with the values stored on channel
+// keepRedactedSecrets 将更新请求中为空或回传 API 脱敏值的密钥替换为 channel 中已存储的值
+func keepRedactedSecrets(channel *model.AlertChannel, req model.AlertChannelRequest) (model.AlertChannelRequest, error) {
+	if req.Password == "" || req.Password == redactedSecret {
+		req.Password = channel.Password
+	}
+	if len(req.Headers) > 0 {
+		headers := make(map[string]string, len(req.Headers))
+		for key, value := range req.Headers {
+			if value == redactedSecret {
+				stored, ok := channel.Headers[key]
+				if !ok {
+					return req, fmt.Errorf("%w: header %s has no stored value to keep", ErrInvalidRequest, key)
+				}
+				value = stored
+			}
+			headers[key] = value
+		}
+		req.Headers = headers
+	}
+	return req, nil
+}
+
+// redactChannel hides the SMTP password and header values, which often carry tokens
+// redactChannel 隐藏 SMTP 密码与请求头的值（通常包含令牌）
+func redactChannel(channel *model.AlertChannel) *model.AlertChannel {
+	redacted := *channel
+	if redacted.Password != "" {
+		redacted.Password = redactedSecret
+	}
+	if len(channel.Headers) > 0 {
+		redacted.Headers = make(map[string]string, len(channel.Headers))
+		for key := range channel.Headers {
+			redacted.Headers[key] = redactedSecret
+		}
+	}
+	return &redacted
+}
+
+// CreateSilence creates an alert silence
+// CreateSilence 创建告警静默
+func (s *AlertService) CreateSilence(req model.AlertSilenceRequest) (*model.AlertSilence, error) {
+	now := time.Now()
+	startsAt := now
+	if req.StartsAt != nil {
+		startsAt = *req.StartsAt
+	}
+	var endsAt time.Time
+	switch {
+	case req.EndsAt != nil:
+		endsAt = *req.EndsAt
+	case req.Duration > 0:
+		endsAt = startsAt.Add(time.Duration(req.Duration) * time.Second)
+	default:
+		return nil, fmt.Errorf("%w: ends_at or duration is required", ErrInvalidRequest)
+	}
+	if !endsAt.After(startsAt) || !endsAt.After(now) {
+		return nil, fmt.Errorf("%w: silence must end after it starts and in the future", ErrInvalidRequest)
+	}
+	if req.RuleID != "" {
+		if _, err := s.metadataService.GetAlertRule(req.RuleID); err != nil {
+			return nil, fmt.Errorf("%w: rule %s not found", ErrInvalidRequest, req.RuleID)
+		}
+	}
+
+	silence := &model.AlertSilence{
+		ID:          fmt.Sprintf("silence_%d", now.UnixNano()),
+		TenantOrgID: req.TenantOrgID,
+		RuleID:      req.RuleID,
+		Namespace:   req.Namespace,
+		Severity:    req.Severity,
+		Comment:     req.Comment,
+		CreatedBy:   req.CreatedBy,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		CreatedAt:   now,
+	}
+	if err := s.metadataService.SaveAlertSilence(silence); err != nil {
+		return nil, err
+	}
+	return silence, nil
+}
+
+// ListSilences lists the silences that have not ended yet
+// ListSilences 列出尚未结束的静默
+func (s *AlertService) ListSilences(tenantOrgID string) ([]*model.AlertSilence, error) {
+	return s.metadataService.ListAlertSilences(tenantOrgID, time.Now())
+}
+
+// ExpireSilence ends a silence immediately
+// ExpireSilence 立即结束静默
+func (s *AlertService) ExpireSilence(id string) (*model.AlertSilence, error) {
+	silence, err := s.metadataService.GetAlertSilence(id)
+	if err != nil {
+		return nil, err
+	}
+	if now := time.Now(); silence.EndsAt.After(now) {
+		silence.EndsAt = now
+		if err := s.metadataService.SaveAlertSilence(silence); err != nil {
+			return nil, err
+		}
+	}
+	return silence, nil
+}
+
+// ListAlerts lists alerts; state is pending, firing, resolved, all, or empty for the active ones
+// ListAlerts 列出告警；state 为 pending、firing、resolved、all，为空时返回活跃告警
+func (s *AlertService) ListAlerts(tenantOrgID, namespace, state string) ([]*model.Alert, error) {
+	var states []string
+	switch state {
+	case "":
+		states = []string{"pending", "firing"}
+	case "all":
+	case "pending", "firing", "resolved":
+		states = []string{state}
+	default:
+		return nil, fmt.Errorf("%w: unknown state %s", ErrInvalidRequest, state)
+	}
+	return s.metadataService.ListAlerts(tenantOrgID, namespace, states, defaultAlertListLimit)
+}
+
+// alertEvaluation caches the data read during one evaluation cycle
+// alertEvaluation 缓存一次评估周期内读取的数据
+type alertEvaluation struct {
+	now     time.Time
+	metrics map[string]*model.Metrics
+	health  map[string]*model.ESClusterHealth
+}
+
+// Evaluate evaluates every enabled rule against every cluster in its scope, moves alerts
+// through pending, firing and resolved and sends the resulting notifications
+// Evaluate 针对作用范围内的每个集群评估所有启用的规则，推进告警的 pending、firing、resolved 状态并发送通知
+func (s *AlertService) Evaluate(now time.Time) {
+	rules, err := s.metadataService.ListAlertRules("")
+	if err != nil {
+		log.Printf("Error listing alert rules: %v", err)
+		return
+	}
+	deployments, err := s.metadataService.ListDeploymentStatus()
+	if err != nil {
+		log.Printf("Error listing deployments for alert evaluation: %v", err)
+		return
+	}
+	active, err := s.metadataService.ListActiveAlerts()
+	if err != nil {
+		log.Printf("Error listing active alerts: %v", err)
+		return
+	}
+	silences, err := s.metadataService.ListAlertSilences("", now)
+	if err != nil {
+		log.Printf("Error listing alert silences: %v", err)
+		return
+	}
+	unannounced, err := s.metadataService.ListUnannouncedResolvedAlerts()
+	if err != nil {
+		log.Printf("Error listing resolved alerts to announce: %v", err)
+	}
+
+	alerts := make(map[string]*model.Alert, len(active))
+	for _, alert := range active {
+		alerts[alert.Fingerprint] = alert
+	}
+	eval := &alertEvaluation{
+		now:     now,
+		metrics: make(map[string]*model.Metrics),
+		health:  make(map[string]*model.ESClusterHealth),
+	}
+
+	seen := make(map[string]bool)
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		for _, deployment := range deployments {
+			if rule.TenantOrgID != "" && rule.TenantOrgID != deployment.TenantOrgID {
+				continue
+			}
+			if rule.Namespace != "" && rule.Namespace != deployment.Namespace {
+				continue
+			}
+			fingerprint := rule.ID + "/" + deployment.Namespace
+			seen[fingerprint] = true
+
+			value, firing, message, known := s.evaluateRule(eval, rule, deployment)
+			if !known {
+				// An alert keeps its state while its value cannot be read
+				// 无法读取告警值时保持告警的当前状态
+				continue
+			}
+			s.transition(rule, deployment, alerts[fingerprint], fingerprint, value, firing, message, silences, now)
+		}
+	}
+
+	// Alerts whose rule was disabled or whose cluster is gone resolve
+	// 规则被禁用或集群已删除的告警转为已解决
+	for fingerprint, alert := range alerts {
+		if seen[fingerprint] {
+			continue
+		}
+		rule, err := s.metadataService.GetAlertRule(alert.RuleID)
+		if err != nil {
+			rule = &model.AlertRule{ID: alert.RuleID, Name: alert.RuleName}
+		}
+		deployment := &model.DeploymentStatus{Namespace: alert.Namespace, TenantOrgID: alert.TenantOrgID}
+		s.transition(rule, deployment, alert, fingerprint, alert.Value, false, "rule disabled or cluster removed", silences, now)
+	}
+
+	// Resolutions that no channel accepted in earlier evaluations are sent again
+	// 之前的评估中未被任何渠道接收的解决通知会再次发送
+	for _, alert := range unannounced {
+		if silenced(alert, silences, now) {
+			continue
+		}
+		rule, err := s.metadataService.GetAlertRule(alert.RuleID)
+		if err != nil {
+			// Without its rule there are no channels left to deliver to
+			// 规则不存在时已没有可发送的渠道
+			alert.NotifiedState = alert.State
+		} else {
+			s.notify(rule, alert, now)
+		}
+		if err := s.metadataService.SaveAlert(alert); err != nil {
+			log.Printf("Error saving alert %s: %v", alert.ID, err)
+		}
+	}
+}
+
+// evaluateRule returns the current value of a rule on a cluster and whether its condition holds;
+// known is false when the value could not be read
+// evaluateRule 返回规则在集群上的当前值以及条件是否成立；无法读取该值时 known 为 false
+func (s *AlertService) evaluateRule(eval *alertEvaluation, rule *model.AlertRule, deployment *model.DeploymentStatus) (value float64, firing bool, message string, known bool) {
+	ns := deployment.Namespace
+	window := time.Duration(rule.Window) * time.Second
+
+	switch rule.Type {
+	case "threshold":
+		value, ok := s.currentValue(eval, rule.Metric, ns)
+		if !ok {
+			return 0, false, "", false
+		}
+		return value, alertOperators[rule.Operator](value, rule.Threshold),
+			fmt.Sprintf("%s is %.2f (%s %g)", rule.Metric, value, rule.Operator, rule.Threshold), true
+
+	case "rate":
+		samples, err := s.metadataService.ListMetricsRange(ns, eval.now.Add(-window), eval.now.Add(time.Second))
+		if err != nil {
+			log.Printf("Error reading metrics of namespace %s for alerting: %v", ns, err)
+			return 0, false, "", false
+		}
+		// Only samples that measured the series take part in the rate
+		// 只有测量到该序列的样本参与变化量计算
+		if metric, ok := seriesMetric[rule.Metric]; ok {
+			measured := samples[:0:0]
+			for _, sample := range samples {
+				if !sample.IsMissing(metric) {
+					measured = append(measured, sample)
+				}
+			}
+			samples = measured
+		}
+		if len(samples) < 2 {
+			return 0, false, "", false
+		}
+		field := metricsSeries[rule.Metric]
+		value := field(&samples[len(samples)-1]) - field(&samples[0])
+		return value, alertOperators[rule.Operator](value, rule.Threshold),
+			fmt.Sprintf("%s changed by %.2f over %s (%s %g)", rule.Metric, value, window, rule.Operator, rule.Threshold), true
+
+	case "absence":
+		// New clusters and a freshly started manager get a full window before they count as silent
+		// 新集群与刚启动的管理器在完整窗口过去之前不视为缺失数据
+		last := deployment.CreatedAt
+		if s.startedAt.After(last) {
+			last = s.startedAt
+		}
+		if _, isHealth := esHealthSeries[rule.Metric]; isHealth {
+			// Reading the health records its time in lastHealth on success
+			// 读取健康状态成功时会在 lastHealth 中记录时间
+			s.currentValue(eval, rule.Metric, ns)
+			s.mu.Lock()
+			if t := s.lastHealth[ns]; t.After(last) {
+				last = t
+			}
+			s.mu.Unlock()
+		} else if metrics := s.latestMetrics(eval, ns); metrics != nil && metrics.Timestamp.After(last) {
+			last = metrics.Timestamp
+		}
+		silent := eval.now.Sub(last)
+		return silent.Seconds(), silent >= window,
+			fmt.Sprintf("no %s data for %s", rule.Metric, silent.Truncate(time.Second)), true
+	}
+	return 0, false, "", false
+}
+
+// currentValue returns the latest value of a metric on a cluster, or false when there is no recent data
+// currentValue 返回集群上某指标的最新值；没有近期数据时返回 false
+func (s *AlertService) currentValue(eval *alertEvaluation, metric, namespace string) (float64, bool) {
+	if field, ok := esHealthSeries[metric]; ok {
+		health, cached := eval.health[namespace]
+		if !cached {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			var err error
+			health, err = s.esStats.ClusterHealth(ctx, namespace)
+			cancel()
+			if err != nil {
+				log.Printf("Error reading cluster health of namespace %s for alerting: %v", namespace, err)
+			} else {
+				s.mu.Lock()
+				s.lastHealth[namespace] = eval.now
+				s.mu.Unlock()
+			}
+			eval.health[namespace] = health
+		}
+		if health == nil {
+			return 0, false
+		}
+		return field(health), true
+	}
+
+	metrics := s.latestMetrics(eval, namespace)
+	if metrics == nil || eval.now.Sub(metrics.Timestamp) > alertStaleAfter {
+		return 0, false
+	}
+	if scaling, ok := seriesMetric[metric]; ok && metrics.IsMissing(scaling) {
+		return 0, false
+	}
+	return metricsSeries[metric](metrics), true
+}
+
+func (s *AlertService) latestMetrics(eval *alertEvaluation, namespace string) *model.Metrics {
+	metrics, cached := eval.metrics[namespace]
+	if !cached {
+		metrics, _ = s.metadataService.GetLatestMetrics(namespace)
+		eval.metrics[namespace] = metrics
+	}
+	return metrics
+}
+
+// transition moves the alert of a rule and cluster to its next state and notifies on
+// firing, on repeats of a firing alert and on resolution
+// transition 将规则与集群对应的告警推进到下一状态，并在触发、重复提醒与解决时发送通知
+func (s *AlertService) transition(rule *model.AlertRule, deployment *model.DeploymentStatus, alert *model.Alert, fingerprint string,
+	value float64, firing bool, message string, silences []*model.AlertSilence, now time.Time) {
+	if !firing {
+		if alert == nil {
+			return
+		}
+		if alert.State == "pending" {
+			// A condition that cleared before its duration never fires
+			// 在持续时间内恢复的条件不会触发告警
+			if err := s.metadataService.DeleteAlert(alert.ID); err != nil {
+				log.Printf("Error deleting pending alert %s: %v", alert.ID, err)
+			}
+			return
+		}
+		alert.State = "resolved"
+		alert.ResolvedAt = &now
+		if message != "" {
+			alert.Message = message
+		}
+	} else {
+		if alert == nil {
+			alert = &model.Alert{
+				ID:          fmt.Sprintf("alert_%d", now.UnixNano()),
+				Fingerprint: fingerprint,
+				RuleID:      rule.ID,
+				TenantOrgID: deployment.TenantOrgID,
+				Namespace:   deployment.Namespace,
+				State:       "pending",
+				StartsAt:    now,
+				CreatedAt:   now,
+			}
+		}
+		alert.Value = value
+		alert.Message = message
+		if alert.State == "pending" && now.Sub(alert.StartsAt) >= time.Duration(rule.For)*time.Second {
+			alert.State = "firing"
+			alert.FiredAt = &now
+		}
+	}
+	alert.RuleName = rule.Name
+	if rule.Severity != "" {
+		alert.Severity = rule.Severity
+	}
+	alert.LastEvaluatedAt = now
+	alert.UpdatedAt = now
+	alert.Silenced = silenced(alert, silences, now)
+
+	if !alert.Silenced && s.shouldNotify(rule, alert, now) {
+		s.notify(rule, alert, now)
+	}
+	if err := s.metadataService.SaveAlert(alert); err != nil {
+		log.Printf("Error saving alert %s: %v", alert.ID, err)
+	}
+}
+
+// shouldNotify deduplicates notifications: a firing alert is announced once and then
+// every repeat interval, a resolution only when the firing was announced
+// shouldNotify 对通知去重：firing 告警只通知一次，之后按重复间隔提醒；只有通知过 firing 的告警才通知解决
+func (s *AlertService) shouldNotify(rule *model.AlertRule, alert *model.Alert, now time.Time) bool {
+	switch alert.State {
+	case "firing":
+		if alert.NotifiedState != "firing" {
+			return true
+		}
+		return rule.RepeatInterval > 0 && alert.LastNotifiedAt != nil &&
+			now.Sub(*alert.LastNotifiedAt) >= time.Duration(rule.RepeatInterval)*time.Second
+	case "resolved":
+		return alert.NotifiedState == "firing"
+	}
+	return false
+}
+
+// notify delivers an alert to the enabled channels of its rule
+// notify 将告警发送到规则中已启用的渠道
+func (s *AlertService) notify(rule *model.AlertRule, alert *model.Alert, now time.Time) {
+	notification := AlertNotification{
+		Status:      alert.State,
+		AlertID:     alert.ID,
+		RuleID:      alert.RuleID,
+		RuleName:    alert.RuleName,
+		TenantOrgID: alert.TenantOrgID,
+		Namespace:   alert.Namespace,
+		Severity:    alert.Severity,
+		Value:       alert.Value,
+		Message:     alert.Message,
+		StartsAt:    alert.StartsAt,
+		ResolvedAt:  alert.ResolvedAt,
+	}
+
+	var errs []string
+	delivered := false
+	for _, id := range rule.Channels {
+		channel, err := s.metadataService.GetAlertChannel(id)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("channel %s: %v", id, err))
+			continue
+		}
+		if !channel.Enabled {
+			continue
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+		err = s.notifier.Notify(ctx, channel, notification)
+		cancel()
+		if err != nil {
+			log.Printf("Error notifying channel %s of alert %s: %v", channel.Name, alert.ID, err)
+			errs = append(errs, fmt.Sprintf("channel %s: %v", channel.Name, err))
+			continue
+		}
+		delivered = true
+	}
+
+	alert.NotifyError = strings.Join(errs, "; ")
+	// Undelivered notifications are retried on the next evaluation
+	// 未送达的通知会在下一次评估时重试
+	if !delivered {
+		return
+	}
+	alert.LastNotifiedAt = &now
+	alert.NotifiedState = alert.State
+}
+
+// silenced reports whether an active silence matches the alert; a tenant silence only
+// matches alerts of that tenant
+// silenced 判断是否有生效中的静默匹配该告警；租户静默只匹配该租户的告警
+func silenced(alert *model.Alert, silences []*model.AlertSilence, now time.Time) bool {
+	for _, silence := range silences {
+		if now.Before(silence.StartsAt) || !now.Before(silence.EndsAt) {
+			continue
+		}
+		if silence.TenantOrgID != "" && silence.TenantOrgID != alert.TenantOrgID {
+			continue
+		}
+		if silence.RuleID != "" && silence.RuleID != alert.RuleID {
+			continue
+		}
+		if silence.Namespace != "" && silence.Namespace != alert.Namespace {
+			continue
+		}
+		if silence.Severity != "" && silence.Severity != alert.Severity {
+			continue
+		}
+		return true
+	}
+	return false
+}
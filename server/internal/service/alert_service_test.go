@@ -0,0 +1,42 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestKeepRedactedSecrets(t *testing.T) {
+	channel := &model.AlertChannel{
+		Password: "s3cret",
+		Headers:  map[string]string{"Authorization": "Bearer token"},
+	}
+	redacted := redactChannel(channel)
+
+	// Round-tripping the redacted channel keeps every stored secret
+	req, err := keepRedactedSecrets(channel, model.AlertChannelRequest{
+		Password: redacted.Password,
+		Headers:  map[string]string{"Authorization": redacted.Headers["Authorization"], "X-Team": "search"},
+	})
+	if err != nil {
+		t.Fatalf("keepRedactedSecrets: %v", err)
+	}
+	if req.Password != "s3cret" {
+		t.Errorf("Password = %q, want the stored password", req.Password)
+	}
+	if want := map[string]string{"Authorization": "Bearer token", "X-Team": "search"}; !reflect.DeepEqual(req.Headers, want) {
+		t.Errorf("Headers = %v, want %v", req.Headers, want)
+	}
+
+	req, err = keepRedactedSecrets(channel, model.AlertChannelRequest{Password: "rotated"})
+	if err != nil || req.Password != "rotated" {
+		t.Errorf("new password = %q, %v, want rotated", req.Password, err)
+	}
+
+	_, err = keepRedactedSecrets(channel, model.AlertChannelRequest{Headers: map[string]string{"X-Unknown": redactedSecret}})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Errorf("redacted value for an unknown header: err = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -0,0 +1,199 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// ErrAliasConflict is returned when an alias name is already taken
+// ErrAliasConflict 别名名称已被占用时返回的错误
+var ErrAliasConflict = errors.New("alias conflict")
+
+// AliasService manages index aliases through the Elasticsearch _aliases API
+// AliasService 通过 Elasticsearch _aliases API 管理索引别名
+type AliasService struct {
+	store           VectorStore
+	metadataService *MetadataService
+}
+
+// NewAliasService creates a new alias service
+// NewAliasService 创建一个新的别名服务
+func NewAliasService(store VectorStore, metadataService *MetadataService) *AliasService {
+	return &AliasService{
+		store:           store,
+		metadataService: metadataService,
+	}
+}
+
+// CreateAlias creates a new alias pointing at one or more indices
+// CreateAlias 创建指向一个或多个索引的新别名
+func (a *AliasService) CreateAlias(req model.AliasRequest) (*model.IndexAlias, error) {
+	if req.Alias == "" {
+		return nil, fmt.Errorf("%w: alias is required", ErrInvalidRequest)
+	}
+	if err := validateAliasTargets(req); err != nil {
+		return nil, err
+	}
+
+	if _, err := a.metadataService.GetIndexAlias(req.Alias); err == nil {
+		return nil, fmt.Errorf("%w: alias %s already exists", ErrAliasConflict, req.Alias)
+	}
+	if _, err := a.metadataService.GetIndexMetadataByName(req.Alias); err == nil {
+		return nil, fmt.Errorf("%w: %s is already a vector index", ErrAliasConflict, req.Alias)
+	}
+	current, err := a.store.GetAliasIndices(req.Alias)
+	if err != nil {
+		return nil, err
+	}
+	if len(current) > 0 {
+		return nil, fmt.Errorf("%w: alias %s already exists in Elasticsearch", ErrAliasConflict, req.Alias)
+	}
+
+	if err := a.store.UpdateAliases(aliasActions(req, nil)); err != nil {
+		return nil, err
+	}
+
+	alias := &model.IndexAlias{
+		ID:         "alias_" + req.Alias,
+		Alias:      req.Alias,
+		Indices:    req.Indices,
+		WriteIndex: req.WriteIndex,
+		Filter:     req.Filter,
+		CreatedBy:  req.TenantID,
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+	if err := a.metadataService.SaveIndexAlias(alias); err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// UpdateAlias atomically repoints an alias at a new set of indices
+// UpdateAlias 原子地将别名重新指向一组新的索引
+func (a *AliasService) UpdateAlias(name string, req model.AliasRequest) (*model.IndexAlias, error) {
+	req.Alias = name
+	if err := validateAliasTargets(req); err != nil {
+		return nil, err
+	}
+
+	alias, err := a.metadataService.GetIndexAlias(name)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := a.store.GetAliasIndices(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := a.store.UpdateAliases(aliasActions(req, current)); err != nil {
+		return nil, err
+	}
+
+	alias.Indices = req.Indices
+	alias.WriteIndex = req.WriteIndex
+	alias.Filter = req.Filter
+	alias.UpdatedAt = time.Now()
+	if err := a.metadataService.SaveIndexAlias(alias); err != nil {
+		return nil, err
+	}
+	return alias, nil
+}
+
+// DeleteAlias removes an alias from all of its indices
+// DeleteAlias 从所有索引上移除别名
+func (a *AliasService) DeleteAlias(name string) error {
+	if _, err := a.metadataService.GetIndexAlias(name); err != nil {
+		return err
+	}
+
+	current, err := a.store.GetAliasIndices(name)
+	if err != nil {
+		return err
+	}
+	if len(current) > 0 {
+		actions := make([]map[string]interface{}, 0, len(current))
+		for _, index := range current {
+			actions = append(actions, map[string]interface{}{
+				"remove": map[string]interface{}{"index": index, "alias": name},
+			})
+		}
+		if err := a.store.UpdateAliases(actions); err != nil {
+			return err
+		}
+	}
+
+	return a.metadataService.DeleteIndexAlias(name)
+}
+
+// GetAlias retrieves an alias, refreshing its indices from Elasticsearch
+// GetAlias 获取别名，并从 Elasticsearch 刷新其指向的索引
+func (a *AliasService) GetAlias(name string) (*model.IndexAlias, error) {
+	alias, err := a.metadataService.GetIndexAlias(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if current, err := a.store.GetAliasIndices(name); err == nil {
+		alias.Indices = current
+	}
+	return alias, nil
+}
+
+// ListAliases lists all managed aliases
+// ListAliases 列出所有受管理的别名
+func (a *AliasService) ListAliases() ([]*model.IndexAlias, error) {
+	return a.metadataService.ListIndexAliases()
+}
+
+// validateAliasTargets checks the indices and write index of an alias request
+// validateAliasTargets 校验别名请求中的索引和写入索引
+func validateAliasTargets(req model.AliasRequest) error {
+	if len(req.Indices) == 0 {
+		return fmt.Errorf("%w: indices is required", ErrInvalidRequest)
+	}
+	if req.WriteIndex == "" {
+		return nil
+	}
+	for _, index := range req.Indices {
+		if index == req.WriteIndex {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: write_index %s is not one of the alias indices", ErrInvalidRequest, req.WriteIndex)
+}
+
+// aliasActions builds the _aliases actions that make the alias point exactly at req.Indices
+// aliasActions 构建使别名恰好指向 req.Indices 的 _aliases 操作
+func aliasActions(req model.AliasRequest, current []string) []map[string]interface{} {
+	wanted := make(map[string]bool, len(req.Indices))
+	for _, index := range req.Indices {
+		wanted[index] = true
+	}
+
+	var actions []map[string]interface{}
+	for _, index := range current {
+		if !wanted[index] {
+			actions = append(actions, map[string]interface{}{
+				"remove": map[string]interface{}{"index": index, "alias": req.Alias},
+			})
+		}
+	}
+
+	for _, index := range req.Indices {
+		add := map[string]interface{}{"index": index, "alias": req.Alias}
+		if req.WriteIndex != "" {
+			add["is_write_index"] = index == req.WriteIndex
+		}
+		if len(req.Filter) > 0 {
+			add["filter"] = req.Filter
+		}
+		actions = append(actions, map[string]interface{}{"add": add})
+	}
+
+	return actions
+}
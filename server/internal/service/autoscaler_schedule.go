@@ -0,0 +1,301 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	_ "time/tzdata" // Schedules name IANA time zones that the host may not ship
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// scheduleDrivingMetric is the driving metric of a recommendation changed by a schedule
+	// scheduleDrivingMetric 被定时计划改变的扩缩容建议所记录的决定指标
+	scheduleDrivingMetric = "schedule"
+	// maxScheduleDuration bounds the window of a schedule, in minutes
+	// maxScheduleDuration 定时计划窗口的最大分钟数
+	maxScheduleDuration = 7 * 24 * 60
+	// maxScheduleHorizon bounds how far ahead scheduled changes are listed
+	// maxScheduleHorizon 列出定时变更时最远的时间范围
+	maxScheduleHorizon = 7 * 24 * time.Hour
+	// maxScheduledChanges bounds the scheduled changes returned by one query
+	// maxScheduledChanges 单次查询返回的定时变更数量上限
+	maxScheduledChanges = 1000
+)
+
+// scheduleWindow is a scaling schedule with its cron expression and time zone resolved
+// scheduleWindow 已解析 cron 表达式与时区的定时扩缩容计划
+type scheduleWindow struct {
+	schedule model.ScalingSchedule
+	cron     *cronExpr
+	location *time.Location
+}
+
+func newScheduleWindow(schedule model.ScalingSchedule) (*scheduleWindow, error) {
+	cron, err := parseCron(schedule.Cron)
+	if err != nil {
+		return nil, err
+	}
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", schedule.Timezone)
+	}
+	return &scheduleWindow{schedule: schedule, cron: cron, location: location}, nil
+}
+
+func (w *scheduleWindow) duration() time.Duration {
+	return time.Duration(w.schedule.Duration) * time.Minute
+}
+
+// activeAt reports whether a window of the schedule is open at t, i.e. the cron fired within the
+// last Duration minutes
+// activeAt 判断 t 时刻计划是否处于生效窗口内，即 cron 在最近 Duration 分钟内触发过
+func (w *scheduleWindow) activeAt(t time.Time) bool {
+	start := w.cron.next(t.In(w.location).Add(-w.duration()))
+	return !start.IsZero() && !start.After(t)
+}
+
+// changes lists the window starts and ends of the schedule within (from, to]
+// changes 列出计划在 (from, to] 内的窗口开始与结束
+func (w *scheduleWindow) changes(from, to time.Time) []model.ScheduledChange {
+	change := func(at time.Time, action string) model.ScheduledChange {
+		return model.ScheduledChange{
+			Time:        at,
+			Action:      action,
+			ScheduleID:  w.schedule.ID,
+			Name:        w.schedule.Name,
+			Namespace:   w.schedule.Namespace,
+			MinReplicas: w.schedule.MinReplicas,
+			MaxReplicas: w.schedule.MaxReplicas,
+		}
+	}
+
+	var changes []model.ScheduledChange
+	// Windows that started before from may still end within the range
+	// 在 from 之前开始的窗口仍可能在范围内结束
+	for start := w.cron.next(from.In(w.location).Add(-w.duration())); !start.IsZero() && !start.After(to); start = w.cron.next(start) {
+		if start.After(from) {
+			changes = append(changes, change(start, "start"))
+		}
+		if end := start.Add(w.duration()); end.After(from) && !end.After(to) {
+			changes = append(changes, change(end.In(w.location), "end"))
+		}
+		if len(changes) >= maxScheduledChanges {
+			break
+		}
+	}
+	return changes
+}
+
+// scheduleOverride returns the floor and ceiling of the enabled schedules of a cluster that are active
+// at t, or nil when none is. Overlapping floors take the highest, ceilings the lowest.
+// scheduleOverride 返回集群在 t 时刻生效的已启用计划的下限与上限，无生效计划时返回 nil；重叠时下限取最大值，上限取最小值
+func (a *AutoscalerService) scheduleOverride(namespace string, t time.Time) *model.ScheduleOverride {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	var override *model.ScheduleOverride
+	for _, schedule := range a.schedules {
+		if schedule.Namespace != namespace || !schedule.Enabled {
+			continue
+		}
+		window, err := newScheduleWindow(schedule)
+		if err != nil || !window.activeAt(t) {
+			continue
+		}
+		if override == nil {
+			override = &model.ScheduleOverride{}
+		}
+		override.Schedules = append(override.Schedules, schedule.ID)
+		if schedule.MinReplicas > override.MinReplicas {
+			override.MinReplicas = schedule.MinReplicas
+		}
+		if schedule.MaxReplicas > 0 && (override.MaxReplicas == 0 || schedule.MaxReplicas < override.MaxReplicas) {
+			override.MaxReplicas = schedule.MaxReplicas
+		}
+	}
+	if override != nil {
+		sort.Strings(override.Schedules)
+	}
+	return override
+}
+
+// applyScheduleOverride bounds the desired replicas of a recommendation by the active schedules; the
+// floor wins over the ceiling and the recommendation is marked as driven by the schedule when it changes
+// applyScheduleOverride 以生效中的计划限制建议的期望副本数；下限优先于上限，发生改变时建议标记为由定时计划决定
+func applyScheduleOverride(recommendation *model.ScalingRecommendation, override *model.ScheduleOverride) {
+	recommendation.Schedule = override
+	desired := recommendation.DesiredReplicas
+	if override.MaxReplicas > 0 && desired > override.MaxReplicas {
+		desired = override.MaxReplicas
+	}
+	if desired < override.MinReplicas {
+		desired = override.MinReplicas
+	}
+	if desired != recommendation.DesiredReplicas {
+		recommendation.DesiredReplicas = desired
+		recommendation.DrivingMetric = scheduleDrivingMetric
+	}
+}
+
+// scheduleReason describes the bounds the active schedules put on the replicas
+// scheduleReason 描述生效中的计划对副本数施加的限制
+func scheduleReason(override *model.ScheduleOverride) string {
+	var bounds []string
+	if override.MinReplicas > 0 {
+		bounds = append(bounds, fmt.Sprintf("at least %d", override.MinReplicas))
+	}
+	if override.MaxReplicas > 0 {
+		bounds = append(bounds, fmt.Sprintf("at most %d", override.MaxReplicas))
+	}
+	return fmt.Sprintf("schedule %s requires %s replicas", strings.Join(override.Schedules, ", "), strings.Join(bounds, " and "))
+}
+
+// CreateSchedule creates a scaling schedule of a cluster
+// CreateSchedule 创建集群的定时扩缩容计划
+func (a *AutoscalerService) CreateSchedule(req model.ScalingScheduleRequest) (*model.ScalingSchedule, error) {
+	now := time.Now()
+	schedule := &model.ScalingSchedule{
+		ID:        fmt.Sprintf("schedule_%d", now.UnixNano()),
+		CreatedAt: now,
+	}
+	if err := a.applySchedule(schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.UpdatedAt = now
+	if err := a.metadataService.SaveScalingSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.schedules[schedule.ID] = *schedule
+	a.mu.Unlock()
+	return schedule, nil
+}
+
+// UpdateSchedule replaces the definition of a scaling schedule
+// UpdateSchedule 替换定时扩缩容计划的定义
+func (a *AutoscalerService) UpdateSchedule(id string, req model.ScalingScheduleRequest) (*model.ScalingSchedule, error) {
+	schedule, err := a.metadataService.GetScalingSchedule(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.applySchedule(schedule, req); err != nil {
+		return nil, err
+	}
+	schedule.UpdatedAt = time.Now()
+	if err := a.metadataService.SaveScalingSchedule(schedule); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.schedules[schedule.ID] = *schedule
+	a.mu.Unlock()
+	return schedule, nil
+}
+
+// GetSchedule gets a scaling schedule
+// GetSchedule 获取定时扩缩容计划
+func (a *AutoscalerService) GetSchedule(id string) (*model.ScalingSchedule, error) {
+	return a.metadataService.GetScalingSchedule(id)
+}
+
+// ListSchedules lists scaling schedules, optionally filtered by namespace
+// ListSchedules 列出定时扩缩容计划，可按命名空间过滤
+func (a *AutoscalerService) ListSchedules(namespace string) ([]*model.ScalingSchedule, error) {
+	return a.metadataService.ListScalingSchedules(namespace)
+}
+
+// DeleteSchedule deletes a scaling schedule
+// DeleteSchedule 删除定时扩缩容计划
+func (a *AutoscalerService) DeleteSchedule(id string) error {
+	if err := a.metadataService.DeleteScalingSchedule(id); err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	delete(a.schedules, id)
+	a.mu.Unlock()
+	return nil
+}
+
+// ListScheduledChanges lists the window starts and ends of the enabled schedules of a cluster within
+// the next horizon, in order; times are in the time zone of their schedule
+// ListScheduledChanges 按时间顺序列出集群已启用计划在未来 horizon 内的窗口开始与结束；时间使用各计划的时区
+func (a *AutoscalerService) ListScheduledChanges(namespace string, horizon time.Duration) ([]model.ScheduledChange, error) {
+	if horizon <= 0 || horizon > maxScheduleHorizon {
+		return nil, fmt.Errorf("%w: horizon must be between 0 and %s", ErrInvalidRequest, maxScheduleHorizon)
+	}
+	if _, err := a.metadataService.GetDeploymentStatus(namespace); err != nil {
+		return nil, err
+	}
+
+	from := time.Now()
+	to := from.Add(horizon)
+	changes := []model.ScheduledChange{}
+	a.mu.RLock()
+	for _, schedule := range a.schedules {
+		if schedule.Namespace != namespace || !schedule.Enabled {
+			continue
+		}
+		window, err := newScheduleWindow(schedule)
+		if err != nil {
+			continue
+		}
+		changes = append(changes, window.changes(from, to)...)
+	}
+	a.mu.RUnlock()
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		if !changes[i].Time.Equal(changes[j].Time) {
+			return changes[i].Time.Before(changes[j].Time)
+		}
+		return changes[i].ScheduleID < changes[j].ScheduleID
+	})
+	if len(changes) > maxScheduledChanges {
+		changes = changes[:maxScheduledChanges]
+	}
+	return changes, nil
+}
+
+// applySchedule validates a schedule request and copies it into schedule
+// applySchedule 校验定时计划请求并写入 schedule
+func (a *AutoscalerService) applySchedule(schedule *model.ScalingSchedule, req model.ScalingScheduleRequest) error {
+	if req.Namespace == "" {
+		return fmt.Errorf("%w: namespace is required", ErrInvalidRequest)
+	}
+	if _, err := a.metadataService.GetDeploymentStatus(req.Namespace); err != nil {
+		return fmt.Errorf("%w: cluster %s not found", ErrInvalidRequest, req.Namespace)
+	}
+	if req.Duration < 1 || req.Duration > maxScheduleDuration {
+		return fmt.Errorf("%w: duration must be between 1 and %d minutes", ErrInvalidRequest, maxScheduleDuration)
+	}
+	if req.MinReplicas < 0 || req.MaxReplicas < 0 {
+		return fmt.Errorf("%w: replicas must not be negative", ErrInvalidRequest)
+	}
+	if req.MinReplicas == 0 && req.MaxReplicas == 0 {
+		return fmt.Errorf("%w: min_replicas or max_replicas is required", ErrInvalidRequest)
+	}
+	if req.MaxReplicas > 0 && req.MaxReplicas < req.MinReplicas {
+		return fmt.Errorf("%w: max_replicas must not be below min_replicas", ErrInvalidRequest)
+	}
+
+	schedule.Namespace = req.Namespace
+	schedule.Name = req.Name
+	schedule.Cron = strings.TrimSpace(req.Cron)
+	schedule.Duration = req.Duration
+	schedule.Timezone = req.Timezone
+	schedule.MinReplicas = req.MinReplicas
+	schedule.MaxReplicas = req.MaxReplicas
+	schedule.Enabled = req.Enabled == nil || *req.Enabled
+	window, err := newScheduleWindow(*schedule)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidRequest, err)
+	}
+	if window.cron.next(time.Now().In(window.location)).IsZero() {
+		return fmt.Errorf("%w: cron expression %q never fires", ErrInvalidRequest, schedule.Cron)
+	}
+	return nil
+}
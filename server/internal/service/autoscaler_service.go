@@ -1,67 +1,115 @@
 package service
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
-	"os/exec"
-	"strconv"
+	"math"
+	"os"
 	"strings"
 	"sync"
 	"time"
 
+	"gorm.io/gorm"
+
 	"es-serverless-manager/internal/model"
 )
 
+// autoscalerConfigID is the ID of the single stored global autoscaler configuration
+// autoscalerConfigID 唯一一条全局自动扩缩容配置记录的 ID
+const autoscalerConfigID = "default"
+
+// Autoscaler modes: off skips evaluation, recommend records decisions without scaling and auto scales
+// 自动扩缩容模式：off 不评估，recommend 只记录决策不扩缩容，auto 实际扩缩容
+const (
+	AutoscalerModeOff       = "off"
+	AutoscalerModeRecommend = "recommend"
+	AutoscalerModeAuto      = "auto"
+)
+
+const (
+	// decisionRetention is how long autoscaler decisions are kept
+	// decisionRetention 自动扩缩容决策的保留时长
+	decisionRetention = 30 * 24 * time.Hour
+	// maxDecisionListLimit bounds the decisions returned by one query
+	// maxDecisionListLimit 单次查询返回的决策数量上限
+	maxDecisionListLimit = 1000
+	// autoscalerMetricsStaleAfter is the age after which the latest sample is not acted on
+	// autoscalerMetricsStaleAfter 最新样本超过该时长后不再据此扩缩容
+	autoscalerMetricsStaleAfter = 5 * time.Minute
+)
+
 // AutoscalerService handles automatic scaling of Elasticsearch clusters
 // AutoscalerService 处理 Elasticsearch 集群的自动扩缩容
 type AutoscalerService struct {
-	config *model.AutoscalerConfig
-	ticker *time.Ticker
-	// Map to store last scaling time for each namespace
-	// 存储每个命名空间的最后一次扩缩容时间的映射
-	lastScalingTime map[string]time.Time
+	config model.AutoscalerConfig
+	// Scaling policies by ID, loaded from the metadata service
+	// 按 ID 存储的扩缩容策略，从元数据服务加载
+	policies map[string]model.ScalingPolicy
+	// Scaling schedules by ID, loaded from the metadata service
+	// 按 ID 存储的定时扩缩容计划，从元数据服务加载
+	schedules map[string]model.ScalingSchedule
+	ticker    *time.Ticker
 	// Map to store historical metrics for each namespace
 	// 存储每个命名空间的历史指标的映射
 	historicalMetrics map[string]*model.HistoricalMetrics
 	metadataService   *MetadataService
+	inspector         ClusterInspector
+	scaler            *ClusterScaler
+	forecaster        *LoadForecaster
+	metrics           *PrometheusExporter
 	mu                sync.RWMutex
 	stopChan          chan struct{}
 }
 
-// NewAutoscalerService creates a new autoscaler with default configuration
-// NewAutoscalerService 创建一个具有默认配置的新自动扩缩容服务
-func NewAutoscalerService(metadataService *MetadataService) *AutoscalerService {
-	config := &model.AutoscalerConfig{
-		HighCPUThreshold:    70.0,
-		LowCPUThreshold:     30.0,
-		HighMemoryThreshold: 70.0,
-		LowMemoryThreshold:  30.0,
-		HighQPSThreshold:    2000.0,
-		LowQPSThreshold:     500.0,
-		HighDiskThreshold:   80.0,
-		LowDiskThreshold:    20.0,
-		ScaleUpFactor:       1.5,
-		ScaleDownFactor:     0.5,
-		MinReplicas:         1,
-		MaxReplicas:         10,
-		ScaleUpCooldown:     300, // 5 minutes cooldown after scaling up
-		ScaleDownCooldown:   600, // 10 minutes cooldown after scaling down
-		ScalingPolicies:     make(map[string]model.ScalingPolicy),
+// DefaultAutoscalerConfig returns the built-in global autoscaler configuration
+// DefaultAutoscalerConfig 返回内置的全局自动扩缩容配置
+func DefaultAutoscalerConfig() model.AutoscalerConfig {
+	return model.AutoscalerConfig{
+		Mode: AutoscalerModeAuto,
+		Targets: []model.MetricTarget{
+			{Metric: "cpu_usage", Target: 70},
+			{Metric: "memory_usage", Target: 75},
+			{Metric: "disk_usage", Target: 80},
+			{Metric: "qps", Target: 1000},
+		},
+		Tolerance:         0.1,
+		ScaleUpFactor:     2,
+		ScaleDownFactor:   0.5,
+		MinReplicas:       1,
+		MaxReplicas:       10,
+		ScaleUpCooldown:   300, // 5 minutes cooldown after scaling up
+		ScaleDownCooldown: 600, // 10 minutes cooldown after scaling down
+		ForecastHorizon:   60,  // Provision for the forecast load of the next hour
 	}
+}
 
+// NewAutoscalerService creates a new autoscaler with default configuration
+// NewAutoscalerService 创建一个具有默认配置的新自动扩缩容服务
+func NewAutoscalerService(metadataService *MetadataService, inspector ClusterInspector, scaler *ClusterScaler, forecaster *LoadForecaster, metrics *PrometheusExporter) *AutoscalerService {
 	return &AutoscalerService{
-		config:            config,
-		lastScalingTime:   make(map[string]time.Time),
+		config:            DefaultAutoscalerConfig(),
+		policies:          make(map[string]model.ScalingPolicy),
+		schedules:         make(map[string]model.ScalingSchedule),
 		historicalMetrics: make(map[string]*model.HistoricalMetrics),
 		metadataService:   metadataService,
+		inspector:         inspector,
+		scaler:            scaler,
+		forecaster:        forecaster,
+		metrics:           metrics,
 		stopChan:          make(chan struct{}),
 	}
 }
 
-// Start begins the autoscaling loop
-// Start 启动自动扩缩容循环
+// Start reloads the stored configuration and policies, then begins the autoscaling loop
+// Start 重新加载已保存的配置与策略，然后启动自动扩缩容循环
 func (a *AutoscalerService) Start() {
+	if err := a.Reload(); err != nil {
+		log.Printf("Error loading autoscaler configuration: %v", err)
+	}
+
 	a.ticker = time.NewTicker(60 * time.Second) // Check every minute
 	go func() {
 		for {
@@ -76,6 +124,55 @@ func (a *AutoscalerService) Start() {
 	}()
 }
 
+// Reload replaces the in-memory configuration and policies with the ones stored in the metadata service;
+// the built-in configuration is kept when none was stored
+// Reload 使用元数据服务中保存的配置与策略替换内存中的副本；未保存配置时保留内置配置
+func (a *AutoscalerService) Reload() error {
+	config, err := a.metadataService.GetAutoscalerConfig()
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	policies, err := a.metadataService.ListScalingPolicies("", "")
+	if err != nil {
+		return err
+	}
+	schedules, err := a.metadataService.ListScalingSchedules("")
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if config != nil {
+		// Fill settings introduced after the configuration was stored
+		// 补全配置保存之后新增的设置
+		defaults := DefaultAutoscalerConfig()
+		if config.Mode == "" {
+			config.Mode = defaults.Mode
+		}
+		if len(config.Targets) == 0 {
+			config.Targets = defaults.Targets
+		}
+		if config.Tolerance == 0 {
+			config.Tolerance = defaults.Tolerance
+		}
+		if config.ForecastHorizon == 0 {
+			config.ForecastHorizon = defaults.ForecastHorizon
+		}
+		a.config = *config
+	}
+	a.policies = make(map[string]model.ScalingPolicy, len(policies))
+	for _, policy := range policies {
+		a.policies[policy.ID] = *policy
+	}
+	a.schedules = make(map[string]model.ScalingSchedule, len(schedules))
+	for _, schedule := range schedules {
+		a.schedules[schedule.ID] = *schedule
+	}
+	log.Printf("Loaded %d autoscaler policies and %d schedules", len(policies), len(schedules))
+	return nil
+}
+
 // Stop stops the autoscaling loop
 // Stop 停止自动扩缩容循环
 func (a *AutoscalerService) Stop() {
@@ -85,21 +182,31 @@ func (a *AutoscalerService) Stop() {
 // checkAndScale checks metrics and scales clusters if needed
 // checkAndScale 检查指标并在需要时扩缩容集群
 func (a *AutoscalerService) checkAndScale() {
+	if _, err := a.metadataService.DeleteAutoscalerDecisionsBefore(time.Now().Add(-decisionRetention)); err != nil {
+		log.Printf("Error deleting expired autoscaler decisions: %v", err)
+	}
+	if _, err := a.metadataService.DeleteLoadForecastsBefore(time.Now().Add(-decisionRetention)); err != nil {
+		log.Printf("Error deleting expired load forecasts: %v", err)
+	}
+
 	// Get list of namespaces with ES clusters from metadata service
 	// 从元数据服务获取具有 ES 集群的命名空间列表
 	deployments, err := a.metadataService.ListDeploymentStatus()
 	if err != nil {
-		// Fallback to kubectl if metadata service fails
-		// 如果元数据服务失败，回退到 kubectl
-		log.Printf("Error getting deployments from metadata service: %v, falling back to kubectl", err)
-		cmd := exec.Command("kubectl", "get", "namespaces", "-l", "es-cluster=true", "-o", "jsonpath={.items[*].metadata.name}")
-		out, err := cmd.CombinedOutput()
+		// Fallback to Kubernetes if metadata service fails
+		// 如果元数据服务失败，回退到 Kubernetes
+		log.Printf("Error getting deployments from metadata service: %v, falling back to Kubernetes", err)
+		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		namespaces, err := a.inspector.ListClusterNamespaces(ctx)
+		cancel()
+		if errors.Is(err, ErrClusterInspectorDisabled) {
+			return
+		}
 		if err != nil {
 			log.Printf("Error getting namespaces: %v", err)
 			return
 		}
 
-		namespaces := parseNamespaces(string(out))
 		for _, ns := range namespaces {
 			a.scaleNamespace(ns, "")
 		}
@@ -108,29 +215,175 @@ func (a *AutoscalerService) checkAndScale() {
 
 	// Scale each deployment
 	// 对每个部署进行扩缩容
+	namespaces := make([]string, 0, len(deployments))
 	for _, deployment := range deployments {
+		namespaces = append(namespaces, deployment.Namespace)
 		a.scaleNamespace(deployment.Namespace, deployment.User)
 	}
+	a.forecaster.RetainNamespaces(namespaces)
 }
 
-// scaleNamespace scales a specific namespace based on its metrics and user policy
-// scaleNamespace 根据指标和用户策略扩缩容特定命名空间
+// scaleNamespace evaluates a specific namespace based on its metrics and policy and records the
+// decision; only clusters in auto mode are actually scaled. Active schedules win over the policy:
+// their floor and ceiling apply, and are applied, even when the policy turns scaling off, disables
+// both directions or the metrics are unavailable.
+// scaleNamespace 根据指标和策略评估特定命名空间并记录决策；只有 auto 模式的集群会被实际扩缩容。
+// 生效中的定时计划优先于策略：即使策略关闭了扩缩容、禁用了两个方向或指标不可用，其下限与上限仍会生效并被执行
 func (a *AutoscalerService) scaleNamespace(namespace string, userID string) {
+	// Get the scaling policy and mode of the cluster
+	// 获取集群的扩缩容策略与模式
+	config := a.GetConfig()
+	policy := a.getScalingPolicy(namespace, userID)
+	mode := policy.Mode
+	if mode == "" {
+		mode = config.Mode
+	}
+	override := a.scheduleOverride(namespace, time.Now())
+	if mode == AutoscalerModeOff && override == nil {
+		a.metrics.ObserveAutoscalerDecision(namespace, "off", "")
+		return
+	}
+
+	decision := &model.AutoscalerDecision{
+		ID:        fmt.Sprintf("decision_%s_%d", namespace, time.Now().UnixNano()),
+		Namespace: namespace,
+		UserID:    userID,
+		Mode:      mode,
+		Timestamp: time.Now(),
+		Policy:    policy,
+	}
+	defer a.recordDecision(decision)
+
 	// Get current replicas
 	// 获取当前副本数
 	currentReplicas, err := a.getCurrentReplicas(namespace)
+	if errors.Is(err, ErrClusterInspectorDisabled) {
+		decision.Outcome, decision.Reason = "disabled", err.Error()
+		return
+	}
 	if err != nil {
 		log.Printf("Error getting current replicas for namespace %s: %v", namespace, err)
+		decision.Outcome, decision.Reason = "error", fmt.Sprintf("failed to get current replicas: %v", err)
+		return
+	}
+	decision.CurrentReplicas = currentReplicas
+	decision.DesiredReplicas = currentReplicas
+
+	recommendation := model.ScalingRecommendation{
+		CurrentReplicas: currentReplicas,
+		DesiredReplicas: currentReplicas,
+	}
+	if mode != AutoscalerModeOff {
+		var ok bool
+		if recommendation, ok = a.metricRecommendation(namespace, userID, currentReplicas, policy, config, override != nil, decision); !ok {
+			return
+		}
+	}
+
+	// Bound the metric decision by the floor and ceiling of the active schedules
+	// 以生效中定时计划的下限与上限约束基于指标的决策
+	if currentReplicas > 0 && override != nil {
+		applyScheduleOverride(&recommendation, override)
+	}
+	decision.Recommendation = recommendation
+	newReplicas := recommendation.DesiredReplicas
+	decision.DesiredReplicas = newReplicas
+	// A reason recorded so far explains why only the schedules applied
+	// 此前记录的原因说明了为何只有定时计划生效
+	note := decision.Reason
+	if note != "" {
+		note += "; "
+	}
+	if newReplicas == currentReplicas {
+		held := fmt.Sprintf("%s asks for the current %d replicas", recommendation.DrivingMetric, currentReplicas)
+		if recommendation.DrivingMetric == "" {
+			held = fmt.Sprintf("the current %d replicas are kept", currentReplicas)
+		}
+		decision.Outcome, decision.Reason = "hold", note+held
+		if len(recommendation.Missing) > 0 {
+			decision.Reason += fmt.Sprintf("; no scale-down while %s is not measured", strings.Join(recommendation.Missing, ", "))
+		}
+		return
+	}
+	outcome := "scale_down"
+	if newReplicas > currentReplicas {
+		outcome = "scale_up"
+	}
+
+	// Check if we're still in cooldown period; scheduled capacity is applied on time
+	// 检查是否仍处于冷却期；定时计划的容量按时生效
+	if recommendation.DrivingMetric != scheduleDrivingMetric && a.isInCooldown(namespace, currentReplicas, newReplicas, config) {
+		log.Printf("Skipping scaling for namespace %s due to cooldown period", namespace)
+		decision.Outcome, decision.Reason = "cooldown", fmt.Sprintf("%s to %d replicas suppressed by the cooldown period", outcome, newReplicas)
+		return
+	}
+
+	// Check tenant quota before scaling, so recommend mode reports what auto mode would do
+	// 扩缩容前检查租户配额，使 recommend 模式反映 auto 模式的行为
+	if err := a.scaler.CheckQuota(namespace, newReplicas); err != nil {
+		if errors.Is(err, ErrQuotaExceeded) {
+			log.Printf("Tenant quota exceeded for namespace %s: %v", namespace, err)
+			decision.Outcome, decision.Reason = "quota_exceeded", err.Error()
+		} else {
+			decision.Outcome, decision.Reason = "error", fmt.Sprintf("failed to check tenant quota: %v", err)
+		}
 		return
 	}
 
+	decision.Outcome = outcome
+	reason := fmt.Sprintf("%s %.2f against target %.2f", recommendation.DrivingMetric, decision.Adjusted[recommendation.DrivingMetric], drivingTarget(recommendation))
+	switch recommendation.DrivingMetric {
+	case forecastDrivingMetric:
+		reason = forecastReason(recommendation)
+	case scheduleDrivingMetric:
+		reason = note + scheduleReason(recommendation.Schedule)
+	}
+	if mode == AutoscalerModeRecommend {
+		log.Printf("Recommending %s for namespace %s from %d to %d replicas", outcome, namespace, currentReplicas, newReplicas)
+		decision.Reason = reason + "; not applied in recommend mode"
+		return
+	}
+
+	// Scale through the same Terraform path as manual scaling; the apply runs in the background
+	// 通过与手动扩缩容相同的 Terraform 路径扩缩容；apply 在后台执行
+	if err := a.scaler.Scale(namespace, newReplicas, ScaleSourceAutoscaler, "autoscaler", reason); err != nil {
+		log.Printf("Error scaling cluster in namespace %s: %v", namespace, err)
+		switch {
+		case errors.Is(err, ErrQuotaExceeded):
+			decision.Outcome, decision.Reason = "quota_exceeded", err.Error()
+		case errors.Is(err, ErrScaleInProgress):
+			decision.Outcome, decision.Reason = "hold", fmt.Sprintf("%s to %d replicas waits for the running scale", outcome, newReplicas)
+		default:
+			decision.Outcome, decision.Reason = "error", fmt.Sprintf("failed to %s to %d replicas: %v", outcome, newReplicas, err)
+		}
+		return
+	}
+	log.Printf("Started scaling cluster in namespace %s from %d to %d replicas", namespace, currentReplicas, newReplicas)
+	decision.Applied = true
+	decision.Reason = reason
+}
+
+// metricRecommendation evaluates the metrics of a cluster against its policy. When it cannot, it
+// records why in the decision and returns false, unless schedules are active: they still apply,
+// so the replicas are left to them.
+// metricRecommendation 按策略评估集群的指标；无法评估时在决策中记录原因并返回 false，
+// 除非有生效中的定时计划：计划仍然生效，副本数交由计划决定
+func (a *AutoscalerService) metricRecommendation(namespace, userID string, currentReplicas int, policy model.ScalingPolicy,
+	config model.AutoscalerConfig, scheduled bool, decision *model.AutoscalerDecision) (model.ScalingRecommendation, bool) {
+	recommendation := model.ScalingRecommendation{
+		CurrentReplicas: currentReplicas,
+		DesiredReplicas: currentReplicas,
+	}
+
 	// Get metrics for namespace
 	// 获取命名空间的指标
 	metrics, err := a.getMetricsForNamespace(namespace)
 	if err != nil {
 		log.Printf("Error getting metrics for namespace %s: %v", namespace, err)
-		return
+		decision.Outcome, decision.Reason = "error", fmt.Sprintf("failed to get metrics: %v", err)
+		return recommendation, scheduled
 	}
+	decision.Inputs = scalingMetricValues(metrics, currentReplicas)
 
 	// Update historical metrics
 	// 更新历史指标
@@ -139,147 +392,153 @@ func (a *AutoscalerService) scaleNamespace(namespace string, userID string) {
 	// Get trend analysis
 	// 获取趋势分析
 	cpuTrend, memoryTrend, diskTrend, qpsTrend := a.getTrendAnalysis(namespace)
+	decision.Trends = map[string]float64{
+		"cpu_usage":    cpuTrend,
+		"memory_usage": memoryTrend,
+		"disk_usage":   diskTrend,
+		"qps":          qpsTrend,
+	}
 
-	// Log trend analysis
-	// 记录趋势分析
-	log.Printf("Namespace %s trends - CPU: %.2f, Memory: %.2f, Disk: %.2f, QPS: %.2f", namespace, cpuTrend, memoryTrend, diskTrend, qpsTrend)
-
-	// Get user-specific scaling policy
-	// 获取用户特定的扩缩容策略
-	policy := a.getUserScalingPolicy(userID)
-
-	// Check if auto-scaling is enabled for this user
-	// 检查是否为此用户启用了自动扩缩容
+	// Check if auto-scaling is enabled for this cluster
+	// 检查是否为此集群启用了自动扩缩容
 	if !policy.EnableAutoScaleUp && !policy.EnableAutoScaleDown {
 		log.Printf("Auto-scaling disabled for user %s in namespace %s", userID, namespace)
-		return
+		decision.Outcome, decision.Reason = "disabled", "scale up and scale down are both disabled by the policy"
+		return recommendation, scheduled
 	}
 
 	// Adjust scaling decision based on trends
 	// 根据趋势调整扩缩容决策
 	adjustedMetrics := a.adjustMetricsBasedOnTrends(metrics, cpuTrend, memoryTrend, diskTrend, qpsTrend)
+	decision.Adjusted = scalingMetricValues(adjustedMetrics, currentReplicas)
 
-	// Determine if we need to scale based on user policy and metrics
-	// 根据用户策略和指标确定是否需要扩缩容
-	newReplicas := a.calculateNewReplicas(currentReplicas, adjustedMetrics, policy)
-	if newReplicas != currentReplicas {
-		// Check if we're still in cooldown period
-		// 检查是否仍处于冷却期
-		if a.isInCooldown(namespace, currentReplicas, newReplicas) {
-			log.Printf("Skipping scaling for namespace %s due to cooldown period", namespace)
-			return
-		}
+	// Determine if we need to scale based on the policy and metrics
+	// 根据策略和指标确定是否需要扩缩容
+	recommendation = a.recommend(currentReplicas, adjustedMetrics, policy, config)
 
-		// Check user-specific limits
-		// 检查用户特定的限制
-		if newReplicas > policy.MaxReplicas {
-			newReplicas = policy.MaxReplicas
-		}
-		if newReplicas < policy.MinReplicas {
-			newReplicas = policy.MinReplicas
+	// Provision ahead for the forecast load over the horizon
+	// 为预测时间范围内的负载提前预留容量
+	if config.Predictive && currentReplicas > 0 {
+		forecast, err := a.forecaster.Forecast(namespace, time.Now(), time.Duration(config.ForecastHorizon)*time.Minute)
+		if err != nil {
+			log.Printf("Error forecasting load for namespace %s: %v", namespace, err)
+		} else if forecast != nil {
+			a.applyForecast(&recommendation, forecast, policy, config)
 		}
+	}
+	return recommendation, true
+}
 
-		// Check tenant quota before scaling (only for scale up)
-		// 扩容前检查租户配额（仅针对扩容）
-		if newReplicas > currentReplicas && userID != "" {
-			hasQuota, quota, err := a.metadataService.CheckTenantQuota(userID)
-			if err != nil {
-				log.Printf("Warning: Failed to check tenant quota for user %s: %v", userID, err)
-			} else if !hasQuota {
-				log.Printf("Tenant quota exceeded for user %s. Max indices: %d, Current indices: %d",
-					userID, quota.MaxIndices, quota.CurrentIndices)
-				return
-			}
-		}
+// recordDecision stores an evaluation and counts its outcome
+// recordDecision 保存一次评估并统计其结果
+func (a *AutoscalerService) recordDecision(decision *model.AutoscalerDecision) {
+	a.metrics.ObserveAutoscalerDecision(decision.Namespace, decision.Outcome, decision.Recommendation.DrivingMetric)
+	if err := a.metadataService.SaveAutoscalerDecision(decision); err != nil {
+		log.Printf("Error saving autoscaler decision for namespace %s: %v", decision.Namespace, err)
+	}
+}
 
-		err := a.scaleCluster(namespace, newReplicas)
-		if err != nil {
-			log.Printf("Error scaling cluster in namespace %s: %v", namespace, err)
-		} else {
-			log.Printf("Scaled cluster in namespace %s from %d to %d replicas", namespace, currentReplicas, newReplicas)
-
-			// Update deployment status in metadata service
-			// 更新元数据服务中的部署状态
-			deployment, err := a.metadataService.GetDeploymentStatus(namespace)
-			if err == nil {
-				deployment.Replicas = newReplicas
-				deployment.Status = "scaling"
-				deployment.UpdatedAt = time.Now()
-				a.metadataService.SaveDeploymentStatus(deployment)
-			}
+// ListDecisions lists the recorded decisions of a cluster within [from, to], newest first
+// ListDecisions 列出集群在 [from, to] 内记录的决策，最新的在前
+func (a *AutoscalerService) ListDecisions(namespace, outcome string, from, to time.Time, limit int) ([]*model.AutoscalerDecision, error) {
+	if limit <= 0 || limit > maxDecisionListLimit {
+		limit = maxDecisionListLimit
+	}
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from must be before to", ErrInvalidRequest)
+	}
+	return a.metadataService.ListAutoscalerDecisions(namespace, outcome, from, to, limit)
+}
 
-			// Update last scaling time
-			// 更新最后一次扩缩容时间
-			a.mu.Lock()
-			a.lastScalingTime[namespace] = time.Now()
-			a.mu.Unlock()
-
-			// Update tenant quota usage if scaled up
-			// 如果扩容，更新租户配额使用情况
-			if newReplicas > currentReplicas && userID != "" {
-				// Note: In a real implementation, you might want to track resource usage more precisely
-				// For now, we're just checking quota, not updating usage for replica scaling
-				// 注意：在实际实现中，您可能希望更精确地跟踪资源使用情况
-				// 目前，我们只是检查配额，而不是更新副本扩容的使用情况
-				log.Printf("Updated tenant quota check for user %s after scaling", userID)
-			}
+// scalingMetricValues reads every scaling metric that was measured
+// scalingMetricValues 读取全部已测得的扩缩容指标
+func scalingMetricValues(metrics *model.Metrics, replicas int) map[string]float64 {
+	values := make(map[string]float64, len(scalingMetrics))
+	if replicas <= 0 {
+		replicas = 1
+	}
+	for name, read := range scalingMetrics {
+		if metrics.IsMissing(name) {
+			continue
 		}
+		values[name] = read(metrics, replicas)
 	}
+	return values
 }
 
-// getUserScalingPolicy gets the scaling policy for a user
-// getUserScalingPolicy 获取用户的扩缩容策略
-func (a *AutoscalerService) getUserScalingPolicy(userID string) model.ScalingPolicy {
-	if userID == "" {
-		// Return default policy if no user ID
-		// 如果没有用户 ID，返回默认策略
-		return model.ScalingPolicy{
-			UserID:              "",
-			EnableAutoScaleUp:   true,
-			EnableAutoScaleDown: true,
-			ScaleUpThreshold:    a.config.HighCPUThreshold,
-			ScaleDownThreshold:  a.config.LowCPUThreshold,
-			MaxReplicas:         a.config.MaxReplicas,
-			MinReplicas:         a.config.MinReplicas,
+// drivingTarget returns the target of the metric that drove a recommendation
+// drivingTarget 返回决定建议的指标的目标值
+func drivingTarget(recommendation model.ScalingRecommendation) float64 {
+	for _, metric := range recommendation.Metrics {
+		if metric.Metric == recommendation.DrivingMetric {
+			return metric.Target
 		}
 	}
+	return 0
+}
 
+// getScalingPolicy returns the policy of a cluster, falling back to the policy of its user and then to
+// a policy that takes the global configuration. When several policies match, a cluster policy of the
+// cluster's user wins, then the oldest one, so the choice does not depend on map order.
+// getScalingPolicy 返回集群的策略，依次回退到其用户的策略以及取全局配置的默认策略；
+// 多条策略匹配时优先选择属于该集群用户的集群策略，其次选择最早创建的策略，使结果不依赖 map 的遍历顺序
+func (a *AutoscalerService) getScalingPolicy(namespace, userID string) model.ScalingPolicy {
 	a.mu.RLock()
 	defer a.mu.RUnlock()
-	// Check if user has a specific policy
-	// 检查用户是否有特定策略
-	if policy, exists := a.config.ScalingPolicies[userID]; exists {
-		return policy
+
+	// rank orders matching policies, lower is better; -1 means the policy does not apply
+	// rank 为匹配的策略排序，越小越优先；-1 表示策略不适用
+	rank := func(policy *model.ScalingPolicy) int {
+		switch {
+		case policy.Namespace != "" && policy.Namespace == namespace && policy.UserID == userID:
+			return 0
+		case policy.Namespace != "" && policy.Namespace == namespace:
+			return 1
+		case policy.Namespace == "" && userID != "" && policy.UserID == userID:
+			return 2
+		}
+		return -1
 	}
 
-	// Return default policy
-	// 返回默认策略
+	var best *model.ScalingPolicy
+	bestRank := -1
+	for id := range a.policies {
+		policy := a.policies[id]
+		r := rank(&policy)
+		if r < 0 {
+			continue
+		}
+		if best == nil || r < bestRank ||
+			(r == bestRank && (policy.CreatedAt.Before(best.CreatedAt) ||
+				(policy.CreatedAt.Equal(best.CreatedAt) && policy.ID < best.ID))) {
+			best, bestRank = &policy, r
+		}
+	}
+	if best != nil {
+		return *best
+	}
+
+	// Return default policy; zero replica limits take the global configuration
+	// 返回默认策略；为零的副本上下限取全局配置
 	return model.ScalingPolicy{
 		UserID:              userID,
 		EnableAutoScaleUp:   true,
 		EnableAutoScaleDown: true,
-		ScaleUpThreshold:    a.config.HighCPUThreshold,
-		ScaleDownThreshold:  a.config.LowCPUThreshold,
-		MaxReplicas:         a.config.MaxReplicas,
-		MinReplicas:         a.config.MinReplicas,
 	}
 }
 
 // getCurrentReplicas gets the current number of replicas for a namespace
 // getCurrentReplicas 获取命名空间的当前副本数
 func (a *AutoscalerService) getCurrentReplicas(namespace string) (int, error) {
-	cmd := exec.Command("kubectl", "-n", namespace, "get", "sts/elasticsearch", "-o", "jsonpath={.spec.replicas}")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return 0, err
-	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
 
-	replicas, err := strconv.Atoi(string(out))
+	sts, err := a.inspector.GetStatefulSet(ctx, namespace, esStatefulSetName)
 	if err != nil {
-		return 0, fmt.Errorf("error parsing replicas: %v", err)
+		return 0, err
 	}
 
-	return replicas, nil
+	return sts.Replicas, nil
 }
 
 // getMetricsForNamespace gets the latest metrics for a namespace
@@ -295,7 +554,7 @@ func (a *AutoscalerService) getMetricsForNamespace(namespace string) (*model.Met
 
 		// Try to read metrics from file
 		// 尝试从文件读取指标
-		file, err := exec.Command("cat", filename).CombinedOutput()
+		file, err := os.ReadFile(filename)
 		if err != nil {
 			return nil, fmt.Errorf("error reading metrics file: %v", err)
 		}
@@ -305,77 +564,132 @@ func (a *AutoscalerService) getMetricsForNamespace(namespace string) (*model.Met
 			return nil, fmt.Errorf("error unmarshaling metrics: %v", err)
 		}
 
-		return &fileMetrics, nil
+		metrics = &fileMetrics
 	}
 
+	// Acting on an old sample would scale for load that may be long gone
+	// 基于过旧的样本扩缩容可能针对的是早已消失的负载
+	if age := time.Since(metrics.Timestamp); age > autoscalerMetricsStaleAfter {
+		return nil, fmt.Errorf("latest metrics are stale (collected %s ago)", age.Truncate(time.Second))
+	}
 	return metrics, nil
 }
 
-// calculateNewReplicas calculates the new number of replicas based on metrics and user policy
-// calculateNewReplicas 根据指标和用户策略计算新的副本数
-func (a *AutoscalerService) calculateNewReplicas(currentReplicas int, metrics *model.Metrics, policy model.ScalingPolicy) int {
-	// Check if we need to scale up
-	// 检查是否需要扩容
-	if policy.EnableAutoScaleUp && a.shouldScaleUp(metrics, policy) {
-		newReplicas := int(float64(currentReplicas) * a.config.ScaleUpFactor)
-		if newReplicas > a.config.MaxReplicas {
-			newReplicas = a.config.MaxReplicas
+// scalingMetrics are the metrics a policy can target; utilization is read from the busiest pod
+// and qps is divided by the current replicas, so each value scales inversely with replicas
+// scalingMetrics 策略可设置目标的指标；使用率取最繁忙的 Pod，qps 除以当前副本数，因此各值都与副本数成反比
+var scalingMetrics = map[string]func(m *model.Metrics, replicas int) float64{
+	"cpu_usage":    func(m *model.Metrics, _ int) float64 { return peakUsage(m.CPUUsage, m.CPUUsageMax) },
+	"memory_usage": func(m *model.Metrics, _ int) float64 { return peakUsage(m.MemoryUsage, m.MemoryUsageMax) },
+	"disk_usage":   func(m *model.Metrics, _ int) float64 { return peakUsage(m.DiskUsage, m.DiskUsageMax) },
+	"heap_usage":   func(m *model.Metrics, _ int) float64 { return peakUsage(m.HeapUsage, m.HeapUsageMax) },
+	"qps":          func(m *model.Metrics, replicas int) float64 { return m.QPS / float64(replicas) },
+}
+
+// recommend computes the desired replicas the way the Kubernetes HPA does: every metric asks for
+// ceil(current * value / target) replicas unless it is within the tolerance of its target, and the
+// largest request wins. The result honours the enabled directions, the step factors and the policy limits.
+// recommend 按 Kubernetes HPA 的方式计算期望副本数：每个指标在超出目标容差时要求 ceil(当前副本数 * 值 / 目标) 个副本，
+// 取最大的要求；结果遵循已启用的方向、步长系数与策略上下限
+func (a *AutoscalerService) recommend(currentReplicas int, metrics *model.Metrics, policy model.ScalingPolicy, config model.AutoscalerConfig) model.ScalingRecommendation {
+	recommendation := model.ScalingRecommendation{
+		CurrentReplicas: currentReplicas,
+		DesiredReplicas: currentReplicas,
+	}
+	if currentReplicas <= 0 {
+		// A stopped cluster is not scaled automatically
+		// 已停止的集群不会被自动扩缩容
+		return recommendation
+	}
+
+	targets := policy.Targets
+	if len(targets) == 0 {
+		targets = config.Targets
+	}
+	tolerance := policy.Tolerance
+	if tolerance == 0 {
+		tolerance = config.Tolerance
+	}
+
+	desired := 0
+	for _, target := range targets {
+		read, ok := scalingMetrics[target.Metric]
+		if !ok || target.Target <= 0 {
+			continue
 		}
-		if newReplicas <= currentReplicas {
-			newReplicas = currentReplicas + 1
+		if metrics.IsMissing(target.Metric) {
+			recommendation.Missing = append(recommendation.Missing, target.Metric)
+			continue
 		}
-		return newReplicas
+		value := read(metrics, currentReplicas)
+		ratio := value / target.Target
+		metricDesired := currentReplicas
+		if math.Abs(ratio-1) > tolerance {
+			metricDesired = int(math.Ceil(float64(currentReplicas) * ratio))
+		}
+		recommendation.Metrics = append(recommendation.Metrics, model.MetricRecommendation{
+			Metric:          target.Metric,
+			Value:           value,
+			Target:          target.Target,
+			Ratio:           ratio,
+			DesiredReplicas: metricDesired,
+		})
+		if recommendation.DrivingMetric == "" || metricDesired > desired {
+			desired = metricDesired
+			recommendation.DrivingMetric = target.Metric
+		}
+	}
+	if recommendation.DrivingMetric == "" {
+		return recommendation
 	}
 
-	// Check if we need to scale down
-	// 检查是否需要缩容
-	if policy.EnableAutoScaleDown && a.shouldScaleDown(metrics, policy) {
-		newReplicas := int(float64(currentReplicas) * a.config.ScaleDownFactor)
-		if newReplicas < a.config.MinReplicas {
-			newReplicas = a.config.MinReplicas
+	// Check the enabled directions and bound the step
+	// 检查已启用的方向并限制步长
+	if desired > currentReplicas {
+		if !policy.EnableAutoScaleUp {
+			desired = currentReplicas
+		} else if limit := int(math.Ceil(float64(currentReplicas) * config.ScaleUpFactor)); desired > limit && limit > currentReplicas {
+			desired = limit
 		}
-		if newReplicas >= currentReplicas {
-			newReplicas = currentReplicas - 1
+	} else if desired < currentReplicas {
+		// An unmeasured metric might be the one keeping the cluster busy
+		// 未测量的指标可能正是使集群繁忙的那个
+		if !policy.EnableAutoScaleDown || len(recommendation.Missing) > 0 {
+			desired = currentReplicas
+		} else if limit := int(math.Floor(float64(currentReplicas) * config.ScaleDownFactor)); desired < limit && limit < currentReplicas {
+			desired = limit
 		}
-		return newReplicas
 	}
 
-	// No scaling needed
-	// 不需要扩缩容
-	return currentReplicas
-}
-
-// shouldScaleUp determines if we should scale up based on metrics and user policy
-// shouldScaleUp 确定是否应根据指标和用户策略进行扩容
-func (a *AutoscalerService) shouldScaleUp(metrics *model.Metrics, policy model.ScalingPolicy) bool {
-	// Scale up if CPU, memory, disk, or QPS is above high threshold
-	// 如果 CPU、内存、磁盘或 QPS 高于高阈值，则扩容
-	return metrics.CPUUsage > policy.ScaleUpThreshold ||
-		metrics.MemoryUsage > policy.ScaleUpThreshold ||
-		metrics.DiskUsage > policy.ScaleUpThreshold ||
-		metrics.QPS > policy.ScaleUpThreshold
-}
-
-// shouldScaleDown determines if we should scale down based on metrics and user policy
-// shouldScaleDown 确定是否应根据指标和用户策略进行缩容
-func (a *AutoscalerService) shouldScaleDown(metrics *model.Metrics, policy model.ScalingPolicy) bool {
-	// Scale down if CPU, memory, disk, and QPS are below low threshold
-	// 如果 CPU、内存、磁盘和 QPS 低于低阈值，则缩容
-	return metrics.CPUUsage < policy.ScaleDownThreshold &&
-		metrics.MemoryUsage < policy.ScaleDownThreshold &&
-		metrics.DiskUsage < policy.ScaleDownThreshold &&
-		metrics.QPS < policy.ScaleDownThreshold
+	// Check the policy limits; unset limits take the global configuration
+	// 检查策略上下限；未设置的上下限取全局配置
+	minReplicas, maxReplicas := policy.MinReplicas, policy.MaxReplicas
+	if minReplicas == 0 {
+		minReplicas = config.MinReplicas
+	}
+	if maxReplicas == 0 {
+		maxReplicas = config.MaxReplicas
+	}
+	if desired > maxReplicas {
+		desired = maxReplicas
+	}
+	if desired < minReplicas {
+		desired = minReplicas
+	}
+	recommendation.DesiredReplicas = desired
+	return recommendation
 }
 
 // isInCooldown checks if the namespace is still in cooldown period after scaling
 // isInCooldown 检查命名空间在扩缩容后是否仍处于冷却期
-func (a *AutoscalerService) isInCooldown(namespace string, currentReplicas, newReplicas int) bool {
-	a.mu.RLock()
-	lastTime, exists := a.lastScalingTime[namespace]
-	a.mu.RUnlock()
-	if !exists {
+func (a *AutoscalerService) isInCooldown(namespace string, currentReplicas, newReplicas int, config model.AutoscalerConfig) bool {
+	// The last scale is persisted with the deployment and includes manual scaling
+	// 最近一次扩缩容时间随部署状态持久化，并包含手动扩缩容
+	deployment, err := a.metadataService.GetDeploymentStatus(namespace)
+	if err != nil || deployment.LastScaledAt == nil {
 		return false // No previous scaling, not in cooldown
 	}
+	lastTime := *deployment.LastScaledAt
 
 	// Determine cooldown period based on scaling direction
 	// 根据扩缩容方向确定冷却期
@@ -383,11 +697,11 @@ func (a *AutoscalerService) isInCooldown(namespace string, currentReplicas, newR
 	if newReplicas > currentReplicas {
 		// Scale up
 		// 扩容
-		cooldownSeconds = a.config.ScaleUpCooldown
+		cooldownSeconds = config.ScaleUpCooldown
 	} else {
 		// Scale down
 		// 缩容
-		cooldownSeconds = a.config.ScaleDownCooldown
+		cooldownSeconds = config.ScaleDownCooldown
 	}
 
 	// Check if cooldown period has passed
@@ -428,10 +742,16 @@ func (a *AutoscalerService) adjustMetricsBasedOnTrends(metrics *model.Metrics, c
 	// Create a copy of the metrics to avoid modifying the original
 	// 创建指标的副本以避免修改原始指标
 	adjusted := &model.Metrics{
-		CPUUsage:    metrics.CPUUsage,
-		MemoryUsage: metrics.MemoryUsage,
-		DiskUsage:   metrics.DiskUsage,
-		QPS:         metrics.QPS,
+		CPUUsage:       metrics.CPUUsage,
+		MemoryUsage:    metrics.MemoryUsage,
+		DiskUsage:      metrics.DiskUsage,
+		CPUUsageMax:    metrics.CPUUsageMax,
+		MemoryUsageMax: metrics.MemoryUsageMax,
+		DiskUsageMax:   metrics.DiskUsageMax,
+		HeapUsage:      metrics.HeapUsage,
+		HeapUsageMax:   metrics.HeapUsageMax,
+		QPS:            metrics.QPS,
+		Missing:        metrics.Missing,
 	}
 
 	// Apply trend-based adjustments
@@ -445,24 +765,30 @@ func (a *AutoscalerService) adjustMetricsBasedOnTrends(metrics *model.Metrics, c
 	// CPU 调整
 	if cpuTrend > 0.5 { // Increasing trend
 		adjusted.CPUUsage *= 1.1 // Increase weight for scaling decision
+		adjusted.CPUUsageMax *= 1.1
 	} else if cpuTrend < -0.5 { // Decreasing trend
 		adjusted.CPUUsage *= 0.9 // Decrease weight for scaling decision
+		adjusted.CPUUsageMax *= 0.9
 	}
 
 	// Memory adjustment
 	// 内存调整
 	if memoryTrend > 0.5 { // Increasing trend
 		adjusted.MemoryUsage *= 1.1
+		adjusted.MemoryUsageMax *= 1.1
 	} else if memoryTrend < -0.5 { // Decreasing trend
 		adjusted.MemoryUsage *= 0.9
+		adjusted.MemoryUsageMax *= 0.9
 	}
 
 	// Disk adjustment
 	// 磁盘调整
 	if diskTrend > 0.5 { // Increasing trend
 		adjusted.DiskUsage *= 1.1
+		adjusted.DiskUsageMax *= 1.1
 	} else if diskTrend < -0.5 { // Decreasing trend
 		adjusted.DiskUsage *= 0.9
+		adjusted.DiskUsageMax *= 0.9
 	}
 
 	// QPS adjustment
@@ -489,71 +815,264 @@ func (a *AutoscalerService) getTrendAnalysis(namespace string) (cpuTrend, memory
 		return 0.0, 0.0, 0.0, 0.0
 	}
 
-	// Calculate average trends
-	// 计算平均趋势
-	count := len(hm.Metrics) - 1
-	if count <= 0 {
-		return 0.0, 0.0, 0.0, 0.0
+	// Calculate average trends over consecutive samples that both measured the metric
+	// 基于均测得该指标的相邻样本计算平均趋势
+	trend := func(metric string, value func(m *model.Metrics) float64) float64 {
+		var sum float64
+		count := 0
+		for i := 1; i < len(hm.Metrics); i++ {
+			prev, cur := &hm.Metrics[i-1], &hm.Metrics[i]
+			if prev.IsMissing(metric) || cur.IsMissing(metric) {
+				continue
+			}
+			sum += value(cur) - value(prev)
+			count++
+		}
+		if count == 0 {
+			return 0
+		}
+		return sum / float64(count)
 	}
 
-	for i := 1; i < len(hm.Metrics); i++ {
-		cpuTrend += (hm.Metrics[i].CPUUsage - hm.Metrics[i-1].CPUUsage)
-		memoryTrend += (hm.Metrics[i].MemoryUsage - hm.Metrics[i-1].MemoryUsage)
-		diskTrend += (hm.Metrics[i].DiskUsage - hm.Metrics[i-1].DiskUsage)
-		qpsTrend += (hm.Metrics[i].QPS - hm.Metrics[i-1].QPS)
+	cpuTrend = trend("cpu_usage", func(m *model.Metrics) float64 { return m.CPUUsage })
+	memoryTrend = trend("memory_usage", func(m *model.Metrics) float64 { return m.MemoryUsage })
+	diskTrend = trend("disk_usage", func(m *model.Metrics) float64 { return m.DiskUsage })
+	qpsTrend = trend("qps", func(m *model.Metrics) float64 { return m.QPS })
+
+	return cpuTrend, memoryTrend, diskTrend, qpsTrend
+}
+
+// GetForecast returns the load forecast of a cluster over the configured horizon with the accuracy of
+// its past forecasts
+// GetForecast 返回集群在配置的时间范围内的负载预测及其历史预测的准确度
+func (a *AutoscalerService) GetForecast(namespace string) (*model.LoadForecastReport, error) {
+	if _, err := a.metadataService.GetDeploymentStatus(namespace); err != nil {
+		return nil, err
 	}
+	config := a.GetConfig()
+	return a.forecaster.Report(namespace, time.Now(), time.Duration(config.ForecastHorizon)*time.Minute)
+}
 
-	cpuTrend /= float64(count)
-	memoryTrend /= float64(count)
-	diskTrend /= float64(count)
-	qpsTrend /= float64(count)
+// GetConfig returns the global autoscaler configuration
+// GetConfig 返回全局自动扩缩容配置
+func (a *AutoscalerService) GetConfig() model.AutoscalerConfig {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.config
+}
 
-	return cpuTrend, memoryTrend, diskTrend, qpsTrend
+// UpdateConfig validates, stores and applies a new global autoscaler configuration
+// UpdateConfig 校验、保存并应用新的全局自动扩缩容配置
+func (a *AutoscalerService) UpdateConfig(config model.AutoscalerConfig) (*model.AutoscalerConfig, error) {
+	if err := validateAutoscalerConfig(config); err != nil {
+		return nil, err
+	}
+	config.UpdatedAt = time.Now()
+	if err := a.metadataService.SaveAutoscalerConfig(&config); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.config = config
+	a.mu.Unlock()
+	return &config, nil
 }
 
-// scaleCluster scales the Elasticsearch cluster in a namespace
-// scaleCluster 扩缩容命名空间中的 Elasticsearch 集群
-func (a *AutoscalerService) scaleCluster(namespace string, replicas int) error {
-	cmd := exec.Command("kubectl", "-n", namespace, "scale", "sts/elasticsearch", "--replicas", strconv.Itoa(replicas))
-	_, err := cmd.CombinedOutput()
-	return err
+// CreatePolicy creates the scaling policy of a cluster or of a user
+// CreatePolicy 创建集群或用户的扩缩容策略
+func (a *AutoscalerService) CreatePolicy(req model.ScalingPolicyRequest) (*model.ScalingPolicy, error) {
+	now := time.Now()
+	policy := &model.ScalingPolicy{
+		ID:        fmt.Sprintf("policy_%d", now.UnixNano()),
+		CreatedAt: now,
+	}
+	if err := a.applyPolicy(policy, req); err != nil {
+		return nil, err
+	}
+	policy.UpdatedAt = now
+	if err := a.metadataService.SaveScalingPolicy(policy); err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	a.policies[policy.ID] = *policy
+	a.mu.Unlock()
+	return policy, nil
 }
 
-// SetUserScalingPolicy sets a user-specific scaling policy
-// SetUserScalingPolicy 设置用户特定的扩缩容策略
-func (a *AutoscalerService) SetUserScalingPolicy(policy model.ScalingPolicy) {
+// UpdatePolicy replaces the definition of a scaling policy
+// UpdatePolicy 替换扩缩容策略的定义
+func (a *AutoscalerService) UpdatePolicy(id string, req model.ScalingPolicyRequest) (*model.ScalingPolicy, error) {
+	policy, err := a.metadataService.GetScalingPolicy(id)
+	if err != nil {
+		return nil, err
+	}
+	if err := a.applyPolicy(policy, req); err != nil {
+		return nil, err
+	}
+	policy.UpdatedAt = time.Now()
+	if err := a.metadataService.SaveScalingPolicy(policy); err != nil {
+		return nil, err
+	}
+
 	a.mu.Lock()
-	defer a.mu.Unlock()
-	a.config.ScalingPolicies[policy.UserID] = policy
+	a.policies[policy.ID] = *policy
+	a.mu.Unlock()
+	return policy, nil
 }
 
-// GetUserScalingPolicy gets a user-specific scaling policy
-// GetUserScalingPolicy 获取用户特定的扩缩容策略
-func (a *AutoscalerService) GetUserScalingPolicy(userID string) (model.ScalingPolicy, bool) {
-	a.mu.RLock()
-	defer a.mu.RUnlock()
-	policy, exists := a.config.ScalingPolicies[userID]
-	return policy, exists
+// GetPolicy gets a scaling policy
+// GetPolicy 获取扩缩容策略
+func (a *AutoscalerService) GetPolicy(id string) (*model.ScalingPolicy, error) {
+	return a.metadataService.GetScalingPolicy(id)
+}
+
+// ListPolicies lists scaling policies, optionally filtered by user and namespace
+// ListPolicies 列出扩缩容策略，可按用户与命名空间过滤
+func (a *AutoscalerService) ListPolicies(userID, namespace string) ([]*model.ScalingPolicy, error) {
+	return a.metadataService.ListScalingPolicies(userID, namespace)
 }
 
-// RemoveUserScalingPolicy removes a user-specific scaling policy
-// RemoveUserScalingPolicy 删除用户特定的扩缩容策略
-func (a *AutoscalerService) RemoveUserScalingPolicy(userID string) {
+// DeletePolicy deletes a scaling policy; its clusters fall back to the next policy in scope
+// DeletePolicy 删除扩缩容策略；其集群回退到下一个适用的策略
+func (a *AutoscalerService) DeletePolicy(id string) error {
+	if err := a.metadataService.DeleteScalingPolicy(id); err != nil {
+		return err
+	}
+
 	a.mu.Lock()
-	defer a.mu.Unlock()
-	delete(a.config.ScalingPolicies, userID)
+	delete(a.policies, id)
+	a.mu.Unlock()
+	return nil
 }
 
-// parseNamespaces parses namespace names from kubectl output
-// parseNamespaces 从 kubectl 输出中解析命名空间名称
-func parseNamespaces(output string) []string {
-	// Split by whitespace and filter out empty strings
-	// 按空白字符分割并过滤掉空字符串
-	names := []string{}
-	for _, name := range strings.Fields(output) {
-		if name != "" {
-			names = append(names, name)
+// applyPolicy validates a policy request and copies it into policy; a cluster policy takes the
+// user of its cluster and zero values are kept, so they follow the global configuration when it changes
+// applyPolicy 校验策略请求并写入 policy；集群策略取其集群的用户，零值原样保存，从而随全局配置的变更而变化
+func (a *AutoscalerService) applyPolicy(policy *model.ScalingPolicy, req model.ScalingPolicyRequest) error {
+	if req.UserID == "" && req.Namespace == "" {
+		return fmt.Errorf("%w: user_id or namespace is required", ErrInvalidRequest)
+	}
+	if req.Namespace != "" {
+		deployment, err := a.metadataService.GetDeploymentStatus(req.Namespace)
+		if err != nil {
+			return fmt.Errorf("%w: cluster %s not found", ErrInvalidRequest, req.Namespace)
+		}
+		if req.UserID == "" {
+			req.UserID = deployment.User
+		} else if req.UserID != deployment.User {
+			return fmt.Errorf("%w: cluster %s does not belong to user %s", ErrInvalidRequest, req.Namespace, req.UserID)
+		}
+	}
+
+	if err := validateMetricTargets(req.Targets); err != nil {
+		return err
+	}
+	if req.Tolerance < 0 || req.Tolerance >= 1 {
+		return fmt.Errorf("%w: tolerance must be between 0 and 1", ErrInvalidRequest)
+	}
+	if req.Mode != "" && !validAutoscalerMode(req.Mode) {
+		return fmt.Errorf("%w: mode must be off, recommend or auto", ErrInvalidRequest)
+	}
+	if req.MinReplicas < 0 || req.MaxReplicas < 0 {
+		return fmt.Errorf("%w: replica limits must not be negative", ErrInvalidRequest)
+	}
+	if req.MinReplicas > 0 && req.MaxReplicas > 0 && req.MaxReplicas < req.MinReplicas {
+		return fmt.Errorf("%w: replicas must satisfy min_replicas <= max_replicas", ErrInvalidRequest)
+	}
+
+	// Only one policy may exist per scope; the unique index settles concurrent creates
+	// 每个作用域只允许一条策略；并发创建由唯一索引裁决
+	// 每个作用域只允许一条策略
+	a.mu.RLock()
+	for _, existing := range a.policies {
+		if existing.ID != policy.ID && existing.UserID == req.UserID && existing.Namespace == req.Namespace {
+			a.mu.RUnlock()
+			return fmt.Errorf("%w: policy %s already covers this scope", ErrInvalidRequest, existing.ID)
 		}
 	}
-	return names
+	a.mu.RUnlock()
+
+	policy.UserID = req.UserID
+	policy.Namespace = req.Namespace
+	policy.Mode = req.Mode
+	policy.EnableAutoScaleUp = req.EnableAutoScaleUp == nil || *req.EnableAutoScaleUp
+	policy.EnableAutoScaleDown = req.EnableAutoScaleDown == nil || *req.EnableAutoScaleDown
+	policy.Targets = req.Targets
+	policy.Tolerance = req.Tolerance
+	policy.MinReplicas = req.MinReplicas
+	policy.MaxReplicas = req.MaxReplicas
+	return nil
+}
+
+// validateAutoscalerConfig checks that there are targets, the tolerance and factors move replicas
+// in the right direction and the replica limits and cooldowns are usable
+// validateAutoscalerConfig 检查是否设置了目标、容差与扩缩容系数方向以及副本上下限与冷却期是否有效
+func validateAutoscalerConfig(config model.AutoscalerConfig) error {
+	if !validAutoscalerMode(config.Mode) {
+		return fmt.Errorf("%w: mode must be off, recommend or auto", ErrInvalidRequest)
+	}
+	if len(config.Targets) == 0 {
+		return fmt.Errorf("%w: at least one target is required", ErrInvalidRequest)
+	}
+	if err := validateMetricTargets(config.Targets); err != nil {
+		return err
+	}
+	if config.Tolerance < 0 || config.Tolerance >= 1 {
+		return fmt.Errorf("%w: tolerance must be between 0 and 1", ErrInvalidRequest)
+	}
+	if config.ScaleUpFactor <= 1 {
+		return fmt.Errorf("%w: scale_up_factor must be greater than 1", ErrInvalidRequest)
+	}
+	if config.ScaleDownFactor <= 0 || config.ScaleDownFactor >= 1 {
+		return fmt.Errorf("%w: scale_down_factor must be between 0 and 1", ErrInvalidRequest)
+	}
+	if config.MinReplicas < 1 || config.MaxReplicas < config.MinReplicas {
+		return fmt.Errorf("%w: replicas must satisfy 1 <= min_replicas <= max_replicas", ErrInvalidRequest)
+	}
+	if config.ScaleUpCooldown < 0 || config.ScaleDownCooldown < 0 {
+		return fmt.Errorf("%w: cooldowns must not be negative", ErrInvalidRequest)
+	}
+	if config.ForecastHorizon < 1 || config.ForecastHorizon > maxForecastHorizon {
+		return fmt.Errorf("%w: forecast_horizon must be between 1 and %d minutes", ErrInvalidRequest, maxForecastHorizon)
+	}
+	return nil
+}
+
+// validAutoscalerMode reports whether mode is off, recommend or auto
+// validAutoscalerMode 判断模式是否为 off、recommend 或 auto
+func validAutoscalerMode(mode string) bool {
+	return mode == AutoscalerModeOff || mode == AutoscalerModeRecommend || mode == AutoscalerModeAuto
+}
+
+// validateMetricTargets checks that every target names a scaling metric once with a usable value
+// validateMetricTargets 检查每个目标是否只指定一次扩缩容指标且取值有效
+func validateMetricTargets(targets []model.MetricTarget) error {
+	seen := make(map[string]bool, len(targets))
+	for _, target := range targets {
+		if _, ok := scalingMetrics[target.Metric]; !ok {
+			return fmt.Errorf("%w: unknown scaling metric %s", ErrInvalidRequest, target.Metric)
+		}
+		if seen[target.Metric] {
+			return fmt.Errorf("%w: metric %s has more than one target", ErrInvalidRequest, target.Metric)
+		}
+		seen[target.Metric] = true
+		if target.Target <= 0 {
+			return fmt.Errorf("%w: target of %s must be positive", ErrInvalidRequest, target.Metric)
+		}
+		if target.Metric != "qps" && target.Target > 100 {
+			return fmt.Errorf("%w: target of %s is a percentage and must not exceed 100", ErrInvalidRequest, target.Metric)
+		}
+	}
+	return nil
+}
+
+// peakUsage returns the busiest pod usage, falling back to the average for
+// metrics recorded before per pod collection
+// peakUsage 返回最繁忙 Pod 的使用率，对按 Pod 采集之前记录的指标回退为平均值
+func peakUsage(avg, max float64) float64 {
+	if max > 0 {
+		return max
+	}
+	return avg
 }
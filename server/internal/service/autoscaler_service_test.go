@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestGetScalingPolicyIsDeterministic(t *testing.T) {
+	a := NewAutoscalerService(nil, NewFakeClusterInspector(), nil, nil, nil)
+	created := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	a.policies = map[string]model.ScalingPolicy{
+		"policy_user":   {ID: "policy_user", UserID: "alice", CreatedAt: created},
+		"policy_other":  {ID: "policy_other", UserID: "bob", Namespace: "tenant-a", CreatedAt: created},
+		"policy_owner":  {ID: "policy_owner", UserID: "alice", Namespace: "tenant-a", CreatedAt: created.Add(time.Hour)},
+		"policy_user_2": {ID: "policy_user_2", UserID: "carol", CreatedAt: created.Add(time.Hour)},
+		"policy_user_1": {ID: "policy_user_1", UserID: "carol", CreatedAt: created},
+	}
+
+	for i := 0; i < 20; i++ {
+		if got := a.getScalingPolicy("tenant-a", "alice").ID; got != "policy_owner" {
+			t.Fatalf("policy of tenant-a = %s, want the cluster policy of its user", got)
+		}
+		if got := a.getScalingPolicy("tenant-b", "carol").ID; got != "policy_user_1" {
+			t.Fatalf("policy of tenant-b = %s, want the oldest user policy", got)
+		}
+	}
+	if got := a.getScalingPolicy("tenant-c", "dave"); got.ID != "" || got.MinReplicas != 0 || got.MaxReplicas != 0 {
+		t.Fatalf("default policy = %+v, want unset replica limits", got)
+	}
+}
+
+func TestRecommendResolvesUnsetReplicaLimits(t *testing.T) {
+	a := NewAutoscalerService(nil, NewFakeClusterInspector(), nil, nil, nil)
+	config := DefaultAutoscalerConfig()
+	config.Targets = []model.MetricTarget{{Metric: "cpu_usage", Target: 50}}
+	config.MaxReplicas = 3
+	policy := model.ScalingPolicy{EnableAutoScaleUp: true, EnableAutoScaleDown: true}
+
+	// The global maximum applies to a policy without its own limit, including after it changes
+	recommendation := a.recommend(2, &model.Metrics{CPUUsage: 100}, policy, config)
+	if recommendation.DesiredReplicas != 3 {
+		t.Fatalf("DesiredReplicas = %d, want the global maximum 3", recommendation.DesiredReplicas)
+	}
+	config.MaxReplicas = 4
+	if recommendation := a.recommend(2, &model.Metrics{CPUUsage: 100}, policy, config); recommendation.DesiredReplicas != 4 {
+		t.Fatalf("DesiredReplicas = %d, want the updated global maximum 4", recommendation.DesiredReplicas)
+	}
+
+	policy.MaxReplicas = 2
+	if recommendation := a.recommend(2, &model.Metrics{CPUUsage: 100}, policy, config); recommendation.DesiredReplicas != 2 {
+		t.Fatalf("DesiredReplicas = %d, want the policy maximum 2", recommendation.DesiredReplicas)
+	}
+}
+
+func TestRecommendHoldsScaleDownWhileMetricsAreMissing(t *testing.T) {
+	a := NewAutoscalerService(nil, NewFakeClusterInspector(), nil, nil, nil)
+	config := DefaultAutoscalerConfig()
+	config.Targets = []model.MetricTarget{{Metric: "cpu_usage", Target: 50}, {Metric: "qps", Target: 100}}
+	policy := model.ScalingPolicy{EnableAutoScaleUp: true, EnableAutoScaleDown: true}
+
+	// With qps measured, low load on both metrics scales down
+	idle := &model.Metrics{CPUUsageMax: 10, QPS: 40}
+	if recommendation := a.recommend(4, idle, policy, config); recommendation.DesiredReplicas >= 4 {
+		t.Fatalf("DesiredReplicas = %d, want a scale-down", recommendation.DesiredReplicas)
+	}
+
+	// An uncollected qps is not read as 0 and holds the scale-down
+	idle.Missing = []string{"qps"}
+	recommendation := a.recommend(4, idle, policy, config)
+	if recommendation.DesiredReplicas != 4 {
+		t.Fatalf("DesiredReplicas = %d, want the current 4 while qps is missing", recommendation.DesiredReplicas)
+	}
+	if len(recommendation.Missing) != 1 || recommendation.Missing[0] != "qps" {
+		t.Fatalf("Missing = %v, want [qps]", recommendation.Missing)
+	}
+	for _, m := range recommendation.Metrics {
+		if m.Metric == "qps" {
+			t.Fatalf("missing qps took part in the recommendation: %+v", m)
+		}
+	}
+
+	// Scaling up on a measured metric is still allowed
+	busy := &model.Metrics{CPUUsageMax: 100, Missing: []string{"qps"}}
+	if recommendation := a.recommend(4, busy, policy, config); recommendation.DesiredReplicas <= 4 {
+		t.Fatalf("DesiredReplicas = %d, want a scale-up", recommendation.DesiredReplicas)
+	}
+
+	// Nothing measured leaves the replicas alone
+	unknown := &model.Metrics{Missing: []string{"cpu_usage", "qps"}}
+	if recommendation := a.recommend(4, unknown, policy, config); recommendation.DesiredReplicas != 4 || recommendation.DrivingMetric != "" {
+		t.Fatalf("recommendation = %+v, want no change", recommendation)
+	}
+}
@@ -0,0 +1,519 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// defaultBenchmarkSampleSize is the default number of documents query vectors are drawn from
+	// defaultBenchmarkSampleSize 默认用于抽取查询向量的文档数
+	defaultBenchmarkSampleSize = 1000
+	// maxBenchmarkSampleSize caps the sample of query vectors kept in memory
+	// maxBenchmarkSampleSize 限制内存中查询向量样本的大小
+	maxBenchmarkSampleSize = 100000
+	// defaultBenchmarkQueryCount is the default number of queries drawn from the sample
+	// defaultBenchmarkQueryCount 默认从样本中抽取的查询数
+	defaultBenchmarkQueryCount = 100
+	// benchmarkWarmupQueries is the number of unmeasured queries run before each setting
+	// benchmarkWarmupQueries 每个参数测量前执行的预热查询数
+	benchmarkWarmupQueries = 5
+)
+
+// errSampleFull stops the export once enough sample documents have been read
+// errSampleFull 样本文档已读够时用于终止导出
+var errSampleFull = errors.New("benchmark sample full")
+
+// BenchmarkService measures recall and latency of vector indexes at different search settings
+// BenchmarkService 在不同搜索参数下测量向量索引的召回率与延迟
+type BenchmarkService struct {
+	store           VectorStore
+	metadataService *MetadataService
+	taskService     *TaskService
+	exportService   *ExportService
+}
+
+// NewBenchmarkService creates a new benchmark service
+// NewBenchmarkService 创建一个新的基准测试服务
+func NewBenchmarkService(store VectorStore, metadataService *MetadataService, taskService *TaskService, exportService *ExportService) *BenchmarkService {
+	return &BenchmarkService{
+		store:           store,
+		metadataService: metadataService,
+		taskService:     taskService,
+		exportService:   exportService,
+	}
+}
+
+// benchmarkDoc is a sample document whose vector can be used as a query
+// benchmarkDoc 向量可用作查询的样本文档
+type benchmarkDoc struct {
+	id     string
+	vector []float32
+}
+
+// StartBenchmark validates the request and runs the benchmark as a tracked background task
+// StartBenchmark 校验请求并以可跟踪的后台任务执行基准测试
+func (b *BenchmarkService) StartBenchmark(indexName string, req model.BenchmarkRequest) (*model.BenchmarkRun, error) {
+	metadata, err := b.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Field == "" {
+		req.Field = "vector"
+	}
+	if req.K <= 0 {
+		req.K = 10
+	}
+	if req.SampleSize <= 0 {
+		req.SampleSize = defaultBenchmarkSampleSize
+	}
+	if req.SampleSize > maxBenchmarkSampleSize {
+		return nil, fmt.Errorf("%w: sample_size must not exceed %d", ErrInvalidRequest, maxBenchmarkSampleSize)
+	}
+	if req.QueryCount <= 0 {
+		req.QueryCount = defaultBenchmarkQueryCount
+	}
+	for _, query := range req.Queries {
+		if metadata.Dimension > 0 && len(query) != metadata.Dimension {
+			return nil, fmt.Errorf("%w: query vector has dimension %d, index expects %d", ErrInvalidRequest, len(query), metadata.Dimension)
+		}
+	}
+
+	mode, err := benchmarkMode(&req, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := b.taskService.CreateTask("benchmark", metadata.IndexName)
+	if err != nil {
+		return nil, err
+	}
+
+	run := &model.BenchmarkRun{
+		ID:        fmt.Sprintf("bench_%d", time.Now().UnixNano()),
+		IndexName: metadata.IndexName,
+		TaskID:    task.ID,
+		Field:     req.Field,
+		Metric:    metadata.Metric,
+		K:         req.K,
+		NList:     metadata.IVFParams.NList,
+		Mode:      mode,
+		Status:    "running",
+		CreatedAt: time.Now(),
+	}
+	if err := b.metadataService.SaveBenchmarkRun(run); err != nil {
+		return nil, err
+	}
+
+	b.taskService.RunTask(task, func(ctx context.Context, progress func(processed, total int64)) (map[string]interface{}, error) {
+		err := b.benchmark(ctx, run, req, progress)
+
+		now := time.Now()
+		run.FinishedAt = &now
+		switch {
+		case errors.Is(err, context.Canceled):
+			run.Status = "cancelled"
+		case err != nil:
+			run.Status = "failed"
+			run.Error = err.Error()
+		default:
+			run.Status = "completed"
+		}
+		if saveErr := b.metadataService.SaveBenchmarkRun(run); saveErr != nil {
+			log.Printf("Error saving benchmark run %s: %v", run.ID, saveErr)
+		}
+
+		return map[string]interface{}{"benchmark_id": run.ID, "results": run.Results}, err
+	})
+
+	return run, nil
+}
+
+// benchmarkMode picks the tuning parameter to sweep and fills in default values
+// benchmarkMode 选择要测试的调优参数并填充默认值
+func benchmarkMode(req *model.BenchmarkRequest, metadata *model.IndexMetadata) (string, error) {
+	if len(req.NProbes) > 0 && len(req.NumCandidates) > 0 {
+		return "", fmt.Errorf("%w: set either nprobes or num_candidates, not both", ErrInvalidRequest)
+	}
+
+	if len(req.NProbes) == 0 && len(req.NumCandidates) == 0 {
+		// Trained IVF indexes are tuned through nprobe, others through num_candidates
+		// 已训练的 IVF 索引通过 nprobe 调优，其余索引通过 num_candidates 调优
+		if metadata.CentroidCount > 0 {
+			for _, nprobe := range []int{1, 5, 10, 20, 50} {
+				if nprobe <= metadata.CentroidCount {
+					req.NProbes = append(req.NProbes, nprobe)
+				}
+			}
+		} else {
+			for _, factor := range []int{1, 2, 5, 10} {
+				req.NumCandidates = append(req.NumCandidates, req.K*factor)
+			}
+		}
+	}
+
+	if len(req.NProbes) > 0 {
+		for _, nprobe := range req.NProbes {
+			if nprobe <= 0 {
+				return "", fmt.Errorf("%w: nprobe must be positive", ErrInvalidRequest)
+			}
+		}
+		return "nprobe", nil
+	}
+
+	for _, numCandidates := range req.NumCandidates {
+		if numCandidates < req.K {
+			return "", fmt.Errorf("%w: num_candidates must be at least k", ErrInvalidRequest)
+		}
+	}
+	return "num_candidates", nil
+}
+
+// benchmark draws the queries, computes exact ground truth over the whole index and measures every setting
+// benchmark 抽取查询、在整个索引上计算精确真实结果并测量每个参数
+func (b *BenchmarkService) benchmark(ctx context.Context, run *model.BenchmarkRun, req model.BenchmarkRequest, progress func(processed, total int64)) error {
+	total, err := b.store.Count(run.IndexName, map[string]interface{}{
+		"exists": map[string]interface{}{"field": req.Field},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to count vectors: %w", err)
+	}
+	if total < int64(req.K) {
+		return fmt.Errorf("%w: index has %d vectors, benchmark needs at least k=%d", ErrInvalidRequest, total, req.K)
+	}
+
+	// sample_size only bounds the documents query vectors are drawn from
+	// sample_size 仅限制用于抽取查询向量的文档数
+	queries := req.Queries
+	if len(queries) == 0 {
+		sample, err := b.loadSample(ctx, run.IndexName, req.Field, req.SampleSize)
+		if err != nil {
+			return fmt.Errorf("failed to load sample: %w", err)
+		}
+		run.SampleSize = len(sample)
+		queries = sampleQueries(sample, req.QueryCount)
+	}
+	run.QueryCount = len(queries)
+
+	// Recall is only meaningful against the exact answer over the whole index, which
+	// a script_score query computes by scoring every vector
+	// 召回率只有与整个索引上的精确结果比较才有意义，script_score 查询通过对每个向量打分得到该结果
+	truth := make([]map[string]bool, len(queries))
+	relevant := 0
+	for i, query := range queries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		response, err := b.store.Search(run.IndexName, exactVectorQuery(req.Field, query, req.K, run.Metric))
+		if err != nil {
+			return fmt.Errorf("failed to compute ground truth: %w", err)
+		}
+		truth[i] = hitIDSet(response)
+		relevant += len(truth[i])
+	}
+	if relevant == 0 {
+		return fmt.Errorf("%w: ground truth is empty", ErrInvalidRequest)
+	}
+
+	settings := len(req.NProbes) + len(req.NumCandidates)
+	processed := int64(0)
+	progress(processed, int64(settings*len(queries)))
+
+	measure := func(setting model.BenchmarkSetting) error {
+		build := func(vector []float32) map[string]interface{} {
+			return benchmarkQuery(req.Field, vector, req.K, setting)
+		}
+
+		for i := 0; i < benchmarkWarmupQueries && i < len(queries); i++ {
+			b.store.Search(run.IndexName, build(queries[i]))
+		}
+
+		latencies := make([]float64, 0, len(queries))
+		found := 0
+		start := time.Now()
+		for i, query := range queries {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			queryStart := time.Now()
+			response, err := b.store.Search(run.IndexName, build(query))
+			latencies = append(latencies, float64(time.Since(queryStart).Microseconds())/1000)
+			processed++
+			progress(processed, int64(settings*len(queries)))
+			if err != nil {
+				setting.Errors++
+				continue
+			}
+			found += countRelevantHits(response, truth[i])
+		}
+		elapsed := time.Since(start)
+
+		setting.Recall = float64(found) / float64(relevant)
+		setting.P50Ms = latencyPercentile(latencies, 50)
+		setting.P95Ms = latencyPercentile(latencies, 95)
+		setting.P99Ms = latencyPercentile(latencies, 99)
+		if elapsed > 0 {
+			setting.QPS = float64(len(queries)) / elapsed.Seconds()
+		}
+
+		run.Results = append(run.Results, setting)
+		if err := b.metadataService.SaveBenchmarkRun(run); err != nil {
+			log.Printf("Error saving benchmark run %s: %v", run.ID, err)
+		}
+		return nil
+	}
+
+	for _, nprobe := range req.NProbes {
+		if err := measure(model.BenchmarkSetting{NProbe: nprobe}); err != nil {
+			return err
+		}
+	}
+	for _, numCandidates := range req.NumCandidates {
+		if err := measure(model.BenchmarkSetting{NumCandidates: numCandidates}); err != nil {
+			return err
+		}
+	}
+
+	log.Printf("Benchmark %s on %s finished: %d settings, %d queries over %d vectors", run.ID, run.IndexName, settings, len(queries), total)
+	return nil
+}
+
+// loadSample exports up to size documents with their vectors
+// loadSample 导出最多 size 个文档及其向量
+func (b *BenchmarkService) loadSample(ctx context.Context, indexName, field string, size int) ([]benchmarkDoc, error) {
+	w := &sampleWriter{field: field, limit: size}
+	req := model.ExportRequest{
+		Fields: []string{field},
+		Query: map[string]interface{}{
+			"exists": map[string]interface{}{"field": field},
+		},
+	}
+	if size < defaultExportBatchSize {
+		req.BatchSize = size
+	}
+
+	if _, err := b.exportService.Export(ctx, indexName, req, w, nil); err != nil && !errors.Is(err, errSampleFull) {
+		return nil, err
+	}
+	return w.docs, nil
+}
+
+// sampleWriter decodes the NDJSON lines written by ExportService.Export into sample documents
+// sampleWriter 将 ExportService.Export 写出的 NDJSON 行解码为样本文档
+type sampleWriter struct {
+	field string
+	limit int
+	docs  []benchmarkDoc
+}
+
+func (w *sampleWriter) Write(p []byte) (int, error) {
+	if len(w.docs) >= w.limit {
+		return 0, errSampleFull
+	}
+
+	// json.Encoder writes exactly one document per call
+	// json.Encoder 每次调用恰好写入一个文档
+	var line struct {
+		ID     string                 `json:"_id"`
+		Source map[string]interface{} `json:"_source"`
+	}
+	if err := json.Unmarshal(p, &line); err != nil {
+		return 0, err
+	}
+
+	values, _ := line.Source[w.field].([]interface{})
+	if len(values) > 0 {
+		vector := make([]float32, len(values))
+		for i, v := range values {
+			f, _ := v.(float64)
+			vector[i] = float32(f)
+		}
+		w.docs = append(w.docs, benchmarkDoc{id: line.ID, vector: vector})
+	}
+	return len(p), nil
+}
+
+// sampleQueries draws up to count query vectors from the sample
+// sampleQueries 从样本中抽取最多 count 个查询向量
+func sampleQueries(sample []benchmarkDoc, count int) [][]float32 {
+	if count > len(sample) {
+		count = len(sample)
+	}
+	queries := make([][]float32, 0, count)
+	for _, i := range rand.Perm(len(sample))[:count] {
+		queries = append(queries, sample[i].vector)
+	}
+	return queries
+}
+
+// benchmarkQuery builds the search body for one query at the given setting
+// benchmarkQuery 按指定参数构建单个查询的搜索请求体
+func benchmarkQuery(field string, vector []float32, k int, setting model.BenchmarkSetting) map[string]interface{} {
+	if setting.NProbe > 0 {
+		query := map[string]interface{}{
+			"ann": map[string]interface{}{
+				"field":     field,
+				"vector":    vector,
+				"algorithm": "ivf",
+				"nprobe":    setting.NProbe,
+				"k":         k,
+			},
+		}
+		return map[string]interface{}{"query": query, "size": k, "_source": false}
+	}
+
+	knn := map[string]interface{}{
+		"field":          field,
+		"query_vector":   vector,
+		"k":              k,
+		"num_candidates": setting.NumCandidates,
+	}
+	return map[string]interface{}{"knn": knn, "size": k, "_source": false}
+}
+
+// exactVectorScripts score a vector with the similarity of the index, shifted to the
+// non-negative scores script_score requires without changing the order
+// exactVectorScripts 按索引的相似度为向量打分，并在不改变顺序的前提下转换为 script_score 要求的非负分数
+var exactVectorScripts = map[string]string{
+	"cosine": "cosineSimilarity(params.query_vector, params.field) + 1.0",
+	"dot":    "double s = dotProduct(params.query_vector, params.field); return s < 0 ? 1 / (1 - s) : s + 1;",
+	"l2":     "1 / (1 + l2norm(params.query_vector, params.field))",
+}
+
+// exactVectorQuery builds a script_score query returning the exact k nearest documents of the whole index
+// exactVectorQuery 构建返回整个索引中精确 k 个最近文档的 script_score 查询
+func exactVectorQuery(field string, vector []float32, k int, metric string) map[string]interface{} {
+	// Normalize aliases such as l2_norm, euclidean, dot_product and ip the same way index creation does
+	// 与创建索引时一样归一化 l2_norm、euclidean、dot_product、ip 等别名
+	metric = storeMetric(esSimilarity(metric))
+	query := map[string]interface{}{
+		"script_score": map[string]interface{}{
+			"query": map[string]interface{}{
+				"exists": map[string]interface{}{"field": field},
+			},
+			"script": map[string]interface{}{
+				"source": exactVectorScripts[metric],
+				"params": map[string]interface{}{
+					"field":        field,
+					"query_vector": vector,
+				},
+			},
+		},
+	}
+	return map[string]interface{}{"query": query, "size": k, "_source": false}
+}
+
+// hitIDSet returns the IDs of the hits of a search response
+// hitIDSet 返回搜索结果中命中文档的 ID 集合
+func hitIDSet(response map[string]interface{}) map[string]bool {
+	hits, _ := response["hits"].(map[string]interface{})
+	hitList, _ := hits["hits"].([]interface{})
+
+	ids := make(map[string]bool, len(hitList))
+	for _, item := range hitList {
+		hit, _ := item.(map[string]interface{})
+		if id, ok := hit["_id"].(string); ok {
+			ids[id] = true
+		}
+	}
+	return ids
+}
+
+// similarity scores two vectors so that higher is always closer
+// similarity 计算两个向量的相似度，数值越大越接近
+func similarity(a, b []float32, metric string) float64 {
+	n := len(a)
+	if len(b) < n {
+		n = len(b)
+	}
+
+	switch metric {
+	case "l2":
+		var sum float64
+		for i := 0; i < n; i++ {
+			d := float64(a[i]) - float64(b[i])
+			sum += d * d
+		}
+		return -sum
+	case "dot":
+		var sum float64
+		for i := 0; i < n; i++ {
+			sum += float64(a[i]) * float64(b[i])
+		}
+		return sum
+	default:
+		var dot, normA, normB float64
+		for i := 0; i < n; i++ {
+			dot += float64(a[i]) * float64(b[i])
+			normA += float64(a[i]) * float64(a[i])
+			normB += float64(b[i]) * float64(b[i])
+		}
+		if normA == 0 || normB == 0 {
+			return 0
+		}
+		return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+	}
+}
+
+// countRelevantHits counts the hits of a search response that are in the ground truth
+// countRelevantHits 统计搜索结果中属于真实结果的命中数
+func countRelevantHits(response map[string]interface{}, truth map[string]bool) int {
+	hits, _ := response["hits"].(map[string]interface{})
+	hitList, _ := hits["hits"].([]interface{})
+
+	found := 0
+	for _, item := range hitList {
+		hit, _ := item.(map[string]interface{})
+		if id, ok := hit["_id"].(string); ok && truth[id] {
+			found++
+		}
+	}
+	return found
+}
+
+// latencyPercentile returns the nearest-rank percentile of the latencies in milliseconds
+// latencyPercentile 返回延迟（毫秒）的最近秩百分位数
+func latencyPercentile(latencies []float64, percentile float64) float64 {
+	if len(latencies) == 0 {
+		return 0
+	}
+	sorted := append([]float64(nil), latencies...)
+	sort.Float64s(sorted)
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// GetBenchmark retrieves a benchmark run of an index
+// GetBenchmark 获取索引的基准测试记录
+func (b *BenchmarkService) GetBenchmark(indexName, id string) (*model.BenchmarkRun, error) {
+	metadata, err := b.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+	return b.metadataService.GetBenchmarkRun(metadata.IndexName, id)
+}
+
+// ListBenchmarks lists the benchmark runs of an index so tuning runs can be compared
+// ListBenchmarks 列出索引的基准测试记录，便于比较不同调优结果
+func (b *BenchmarkService) ListBenchmarks(indexName string) ([]*model.BenchmarkRun, error) {
+	metadata, err := b.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+	return b.metadataService.ListBenchmarkRuns(metadata.IndexName)
+}
@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestBenchmarkGroundTruthCoversIndexBeyondSample(t *testing.T) {
+	docs := make(map[string][]float64)
+	for i := 0; i < 40; i++ {
+		docs[fmt.Sprintf("d%02d", i)] = []float64{float64(i), float64(i % 7)}
+	}
+	store := newTestStore(t, "bench", "l2", docs)
+	benchmarks := NewBenchmarkService(store, newTestMetadataService(t), nil, NewExportService(store, nil, t.TempDir()))
+
+	// The exact answer comes from the whole index, not from the loaded sample
+	response, err := store.Search("bench", exactVectorQuery("vector", []float32{39, 4}, 3, "l2_norm"))
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if got := hitIDs(t, response); fmt.Sprint(got) != "[d39 d38 d37]" {
+		t.Fatalf("exact hits = %v, want [d39 d38 d37]", got)
+	}
+
+	tests := []struct {
+		name string
+		req  model.BenchmarkRequest
+	}{
+		{"sampled queries", model.BenchmarkRequest{QueryCount: 5, SampleSize: 5}},
+		{"given queries", model.BenchmarkRequest{Queries: [][]float32{{39, 4}, {0, 0}}, SampleSize: 1}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := tt.req
+			req.Field = "vector"
+			req.K = 3
+			req.NumCandidates = []int{3, 10}
+			run := &model.BenchmarkRun{ID: "bench_" + tt.name, IndexName: "bench", Metric: "l2", K: req.K}
+
+			if err := benchmarks.benchmark(context.Background(), run, req, func(processed, total int64) {}); err != nil {
+				t.Fatalf("benchmark: %v", err)
+			}
+			if len(run.Results) != 2 {
+				t.Fatalf("results = %+v, want 2 settings", run.Results)
+			}
+			// The memory store answers kNN exactly, so recall against the full index is perfect
+			for _, setting := range run.Results {
+				if setting.Recall != 1 || setting.Errors != 0 {
+					t.Errorf("setting %+v, want recall 1 without errors", setting)
+				}
+			}
+		})
+	}
+}
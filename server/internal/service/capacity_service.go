@@ -0,0 +1,231 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// defaultCapacityNList is the IVF list count assumed when none is given
+	// defaultCapacityNList 未指定时假定的 IVF 聚类中心数
+	defaultCapacityNList = 100
+	// baseHeapBytes is the heap an idle Elasticsearch node needs
+	// baseHeapBytes 空闲 Elasticsearch 节点所需的堆内存
+	baseHeapBytes = 1 << 30
+	// maxHeapBytes keeps the heap below the compressed object pointer limit
+	// maxHeapBytes 使堆内存低于压缩指针上限
+	maxHeapBytes = 31 << 30
+	// heapPerDiskRatio is the heap kept for segment and mapping structures per byte on disk
+	// heapPerDiskRatio 每字节磁盘数据所需的段与映射结构堆内存比例
+	heapPerDiskRatio = 0.01
+	// diskOverheadFactor covers _source, translog and segment merges
+	// diskOverheadFactor 覆盖 _source、translog 以及段合并的额外磁盘开销
+	diskOverheadFactor = 1.5
+	// diskWatermark is the high disk watermark; data should stay below it
+	// diskWatermark 磁盘高水位线，数据应保持在其之下
+	diskWatermark = 0.85
+	// vectorDimsPerCore is the vector components (count x dims) one core can serve
+	// vectorDimsPerCore 单核可服务的向量分量数（数量 x 维度）
+	vectorDimsPerCore = 2_000_000_000
+)
+
+// CapacityService estimates the resources a vector workload needs
+// CapacityService 估算向量负载所需的资源
+type CapacityService struct{}
+
+// NewCapacityService creates a new capacity service
+// NewCapacityService 创建一个新的容量规划服务
+func NewCapacityService() *CapacityService {
+	return &CapacityService{}
+}
+
+// Estimate computes heap, off-heap and disk requirements and the per node resources
+// to request. When the request carries resources they are checked against the estimate.
+// Estimate 计算堆内存、堆外内存与磁盘需求以及单节点推荐资源；若请求携带资源则与估算结果比对
+func (s *CapacityService) Estimate(req model.CapacityRequest) (*model.CapacityEstimate, error) {
+	if req.Dimension <= 0 {
+		return nil, fmt.Errorf("%w: dimension must be positive", ErrInvalidRequest)
+	}
+	if req.VectorCount < 0 {
+		return nil, fmt.Errorf("%w: vector_count must not be negative", ErrInvalidRequest)
+	}
+	quantization, err := NormalizeQuantization(req.Quantization)
+	if err != nil {
+		return nil, err
+	}
+	engine := strings.ToLower(req.Engine)
+	if engine == "" {
+		engine = "hnsw"
+	}
+	if engine != "hnsw" && engine != "ivf" && engine != "flat" {
+		return nil, fmt.Errorf("%w: unknown engine %s (expected hnsw, ivf or flat)", ErrInvalidRequest, req.Engine)
+	}
+
+	nodes := req.Nodes
+	if nodes <= 0 {
+		nodes = 1
+	}
+	replicas := req.IndexReplicas
+	if replicas < 0 {
+		return nil, fmt.Errorf("%w: index_replicas must not be negative", ErrInvalidRequest)
+	}
+	if replicas >= nodes {
+		// Replica shards are never allocated on the node holding the primary
+		// 副本分片不会分配到主分片所在的节点
+		replicas = nodes - 1
+	}
+	copies := int64(1 + replicas)
+
+	dims := int64(req.Dimension)
+	rawBytes := req.VectorCount * dims * 4
+	quantizedBytes := req.VectorCount * quantizedVectorSize(req.Dimension, quantization)
+
+	// Off-heap memory of one copy: the searched vectors plus the graph or IVF lists
+	// 单份数据的堆外内存：被检索的向量加上图或 IVF 倒排列表
+	var offHeap int64
+	switch engine {
+	case "hnsw":
+		offHeap = EstimateVectorMemory(req.VectorCount, req.Dimension, quantization)
+	case "ivf":
+		nlist := int64(req.NList)
+		if nlist <= 0 {
+			nlist = defaultCapacityNList
+		}
+		offHeap = quantizedBytes + nlist*dims*4 + req.VectorCount*4
+	default:
+		offHeap = quantizedBytes
+	}
+
+	// Disk of one copy: float vectors are always kept, quantized copies and graphs on top
+	// 单份数据的磁盘：始终保留浮点向量，另加量化副本和图结构
+	disk := rawBytes
+	if quantization != "none" {
+		disk += quantizedBytes
+	}
+	disk += offHeap - quantizedBytes
+	disk = int64(float64(disk) * diskOverheadFactor)
+
+	offHeapPerNode := ceilDiv(offHeap*copies, int64(nodes))
+	diskPerNode := ceilDiv(disk*copies, int64(nodes))
+	heapPerNode := int64(baseHeapBytes) + int64(float64(diskPerNode)*heapPerDiskRatio)
+	if heapPerNode > maxHeapBytes {
+		heapPerNode = maxHeapBytes
+	}
+
+	// The heap should not exceed half of the container memory, the rest is page cache
+	// 堆内存不应超过容器内存的一半，其余用作页缓存
+	memoryPerNode := heapPerNode + offHeapPerNode
+	if memoryPerNode < 2*heapPerNode {
+		memoryPerNode = 2 * heapPerNode
+	}
+	vectorsPerNode := ceilDiv(req.VectorCount*copies, int64(nodes))
+	cores := ceilDiv(vectorsPerNode*dims, vectorDimsPerCore)
+	if cores < 1 {
+		cores = 1
+	}
+
+	estimate := &model.CapacityEstimate{
+		Dimension:           req.Dimension,
+		VectorCount:         req.VectorCount,
+		Nodes:               nodes,
+		IndexReplicas:       replicas,
+		Quantization:        quantization,
+		Engine:              engine,
+		RawVectorBytes:      rawBytes,
+		OffHeapBytes:        offHeap * copies,
+		DiskBytes:           disk * copies,
+		HeapBytesPerNode:    heapPerNode,
+		OffHeapBytesPerNode: offHeapPerNode,
+		DiskBytesPerNode:    diskPerNode,
+		RecommendedCPU:      strconv.FormatInt(cores, 10),
+		RecommendedMemory:   formatGi(memoryPerNode),
+		RecommendedDiskSize: formatGi(int64(float64(diskPerNode) / diskWatermark)),
+	}
+	if req.IndexReplicas > replicas {
+		estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("index_replicas lowered to %d: %d node(s) cannot hold %d replica(s)", replicas, nodes, req.IndexReplicas))
+	}
+	s.check(estimate, req.CPU, req.Memory, req.DiskSize)
+	return estimate, nil
+}
+
+// EstimateForCluster builds an estimate from a cluster creation request
+// EstimateForCluster 根据集群创建请求进行估算
+func (s *CapacityService) EstimateForCluster(req model.CreateRequest) (*model.CapacityEstimate, error) {
+	return s.Estimate(model.CapacityRequest{
+		Dimension:     req.Dimension,
+		VectorCount:   int64(req.VectorCount),
+		Nodes:         req.Replicas,
+		IndexReplicas: req.IndexReplicas,
+		Quantization:  req.Quantization,
+		Engine:        req.Engine,
+		CPU:           req.CPURequest,
+		Memory:        req.MemRequest,
+		DiskSize:      req.DiskSize,
+	})
+}
+
+// check compares requested per node resources with the estimate and records warnings
+// check 将请求的单节点资源与估算结果比对并记录警告
+func (s *CapacityService) check(estimate *model.CapacityEstimate, cpu, memory, disk string) {
+	if cpu != "" {
+		cores, err := parseCPU(cpu)
+		recommended, _ := parseCPU(estimate.RecommendedCPU)
+		if err != nil {
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("cannot parse cpu %q", cpu))
+		} else if cores < recommended {
+			estimate.UnderProvisioned = true
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("cpu %s is below the recommended %s", cpu, estimate.RecommendedCPU))
+		}
+	}
+	if memory != "" {
+		if bytes := parseQuantity(memory); bytes < estimate.HeapBytesPerNode+estimate.OffHeapBytesPerNode {
+			estimate.UnderProvisioned = true
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("memory %s cannot hold the heap and vector data (%s), recommended %s",
+				memory, formatStorageSize(estimate.HeapBytesPerNode+estimate.OffHeapBytesPerNode), estimate.RecommendedMemory))
+		}
+	}
+	if disk != "" {
+		if bytes := parseQuantity(disk); float64(bytes)*diskWatermark < float64(estimate.DiskBytesPerNode) {
+			estimate.UnderProvisioned = true
+			estimate.Warnings = append(estimate.Warnings, fmt.Sprintf("disk %s would exceed the %.0f%% watermark (%s of data), recommended %s",
+				disk, diskWatermark*100, formatStorageSize(estimate.DiskBytesPerNode), estimate.RecommendedDiskSize))
+		}
+	}
+}
+
+// parseCPU parses a Kubernetes CPU quantity such as "500m", "2" or the "123456n"
+// reported by the metrics API into cores
+// parseCPU 将 Kubernetes CPU 数量（如 "500m"、"2" 或 Metrics API 返回的 "123456n"）解析为核数
+func parseCPU(s string) (float64, error) {
+	s = strings.TrimSpace(s)
+	for suffix, scale := range map[string]float64{"m": 1e-3, "u": 1e-6, "n": 1e-9} {
+		if strings.HasSuffix(s, suffix) {
+			value, err := strconv.ParseFloat(strings.TrimSuffix(s, suffix), 64)
+			return value * scale, err
+		}
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+// parseQuantity parses a memory or disk quantity; a bare number means Gi as in CreateCluster
+// parseQuantity 解析内存或磁盘数量；与 CreateCluster 一致，纯数字表示 Gi
+func parseQuantity(s string) int64 {
+	if _, err := strconv.Atoi(strings.TrimSpace(s)); err == nil {
+		s = strings.TrimSpace(s) + "Gi"
+	}
+	return parseStorageSize(s)
+}
+
+// formatGi rounds bytes up to whole Gi
+// formatGi 将字节数向上取整为整数 Gi
+func formatGi(bytes int64) string {
+	return fmt.Sprintf("%dGi", int64(math.Ceil(float64(bytes)/(1<<30))))
+}
+
+func ceilDiv(a, b int64) int64 {
+	return (a + b - 1) / b
+}
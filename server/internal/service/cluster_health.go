@@ -0,0 +1,77 @@
+package service
+
+import (
+	"fmt"
+
+	"es-serverless-manager/internal/model"
+)
+
+// maxUnhealthyShards bounds the shards kept with a deployment status
+// maxUnhealthyShards 部署状态中保留的异常分片数量上限
+const maxUnhealthyShards = 50
+
+// DeriveClusterStatus combines the StatefulSet readiness with the Elasticsearch cluster health into
+// the overall status of a cluster and the reason for it. sts is nil when the StatefulSet could not be
+// read and health is nil when Elasticsearch has not been checked yet; a health status of "unreachable"
+// means the check failed. "running" means healthy, previous keeps scaling and deleting in progress.
+// DeriveClusterStatus 结合 StatefulSet 就绪情况与 Elasticsearch 集群健康状态得出集群的整体状态及原因。
+// 无法读取 StatefulSet 时 sts 为 nil，尚未检查 Elasticsearch 时 health 为 nil，健康状态为 "unreachable" 表示检查失败。
+// "running" 表示健康，previous 用于保留进行中的扩缩容与删除状态
+func DeriveClusterStatus(sts *StatefulSetStatus, health *model.ESClusterHealth, previous string) (string, string) {
+	if previous == "deleting" {
+		return previous, ""
+	}
+
+	if sts == nil {
+		return "unknown", "statefulset could not be read"
+	}
+	if sts.Replicas == 0 {
+		return "stopped", "statefulset is scaled to zero"
+	}
+	if sts.ReadyReplicas == 0 {
+		return "unavailable", fmt.Sprintf("0/%d pods ready", sts.Replicas)
+	}
+
+	if health != nil {
+		switch health.Status {
+		case "red":
+			return "unhealthy", fmt.Sprintf("elasticsearch is red with %d unassigned shards", health.UnassignedShards)
+		case "unreachable":
+			return "degraded", "elasticsearch is unreachable"
+		}
+	}
+
+	if sts.ReadyReplicas < sts.Replicas {
+		reason := fmt.Sprintf("%d/%d pods ready", sts.ReadyReplicas, sts.Replicas)
+		if previous == "scaling" {
+			return "scaling", reason
+		}
+		return "degraded", reason
+	}
+
+	if health != nil && health.Status == "yellow" {
+		return "degraded", fmt.Sprintf("elasticsearch is yellow with %d unassigned shards", health.UnassignedShards)
+	}
+	return "running", ""
+}
+
+// unhealthyShards keeps the shards that are not started, unassigned ones first
+// unhealthyShards 保留未处于 STARTED 状态的分片，未分配的分片排在前面
+func unhealthyShards(shards []model.ESShard) []model.ESShard {
+	var unassigned, moving []model.ESShard
+	for _, shard := range shards {
+		switch shard.State {
+		case "STARTED":
+		case "UNASSIGNED":
+			unassigned = append(unassigned, shard)
+		default:
+			moving = append(moving, shard)
+		}
+	}
+
+	result := append(unassigned, moving...)
+	if len(result) > maxUnhealthyShards {
+		result = result[:maxUnhealthyShards]
+	}
+	return result
+}
@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// esClusterLabelSelector selects the namespaces of tenant clusters
+	// esClusterLabelSelector 用于选择租户集群命名空间的标签选择器
+	esClusterLabelSelector = "es-cluster=true"
+	// esStatefulSetName is the StatefulSet created by the tenant chart
+	// esStatefulSetName 租户 Chart 创建的 StatefulSet 名称
+	esStatefulSetName = "elasticsearch"
+)
+
+// ClusterInspector reads and scales the Kubernetes resources of tenant clusters
+// ClusterInspector 读取并扩缩容租户集群的 Kubernetes 资源
+type ClusterInspector interface {
+	// ListClusterNamespaces lists the namespaces labelled es-cluster=true
+	// ListClusterNamespaces 列出带有 es-cluster=true 标签的命名空间
+	ListClusterNamespaces(ctx context.Context) ([]string, error)
+	// GetStatefulSet returns the spec and status of a StatefulSet
+	// GetStatefulSet 返回 StatefulSet 的规格与状态
+	GetStatefulSet(ctx context.Context, namespace, name string) (*StatefulSetStatus, error)
+	// ListPodMetrics returns the current CPU and memory usage of the pods in a namespace
+	// ListPodMetrics 返回命名空间中各 Pod 当前的 CPU 与内存使用量
+	ListPodMetrics(ctx context.Context, namespace string) ([]PodUsage, error)
+	// ScaleStatefulSet sets the replicas of a StatefulSet through its scale subresource
+	// ScaleStatefulSet 通过 scale 子资源设置 StatefulSet 的副本数
+	ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int) error
+}
+
+var (
+	_ ClusterInspector = (*KubeInspector)(nil)
+	_ ClusterInspector = (*FakeClusterInspector)(nil)
+	_ ClusterInspector = DisabledClusterInspector{}
+)
+
+// ErrClusterInspectorDisabled is returned by every call when no Kubernetes configuration is available
+// ErrClusterInspectorDisabled 没有可用的 Kubernetes 配置时，每次调用都返回该错误
+var ErrClusterInspectorDisabled = errors.New("Kubernetes cluster inspector is disabled")
+
+// StatefulSetStatus represents the replicas and container resources of a StatefulSet
+// StatefulSetStatus StatefulSet 的副本数与容器资源
+type StatefulSetStatus struct {
+	Name            string
+	Replicas        int
+	ReadyReplicas   int
+	CurrentReplicas int
+	Limits          model.ResourceLimits
+	Requests        model.ResourceRequests
+}
+
+// PodUsage represents the resource usage of one pod
+// PodUsage 单个 Pod 的资源使用量
+type PodUsage struct {
+	Name        string
+	CPUCores    float64
+	MemoryBytes int64
+}
+
+// FakeClusterInspector is an in-memory ClusterInspector for tests and local runs
+// FakeClusterInspector 用于测试与本地运行的内存版 ClusterInspector
+type FakeClusterInspector struct {
+	Namespaces   []string
+	StatefulSets map[string]*StatefulSetStatus // 以命名空间为键
+	Pods         map[string][]PodUsage         // 以命名空间为键
+	mu           sync.Mutex
+}
+
+// NewFakeClusterInspector creates an empty fake inspector
+// NewFakeClusterInspector 创建一个空的模拟检查器
+func NewFakeClusterInspector() *FakeClusterInspector {
+	return &FakeClusterInspector{
+		StatefulSets: make(map[string]*StatefulSetStatus),
+		Pods:         make(map[string][]PodUsage),
+	}
+}
+
+// AddCluster registers a namespace with a ready StatefulSet of the given size
+// AddCluster 注册一个命名空间及指定副本数且已就绪的 StatefulSet
+func (f *FakeClusterInspector) AddCluster(namespace string, replicas int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.Namespaces = append(f.Namespaces, namespace)
+	f.StatefulSets[namespace] = &StatefulSetStatus{
+		Name:            esStatefulSetName,
+		Replicas:        replicas,
+		ReadyReplicas:   replicas,
+		CurrentReplicas: replicas,
+	}
+}
+
+func (f *FakeClusterInspector) ListClusterNamespaces(ctx context.Context) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	namespaces := append([]string(nil), f.Namespaces...)
+	sort.Strings(namespaces)
+	return namespaces, nil
+}
+
+func (f *FakeClusterInspector) GetStatefulSet(ctx context.Context, namespace, name string) (*StatefulSetStatus, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sts, ok := f.StatefulSets[namespace]
+	if !ok || sts.Name != name {
+		return nil, fmt.Errorf("statefulset %s/%s not found", namespace, name)
+	}
+	copied := *sts
+	return &copied, nil
+}
+
+func (f *FakeClusterInspector) ListPodMetrics(ctx context.Context, namespace string) ([]PodUsage, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]PodUsage(nil), f.Pods[namespace]...), nil
+}
+
+// ScaleStatefulSet updates the replicas immediately, as if every pod became ready at once
+// ScaleStatefulSet 立即更新副本数，相当于所有 Pod 同时就绪
+func (f *FakeClusterInspector) ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sts, ok := f.StatefulSets[namespace]
+	if !ok || sts.Name != name {
+		return fmt.Errorf("statefulset %s/%s not found", namespace, name)
+	}
+	sts.Replicas = replicas
+	sts.ReadyReplicas = replicas
+	sts.CurrentReplicas = replicas
+	return nil
+}
+
+// DisabledClusterInspector stands in when the server runs without a Kubernetes cluster, so the
+// checks that need one are skipped instead of keeping the server from starting
+// DisabledClusterInspector 在服务没有 Kubernetes 集群时使用，使依赖集群的检查被跳过，而不是阻止服务启动
+type DisabledClusterInspector struct{}
+
+func (DisabledClusterInspector) ListClusterNamespaces(ctx context.Context) ([]string, error) {
+	return nil, ErrClusterInspectorDisabled
+}
+
+func (DisabledClusterInspector) GetStatefulSet(ctx context.Context, namespace, name string) (*StatefulSetStatus, error) {
+	return nil, ErrClusterInspectorDisabled
+}
+
+func (DisabledClusterInspector) ListPodMetrics(ctx context.Context, namespace string) ([]PodUsage, error) {
+	return nil, ErrClusterInspectorDisabled
+}
+
+func (DisabledClusterInspector) ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int) error {
+	return ErrClusterInspectorDisabled
+}
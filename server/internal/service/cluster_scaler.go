@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+var (
+	// ErrQuotaExceeded is returned when a scale up would exceed the tenant quota
+	// ErrQuotaExceeded 扩容将超出租户配额时返回
+	ErrQuotaExceeded = errors.New("tenant quota exceeded")
+	// ErrScaleInProgress is returned when another scale of the same cluster is running
+	// ErrScaleInProgress 同一集群的另一次扩缩容正在进行时返回
+	ErrScaleInProgress = errors.New("scale already in progress")
+)
+
+// Scale sources recorded in the audit trail
+// 审计记录中的扩缩容来源
+const (
+	ScaleSourceManual     = "manual"
+	ScaleSourceAutoscaler = "autoscaler"
+)
+
+// scaleApplyTimeout bounds the Terraform apply of one scale
+// scaleApplyTimeout 限制一次扩缩容的 Terraform apply 时长
+const scaleApplyTimeout = 30 * time.Minute
+
+// ClusterScaler is the single scale path of tenant clusters: it checks the tenant quota, applies the
+// new replicas through Terraform so later applies keep them, updates the metadata and audits every attempt
+// ClusterScaler 租户集群唯一的扩缩容路径：检查租户配额，通过 Terraform 应用新的副本数以免后续 apply 将其还原，
+// 更新元数据并审计每一次尝试
+type ClusterScaler struct {
+	metadataService  *MetadataService
+	terraformManager *TerraformManager
+	mu               sync.Mutex
+	// scaling holds the namespaces with a scale in flight
+	// scaling 记录正在扩缩容的命名空间
+	scaling map[string]bool
+	wg      sync.WaitGroup
+}
+
+// NewClusterScaler creates a new cluster scaler
+// NewClusterScaler 创建一个新的集群扩缩容器
+func NewClusterScaler(metadataService *MetadataService, terraformManager *TerraformManager) *ClusterScaler {
+	return &ClusterScaler{
+		metadataService:  metadataService,
+		terraformManager: terraformManager,
+		scaling:          make(map[string]bool),
+	}
+}
+
+// Scale checks and starts setting the replicas of a cluster. source is manual or autoscaler, actor who
+// asked for it and reason why. The Terraform apply runs in the background, bounded by scaleApplyTimeout,
+// so a slow apply never holds up the caller or other clusters; its result is recorded in the audit trail.
+// Scale 校验并开始设置集群的副本数；source 为 manual 或 autoscaler，actor 为发起者，reason 为原因。
+// Terraform apply 在后台执行并受 scaleApplyTimeout 限制，缓慢的 apply 不会阻塞调用方或其他集群；其结果记录在审计中
+func (s *ClusterScaler) Scale(namespace string, replicas int, source, actor, reason string) error {
+	if replicas < 0 {
+		return fmt.Errorf("%w: replicas must not be negative", ErrInvalidRequest)
+	}
+	deployment, err := s.metadataService.GetDeploymentStatus(namespace)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	if s.scaling[namespace] {
+		s.mu.Unlock()
+		return ErrScaleInProgress
+	}
+	s.scaling[namespace] = true
+	s.mu.Unlock()
+	done := func() {
+		s.mu.Lock()
+		delete(s.scaling, namespace)
+		s.mu.Unlock()
+	}
+
+	event := &model.ScaleEvent{
+		ID:           fmt.Sprintf("scale_%s_%d", namespace, time.Now().UnixNano()),
+		Namespace:    namespace,
+		TenantOrgID:  deployment.TenantOrgID,
+		User:         deployment.User,
+		Source:       source,
+		Actor:        actor,
+		FromReplicas: deployment.Replicas,
+		ToReplicas:   replicas,
+		Reason:       reason,
+		CreatedAt:    time.Now(),
+	}
+
+	if err := s.checkQuota(deployment, replicas); err != nil {
+		event.Result, event.Error = "rejected", err.Error()
+		s.audit(event)
+		done()
+		return err
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer done()
+		defer s.audit(event)
+
+		ctx, cancel := context.WithTimeout(context.Background(), scaleApplyTimeout)
+		defer cancel()
+		if err := s.apply(ctx, deployment, replicas); err != nil {
+			log.Printf("Error scaling cluster in namespace %s to %d replicas: %v", namespace, replicas, err)
+			event.Result, event.Error = "failed", err.Error()
+			return
+		}
+		event.Result = "succeeded"
+	}()
+	return nil
+}
+
+// Wait blocks until the scales in flight have finished
+// Wait 阻塞直到进行中的扩缩容全部结束
+func (s *ClusterScaler) Wait() {
+	s.wg.Wait()
+}
+
+// apply runs Terraform with the new replicas and records them in the metadata
+// apply 以新的副本数执行 Terraform 并将其写入元数据
+func (s *ClusterScaler) apply(ctx context.Context, deployment *model.DeploymentStatus, replicas int) error {
+	// Apply changes via Terraform
+	// 通过 Terraform 应用变更
+	tenantContainer, err := s.metadataService.GetTenantContainer(deployment.User, deployment.ServiceName)
+	if err != nil {
+		tenantContainer = nil
+	}
+	if err := s.terraformManager.CreateClusterContext(ctx, tenantConfigFromDeployment(deployment, tenantContainer, replicas)); err != nil {
+		return fmt.Errorf("failed to scale cluster: %v", err)
+	}
+
+	// deployment was read before the apply, write only the scale columns so the health and metrics
+	// monitoring recorded meanwhile are kept
+	// deployment 在 apply 之前读取，仅写入扩缩容相关的列，以保留期间监控记录的健康状态与指标
+	now := time.Now()
+	deployment.Replicas = replicas
+	deployment.UpdatedAt = now
+	deployment.LastScaledAt = &now
+	deployment.Status = "scaling"
+	if err := s.metadataService.UpdateDeploymentStatusColumns(deployment, "replicas", "last_scaled_at", "status", "updated_at"); err != nil {
+		log.Printf("Error updating deployment status for namespace %s after scaling: %v", deployment.Namespace, err)
+	}
+
+	if tenantContainer != nil {
+		tenantContainer.Replicas = replicas
+		tenantContainer.SyncTime = now
+		s.metadataService.SaveTenantContainer(tenantContainer)
+	}
+	return nil
+}
+
+// CheckQuota reports whether scaling a cluster to replicas stays within its tenant quota
+// CheckQuota 判断将集群扩缩到指定副本数是否仍在租户配额内
+func (s *ClusterScaler) CheckQuota(namespace string, replicas int) error {
+	deployment, err := s.metadataService.GetDeploymentStatus(namespace)
+	if err != nil {
+		return err
+	}
+	return s.checkQuota(deployment, replicas)
+}
+
+// checkQuota checks the index quota and the replica quota of the tenant on scale up; scale down is
+// always allowed
+// checkQuota 扩容时检查租户的索引配额与副本配额；缩容始终允许
+func (s *ClusterScaler) checkQuota(deployment *model.DeploymentStatus, replicas int) error {
+	if replicas <= deployment.Replicas || deployment.User == "" {
+		return nil
+	}
+
+	hasQuota, quota, err := s.metadataService.CheckTenantQuota(deployment.User)
+	if err != nil {
+		log.Printf("Warning: Failed to check tenant quota for user %s: %v", deployment.User, err)
+		return nil
+	}
+	if !hasQuota {
+		return fmt.Errorf("%w: max indices %d, current indices %d", ErrQuotaExceeded, quota.MaxIndices, quota.CurrentIndices)
+	}
+	if quota.MaxReplicas <= 0 {
+		return nil
+	}
+
+	deployments, err := s.metadataService.ListDeploymentStatus()
+	if err != nil {
+		return err
+	}
+	total := replicas
+	for _, other := range deployments {
+		if other.User == deployment.User && other.Namespace != deployment.Namespace {
+			total += other.Replicas
+		}
+	}
+	if total > quota.MaxReplicas {
+		return fmt.Errorf("%w: %d replicas across the tenant's clusters exceed the limit of %d", ErrQuotaExceeded, total, quota.MaxReplicas)
+	}
+	return nil
+}
+
+// ListEvents lists the scale audit records of a cluster, newest first
+// ListEvents 列出集群的扩缩容审计记录，最新的在前
+func (s *ClusterScaler) ListEvents(namespace string, limit int) ([]*model.ScaleEvent, error) {
+	if limit <= 0 || limit > 1000 {
+		limit = 1000
+	}
+	return s.metadataService.ListScaleEvents(namespace, limit)
+}
+
+func (s *ClusterScaler) audit(event *model.ScaleEvent) {
+	log.Printf("Scale %s of namespace %s by %s (%s): %d -> %d replicas, %s", event.Result, event.Namespace, event.Actor, event.Source, event.FromReplicas, event.ToReplicas, event.Reason)
+	if err := s.metadataService.SaveScaleEvent(event); err != nil {
+		log.Printf("Error saving scale event for namespace %s: %v", event.Namespace, err)
+	}
+}
+
+// tenantConfigFromDeployment rebuilds the Terraform configuration of a cluster with new replicas; the
+// resources come from the deployment details and fall back to the tenant container record
+// tenantConfigFromDeployment 以新的副本数重建集群的 Terraform 配置；资源取自部署详情，缺失时回退到租户容器记录
+func tenantConfigFromDeployment(deployment *model.DeploymentStatus, container *model.TenantContainer, replicas int) model.TenantConfig {
+	getString := func(v interface{}) string {
+		if s, ok := v.(string); ok {
+			return s
+		}
+		return ""
+	}
+
+	getInt := func(v interface{}) int {
+		if i, ok := v.(int); ok {
+			return i
+		}
+		if f, ok := v.(float64); ok {
+			return int(f)
+		}
+		return 0
+	}
+
+	tenantConfig := model.TenantConfig{
+		TenantOrgID:     deployment.TenantOrgID,
+		User:            deployment.User,
+		ServiceName:     deployment.ServiceName,
+		Replicas:        replicas,
+		CPU:             getString(deployment.Details["cpu_request"]),
+		Memory:          getString(deployment.Details["mem_request"]),
+		DiskSize:        getString(deployment.Details["disk_size"]),
+		StorageClass:    "hostpath",
+		GPUCount:        getInt(deployment.Details["gpu_count"]),
+		VectorDimension: getInt(deployment.Details["dimension"]),
+		VectorCount:     getInt(deployment.Details["vector_count"]),
+	}
+
+	if container != nil {
+		if tenantConfig.CPU == "" {
+			tenantConfig.CPU = resourceRequest(container.CPU)
+		}
+		if tenantConfig.Memory == "" {
+			tenantConfig.Memory = resourceRequest(container.Memory)
+		}
+		if tenantConfig.DiskSize == "" {
+			tenantConfig.DiskSize = container.Disk
+		}
+	}
+	if tenantConfig.GPUCount == 0 {
+		tenantConfig.GPUCount = deployment.GPUCount
+	}
+	if tenantConfig.VectorDimension == 0 {
+		tenantConfig.VectorDimension = deployment.Dimension
+	}
+	if tenantConfig.VectorCount == 0 {
+		tenantConfig.VectorCount = deployment.VectorCount
+	}
+
+	if tenantConfig.CPU == "" {
+		tenantConfig.CPU = "500m"
+	}
+	if tenantConfig.Memory == "" {
+		tenantConfig.Memory = "1Gi"
+	}
+	if tenantConfig.DiskSize == "" {
+		tenantConfig.DiskSize = "10Gi"
+	}
+	return tenantConfig
+}
+
+// resourceRequest returns the request of a tenant container resource, which is stored as "request/limit"
+// resourceRequest 返回租户容器资源的请求值，该资源以 "request/limit" 形式存储
+func resourceRequest(resource string) string {
+	request, _, _ := strings.Cut(resource, "/")
+	return strings.TrimSpace(request)
+}
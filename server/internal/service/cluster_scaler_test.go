@@ -0,0 +1,73 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestDeploymentStatusColumnUpdatesKeepConcurrentWrites(t *testing.T) {
+	metadata := newTestMetadataService(t)
+	if err := metadata.SaveDeploymentStatus(&model.DeploymentStatus{ID: "d1", Namespace: "tenant-a", Status: "running", Replicas: 1}); err != nil {
+		t.Fatalf("SaveDeploymentStatus: %v", err)
+	}
+
+	// A scale reads the row before its apply ...
+	scale, err := metadata.GetDeploymentStatus("tenant-a")
+	if err != nil {
+		t.Fatalf("GetDeploymentStatus: %v", err)
+	}
+
+	// ... monitoring records health and the cluster is marked for deletion meanwhile
+	monitored, _ := metadata.GetDeploymentStatus("tenant-a")
+	monitored.CPUUsage = 42
+	monitored.ESHealth.Status = "green"
+	if err := metadata.UpdateDeploymentStatusColumns(monitored, "cpu_usage", "es_status"); err != nil {
+		t.Fatalf("UpdateDeploymentStatusColumns: %v", err)
+	}
+	if err := metadata.SetDeploymentStatusIf("tenant-a", "running", "deleting", ""); err != nil {
+		t.Fatalf("SetDeploymentStatusIf: %v", err)
+	}
+
+	now := time.Now()
+	scale.Replicas = 3
+	scale.LastScaledAt = &now
+	if err := metadata.UpdateDeploymentStatusColumns(scale, "replicas", "last_scaled_at"); err != nil {
+		t.Fatalf("UpdateDeploymentStatusColumns: %v", err)
+	}
+	// A status derived from a stale read is dropped
+	if err := metadata.SetDeploymentStatusIf("tenant-a", "running", "high_load", ""); err != nil {
+		t.Fatalf("SetDeploymentStatusIf: %v", err)
+	}
+
+	got, err := metadata.GetDeploymentStatus("tenant-a")
+	if err != nil {
+		t.Fatalf("GetDeploymentStatus: %v", err)
+	}
+	if got.Replicas != 3 || got.LastScaledAt == nil {
+		t.Errorf("replicas = %d, last scaled at %v, want the scale to be recorded", got.Replicas, got.LastScaledAt)
+	}
+	if got.CPUUsage != 42 || got.ESHealth.Status != "green" {
+		t.Errorf("cpu usage = %v, es status = %q, want the monitoring values kept", got.CPUUsage, got.ESHealth.Status)
+	}
+	if got.Status != "deleting" {
+		t.Errorf("status = %q, want deleting", got.Status)
+	}
+}
+
+func TestTenantConfigFromDeploymentUsesContainerRequests(t *testing.T) {
+	deployment := &model.DeploymentStatus{Namespace: "tenant-a", User: "alice", ServiceName: "search"}
+	container := &model.TenantContainer{CPU: "1/2", Memory: "2Gi/4Gi", Disk: "20Gi"}
+
+	config := tenantConfigFromDeployment(deployment, container, 3)
+	if config.CPU != "1" || config.Memory != "2Gi" || config.DiskSize != "20Gi" || config.Replicas != 3 {
+		t.Errorf("config = %+v, want the requests 1 and 2Gi with 20Gi disk and 3 replicas", config)
+	}
+
+	deployment.Details = map[string]interface{}{"cpu_request": "500m", "mem_request": "1Gi"}
+	config = tenantConfigFromDeployment(deployment, container, 3)
+	if config.CPU != "500m" || config.Memory != "1Gi" {
+		t.Errorf("config = %+v, want the requests from the deployment details", config)
+	}
+}
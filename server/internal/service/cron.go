@@ -0,0 +1,157 @@
+package service
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronExpr is a parsed five-field cron expression: minute, hour, day of month, month and day of week
+// cronExpr 解析后的五段式 cron 表达式：分、时、日、月、周
+type cronExpr struct {
+	minute, hour, dom, month, dow uint64
+	// domAny and dowAny record a day field that allows every day after parsing, such as "*", "?",
+	// "1-31" or "0-6"; when both day fields are restricted either may match
+	// domAny 与 dowAny 记录解析后允许每一天的日字段，如 "*"、"?"、"1-31" 或 "0-6"；两个日字段都受限时满足其一即可
+	domAny, dowAny bool
+}
+
+// cronField is the range of one cron field
+// cronField 单个 cron 字段的取值范围
+type cronField struct {
+	name     string
+	min, max int
+}
+
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// parseCron parses a five-field cron expression. Every field accepts "*", values, ranges "a-b",
+// steps "*/n" and "a-b/n" and comma separated lists; the day fields also accept "?" for "*", and 7 is
+// also Sunday in the day of week.
+// parseCron 解析五段式 cron 表达式；每个字段支持 "*"、数值、范围 "a-b"、步长 "*/n" 与 "a-b/n" 以及逗号分隔的列表；
+// 日字段还支持与 "*" 等价的 "?"，周字段中 7 也表示周日
+func parseCron(spec string) (*cronExpr, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields", spec, len(cronFields))
+	}
+
+	bits := make([]uint64, len(parts))
+	for i, part := range parts {
+		if part == "?" && (i == 2 || i == 4) {
+			part = "*"
+		}
+		value, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, err
+		}
+		bits[i] = value
+	}
+	expr := &cronExpr{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+	}
+	if expr.dow&(1<<7) != 0 {
+		expr.dow |= 1
+	}
+	expr.domAny = expr.dom == cronFieldMask(1, 31)
+	expr.dowAny = expr.dow&cronFieldMask(0, 6) == cronFieldMask(0, 6)
+	return expr, nil
+}
+
+// cronFieldMask returns the bits of the values low to high
+// cronFieldMask 返回 low 到 high 各值对应的位
+func cronFieldMask(low, high int) uint64 {
+	var bits uint64
+	for v := low; v <= high; v++ {
+		bits |= 1 << uint(v)
+	}
+	return bits
+}
+
+func parseCronField(part string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, item := range strings.Split(part, ",") {
+		rangePart, step := item, 1
+		if i := strings.Index(item, "/"); i >= 0 {
+			n, err := strconv.Atoi(item[i+1:])
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %s field %q", field.name, part)
+			}
+			rangePart, step = item[:i], n
+		}
+
+		low, high := field.min, field.max
+		if rangePart != "*" {
+			bounds := strings.SplitN(rangePart, "-", 2)
+			var err error
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid value in %s field %q", field.name, part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid value in %s field %q", field.name, part)
+				}
+			} else if step > 1 {
+				high = field.max
+			}
+		}
+		if low < field.min || high > field.max || low > high {
+			return 0, fmt.Errorf("%s field %q is out of range %d-%d", field.name, part, field.min, field.max)
+		}
+		for v := low; v <= high; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// next returns the first time after t, in the location of t, at which the expression fires, or the
+// zero time when it does not fire within five years
+// next 返回 t 之后（按 t 的时区）表达式首次触发的时间；五年内不触发时返回零值
+func (c *cronExpr) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if c.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if !c.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+			continue
+		}
+		if c.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+			continue
+		}
+		if c.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (c *cronExpr) matchDay(t time.Time) bool {
+	dom := c.dom&(1<<uint(t.Day())) != 0
+	dow := c.dow&(1<<uint(t.Weekday())) != 0
+	if c.domAny || c.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCronDayFields(t *testing.T) {
+	// Thursday 1 January 2026
+	from := time.Date(2026, 1, 1, 8, 0, 0, 0, time.UTC)
+	cases := []struct {
+		spec string
+		want time.Time
+	}{
+		// A day field that allows every day leaves the other one alone
+		{"0 9 * * 1", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 ? * 1", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 1-31 * 1", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 15 * ?", time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 15 * 0-6", time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)},
+		{"0 9 15 * 0-7", time.Date(2026, 1, 15, 9, 0, 0, 0, time.UTC)},
+		// Two restricted day fields match on either
+		{"0 9 15 * 1", time.Date(2026, 1, 5, 9, 0, 0, 0, time.UTC)},
+		{"0 9 2-31 * 1-5", time.Date(2026, 1, 1, 9, 0, 0, 0, time.UTC)},
+	}
+	for _, c := range cases {
+		expr, err := parseCron(c.spec)
+		if err != nil {
+			t.Fatalf("parseCron(%q): %v", c.spec, err)
+		}
+		if got := expr.next(from); !got.Equal(c.want) {
+			t.Errorf("%q next = %v, want %v", c.spec, got, c.want)
+		}
+	}
+
+	if _, err := parseCron("? 9 * * *"); err == nil {
+		t.Errorf("parseCron accepted ? in the minute field")
+	}
+}
@@ -0,0 +1,298 @@
+package service
+
+import (
+	"container/list"
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// defaultEmbeddingBatchSize is the number of texts sent to an encoder in one call
+	// defaultEmbeddingBatchSize 单次调用编码器的默认文本数
+	defaultEmbeddingBatchSize = 64
+	// defaultEmbeddingCacheSize is the number of cached text vectors
+	// defaultEmbeddingCacheSize 默认缓存的文本向量数
+	defaultEmbeddingCacheSize = 10000
+	// defaultTextField is the document field encoded when an index does not name one
+	// defaultTextField 索引未指定时默认编码的文档字段
+	defaultTextField = "text"
+)
+
+// EmbeddingService turns text into vectors through registered encoders,
+// batching encoder calls and caching vectors of recently seen texts
+// EmbeddingService 通过已注册的编码器将文本转换为向量，对编码调用进行批处理并缓存最近文本的向量
+type EmbeddingService struct {
+	encoders  map[string]Encoder
+	batchSize int
+	cacheSize int
+	// LRU cache keyed by encoder name and text
+	// 以编码器名称和文本为键的 LRU 缓存
+	cache map[string]*list.Element
+	order *list.List
+	mu    sync.Mutex
+}
+
+// embeddingCacheEntry is a cached vector
+// embeddingCacheEntry 缓存的向量
+type embeddingCacheEntry struct {
+	key    string
+	vector []float32
+}
+
+// NewEmbeddingService creates a new embedding service
+// NewEmbeddingService 创建一个新的向量编码服务
+func NewEmbeddingService(batchSize, cacheSize int) *EmbeddingService {
+	if batchSize <= 0 {
+		batchSize = defaultEmbeddingBatchSize
+	}
+	if cacheSize < 0 {
+		cacheSize = defaultEmbeddingCacheSize
+	}
+	return &EmbeddingService{
+		encoders:  make(map[string]Encoder),
+		batchSize: batchSize,
+		cacheSize: cacheSize,
+		cache:     make(map[string]*list.Element),
+		order:     list.New(),
+	}
+}
+
+// Register adds an encoder, replacing any encoder with the same name
+// Register 注册编码器，同名编码器将被替换
+func (e *EmbeddingService) Register(encoder Encoder) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.encoders[encoder.Name()] = encoder
+}
+
+// GetEncoder returns a registered encoder by name
+// GetEncoder 根据名称获取已注册的编码器
+func (e *EmbeddingService) GetEncoder(name string) (Encoder, error) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	encoder, ok := e.encoders[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown encoder %s", ErrInvalidRequest, name)
+	}
+	return encoder, nil
+}
+
+// ListEncoders lists the registered encoders in name order
+// ListEncoders 按名称顺序列出已注册的编码器
+func (e *EmbeddingService) ListEncoders() []model.EncoderInfo {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	infos := make([]model.EncoderInfo, 0, len(e.encoders))
+	for name, encoder := range e.encoders {
+		infos = append(infos, model.EncoderInfo{Name: name, Dimension: encoder.Dimension()})
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Embed encodes texts with the named encoder. Cached texts are served from the
+// cache and the rest are sent to the encoder in batches.
+// Embed 使用指定编码器编码文本；已缓存的文本直接从缓存返回，其余文本分批发送给编码器
+func (e *EmbeddingService) Embed(ctx context.Context, encoderName string, texts []string) ([][]float32, error) {
+	encoder, err := e.GetEncoder(encoderName)
+	if err != nil {
+		return nil, err
+	}
+
+	// Encode every distinct uncached text once
+	// 每个未缓存的不同文本只编码一次
+	vectors := make([][]float32, len(texts))
+	positions := make(map[string][]int)
+	var missing []string
+	for i, text := range texts {
+		if vector, ok := e.cached(encoderName, text); ok {
+			vectors[i] = vector
+			continue
+		}
+		if _, seen := positions[text]; !seen {
+			missing = append(missing, text)
+		}
+		positions[text] = append(positions[text], i)
+	}
+
+	for start := 0; start < len(missing); start += e.batchSize {
+		end := start + e.batchSize
+		if end > len(missing) {
+			end = len(missing)
+		}
+
+		batch := missing[start:end]
+		encoded, err := encoder.Encode(ctx, batch)
+		if err != nil {
+			return nil, fmt.Errorf("encoder %s failed: %w", encoderName, err)
+		}
+		if len(encoded) != len(batch) {
+			return nil, fmt.Errorf("encoder %s returned %d vectors for %d texts", encoderName, len(encoded), len(batch))
+		}
+
+		for j, text := range batch {
+			if len(encoded[j]) != encoder.Dimension() {
+				return nil, fmt.Errorf("encoder %s returned dimension %d, expected %d", encoderName, len(encoded[j]), encoder.Dimension())
+			}
+			for _, i := range positions[text] {
+				vectors[i] = encoded[j]
+			}
+			e.store(encoderName, text, encoded[j])
+		}
+	}
+
+	return vectors, nil
+}
+
+// EmbedDocuments fills the vector field of documents that carry text instead of a vector
+// EmbedDocuments 为携带文本而非向量的文档填充向量字段
+func (e *EmbeddingService) EmbedDocuments(ctx context.Context, metadata *model.IndexMetadata, docs []map[string]interface{}) error {
+	if metadata == nil || metadata.Encoder == "" {
+		return nil
+	}
+	textField := metadata.TextField
+	if textField == "" {
+		textField = defaultTextField
+	}
+
+	var texts []string
+	var targets []map[string]interface{}
+	for _, doc := range docs {
+		if _, ok := doc["vector"]; ok {
+			continue
+		}
+		if text, ok := doc[textField].(string); ok && text != "" {
+			texts = append(texts, text)
+			targets = append(targets, doc)
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	vectors, err := e.embedForIndex(ctx, metadata, texts)
+	if err != nil {
+		return err
+	}
+	for i, doc := range targets {
+		doc["vector"] = vectors[i]
+	}
+	return nil
+}
+
+// EmbedSearch replaces query_text in the knn section or the plugin ann query with
+// the encoded query vector
+// EmbedSearch 将 knn 或插件 ann 查询中的 query_text 替换为编码后的查询向量
+func (e *EmbeddingService) EmbedSearch(ctx context.Context, metadata *model.IndexMetadata, query map[string]interface{}) error {
+	var target map[string]interface{}
+	vectorKey := ""
+	if knn, ok := query["knn"].(map[string]interface{}); ok {
+		target, vectorKey = knn, "query_vector"
+	} else if clause, ok := query["query"].(map[string]interface{}); ok {
+		if ann, ok := clause["ann"].(map[string]interface{}); ok {
+			target, vectorKey = ann, "vector"
+		}
+	}
+	if target == nil {
+		return nil
+	}
+
+	text, ok := target["query_text"].(string)
+	if !ok {
+		return nil
+	}
+	if metadata == nil || metadata.Encoder == "" {
+		return fmt.Errorf("%w: index has no encoder, query_text is not supported", ErrInvalidRequest)
+	}
+
+	vectors, err := e.embedForIndex(ctx, metadata, []string{text})
+	if err != nil {
+		return err
+	}
+	delete(target, "query_text")
+	target[vectorKey] = vectors[0]
+	return nil
+}
+
+// EmbedBatchSearch converts the texts of a batch search into query vectors
+// EmbedBatchSearch 将批量搜索中的文本转换为查询向量
+func (e *EmbeddingService) EmbedBatchSearch(ctx context.Context, metadata *model.IndexMetadata, req *model.BatchSearchRequest) error {
+	if len(req.Texts) == 0 {
+		return nil
+	}
+	if len(req.Vectors) > 0 {
+		return fmt.Errorf("%w: set either vectors or texts, not both", ErrInvalidRequest)
+	}
+	if metadata == nil || metadata.Encoder == "" {
+		return fmt.Errorf("%w: index has no encoder, texts are not supported", ErrInvalidRequest)
+	}
+
+	vectors, err := e.embedForIndex(ctx, metadata, req.Texts)
+	if err != nil {
+		return err
+	}
+	req.Vectors = vectors
+	req.Texts = nil
+	return nil
+}
+
+// embedForIndex encodes texts with the index encoder and enforces the index dimension
+// embedForIndex 使用索引的编码器编码文本，并校验索引维度
+func (e *EmbeddingService) embedForIndex(ctx context.Context, metadata *model.IndexMetadata, texts []string) ([][]float32, error) {
+	vectors, err := e.Embed(ctx, metadata.Encoder, texts)
+	if err != nil {
+		return nil, err
+	}
+	for _, vector := range vectors {
+		if metadata.Dimension > 0 && len(vector) != metadata.Dimension {
+			return nil, fmt.Errorf("%w: encoder %s produces dimension %d, index %s expects %d",
+				ErrInvalidRequest, metadata.Encoder, len(vector), metadata.IndexName, metadata.Dimension)
+		}
+	}
+	return vectors, nil
+}
+
+// cached looks up a vector and marks it as recently used
+// cached 查找缓存向量并将其标记为最近使用
+func (e *EmbeddingService) cached(encoderName, text string) ([]float32, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	element, ok := e.cache[encoderName+"\x00"+text]
+	if !ok {
+		return nil, false
+	}
+	e.order.MoveToFront(element)
+	return element.Value.(*embeddingCacheEntry).vector, true
+}
+
+// store caches a vector, evicting the least recently used entry when full
+// store 缓存向量，缓存满时淘汰最久未使用的条目
+func (e *EmbeddingService) store(encoderName, text string, vector []float32) {
+	if e.cacheSize == 0 {
+		return
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	key := encoderName + "\x00" + text
+	if element, ok := e.cache[key]; ok {
+		element.Value.(*embeddingCacheEntry).vector = vector
+		e.order.MoveToFront(element)
+		return
+	}
+
+	e.cache[key] = e.order.PushFront(&embeddingCacheEntry{key: key, vector: vector})
+	if e.order.Len() > e.cacheSize {
+		oldest := e.order.Back()
+		e.order.Remove(oldest)
+		delete(e.cache, oldest.Value.(*embeddingCacheEntry).key)
+	}
+}
@@ -0,0 +1,201 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Encoder turns text into vectors
+// Encoder 将文本转换为向量
+type Encoder interface {
+	// Name identifies the encoder; it is recorded on the indexes that use it
+	// Name 编码器标识，记录在使用它的索引上
+	Name() string
+	// Dimension is the length of every vector produced by the encoder
+	// Dimension 编码器生成的向量长度
+	Dimension() int
+	// Encode returns one vector per text, in order
+	// Encode 按顺序为每个文本返回一个向量
+	Encode(ctx context.Context, texts []string) ([][]float32, error)
+}
+
+// HashingEncoder is a deterministic encoder based on feature hashing of word
+// tokens. It needs no model or network and is meant for tests and local setups.
+// HashingEncoder 基于词特征哈希的确定性编码器，无需模型或网络，适用于测试和本地环境
+type HashingEncoder struct {
+	dimension int
+}
+
+// NewHashingEncoder creates a hashing encoder producing vectors of the given dimension
+// NewHashingEncoder 创建生成指定维度向量的哈希编码器
+func NewHashingEncoder(dimension int) (*HashingEncoder, error) {
+	if dimension <= 0 {
+		return nil, fmt.Errorf("%w: hashing encoder dimension must be positive, got %d", ErrInvalidRequest, dimension)
+	}
+	return &HashingEncoder{dimension: dimension}, nil
+}
+
+func (e *HashingEncoder) Name() string {
+	return "hashing"
+}
+
+func (e *HashingEncoder) Dimension() int {
+	return e.dimension
+}
+
+// Encode hashes every lower-cased token into a signed bucket and L2-normalizes the result
+// Encode 将每个小写词元哈希到带符号的桶中，并对结果进行 L2 归一化
+func (e *HashingEncoder) Encode(ctx context.Context, texts []string) ([][]float32, error) {
+	vectors := make([][]float32, len(texts))
+	for i, text := range texts {
+		vector := make([]float32, e.dimension)
+		tokens := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+			return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+		})
+		for _, token := range tokens {
+			h := fnv.New64a()
+			h.Write([]byte(token))
+			sum := h.Sum64()
+			sign := float32(1)
+			if sum>>63 == 1 {
+				sign = -1
+			}
+			vector[sum%uint64(e.dimension)] += sign
+		}
+		vectors[i] = normalizeVector(vector)
+	}
+	return vectors, nil
+}
+
+// openAIModelDimensions are the native output dimensions of the OpenAI embedding models
+// openAIModelDimensions OpenAI 向量模型的原生输出维度
+var openAIModelDimensions = map[string]int{
+	"text-embedding-ada-002": 1536,
+	"text-embedding-3-small": 1536,
+	"text-embedding-3-large": 3072,
+}
+
+// OpenAIEncoder calls an OpenAI-compatible /embeddings endpoint
+// OpenAIEncoder 调用兼容 OpenAI 的 /embeddings 接口
+type OpenAIEncoder struct {
+	name      string
+	baseURL   string
+	apiKey    string
+	model     string
+	dimension int
+	// sendDimensions asks the model to shorten its vectors, which only text-embedding-3 models support
+	// sendDimensions 请求模型缩短向量，仅 text-embedding-3 系列模型支持
+	sendDimensions bool
+	httpClient     *http.Client
+}
+
+// NewOpenAIEncoder creates an encoder for an OpenAI-compatible endpoint.
+// baseURL is the API root, for example https://api.openai.com/v1. A dimension of 0 uses
+// the native dimension of a known model; other models need the dimension they return.
+// NewOpenAIEncoder 创建兼容 OpenAI 接口的编码器，baseURL 为 API 根地址（如 https://api.openai.com/v1）；
+// dimension 为 0 时使用已知模型的原生维度，其他模型需指定其返回的维度
+func NewOpenAIEncoder(name, baseURL, apiKey, model string, dimension int) (*OpenAIEncoder, error) {
+	native := openAIModelDimensions[model]
+	if dimension < 0 {
+		return nil, fmt.Errorf("%w: embedding dimension must not be negative", ErrInvalidRequest)
+	}
+	if dimension == 0 {
+		if native == 0 {
+			return nil, fmt.Errorf("%w: embedding dimension is required for model %s", ErrInvalidRequest, model)
+		}
+		dimension = native
+	}
+
+	supportsDimensions := strings.HasPrefix(model, "text-embedding-3")
+	if native > 0 && dimension != native && !supportsDimensions {
+		return nil, fmt.Errorf("%w: model %s only produces %d dimensions", ErrInvalidRequest, model, native)
+	}
+
+	return &OpenAIEncoder{
+		name:           name,
+		baseURL:        strings.TrimRight(baseURL, "/"),
+		apiKey:         apiKey,
+		model:          model,
+		dimension:      dimension,
+		sendDimensions: supportsDimensions && dimension != native,
+		httpClient: &http.Client{
+			Timeout: 60 * time.Second,
+		},
+	}, nil
+}
+
+func (e *OpenAIEncoder) Name() string {
+	return e.name
+}
+
+func (e *OpenAIEncoder) Dimension() int {
+	return e.dimension
+}
+
+// Encode sends the texts in one /embeddings request
+// Encode 通过一次 /embeddings 请求编码所有文本
+func (e *OpenAIEncoder) Encode(ctx context.Context, texts []string) ([][]float32, error) {
+	payload := map[string]interface{}{
+		"model": e.model,
+		"input": texts,
+	}
+	if e.sendDimensions {
+		payload["dimensions"] = e.dimension
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", e.baseURL+"/embeddings", bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if e.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+e.apiKey)
+	}
+
+	resp, err := e.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("embedding request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Data []struct {
+			Index     int       `json:"index"`
+			Embedding []float32 `json:"embedding"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if len(result.Data) != len(texts) {
+		return nil, fmt.Errorf("embedding endpoint returned %d vectors for %d texts", len(result.Data), len(texts))
+	}
+
+	// Items carry their input position and are not guaranteed to be in order
+	// 返回项带有输入位置，顺序不保证与输入一致
+	vectors := make([][]float32, len(texts))
+	for _, item := range result.Data {
+		if item.Index < 0 || item.Index >= len(texts) {
+			return nil, fmt.Errorf("embedding endpoint returned invalid index %d", item.Index)
+		}
+		vectors[item.Index] = item.Embedding
+	}
+	return vectors, nil
+}
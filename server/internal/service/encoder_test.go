@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// embeddingStub serves an OpenAI-compatible /embeddings endpoint that returns its
+// items in reverse order and records the last request payload
+func embeddingStub(t *testing.T, dimension int) (*httptest.Server, *map[string]interface{}) {
+	t.Helper()
+
+	var lastPayload map[string]interface{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/embeddings" {
+			http.NotFound(w, r)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer secret" {
+			http.Error(w, "bad auth "+got, http.StatusUnauthorized)
+			return
+		}
+		var payload map[string]interface{}
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		lastPayload = payload
+
+		inputs, _ := payload["input"].([]interface{})
+		data := make([]map[string]interface{}, 0, len(inputs))
+		for i := len(inputs) - 1; i >= 0; i-- {
+			embedding := make([]float32, dimension)
+			embedding[0] = float32(i)
+			data = append(data, map[string]interface{}{"index": i, "embedding": embedding})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"data": data})
+	}))
+	t.Cleanup(server.Close)
+	return server, &lastPayload
+}
+
+func TestOpenAIEncoderSendsDimensionsOnlyWhenSupported(t *testing.T) {
+	tests := []struct {
+		name          string
+		model         string
+		dimension     int
+		wantDimension int
+		wantSent      bool
+	}{
+		{name: "shortened text-embedding-3", model: "text-embedding-3-small", dimension: 256, wantDimension: 256, wantSent: true},
+		{name: "native text-embedding-3", model: "text-embedding-3-large", dimension: 0, wantDimension: 3072, wantSent: false},
+		{name: "ada-002", model: "text-embedding-ada-002", dimension: 1536, wantDimension: 1536, wantSent: false},
+		{name: "custom model", model: "bge-small", dimension: 384, wantDimension: 384, wantSent: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, lastPayload := embeddingStub(t, tt.wantDimension)
+			encoder, err := NewOpenAIEncoder("openai", server.URL+"/", "secret", tt.model, tt.dimension)
+			if err != nil {
+				t.Fatalf("NewOpenAIEncoder: %v", err)
+			}
+			if encoder.Dimension() != tt.wantDimension {
+				t.Fatalf("Dimension() = %d, want %d", encoder.Dimension(), tt.wantDimension)
+			}
+
+			vectors, err := encoder.Encode(context.Background(), []string{"a", "b", "c"})
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+			for i, vector := range vectors {
+				if len(vector) != tt.wantDimension || vector[0] != float32(i) {
+					t.Fatalf("vector %d = len %d marker %v, want len %d marker %d", i, len(vector), vector[0], tt.wantDimension, i)
+				}
+			}
+
+			dimensions, sent := (*lastPayload)["dimensions"]
+			if sent != tt.wantSent {
+				t.Fatalf("dimensions sent = %v (%v), want %v", sent, dimensions, tt.wantSent)
+			}
+			if sent && dimensions != float64(tt.wantDimension) {
+				t.Fatalf("dimensions = %v, want %d", dimensions, tt.wantDimension)
+			}
+		})
+	}
+}
+
+func TestNewOpenAIEncoderRejectsInvalidDimensions(t *testing.T) {
+	tests := []struct {
+		model     string
+		dimension int
+	}{
+		{model: "text-embedding-ada-002", dimension: 256},
+		{model: "bge-small", dimension: 0},
+		{model: "text-embedding-3-small", dimension: -1},
+	}
+
+	for _, tt := range tests {
+		if _, err := NewOpenAIEncoder("openai", "http://localhost", "", tt.model, tt.dimension); !errors.Is(err, ErrInvalidRequest) {
+			t.Errorf("NewOpenAIEncoder(%s, %d): err = %v, want ErrInvalidRequest", tt.model, tt.dimension, err)
+		}
+	}
+}
+
+func TestOpenAIEncoderReportsErrorStatus(t *testing.T) {
+	server, _ := embeddingStub(t, 4)
+	encoder, err := NewOpenAIEncoder("openai", server.URL, "wrong", "bge-small", 4)
+	if err != nil {
+		t.Fatalf("NewOpenAIEncoder: %v", err)
+	}
+
+	_, err = encoder.Encode(context.Background(), []string{"a"})
+	if err == nil || !strings.Contains(err.Error(), "status 401") {
+		t.Fatalf("Encode with bad key: err = %v, want status 401", err)
+	}
+}
+
+func TestHashingEncoder(t *testing.T) {
+	if _, err := NewHashingEncoder(0); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("NewHashingEncoder(0): err = %v, want ErrInvalidRequest", err)
+	}
+
+	encoder, err := NewHashingEncoder(16)
+	if err != nil {
+		t.Fatalf("NewHashingEncoder: %v", err)
+	}
+	vectors, err := encoder.Encode(context.Background(), []string{"Hello, world", "hello world", ""})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if len(vectors) != 3 || len(vectors[0]) != 16 {
+		t.Fatalf("Encode returned %d vectors of length %d, want 3 of 16", len(vectors), len(vectors[0]))
+	}
+	for i := range vectors[0] {
+		if vectors[0][i] != vectors[1][i] {
+			t.Fatalf("same tokens encoded differently: %v vs %v", vectors[0], vectors[1])
+		}
+	}
+	for _, v := range vectors[2] {
+		if v != 0 {
+			t.Fatalf("empty text encoded to %v, want zero vector", vectors[2])
+		}
+	}
+}
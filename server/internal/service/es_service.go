@@ -2,20 +2,52 @@ package service
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"sort"
+	"strings"
+	"sync"
 	"time"
 
 	"es-serverless-manager/internal/model"
 )
 
+const (
+	// defaultBatchSearchSize is the number of queries sent in one _msearch request
+	// defaultBatchSearchSize 单次 _msearch 请求包含的默认查询数
+	defaultBatchSearchSize = 100
+	// defaultBatchSearchConcurrency is the number of _msearch requests in flight
+	// defaultBatchSearchConcurrency 默认并发的 _msearch 请求数
+	defaultBatchSearchConcurrency = 4
+	// maxBatchSearchConcurrency caps the concurrency a caller can request
+	// maxBatchSearchConcurrency 调用方可请求的最大并发数
+	maxBatchSearchConcurrency = 16
+)
+
+// ErrDocumentNotFound is returned when a document does not exist
+// ErrDocumentNotFound 文档不存在时返回的错误
+var ErrDocumentNotFound = errors.New("document not found")
+
+// ErrInvalidRequest wraps validation errors detected by services
+// ErrInvalidRequest 包装服务层检测到的参数校验错误
+var ErrInvalidRequest = errors.New("invalid request")
+
+// ErrIndexBusy is returned when another operation is already changing the index
+// ErrIndexBusy 当其他操作正在修改索引时返回的错误
+var ErrIndexBusy = errors.New("index is busy with another operation")
+
 // ESService handles Elasticsearch operations
 // ESService 处理 Elasticsearch 操作
 type ESService struct {
 	baseURL    string
 	httpClient *http.Client
+	// adminClient is used for long running admin calls such as IVF training
+	// adminClient 用于 IVF 训练等长时间运行的管理请求
+	adminClient *http.Client
 }
 
 // NewESService creates a new Elasticsearch service
@@ -26,6 +58,56 @@ func NewESService(baseURL string) *ESService {
 		httpClient: &http.Client{
 			Timeout: 30 * time.Second,
 		},
+		adminClient: &http.Client{
+			Timeout: 30 * time.Minute,
+		},
+	}
+}
+
+// BuildVectorMapping builds the index mapping for a vector index request
+// BuildVectorMapping 根据向量索引请求构建索引映射
+func BuildVectorMapping(req model.VectorIndexRequest) model.VectorIndexMapping {
+	vector := map[string]interface{}{
+		"type":       "dense_vector",
+		"dims":       req.Dimension,
+		"index":      true,
+		"similarity": esSimilarity(req.Metric),
+	}
+	// Quantized indexes keep the raw floats on disk for rescoring only
+	// 量化索引仅在磁盘上保留原始浮点向量用于重打分
+	if quantization, err := NormalizeQuantization(req.Quantization); err == nil && quantization != "none" {
+		vector["index_options"] = map[string]interface{}{"type": esIndexOptionsType(quantization)}
+	}
+
+	mapping := model.VectorIndexMapping{
+		Properties: map[string]interface{}{
+			"vector": vector,
+		},
+	}
+
+	// Add user defined fields
+	// 添加用户定义的字段
+	for k, v := range req.FieldMapping {
+		mapping.Properties[k] = map[string]interface{}{
+			"type": v,
+		}
+	}
+
+	return mapping
+}
+
+// esSimilarity maps the API metric names (L2, cosine, dot) to Elasticsearch similarities
+// esSimilarity 将 API 度量名称（L2、cosine、dot）映射为 Elasticsearch 相似度
+func esSimilarity(metric string) string {
+	switch strings.ToLower(metric) {
+	case "l2", "l2_norm", "euclidean":
+		return "l2_norm"
+	case "dot", "dot_product", "ip":
+		return "dot_product"
+	case "", "cosine":
+		return "cosine"
+	default:
+		return metric
 	}
 }
 
@@ -98,7 +180,7 @@ func (s *ESService) DeleteIndex(indexName string) error {
 
 // IndexDocument indexes a document in Elasticsearch
 // IndexDocument 在 Elasticsearch 中索引文档
-func (s *ESService) IndexDocument(indexName, docID string, document map[string]interface{}) error {
+func (s *ESService) IndexDocument(indexName, docID string, document map[string]interface{}) (*model.DocumentResult, error) {
 	var url string
 	if docID != "" {
 		url = fmt.Sprintf("%s/%s/_doc/%s", s.baseURL, indexName, docID)
@@ -107,6 +189,372 @@ func (s *ESService) IndexDocument(indexName, docID string, document map[string]i
 	}
 
 	body, err := json.Marshal(document)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return decodeDocumentResult(resp.Body)
+}
+
+// GetDocument retrieves a document by ID
+// GetDocument 根据 ID 获取文档
+func (s *ESService) GetDocument(indexName, docID string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/%s/_doc/%s", s.baseURL, indexName, docID)
+
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, ErrDocumentNotFound
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// UpdateDocument applies a partial update to a document
+// UpdateDocument 对文档进行部分更新
+func (s *ESService) UpdateDocument(indexName, docID string, fields map[string]interface{}) (*model.DocumentResult, error) {
+	url := fmt.Sprintf("%s/%s/_update/%s", s.baseURL, indexName, docID)
+
+	body, err := json.Marshal(map[string]interface{}{"doc": fields})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, ErrDocumentNotFound
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return decodeDocumentResult(resp.Body)
+}
+
+// DeleteDocument deletes a document by ID
+// DeleteDocument 根据 ID 删除文档
+func (s *ESService) DeleteDocument(indexName, docID string) (*model.DocumentResult, error) {
+	url := fmt.Sprintf("%s/%s/_doc/%s", s.baseURL, indexName, docID)
+
+	req, err := http.NewRequest("DELETE", url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, ErrDocumentNotFound
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return decodeDocumentResult(resp.Body)
+}
+
+// BulkIndex indexes many documents in one _bulk request
+// BulkIndex 通过一次 _bulk 请求写入多个文档
+func (s *ESService) BulkIndex(indexName string, docs []model.BulkDocument) (*model.BulkResponse, error) {
+	url := fmt.Sprintf("%s/%s/_bulk", s.baseURL, indexName)
+
+	// _bulk takes an action line followed by the document for every item
+	// _bulk 每个条目由一行操作和一行文档组成
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		action := map[string]interface{}{}
+		if doc.ID != "" {
+			action["_id"] = doc.ID
+		}
+		if err := encoder.Encode(map[string]interface{}{"index": action}); err != nil {
+			return nil, err
+		}
+		if err := encoder.Encode(doc.Document); err != nil {
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequest("POST", url, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Took  int64 `json:"took"`
+		Items []map[string]struct {
+			Index   string `json:"_index"`
+			ID      string `json:"_id"`
+			Result  string `json:"result"`
+			Version int64  `json:"_version"`
+			Error   *struct {
+				Reason string `json:"reason"`
+			} `json:"error"`
+		} `json:"items"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	response := &model.BulkResponse{
+		Took:  result.Took,
+		Items: make([]model.DocumentResult, 0, len(result.Items)),
+	}
+	for _, item := range result.Items {
+		op := item["index"]
+		doc := model.DocumentResult{
+			Index:   op.Index,
+			ID:      op.ID,
+			Result:  op.Result,
+			Version: op.Version,
+		}
+		if op.Error != nil {
+			doc.Error = op.Error.Reason
+			response.Errors++
+		}
+		response.Items = append(response.Items, doc)
+	}
+
+	return response, nil
+}
+
+// DeleteByQuery starts an asynchronous delete-by-query and returns the ES task ID
+// DeleteByQuery 异步执行按查询删除，返回 ES 任务 ID
+func (s *ESService) DeleteByQuery(indexName string, query map[string]interface{}) (string, error) {
+	url := fmt.Sprintf("%s/%s/_delete_by_query?wait_for_completion=false&conflicts=proceed&refresh=true", s.baseURL, indexName)
+
+	body, err := json.Marshal(map[string]interface{}{"query": query})
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Task == "" {
+		return "", fmt.Errorf("ES did not return a task ID")
+	}
+
+	return result.Task, nil
+}
+
+// GetTask retrieves the status of an Elasticsearch task
+// GetTask 获取 Elasticsearch 任务状态
+func (s *ESService) GetTask(taskID string) (map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/_tasks/%s", s.baseURL, taskID)
+
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CancelTask cancels a running Elasticsearch task
+// CancelTask 取消正在运行的 Elasticsearch 任务
+func (s *ESService) CancelTask(taskID string) error {
+	url := fmt.Sprintf("%s/_tasks/%s/_cancel", s.baseURL, taskID)
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// Reindex starts an asynchronous _reindex from source to dest and returns the ES task ID.
+// script is an optional painless script applied to every document.
+// Reindex 异步执行从 source 到 dest 的 _reindex 并返回 ES 任务 ID，script 为可选的 painless 脚本
+func (s *ESService) Reindex(source, dest string, script map[string]interface{}) (string, error) {
+	url := fmt.Sprintf("%s/_reindex?wait_for_completion=false", s.baseURL)
+
+	// Documents keep their source versions and version conflicts are skipped, so running the
+	// same reindex again only copies documents changed since the previous run
+	// 文档保留源版本号并跳过版本冲突，因此再次执行同一重建只会复制上次之后变更的文档
+	payload := map[string]interface{}{
+		"conflicts": "proceed",
+		"source":    map[string]interface{}{"index": source},
+		"dest":      map[string]interface{}{"index": dest, "version_type": "external"},
+	}
+	if script != nil {
+		payload["script"] = script
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return "", err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Task string `json:"task"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+	if result.Task == "" {
+		return "", fmt.Errorf("ES did not return a task ID")
+	}
+
+	return result.Task, nil
+}
+
+// GetAliasIndices returns the indices an alias points to, or nil if the alias does not exist
+// GetAliasIndices 返回别名指向的索引列表，别名不存在时返回 nil
+func (s *ESService) GetAliasIndices(alias string) ([]string, error) {
+	url := fmt.Sprintf("%s/_alias/%s", s.baseURL, alias)
+
+	resp, err := s.httpClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, nil
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result map[string]interface{}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	indices := make([]string, 0, len(result))
+	for index := range result {
+		indices = append(indices, index)
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// UpdateAliases applies a list of _aliases actions atomically
+// UpdateAliases 原子地执行一组 _aliases 操作
+func (s *ESService) UpdateAliases(actions []map[string]interface{}) error {
+	url := fmt.Sprintf("%s/_aliases", s.baseURL)
+
+	body, err := json.Marshal(map[string]interface{}{"actions": actions})
 	if err != nil {
 		return err
 	}
@@ -131,6 +579,148 @@ func (s *ESService) IndexDocument(indexName, docID string, document map[string]i
 	return nil
 }
 
+// UpdateIndexSettings updates dynamic settings of an index
+// UpdateIndexSettings 更新索引的动态设置
+func (s *ESService) UpdateIndexSettings(indexName string, settings map[string]interface{}) error {
+	url := fmt.Sprintf("%s/%s/_settings", s.baseURL, indexName)
+
+	body, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("PUT", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// Refresh refreshes an index so recent writes become searchable
+// Refresh 刷新索引，使最近的写入可被搜索
+func (s *ESService) Refresh(indexName string) error {
+	url := fmt.Sprintf("%s/%s/_refresh", s.baseURL, indexName)
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// TrainIVFIndex asks the IVF plugin to train the coarse quantizer of an index
+// through its REST endpoint POST /{index}/_ivf/train. The call blocks until training finishes.
+// TrainIVFIndex 通过 IVF 插件的 REST 接口（POST /{index}/_ivf/train）训练索引的粗量化器，调用会阻塞直到训练完成
+func (s *ESService) TrainIVFIndex(ctx context.Context, indexName string, params map[string]interface{}) (*model.TrainResult, error) {
+	url := fmt.Sprintf("%s/%s/_ivf/train", s.baseURL, indexName)
+
+	body, err := json.Marshal(params)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.adminClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result model.TrainResult
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	if !result.Success {
+		return &result, fmt.Errorf("IVF training failed: %s", result.Message)
+	}
+
+	return &result, nil
+}
+
+// GetIndexUsage returns the primary document count and store size of an index
+// GetIndexUsage 获取索引主分片的文档数和存储大小
+func (s *ESService) GetIndexUsage(indexName string) (docCount int64, storageBytes int64, err error) {
+	stats, err := s.GetIndexStats(indexName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	all, _ := stats["_all"].(map[string]interface{})
+	primaries, _ := all["primaries"].(map[string]interface{})
+	if primaries == nil {
+		return 0, 0, fmt.Errorf("unexpected stats format for index %s", indexName)
+	}
+
+	if docs, ok := primaries["docs"].(map[string]interface{}); ok {
+		if count, ok := docs["count"].(float64); ok {
+			docCount = int64(count)
+		}
+	}
+	if store, ok := primaries["store"].(map[string]interface{}); ok {
+		if size, ok := store["size_in_bytes"].(float64); ok {
+			storageBytes = int64(size)
+		}
+	}
+
+	return docCount, storageBytes, nil
+}
+
+// decodeDocumentResult decodes a single document write response
+// decodeDocumentResult 解析单个文档写操作的响应
+func decodeDocumentResult(r io.Reader) (*model.DocumentResult, error) {
+	var result struct {
+		Index   string `json:"_index"`
+		ID      string `json:"_id"`
+		Result  string `json:"result"`
+		Version int64  `json:"_version"`
+	}
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	return &model.DocumentResult{
+		Index:   result.Index,
+		ID:      result.ID,
+		Result:  result.Result,
+		Version: result.Version,
+	}, nil
+}
+
 // ListIndexes lists all indexes (simple implementation)
 // ListIndexes 列出所有索引（简单实现）
 func (s *ESService) ListIndexes() ([]string, error) {
@@ -170,6 +760,9 @@ func (s *ESService) ListIndexes() ([]string, error) {
 // Search 执行 Elasticsearch 搜索查询
 func (s *ESService) Search(indexName string, query map[string]interface{}) (map[string]interface{}, error) {
 	url := fmt.Sprintf("%s/%s/_search", s.baseURL, indexName)
+	if indexName == "" {
+		url = fmt.Sprintf("%s/_search", s.baseURL)
+	}
 
 	body, err := json.Marshal(query)
 	if err != nil {
@@ -201,6 +794,121 @@ func (s *ESService) Search(indexName string, query map[string]interface{}) (map[
 	return result, nil
 }
 
+// Count returns the number of documents matching a query
+// Count 返回匹配查询条件的文档数
+func (s *ESService) Count(indexName string, query map[string]interface{}) (int64, error) {
+	url := fmt.Sprintf("%s/%s/_count", s.baseURL, indexName)
+
+	payload := map[string]interface{}{}
+	if len(query) > 0 {
+		payload["query"] = query
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewBuffer(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return 0, fmt.Errorf("%w: %s", ErrIndexNotFound, indexName)
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return 0, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Count int64 `json:"count"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return 0, err
+	}
+
+	return result.Count, nil
+}
+
+// OpenPointInTime opens a point-in-time on an index and returns its ID
+// OpenPointInTime 在索引上打开一个 point-in-time 并返回其 ID
+func (s *ESService) OpenPointInTime(indexName, keepAlive string) (string, error) {
+	url := fmt.Sprintf("%s/%s/_pit?keep_alive=%s", s.baseURL, indexName, keepAlive)
+
+	req, err := http.NewRequest("POST", url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return "", fmt.Errorf("%w: %s", ErrIndexNotFound, indexName)
+	}
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		ID string `json:"id"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return "", err
+	}
+
+	return result.ID, nil
+}
+
+// ClosePointInTime closes a point-in-time
+// ClosePointInTime 关闭 point-in-time
+func (s *ESService) ClosePointInTime(pitID string) error {
+	url := fmt.Sprintf("%s/_pit", s.baseURL)
+
+	body, err := json.Marshal(map[string]interface{}{"id": pitID})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("DELETE", url, bytes.NewBuffer(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 && resp.StatusCode != 404 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	return nil
+}
+
+// SearchPointInTime runs a search against an open point-in-time.
+// The body must contain a "pit" section, so no index is given in the URL.
+// SearchPointInTime 在已打开的 point-in-time 上执行搜索，请求体需包含 "pit"，因此 URL 中不带索引
+func (s *ESService) SearchPointInTime(body map[string]interface{}) (map[string]interface{}, error) {
+	return s.Search("", body)
+}
+
 // GetIndexStats gets statistics for an index
 // GetIndexStats 获取索引统计信息
 func (s *ESService) GetIndexStats(indexName string) (map[string]interface{}, error) {
@@ -224,3 +932,189 @@ func (s *ESService) GetIndexStats(indexName string) (map[string]interface{}, err
 
 	return result, nil
 }
+
+// MultiSearch runs several search bodies against an index in one _msearch request
+// MultiSearch 通过一次 _msearch 请求对索引执行多个查询
+func (s *ESService) MultiSearch(indexName string, queries []map[string]interface{}) ([]map[string]interface{}, error) {
+	url := fmt.Sprintf("%s/%s/_msearch", s.baseURL, indexName)
+
+	// Build the NDJSON body: an empty header line followed by the query body
+	// 构建 NDJSON 请求体：每个查询前是一个空的头部行
+	var buf bytes.Buffer
+	for _, query := range queries {
+		body, err := json.Marshal(query)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("{}\n")
+		buf.Write(body)
+		buf.WriteByte('\n')
+	}
+
+	req, err := http.NewRequest("POST", url, &buf)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("ES request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+
+	var result struct {
+		Responses []map[string]interface{} `json:"responses"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+
+	if len(result.Responses) != len(queries) {
+		return nil, fmt.Errorf("ES _msearch returned %d responses for %d queries", len(result.Responses), len(queries))
+	}
+
+	return result.Responses, nil
+}
+
+// BatchSearch runs many kNN queries through _msearch with bounded concurrency
+// BatchSearch 通过 _msearch 以有限并发执行大量 kNN 查询，结果按请求顺序返回
+func (s *ESService) BatchSearch(indexName string, req model.BatchSearchRequest) *model.BatchSearchResponse {
+	start := time.Now()
+
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultBatchSearchSize
+	}
+	concurrency := req.Concurrency
+	if concurrency <= 0 {
+		concurrency = defaultBatchSearchConcurrency
+	}
+	if concurrency > maxBatchSearchConcurrency {
+		concurrency = maxBatchSearchConcurrency
+	}
+
+	queries, rescores := buildBatchSearchQueries(req)
+	results := make([]model.BatchSearchResult, len(queries))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+
+	for offset := 0; offset < len(queries); offset += batchSize {
+		end := offset + batchSize
+		if end > len(queries) {
+			end = len(queries)
+		}
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(offset, end int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			responses, err := s.MultiSearch(indexName, queries[offset:end])
+			for i := offset; i < end; i++ {
+				results[i].Index = i
+				if err != nil {
+					results[i].Error = err.Error()
+					continue
+				}
+				rescores[i].Apply(responses[i-offset])
+				fillBatchSearchResult(&results[i], responses[i-offset])
+			}
+		}(offset, end)
+	}
+	wg.Wait()
+
+	return newBatchSearchResponse(results, time.Since(start))
+}
+
+// buildBatchSearchQueries builds one kNN search body per query vector, together with the
+// rescoring of oversampled queries (nil entries when there is nothing to rescore)
+// buildBatchSearchQueries 为每个查询向量构建一个 kNN 搜索请求体，并返回过采样查询的重打分（无需重打分时为 nil）
+func buildBatchSearchQueries(req model.BatchSearchRequest) ([]map[string]interface{}, []*KNNRescore) {
+	field := req.Field
+	if field == "" {
+		field = "vector"
+	}
+	k := req.K
+	if k <= 0 {
+		k = 10
+	}
+	numCandidates := req.NumCandidates
+	if numCandidates < k {
+		numCandidates = k * 10
+	}
+
+	queries := make([]map[string]interface{}, len(req.Vectors))
+	rescores := make([]*KNNRescore, len(req.Vectors))
+	for i, vector := range req.Vectors {
+		knn := map[string]interface{}{
+			"field":          field,
+			"query_vector":   vector,
+			"k":              k,
+			"num_candidates": numCandidates,
+		}
+		if len(req.Filter) > 0 {
+			knn["filter"] = req.Filter
+		}
+		query := map[string]interface{}{
+			"knn":  knn,
+			"size": k,
+		}
+		if len(req.Source) > 0 {
+			query["_source"] = req.Source
+		}
+		rescores[i] = OversampleKNNQuery(query, req.Metric, req.Oversample)
+		queries[i] = query
+	}
+	return queries, rescores
+}
+
+// newBatchSearchResponse summarizes the per-query results of a batch search
+// newBatchSearchResponse 汇总批量搜索中每个查询的结果
+func newBatchSearchResponse(results []model.BatchSearchResult, took time.Duration) *model.BatchSearchResponse {
+	response := &model.BatchSearchResponse{
+		Took:    took.Milliseconds(),
+		Total:   len(results),
+		Results: results,
+	}
+	for _, result := range results {
+		if result.Error != "" {
+			response.Errors++
+		}
+	}
+	return response
+}
+
+// fillBatchSearchResult copies hits or the per-query error from an _msearch response item
+// fillBatchSearchResult 从 _msearch 的单个响应中提取命中结果或错误信息
+func fillBatchSearchResult(result *model.BatchSearchResult, response map[string]interface{}) {
+	if errObj, ok := response["error"]; ok {
+		if errMap, ok := errObj.(map[string]interface{}); ok {
+			if reason, ok := errMap["reason"].(string); ok {
+				result.Error = reason
+				return
+			}
+		}
+		result.Error = fmt.Sprintf("%v", errObj)
+		return
+	}
+
+	if took, ok := response["took"].(float64); ok {
+		result.Took = int(took)
+	}
+
+	result.Hits = []map[string]interface{}{}
+	hits, _ := response["hits"].(map[string]interface{})
+	hitList, _ := hits["hits"].([]interface{})
+	for _, hit := range hitList {
+		if hitMap, ok := hit.(map[string]interface{}); ok {
+			result.Hits = append(result.Hits, hitMap)
+		}
+	}
+}
@@ -0,0 +1,435 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// DefaultTenantESURLTemplate addresses the Elasticsearch service that the tenant chart
+// creates in every tenant namespace; {namespace} is replaced by the namespace
+// DefaultTenantESURLTemplate 租户 Chart 在每个租户命名空间中创建的 Elasticsearch 服务地址，
+// {namespace} 会被替换为命名空间
+const DefaultTenantESURLTemplate = "http://elasticsearch.{namespace}.svc.cluster.local:9200"
+
+// SearchStats represents the search rates and latencies of a tenant cluster over
+// the interval between two collections
+// SearchStats 租户集群在两次采集之间的搜索速率与延迟
+type SearchStats struct {
+	QPS          float64
+	PluginQPS    float64
+	AvgLatencyMs float64
+	P95LatencyMs float64
+	P99LatencyMs float64
+	Indices      map[string]model.IndexSearchMetrics
+	// Per node JVM heap and rates keyed by node name, which is the pod name
+	// 以节点名称（即 Pod 名称）为键的节点 JVM 堆与速率
+	Nodes map[string]NodeStats
+	// Interval is zero on the first collection of a namespace, when no rate can be computed yet
+	// 命名空间首次采集时 Interval 为零，此时尚无法计算速率
+	Interval time.Duration
+}
+
+// ESStatsCollector computes search rates of tenant clusters from the deltas of
+// Elasticsearch search counters between collection cycles
+// ESStatsCollector 根据采集周期之间 Elasticsearch 搜索计数器的差值计算租户集群的搜索速率
+type ESStatsCollector struct {
+	urlTemplate string
+	httpClient  *http.Client
+	// Counters of the previous collection for each namespace
+	// 每个命名空间上一次采集的计数器
+	previous map[string]*esStatsSnapshot
+	mu       sync.Mutex
+}
+
+// NodeStats represents the JVM heap, disk and the search and indexing rates of one node
+// NodeStats 单个节点的 JVM 堆、磁盘以及搜索、写入速率
+type NodeStats struct {
+	HeapUsedBytes int64
+	HeapMaxBytes  int64
+	// Usage of the data paths, which are the persistent volume of the pod
+	// 数据路径（即 Pod 的持久卷）的使用量
+	DiskUsedBytes  int64
+	DiskTotalBytes int64
+	// Shard level queries and indexed documents per second
+	// 每秒分片级查询数与写入文档数
+	SearchRate   float64
+	IndexingRate float64
+}
+
+// esStatsSnapshot holds the raw counters of one collection
+// esStatsSnapshot 一次采集的原始计数器
+type esStatsSnapshot struct {
+	at time.Time
+	// Counters keyed by node id
+	// 以节点 ID 为键的计数器
+	nodes map[string]esSearchCounter
+	// Indexed document counters keyed by node id
+	// 以节点 ID 为键的写入文档计数器
+	nodeIndexing map[string]int64
+	// Counters keyed by index/shard/node/primary
+	// 以 索引/分片/节点/主副 为键的计数器
+	shards map[string]esSearchCounter
+	// Whether an index is served by the IVF plugin, probed once per index
+	// 索引是否由 IVF 插件提供服务，每个索引仅探测一次
+	ivf map[string]bool
+}
+
+// esSearchCounter is a pair of cumulative search counters
+// esSearchCounter 一组累计搜索计数器
+type esSearchCounter struct {
+	QueryTotal  int64 `json:"query_total"`
+	QueryTimeMs int64 `json:"query_time_in_millis"`
+}
+
+// NewESStatsCollector creates a collector; urlTemplate may contain {namespace}
+// NewESStatsCollector 创建采集器，urlTemplate 中可包含 {namespace}
+func NewESStatsCollector(urlTemplate string) *ESStatsCollector {
+	if urlTemplate == "" {
+		urlTemplate = DefaultTenantESURLTemplate
+	}
+	return &ESStatsCollector{
+		urlTemplate: strings.TrimRight(urlTemplate, "/"),
+		httpClient: &http.Client{
+			Timeout: 10 * time.Second,
+		},
+		previous: make(map[string]*esStatsSnapshot),
+	}
+}
+
+// Collect reads the node and shard search counters of a tenant cluster and returns
+// the rates since the previous collection of the same namespace
+// Collect 读取租户集群的节点与分片搜索计数器，并返回自该命名空间上次采集以来的速率
+func (c *ESStatsCollector) Collect(ctx context.Context, namespace string) (*SearchStats, error) {
+	baseURL := strings.ReplaceAll(c.urlTemplate, "{namespace}", namespace)
+
+	var nodeStats struct {
+		Nodes map[string]struct {
+			Name    string `json:"name"`
+			Indices struct {
+				Search   esSearchCounter `json:"search"`
+				Indexing struct {
+					IndexTotal int64 `json:"index_total"`
+				} `json:"indexing"`
+			} `json:"indices"`
+			JVM struct {
+				Mem struct {
+					HeapUsedBytes int64 `json:"heap_used_in_bytes"`
+					HeapMaxBytes  int64 `json:"heap_max_in_bytes"`
+				} `json:"mem"`
+			} `json:"jvm"`
+			FS struct {
+				Total struct {
+					TotalBytes int64 `json:"total_in_bytes"`
+					FreeBytes  int64 `json:"free_in_bytes"`
+				} `json:"total"`
+			} `json:"fs"`
+		} `json:"nodes"`
+	}
+	if err := c.getJSON(ctx, baseURL+"/_nodes/stats/jvm,fs,indices/search,indexing", &nodeStats); err != nil {
+		return nil, fmt.Errorf("failed to read node stats: %w", err)
+	}
+
+	var indexStats struct {
+		Indices map[string]struct {
+			Shards map[string][]struct {
+				Routing struct {
+					Node    string `json:"node"`
+					Primary bool   `json:"primary"`
+				} `json:"routing"`
+				Search esSearchCounter `json:"search"`
+			} `json:"shards"`
+		} `json:"indices"`
+	}
+	if err := c.getJSON(ctx, baseURL+"/_stats/search?level=shards", &indexStats); err != nil {
+		return nil, fmt.Errorf("failed to read index stats: %w", err)
+	}
+
+	c.mu.Lock()
+	prev := c.previous[namespace]
+	c.mu.Unlock()
+
+	current := &esStatsSnapshot{
+		at:           time.Now(),
+		nodes:        make(map[string]esSearchCounter),
+		nodeIndexing: make(map[string]int64),
+		shards:       make(map[string]esSearchCounter),
+		ivf:          make(map[string]bool),
+	}
+	for id, node := range nodeStats.Nodes {
+		current.nodes[id] = node.Indices.Search
+		current.nodeIndexing[id] = node.Indices.Indexing.IndexTotal
+	}
+
+	stats := &SearchStats{
+		Indices: make(map[string]model.IndexSearchMetrics),
+		Nodes:   make(map[string]NodeStats),
+	}
+	if prev != nil {
+		stats.Interval = current.at.Sub(prev.at)
+	}
+	seconds := stats.Interval.Seconds()
+
+	for id, node := range nodeStats.Nodes {
+		nodeStat := NodeStats{
+			HeapUsedBytes:  node.JVM.Mem.HeapUsedBytes,
+			HeapMaxBytes:   node.JVM.Mem.HeapMaxBytes,
+			DiskUsedBytes:  node.FS.Total.TotalBytes - node.FS.Total.FreeBytes,
+			DiskTotalBytes: node.FS.Total.TotalBytes,
+		}
+		if seconds > 0 {
+			queries, _ := counterDelta(prev.nodes[id], current.nodes[id])
+			nodeStat.SearchRate = float64(queries) / seconds
+			indexed := current.nodeIndexing[id] - prev.nodeIndexing[id]
+			if indexed < 0 {
+				indexed = current.nodeIndexing[id]
+			}
+			nodeStat.IndexingRate = float64(indexed) / seconds
+		}
+		stats.Nodes[node.Name] = nodeStat
+	}
+
+	// Cluster average latency comes from the node counters
+	// 集群平均延迟来自节点计数器
+	var clusterQueries, clusterTimeMs int64
+	for id, counter := range current.nodes {
+		if prev != nil {
+			queries, timeMs := counterDelta(prev.nodes[id], counter)
+			clusterQueries += queries
+			clusterTimeMs += timeMs
+		}
+	}
+	if clusterQueries > 0 {
+		stats.AvgLatencyMs = float64(clusterTimeMs) / float64(clusterQueries)
+	}
+
+	// Every search request queries one copy of each primary shard, so the request
+	// rate of an index is its shard query rate divided by its primary count
+	// 每个搜索请求查询每个主分片的一个副本，因此索引的请求速率等于分片查询速率除以主分片数
+	var clusterSamples []weightedSample
+	for index, entry := range indexStats.Indices {
+		metrics := model.IndexSearchMetrics{}
+		var queries, timeMs int64
+		var samples []weightedSample
+		for shardID, copies := range entry.Shards {
+			for _, shard := range copies {
+				key := fmt.Sprintf("%s/%s/%s/%t", index, shardID, shard.Routing.Node, shard.Routing.Primary)
+				current.shards[key] = shard.Search
+				metrics.QueryTotal += shard.Search.QueryTotal
+				if prev == nil {
+					continue
+				}
+				q, t := counterDelta(prev.shards[key], shard.Search)
+				queries += q
+				timeMs += t
+				if q > 0 {
+					samples = append(samples, weightedSample{value: float64(t) / float64(q), weight: q})
+				}
+			}
+		}
+
+		if seconds > 0 && len(entry.Shards) > 0 {
+			metrics.QPS = float64(queries) / float64(len(entry.Shards)) / seconds
+		}
+		if queries > 0 {
+			metrics.AvgLatencyMs = float64(timeMs) / float64(queries)
+		}
+		metrics.P95LatencyMs = weightedPercentile(samples, 0.95)
+		metrics.P99LatencyMs = weightedPercentile(samples, 0.99)
+		clusterSamples = append(clusterSamples, samples...)
+
+		if !strings.HasPrefix(index, ".") {
+			isIVF, probed := false, false
+			if prev != nil {
+				isIVF, probed = prev.ivf[index]
+			}
+			if !probed || isIVF {
+				// A failed probe is retried on the next collection
+				// 探测失败时在下一次采集重试
+				if plugin, err := c.pluginStats(ctx, baseURL, index); err == nil {
+					isIVF, probed = plugin != nil, true
+					metrics.Plugin = plugin
+				}
+			}
+			if probed {
+				current.ivf[index] = isIVF
+			}
+			if isIVF {
+				stats.PluginQPS += metrics.QPS
+			}
+		}
+
+		stats.QPS += metrics.QPS
+		stats.Indices[index] = metrics
+	}
+	stats.P95LatencyMs = weightedPercentile(clusterSamples, 0.95)
+	stats.P99LatencyMs = weightedPercentile(clusterSamples, 0.99)
+
+	c.mu.Lock()
+	c.previous[namespace] = current
+	c.mu.Unlock()
+	return stats, nil
+}
+
+// Retain drops the counters of namespaces that are no longer monitored
+// Retain 删除不再被监控的命名空间的计数器
+func (c *ESStatsCollector) Retain(namespaces []string) {
+	keep := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		keep[ns] = true
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for ns := range c.previous {
+		if !keep[ns] {
+			delete(c.previous, ns)
+		}
+	}
+}
+
+// ClusterHealth reads _cluster/health of a tenant cluster
+// ClusterHealth 读取租户集群的 _cluster/health
+func (c *ESStatsCollector) ClusterHealth(ctx context.Context, namespace string) (*model.ESClusterHealth, error) {
+	baseURL := strings.ReplaceAll(c.urlTemplate, "{namespace}", namespace)
+	var health model.ESClusterHealth
+	if err := c.getJSON(ctx, baseURL+"/_cluster/health", &health); err != nil {
+		return nil, fmt.Errorf("failed to read cluster health: %v", err)
+	}
+	return &health, nil
+}
+
+// Shards reads _cat/shards of a tenant cluster
+// Shards 读取租户集群的 _cat/shards
+func (c *ESStatsCollector) Shards(ctx context.Context, namespace string) ([]model.ESShard, error) {
+	baseURL := strings.ReplaceAll(c.urlTemplate, "{namespace}", namespace)
+	var shards []model.ESShard
+	if err := c.getJSON(ctx, baseURL+"/_cat/shards?format=json&h=index,shard,prirep,state,node,unassigned.reason", &shards); err != nil {
+		return nil, fmt.Errorf("failed to read shards: %v", err)
+	}
+	return shards, nil
+}
+
+// pluginStats reads the IVF plugin counters of an index. A nil result without
+// error means the index is not served by the plugin.
+// pluginStats 读取索引的 IVF 插件计数器，返回 nil 且无错误表示该索引不由插件提供服务
+func (c *ESStatsCollector) pluginStats(ctx context.Context, baseURL, index string) (*model.IVFPluginStats, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", fmt.Sprintf("%s/%s/_ivf/stats", baseURL, index), nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 500 {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("IVF stats request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	// The plugin answers 404 for indices without an IVF index; clusters without
+	// the plugin reject the unknown route with another 4xx
+	// 插件对没有 IVF 索引的索引返回 404；未安装插件的集群以其他 4xx 拒绝未知路由
+	if resp.StatusCode >= 400 {
+		io.Copy(io.Discard, resp.Body)
+		return nil, nil
+	}
+
+	var raw struct {
+		NList          int     `json:"nlist"`
+		Dimension      int     `json:"dimension"`
+		MetricType     string  `json:"metricType"`
+		IsTrained      bool    `json:"isTrained"`
+		TotalVectors   int64   `json:"totalVectors"`
+		MinClusterSize int64   `json:"minClusterSize"`
+		MaxClusterSize int64   `json:"maxClusterSize"`
+		AvgClusterSize float64 `json:"avgClusterSize"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, err
+	}
+	return &model.IVFPluginStats{
+		NList:          raw.NList,
+		Dimension:      raw.Dimension,
+		MetricType:     raw.MetricType,
+		IsTrained:      raw.IsTrained,
+		TotalVectors:   raw.TotalVectors,
+		MinClusterSize: raw.MinClusterSize,
+		MaxClusterSize: raw.MaxClusterSize,
+		AvgClusterSize: raw.AvgClusterSize,
+	}, nil
+}
+
+// getJSON performs a GET request and decodes the JSON response
+// getJSON 执行 GET 请求并解析 JSON 响应
+func (c *ESStatsCollector) getJSON(ctx context.Context, url string, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 400 {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("request failed with status %d: %s", resp.StatusCode, string(body))
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
+
+// counterDelta returns the increase of a counter pair; a counter that went
+// backwards was reset by a node restart and counts from zero
+// counterDelta 返回计数器的增量；计数器回退说明节点重启导致重置，此时从零开始计算
+func counterDelta(prev, cur esSearchCounter) (queries, timeMs int64) {
+	if cur.QueryTotal < prev.QueryTotal || cur.QueryTimeMs < prev.QueryTimeMs {
+		return cur.QueryTotal, cur.QueryTimeMs
+	}
+	return cur.QueryTotal - prev.QueryTotal, cur.QueryTimeMs - prev.QueryTimeMs
+}
+
+// weightedSample is a value weighted by the number of observations it stands for,
+// such as the mean latency of one shard copy weighted by the queries it served
+// weightedSample 按其代表的观测数加权的数值，例如以分片副本处理的查询数加权的平均延迟
+type weightedSample struct {
+	value  float64
+	weight int64
+}
+
+// weightedPercentile returns the nearest rank percentile of weighted samples. Search
+// latency percentiles use per shard mean latencies because Elasticsearch exposes no
+// latency histogram, so they are the tail across shard copies rather than across
+// individual requests.
+// weightedPercentile 返回加权样本的最近秩分位数。由于 Elasticsearch 不提供延迟直方图，
+// 搜索延迟分位数基于各分片的平均延迟计算，因此是分片副本之间的尾部延迟，而非单个请求的尾部延迟
+func weightedPercentile(samples []weightedSample, p float64) float64 {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]weightedSample(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].value < sorted[j].value })
+
+	var total int64
+	for _, s := range sorted {
+		total += s.weight
+	}
+	threshold := p * float64(total)
+	var cumulative int64
+	for _, s := range sorted {
+		cumulative += s.weight
+		if float64(cumulative) >= threshold {
+			return s.value
+		}
+	}
+	return sorted[len(sorted)-1].value
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// exportKeepAlive is how long ES keeps the point-in-time alive between pages
+	// exportKeepAlive 两次翻页之间 ES 保留 point-in-time 的时间
+	exportKeepAlive = "2m"
+	// defaultExportBatchSize is the default number of documents per page
+	// defaultExportBatchSize 默认每页文档数
+	defaultExportBatchSize = 1000
+	// maxExportBatchSize caps the page size a caller can request
+	// maxExportBatchSize 调用方可请求的最大每页文档数
+	maxExportBatchSize = 10000
+)
+
+// ExportService exports vector indexes as NDJSON using point-in-time and search_after
+// ExportService 使用 point-in-time 和 search_after 将向量索引导出为 NDJSON
+type ExportService struct {
+	store       VectorStore
+	taskService *TaskService
+	exportDir   string
+}
+
+// NewExportService creates a new export service
+// NewExportService 创建一个新的导出服务
+func NewExportService(store VectorStore, taskService *TaskService, exportDir string) *ExportService {
+	return &ExportService{
+		store:       store,
+		taskService: taskService,
+		exportDir:   exportDir,
+	}
+}
+
+// ExportCursor is an export whose documents are counted and whose point-in-time is open,
+// so failures to start it can be reported before any output is written
+// ExportCursor 已统计文档数并打开 point-in-time 的导出，使启动失败能在写出任何内容之前报告
+type ExportCursor struct {
+	store     VectorStore
+	req       model.ExportRequest
+	batchSize int
+	total     int64
+	pitID     string
+}
+
+// OpenExport counts the matching documents of an index and opens a point-in-time on it.
+// The caller must Close the returned cursor.
+// OpenExport 统计索引中匹配的文档数并在其上打开 point-in-time，调用方必须关闭返回的游标
+func (e *ExportService) OpenExport(indexName string, req model.ExportRequest) (*ExportCursor, error) {
+	batchSize := req.BatchSize
+	if batchSize <= 0 {
+		batchSize = defaultExportBatchSize
+	}
+	if batchSize > maxExportBatchSize {
+		batchSize = maxExportBatchSize
+	}
+
+	total, err := e.store.Count(indexName, req.Query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count documents: %w", err)
+	}
+
+	pitID, err := e.store.OpenPointInTime(indexName, exportKeepAlive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open point-in-time: %w", err)
+	}
+
+	return &ExportCursor{
+		store:     e.store,
+		req:       req,
+		batchSize: batchSize,
+		total:     total,
+		pitID:     pitID,
+	}, nil
+}
+
+// Export streams every matching document of an index to w as NDJSON lines of
+// the form {"_id": ..., "_source": {...}}. It returns the number of documents written.
+// Export 将索引中所有匹配的文档以 {"_id": ..., "_source": {...}} 形式的 NDJSON 行写入 w，返回写入的文档数
+func (e *ExportService) Export(ctx context.Context, indexName string, req model.ExportRequest, w io.Writer, progress func(processed, total int64)) (int64, error) {
+	cursor, err := e.OpenExport(indexName, req)
+	if err != nil {
+		return 0, err
+	}
+	defer cursor.Close()
+	return cursor.WriteTo(ctx, w, progress)
+}
+
+// Close closes the point-in-time of the export
+// Close 关闭导出的 point-in-time
+func (c *ExportCursor) Close() error {
+	// The PIT ID may have changed while paging, always close the latest one
+	// 翻页过程中 PIT ID 可能变化，始终关闭最新的 ID
+	return c.store.ClosePointInTime(c.pitID)
+}
+
+// WriteTo pages through the point-in-time and writes every document to w as NDJSON.
+// It returns the number of documents written.
+// WriteTo 在 point-in-time 上翻页并将每个文档以 NDJSON 写入 w，返回写入的文档数
+func (c *ExportCursor) WriteTo(ctx context.Context, w io.Writer, progress func(processed, total int64)) (int64, error) {
+	req := c.req
+	var gz *gzip.Writer
+	out := w
+	if req.Gzip {
+		gz = gzip.NewWriter(w)
+		defer gz.Close()
+		out = gz
+	}
+	encoder := json.NewEncoder(out)
+
+	var written int64
+	var searchAfter interface{}
+	for {
+		if err := ctx.Err(); err != nil {
+			return written, err
+		}
+
+		body := map[string]interface{}{
+			"size":             c.batchSize,
+			"pit":              map[string]interface{}{"id": c.pitID, "keep_alive": exportKeepAlive},
+			"sort":             []interface{}{map[string]interface{}{"_shard_doc": "asc"}},
+			"track_total_hits": false,
+		}
+		if len(req.Query) > 0 {
+			body["query"] = req.Query
+		}
+		if len(req.Fields) > 0 {
+			body["_source"] = req.Fields
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+
+		result, err := c.store.SearchPointInTime(body)
+		if err != nil {
+			return written, err
+		}
+		if newID, ok := result["pit_id"].(string); ok && newID != "" {
+			c.pitID = newID
+		}
+
+		hits, _ := result["hits"].(map[string]interface{})
+		hitList, _ := hits["hits"].([]interface{})
+		if len(hitList) == 0 {
+			break
+		}
+
+		for _, item := range hitList {
+			hit, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			line := map[string]interface{}{
+				"_id":     hit["_id"],
+				"_source": hit["_source"],
+			}
+			if err := encoder.Encode(line); err != nil {
+				return written, err
+			}
+			written++
+			searchAfter = hit["sort"]
+		}
+
+		// Push the page to the client so large exports stream continuously
+		// 将本页数据推送给客户端，使大导出能够持续流式输出
+		if gz != nil {
+			gz.Flush()
+		}
+		if flusher, ok := w.(http.Flusher); ok {
+			flusher.Flush()
+		}
+		if progress != nil {
+			progress(written, c.total)
+		}
+
+		if len(hitList) < c.batchSize {
+			break
+		}
+	}
+
+	return written, nil
+}
+
+// StartExportJob exports an index into a file under the export directory as a tracked task
+// StartExportJob 以可跟踪任务的方式将索引导出到导出目录下的文件
+func (e *ExportService) StartExportJob(indexName string, req model.ExportRequest) (*model.OperationTask, error) {
+	if err := os.MkdirAll(e.exportDir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create export directory: %w", err)
+	}
+
+	task, err := e.taskService.CreateTask("export", indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	fileName := fmt.Sprintf("%s-%s.ndjson", indexName, time.Now().Format("20060102-150405"))
+	if req.Gzip {
+		fileName += ".gz"
+	}
+	path := filepath.Join(e.exportDir, fileName)
+
+	e.taskService.RunTask(task, func(ctx context.Context, progress func(processed, total int64)) (map[string]interface{}, error) {
+		f, err := os.Create(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create export file: %w", err)
+		}
+
+		buf := bufio.NewWriter(f)
+		written, err := e.Export(ctx, indexName, req, buf, progress)
+		if flushErr := buf.Flush(); err == nil {
+			err = flushErr
+		}
+		if closeErr := f.Close(); err == nil {
+			err = closeErr
+		}
+
+		result := map[string]interface{}{
+			"file":      path,
+			"documents": written,
+		}
+		if err != nil {
+			// Do not leave partial exports behind
+			// 不保留不完整的导出文件
+			os.Remove(path)
+			return result, err
+		}
+		if info, statErr := os.Stat(path); statErr == nil {
+			result["bytes"] = info.Size()
+		}
+		return result, nil
+	})
+
+	return task, nil
+}
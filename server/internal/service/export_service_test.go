@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+	"testing"
+
+	"es-serverless-manager/internal/model"
+)
+
+// exportedIDs decodes NDJSON export lines and returns their document IDs in order
+func exportedIDs(t *testing.T, r io.Reader) []string {
+	t.Helper()
+
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line struct {
+			ID     string                 `json:"_id"`
+			Source map[string]interface{} `json:"_source"`
+		}
+		if err := json.Unmarshal(scanner.Bytes(), &line); err != nil {
+			t.Fatalf("invalid NDJSON line %q: %v", scanner.Text(), err)
+		}
+		if line.Source == nil {
+			t.Fatalf("line %q has no _source", scanner.Text())
+		}
+		ids = append(ids, line.ID)
+	}
+	if err := scanner.Err(); err != nil {
+		t.Fatalf("reading export: %v", err)
+	}
+	return ids
+}
+
+func TestExportServiceExportPagesThroughTheIndex(t *testing.T) {
+	docs := map[string][]float64{"a": {1, 0}, "b": {2, 0}, "c": {3, 0}, "d": {4, 0}, "e": {5, 0}}
+	store := newTestStore(t, "export", "l2", docs)
+	exportService := NewExportService(store, nil, t.TempDir())
+
+	var buf bytes.Buffer
+	var lastProcessed, lastTotal int64
+	written, err := exportService.Export(context.Background(), "export", model.ExportRequest{BatchSize: 2}, &buf, func(processed, total int64) {
+		lastProcessed, lastTotal = processed, total
+	})
+	if err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+	if written != 5 || lastProcessed != 5 || lastTotal != 5 {
+		t.Fatalf("written = %d, progress = %d/%d, want 5 and 5/5", written, lastProcessed, lastTotal)
+	}
+
+	ids := exportedIDs(t, &buf)
+	sort.Strings(ids)
+	if len(ids) != 5 || ids[0] != "a" || ids[4] != "e" {
+		t.Fatalf("exported ids = %v, want a..e", ids)
+	}
+}
+
+func TestExportServiceExportFiltersAndCompresses(t *testing.T) {
+	docs := map[string][]float64{"a": {1, 0}, "b": {2, 0}, "c": {3, 0}}
+	store := newTestStore(t, "export", "l2", docs)
+	exportService := NewExportService(store, nil, t.TempDir())
+
+	var buf bytes.Buffer
+	req := model.ExportRequest{
+		Gzip:   true,
+		Fields: []string{"category"},
+		Query:  map[string]interface{}{"term": map[string]interface{}{"category": "c1"}},
+	}
+	if _, err := exportService.Export(context.Background(), "export", req, &buf, nil); err != nil {
+		t.Fatalf("Export: %v", err)
+	}
+
+	gz, err := gzip.NewReader(&buf)
+	if err != nil {
+		t.Fatalf("gzip.NewReader: %v", err)
+	}
+	ids := exportedIDs(t, gz)
+	sort.Strings(ids)
+	if len(ids) != 2 || ids[0] != "a" || ids[1] != "c" {
+		t.Fatalf("exported ids = %v, want [a c]", ids)
+	}
+}
+
+func TestExportServiceOpenExportReportsMissingIndex(t *testing.T) {
+	exportService := NewExportService(NewMemoryStore(), nil, t.TempDir())
+
+	if _, err := exportService.OpenExport("missing", model.ExportRequest{}); !errors.Is(err, ErrIndexNotFound) {
+		t.Fatalf("OpenExport on missing index: err = %v, want ErrIndexNotFound", err)
+	}
+}
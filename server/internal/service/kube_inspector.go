@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/clientcmd"
+	metricsclient "k8s.io/metrics/pkg/client/clientset/versioned"
+)
+
+// KubeInspector is a ClusterInspector backed by client-go and the metrics.k8s.io API
+// KubeInspector 基于 client-go 与 metrics.k8s.io API 的 ClusterInspector
+type KubeInspector struct {
+	client  kubernetes.Interface
+	metrics metricsclient.Interface
+}
+
+// NewKubeInspector creates an inspector from a Kubernetes and a metrics clientset
+// NewKubeInspector 根据 Kubernetes 与 metrics 客户端创建检查器
+func NewKubeInspector(client kubernetes.Interface, metrics metricsclient.Interface) *KubeInspector {
+	return &KubeInspector{
+		client:  client,
+		metrics: metrics,
+	}
+}
+
+// NewKubeInspectorFromEnv uses the in-cluster service account when running in a pod,
+// and the kubeconfig (KUBECONFIG or ~/.kube/config) otherwise
+// NewKubeInspectorFromEnv 在 Pod 中运行时使用集群内服务账号，否则使用 kubeconfig（KUBECONFIG 或 ~/.kube/config）
+func NewKubeInspectorFromEnv() (*KubeInspector, error) {
+	config, err := rest.InClusterConfig()
+	if errors.Is(err, rest.ErrNotInCluster) {
+		loader := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+			clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+		config, err = loader.ClientConfig()
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to load Kubernetes config: %v", err)
+	}
+	config.Timeout = 30 * time.Second
+
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %v", err)
+	}
+	metrics, err := metricsclient.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create metrics client: %v", err)
+	}
+	return NewKubeInspector(client, metrics), nil
+}
+
+func (k *KubeInspector) ListClusterNamespaces(ctx context.Context) ([]string, error) {
+	list, err := k.client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{LabelSelector: esClusterLabelSelector})
+	if err != nil {
+		return nil, err
+	}
+
+	namespaces := make([]string, 0, len(list.Items))
+	for _, item := range list.Items {
+		namespaces = append(namespaces, item.Name)
+	}
+	return namespaces, nil
+}
+
+func (k *KubeInspector) GetStatefulSet(ctx context.Context, namespace, name string) (*StatefulSetStatus, error) {
+	sts, err := k.client.AppsV1().StatefulSets(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	status := &StatefulSetStatus{
+		Name:            sts.Name,
+		Replicas:        1,
+		ReadyReplicas:   int(sts.Status.ReadyReplicas),
+		CurrentReplicas: int(sts.Status.CurrentReplicas),
+	}
+	if sts.Spec.Replicas != nil {
+		status.Replicas = int(*sts.Spec.Replicas)
+	}
+	if containers := sts.Spec.Template.Spec.Containers; len(containers) > 0 {
+		resources := containers[0].Resources
+		status.Limits.CPU = quantityString(resources.Limits, corev1.ResourceCPU)
+		status.Limits.Memory = quantityString(resources.Limits, corev1.ResourceMemory)
+		status.Requests.CPU = quantityString(resources.Requests, corev1.ResourceCPU)
+		status.Requests.Memory = quantityString(resources.Requests, corev1.ResourceMemory)
+	}
+	return status, nil
+}
+
+func (k *KubeInspector) ListPodMetrics(ctx context.Context, namespace string) ([]PodUsage, error) {
+	list, err := k.metrics.MetricsV1beta1().PodMetricses(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	pods := make([]PodUsage, 0, len(list.Items))
+	for _, item := range list.Items {
+		usage := PodUsage{Name: item.Name}
+		for _, container := range item.Containers {
+			usage.CPUCores += container.Usage.Cpu().AsApproximateFloat64()
+			usage.MemoryBytes += container.Usage.Memory().Value()
+		}
+		pods = append(pods, usage)
+	}
+	return pods, nil
+}
+
+func (k *KubeInspector) ScaleStatefulSet(ctx context.Context, namespace, name string, replicas int) error {
+	patch, err := json.Marshal(map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": replicas},
+	})
+	if err != nil {
+		return err
+	}
+	_, err = k.client.AppsV1().StatefulSets(namespace).Patch(ctx, name, types.MergePatchType, patch, metav1.PatchOptions{}, "scale")
+	return err
+}
+
+// quantityString formats a resource quantity, or returns "" when it is not set
+// quantityString 格式化资源数量，未设置时返回空字符串
+func quantityString(resources corev1.ResourceList, name corev1.ResourceName) string {
+	quantity, ok := resources[name]
+	if !ok {
+		return ""
+	}
+	return quantity.String()
+}
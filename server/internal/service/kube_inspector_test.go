@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+	metricsv1beta1 "k8s.io/metrics/pkg/apis/metrics/v1beta1"
+	metricsfake "k8s.io/metrics/pkg/client/clientset/versioned/fake"
+)
+
+func newTestKubeInspector(t *testing.T, objects ...interface{}) *KubeInspector {
+	t.Helper()
+
+	client := fake.NewSimpleClientset()
+	metrics := metricsfake.NewSimpleClientset()
+	for _, object := range objects {
+		var err error
+		switch o := object.(type) {
+		case *corev1.Namespace:
+			_, err = client.CoreV1().Namespaces().Create(context.Background(), o, metav1.CreateOptions{})
+		case *appsv1.StatefulSet:
+			_, err = client.AppsV1().StatefulSets(o.Namespace).Create(context.Background(), o, metav1.CreateOptions{})
+		case *metricsv1beta1.PodMetrics:
+			// The fake tracker cannot guess the "pods" resource of PodMetrics from its kind
+			err = metrics.Tracker().Create(metricsv1beta1.SchemeGroupVersion.WithResource("pods"), o, o.Namespace)
+		default:
+			t.Fatalf("unsupported object %T", object)
+		}
+		if err != nil {
+			t.Fatalf("creating %T: %v", object, err)
+		}
+	}
+	return NewKubeInspector(client, metrics)
+}
+
+func testStatefulSet(namespace string, replicas int32) *appsv1.StatefulSet {
+	return &appsv1.StatefulSet{
+		ObjectMeta: metav1.ObjectMeta{Name: esStatefulSetName, Namespace: namespace},
+		Spec: appsv1.StatefulSetSpec{
+			Replicas: &replicas,
+			Template: corev1.PodTemplateSpec{
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{
+						Name: "elasticsearch",
+						Resources: corev1.ResourceRequirements{
+							Limits: corev1.ResourceList{
+								corev1.ResourceMemory: resource.MustParse("4Gi"),
+							},
+							Requests: corev1.ResourceList{
+								corev1.ResourceCPU:    resource.MustParse("500m"),
+								corev1.ResourceMemory: resource.MustParse("2Gi"),
+							},
+						},
+					}},
+				},
+			},
+		},
+		Status: appsv1.StatefulSetStatus{ReadyReplicas: replicas - 1, CurrentReplicas: replicas},
+	}
+}
+
+func TestKubeInspectorListClusterNamespaces(t *testing.T) {
+	inspector := newTestKubeInspector(t,
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-a", Labels: map[string]string{"es-cluster": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "tenant-b", Labels: map[string]string{"es-cluster": "true"}}},
+		&corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "kube-system"}},
+	)
+
+	namespaces, err := inspector.ListClusterNamespaces(context.Background())
+	if err != nil {
+		t.Fatalf("ListClusterNamespaces: %v", err)
+	}
+	sort.Strings(namespaces)
+	if want := []string{"tenant-a", "tenant-b"}; !reflect.DeepEqual(namespaces, want) {
+		t.Fatalf("namespaces = %v, want %v", namespaces, want)
+	}
+}
+
+func TestKubeInspectorGetStatefulSet(t *testing.T) {
+	inspector := newTestKubeInspector(t, testStatefulSet("tenant-a", 3))
+
+	sts, err := inspector.GetStatefulSet(context.Background(), "tenant-a", esStatefulSetName)
+	if err != nil {
+		t.Fatalf("GetStatefulSet: %v", err)
+	}
+	if sts.Replicas != 3 || sts.ReadyReplicas != 2 || sts.CurrentReplicas != 3 {
+		t.Fatalf("replicas = %d/%d/%d, want 3/2/3", sts.Replicas, sts.ReadyReplicas, sts.CurrentReplicas)
+	}
+	// Unset quantities stay empty instead of becoming "0"
+	if sts.Limits.CPU != "" || sts.Limits.Memory != "4Gi" || sts.Requests.CPU != "500m" || sts.Requests.Memory != "2Gi" {
+		t.Fatalf("limits = %+v, requests = %+v", sts.Limits, sts.Requests)
+	}
+
+	if _, err := inspector.GetStatefulSet(context.Background(), "tenant-b", esStatefulSetName); err == nil {
+		t.Fatalf("GetStatefulSet on missing StatefulSet: want error")
+	}
+}
+
+func TestKubeInspectorListPodMetrics(t *testing.T) {
+	inspector := newTestKubeInspector(t, &metricsv1beta1.PodMetrics{
+		ObjectMeta: metav1.ObjectMeta{Name: "elasticsearch-0", Namespace: "tenant-a"},
+		Containers: []metricsv1beta1.ContainerMetrics{
+			{Name: "elasticsearch", Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("250m"),
+				corev1.ResourceMemory: resource.MustParse("1Gi"),
+			}},
+			{Name: "exporter", Usage: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			}},
+		},
+	})
+
+	pods, err := inspector.ListPodMetrics(context.Background(), "tenant-a")
+	if err != nil {
+		t.Fatalf("ListPodMetrics: %v", err)
+	}
+	if len(pods) != 1 || pods[0].Name != "elasticsearch-0" {
+		t.Fatalf("pods = %+v, want elasticsearch-0", pods)
+	}
+	if pods[0].CPUCores < 0.299 || pods[0].CPUCores > 0.301 || pods[0].MemoryBytes != (1<<30)+(64<<20) {
+		t.Fatalf("usage = %v cores, %d bytes, want 0.3 cores, %d bytes", pods[0].CPUCores, pods[0].MemoryBytes, (1<<30)+(64<<20))
+	}
+}
+
+func TestKubeInspectorScaleStatefulSet(t *testing.T) {
+	inspector := newTestKubeInspector(t, testStatefulSet("tenant-a", 3))
+
+	if err := inspector.ScaleStatefulSet(context.Background(), "tenant-a", esStatefulSetName, 5); err != nil {
+		t.Fatalf("ScaleStatefulSet: %v", err)
+	}
+	sts, err := inspector.GetStatefulSet(context.Background(), "tenant-a", esStatefulSetName)
+	if err != nil {
+		t.Fatalf("GetStatefulSet: %v", err)
+	}
+	if sts.Replicas != 5 {
+		t.Fatalf("replicas after scale = %d, want 5", sts.Replicas)
+	}
+}
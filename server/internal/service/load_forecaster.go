@@ -0,0 +1,435 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	hoursPerDay  = 24
+	hoursPerWeek = 7 * hoursPerDay
+	// forecastHistory is how much metrics history the forecaster learns from
+	// forecastHistory 预测模型学习的指标历史时长
+	forecastHistory = 28 * 24 * time.Hour
+	// minForecastHours is the history a series needs before it is forecast; two weeks also learn the
+	// weekly season
+	// minForecastHours 序列开始预测前所需的历史小时数；满两周后同时学习周季节性
+	minForecastHours = 2 * hoursPerDay
+	// maxForecastHorizon bounds the forecast horizon, in minutes
+	// maxForecastHorizon 预测时间范围的上限（分钟）
+	maxForecastHorizon = 24 * 60
+	// forecastAccuracyWindow is how far back forecasts are compared with the actual load
+	// forecastAccuracyWindow 将预测与实际负载进行对比的时间范围
+	forecastAccuracyWindow = 7 * 24 * time.Hour
+	// forecastDrivingMetric is the driving metric of a recommendation raised by the forecast
+	// forecastDrivingMetric 被预测提高的扩缩容建议所记录的决定指标
+	forecastDrivingMetric = "forecast"
+)
+
+// Smoothing factors of the level, trend, daily season and weekly season
+// 水平、趋势、日季节与周季节的平滑系数
+const (
+	hwAlpha = 0.2
+	hwBeta  = 0.01
+	hwGamma = 0.2
+	hwDelta = 0.2
+)
+
+// forecastSeries lists the history series the scaling metrics are read from
+// forecastSeries 列出扩缩容指标所依据的历史序列
+var forecastSeries = []string{
+	"cpu_usage", "cpu_usage_max",
+	"memory_usage", "memory_usage_max",
+	"disk_usage", "disk_usage_max",
+	"heap_usage", "heap_usage_max",
+	"qps",
+}
+
+// holtWinters is an additive Holt-Winters model with a daily and, given two weeks of history, a
+// weekly season over hourly observations
+// holtWinters 基于小时观测值的加法 Holt-Winters 模型，包含日季节性，历史满两周时还包含周季节性
+type holtWinters struct {
+	level, trend float64
+	daily        []float64
+	weekly       []float64 // 历史不足两周时为空
+	observed     int
+}
+
+// fitHoltWinters fits a model to hourly observations, or returns nil when there are too few of them
+// fitHoltWinters 使用小时观测值拟合模型，观测值过少时返回 nil
+func fitHoltWinters(y []float64) *holtWinters {
+	if len(y) < minForecastHours {
+		return nil
+	}
+
+	mean := func(values []float64) float64 {
+		var sum float64
+		for _, v := range values {
+			sum += v
+		}
+		return sum / float64(len(values))
+	}
+
+	// Initialise the level and trend from the first two days and the seasons from the average
+	// deviations of the first week, or two weeks for the weekly season
+	// 以前两天初始化水平与趋势，以第一周（周季节性为前两周）的平均偏差初始化季节分量
+	hw := &holtWinters{daily: make([]float64, hoursPerDay)}
+	hw.level = mean(y[:hoursPerDay])
+	hw.trend = (mean(y[hoursPerDay:2*hoursPerDay]) - hw.level) / hoursPerDay
+
+	days := min(len(y)/hoursPerDay, 7)
+	for d := 0; d < days; d++ {
+		day := y[d*hoursPerDay : (d+1)*hoursPerDay]
+		dayMean := mean(day)
+		for h, v := range day {
+			hw.daily[h] += (v - dayMean) / float64(days)
+		}
+	}
+	if len(y) >= 2*hoursPerWeek {
+		hw.weekly = make([]float64, hoursPerWeek)
+		for w := 0; w < 2; w++ {
+			week := y[w*hoursPerWeek : (w+1)*hoursPerWeek]
+			weekMean := mean(week)
+			for h, v := range week {
+				hw.weekly[h] += (v - weekMean - hw.daily[h%hoursPerDay]) / 2
+			}
+		}
+	}
+
+	for t, v := range y {
+		daily := hw.daily[t%hoursPerDay]
+		weekly := hw.weeklyAt(t)
+		level := hwAlpha*(v-daily-weekly) + (1-hwAlpha)*(hw.level+hw.trend)
+		hw.trend = hwBeta*(level-hw.level) + (1-hwBeta)*hw.trend
+		hw.level = level
+		hw.daily[t%hoursPerDay] = hwGamma*(v-level-weekly) + (1-hwGamma)*daily
+		if hw.weekly != nil {
+			hw.weekly[t%hoursPerWeek] = hwDelta*(v-level-daily) + (1-hwDelta)*weekly
+		}
+	}
+	hw.observed = len(y)
+	return hw
+}
+
+func (hw *holtWinters) weeklyAt(t int) float64 {
+	if hw.weekly == nil {
+		return 0
+	}
+	return hw.weekly[t%hoursPerWeek]
+}
+
+// forecast predicts the observation steps hours after the last one; load is never negative
+// forecast 预测最后一个观测值之后第 steps 小时的值；负载不会为负
+func (hw *holtWinters) forecast(steps int) float64 {
+	t := hw.observed - 1 + steps
+	return math.Max(0, hw.level+float64(steps)*hw.trend+hw.daily[t%hoursPerDay]+hw.weeklyAt(t))
+}
+
+func (hw *holtWinters) seasonality() string {
+	if hw.weekly != nil {
+		return "daily+weekly"
+	}
+	return "daily"
+}
+
+// loadForecastFit holds the models of a cluster fitted on the complete hours before hour
+// loadForecastFit 使用 hour 之前的完整小时拟合的集群模型
+type loadForecastFit struct {
+	hour   time.Time
+	models map[string]*holtWinters
+}
+
+// LoadForecaster learns the daily and weekly seasonality of the hourly p95 load of each cluster from the
+// metrics history and forecasts the load of the coming hours. Models are refitted once per hour; the
+// forecast of every hour is stored and compared with the actual load once the hour is complete.
+// LoadForecaster 从指标历史中学习各集群小时 p95 负载的日季节性与周季节性，并预测未来数小时的负载；
+// 模型每小时重新拟合一次，每个小时的预测都会被保存，并在该小时结束后与实际负载对比
+type LoadForecaster struct {
+	metadataService *MetadataService
+	history         *MetricsHistoryService
+	mu              sync.Mutex
+	fits            map[string]*loadForecastFit
+}
+
+// NewLoadForecaster creates a new load forecaster
+// NewLoadForecaster 创建一个新的负载预测器
+func NewLoadForecaster(metadataService *MetadataService, history *MetricsHistoryService) *LoadForecaster {
+	return &LoadForecaster{
+		metadataService: metadataService,
+		history:         history,
+		fits:            make(map[string]*loadForecastFit),
+	}
+}
+
+// Forecast returns the peak forecast of every series within horizon from now as metrics, or nil when
+// no series has enough history
+// Forecast 以指标形式返回从现在起 horizon 内各序列的预测峰值；没有序列具备足够历史时返回 nil
+func (f *LoadForecaster) Forecast(namespace string, now time.Time, horizon time.Duration) (*model.Metrics, error) {
+	fit, err := f.fit(namespace, now)
+	if err != nil || len(fit.models) == 0 {
+		return nil, err
+	}
+
+	peak := make(map[string]float64, len(fit.models))
+	for series, hw := range fit.models {
+		for step := 1; step <= forecastSteps(fit.hour, now, horizon); step++ {
+			peak[series] = math.Max(peak[series], hw.forecast(step))
+		}
+	}
+	// Scaling metrics without a fitted model have no forecast
+	// 没有拟合模型的扩缩容指标没有预测值
+	var missing []string
+	for metric := range scalingMetrics {
+		if _, ok := fit.models[metric]; !ok {
+			missing = append(missing, metric)
+		}
+	}
+	sort.Strings(missing)
+
+	return &model.Metrics{
+		Namespace:      namespace,
+		Missing:        missing,
+		CPUUsage:       peak["cpu_usage"],
+		CPUUsageMax:    peak["cpu_usage_max"],
+		MemoryUsage:    peak["memory_usage"],
+		MemoryUsageMax: peak["memory_usage_max"],
+		DiskUsage:      peak["disk_usage"],
+		DiskUsageMax:   peak["disk_usage_max"],
+		HeapUsage:      peak["heap_usage"],
+		HeapUsageMax:   peak["heap_usage_max"],
+		QPS:            peak["qps"],
+		Timestamp:      now,
+	}, nil
+}
+
+// Report returns the hourly forecast of a cluster within horizon from now with the accuracy of the
+// forecasts of the last week
+// Report 返回集群从现在起 horizon 内的逐小时预测以及最近一周预测的准确度
+func (f *LoadForecaster) Report(namespace string, now time.Time, horizon time.Duration) (*model.LoadForecastReport, error) {
+	fit, err := f.fit(namespace, now)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &model.LoadForecastReport{
+		Namespace:   namespace,
+		FittedAt:    fit.hour,
+		Seasonality: make(map[string]string, len(fit.models)),
+		Points:      []model.LoadForecastPoint{},
+		Accuracy:    []model.LoadForecastAccuracy{},
+	}
+	for _, series := range forecastSeries {
+		hw, ok := fit.models[series]
+		if !ok {
+			continue
+		}
+		report.Seasonality[series] = hw.seasonality()
+		for step := 1; step <= forecastSteps(fit.hour, now, horizon); step++ {
+			report.Points = append(report.Points, model.LoadForecastPoint{
+				Series:    series,
+				Time:      fit.hour.Add(time.Duration(step-1) * time.Hour),
+				Predicted: hw.forecast(step),
+			})
+		}
+	}
+
+	forecasts, err := f.metadataService.ListLoadForecasts(namespace, now.Add(-forecastAccuracyWindow), now)
+	if err != nil {
+		return nil, err
+	}
+	accuracy := make(map[string]*model.LoadForecastAccuracy)
+	nonZero := make(map[string]int)
+	for _, forecast := range forecasts {
+		if forecast.Actual == nil {
+			continue
+		}
+		entry, ok := accuracy[forecast.Series]
+		if !ok {
+			entry = &model.LoadForecastAccuracy{Series: forecast.Series}
+			accuracy[forecast.Series] = entry
+		}
+		entry.Samples++
+		entry.MAE += math.Abs(forecast.Predicted - *forecast.Actual)
+		if *forecast.Actual > 0 {
+			entry.MAPE += math.Abs(forecast.Predicted-*forecast.Actual) / *forecast.Actual * 100
+			nonZero[forecast.Series]++
+		}
+	}
+	for _, series := range forecastSeries {
+		entry, ok := accuracy[series]
+		if !ok {
+			continue
+		}
+		entry.MAE /= float64(entry.Samples)
+		if nonZero[series] > 0 {
+			entry.MAPE /= float64(nonZero[series])
+		}
+		report.Accuracy = append(report.Accuracy, *entry)
+	}
+	return report, nil
+}
+
+// forecastSteps returns how many hours starting at hour are needed to cover horizon from now
+// forecastSteps 返回从 hour 开始覆盖从现在起 horizon 所需的小时数
+func forecastSteps(hour, now time.Time, horizon time.Duration) int {
+	return int(now.Add(horizon).Sub(hour)/time.Hour) + 1
+}
+
+// fit returns the models of a cluster for the current hour, refitting them when the hour changed
+// fit 返回集群当前小时的模型，小时变化时重新拟合
+func (f *LoadForecaster) fit(namespace string, now time.Time) (*loadForecastFit, error) {
+	hour := now.Truncate(time.Hour)
+
+	f.mu.Lock()
+	cached, ok := f.fits[namespace]
+	f.mu.Unlock()
+	if ok && cached.hour.Equal(hour) {
+		return cached, nil
+	}
+
+	// The history is loaded and fitted without the lock, so other clusters and readers are not held up
+	// 在不持锁的情况下加载历史并拟合，避免阻塞其他集群与读取方
+	from := hour.Add(-forecastHistory)
+	history, err := f.history.Query(namespace, from, hour, time.Hour, forecastSeries)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metrics history: %w", err)
+	}
+
+	fit := &loadForecastFit{hour: hour, models: make(map[string]*holtWinters)}
+	actuals := make(map[string]map[int64]float64, len(forecastSeries))
+	for _, series := range forecastSeries {
+		points := history.Series[series]
+		actuals[series] = make(map[int64]float64, len(points))
+		for _, point := range points {
+			actuals[series][point.Timestamp.Unix()] = point.P95
+		}
+		if hw := fitHoltWinters(hourlyValues(points, from, hour)); hw != nil {
+			fit.models[series] = hw
+		}
+	}
+
+	// A concurrent fit of the same hour may have been stored first; it is kept and already persisted
+	// 同一小时的并发拟合可能已先被保存；保留该结果，它已完成持久化
+	f.mu.Lock()
+	if current, ok := f.fits[namespace]; ok && !current.hour.Before(hour) {
+		f.mu.Unlock()
+		return current, nil
+	}
+	f.fits[namespace] = fit
+	f.mu.Unlock()
+
+	f.recordActuals(namespace, from, hour, actuals)
+	for series, hw := range fit.models {
+		forecast := &model.LoadForecast{
+			ID:        fmt.Sprintf("forecast_%s_%s_%d", namespace, series, hour.Unix()),
+			Namespace: namespace,
+			Series:    series,
+			Time:      hour,
+			Predicted: hw.forecast(1),
+			CreatedAt: now,
+		}
+		if err := f.metadataService.SaveLoadForecast(forecast); err != nil {
+			log.Printf("Error saving load forecast for namespace %s: %v", namespace, err)
+		}
+	}
+	return fit, nil
+}
+
+// recordActuals fills in the actual load of the forecasts whose hour is now complete
+// recordActuals 为所在小时已结束的预测填入实际负载
+func (f *LoadForecaster) recordActuals(namespace string, from, to time.Time, actuals map[string]map[int64]float64) {
+	forecasts, err := f.metadataService.ListLoadForecasts(namespace, from, to)
+	if err != nil {
+		log.Printf("Error listing load forecasts for namespace %s: %v", namespace, err)
+		return
+	}
+	for _, forecast := range forecasts {
+		if forecast.Actual != nil {
+			continue
+		}
+		actual, ok := actuals[forecast.Series][forecast.Time.Unix()]
+		if !ok {
+			continue
+		}
+		forecast.Actual = &actual
+		if err := f.metadataService.SaveLoadForecast(forecast); err != nil {
+			log.Printf("Error saving load forecast for namespace %s: %v", namespace, err)
+		}
+	}
+}
+
+// RetainNamespaces drops the models of clusters that no longer exist
+// RetainNamespaces 删除已不存在的集群的模型
+func (f *LoadForecaster) RetainNamespaces(namespaces []string) {
+	keep := make(map[string]bool, len(namespaces))
+	for _, namespace := range namespaces {
+		keep[namespace] = true
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for namespace := range f.fits {
+		if !keep[namespace] {
+			delete(f.fits, namespace)
+		}
+	}
+}
+
+// hourlyValues turns the hourly p95 points in [from, to) into a gapless series starting at the first
+// point; missing hours repeat the previous value
+// hourlyValues 将 [from, to) 内的小时 p95 点转换为从第一个点开始的连续序列；缺失的小时沿用前一个值
+func hourlyValues(points []model.SeriesPoint, from, to time.Time) []float64 {
+	byHour := make(map[int64]float64, len(points))
+	start := to
+	for _, point := range points {
+		if point.Timestamp.Before(from) || !point.Timestamp.Before(to) {
+			continue
+		}
+		byHour[point.Timestamp.Unix()] = point.P95
+		if point.Timestamp.Before(start) {
+			start = point.Timestamp
+		}
+	}
+
+	var values []float64
+	for t := start; t.Before(to); t = t.Add(time.Hour) {
+		value, ok := byHour[t.Unix()]
+		if !ok {
+			value = values[len(values)-1]
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+// applyForecast raises a recommendation to the replicas the forecast peak load asks for, so clusters are
+// scaled up ahead of expected peaks and not scaled down right before them. The forecast goes through the
+// same targets, step factors and policy limits as the current load.
+// applyForecast 将扩缩容建议提高到预测峰值负载所要求的副本数，使集群在预期高峰前提前扩容且不会在高峰前缩容；
+// 预测负载与当前负载使用相同的目标、步长系数与策略上下限
+func (a *AutoscalerService) applyForecast(recommendation *model.ScalingRecommendation, forecast *model.Metrics, policy model.ScalingPolicy, config model.AutoscalerConfig) {
+	predicted := a.recommend(recommendation.CurrentReplicas, forecast, policy, config)
+	recommendation.Forecast = predicted.Metrics
+	if predicted.DrivingMetric != "" && predicted.DesiredReplicas > recommendation.DesiredReplicas {
+		recommendation.DesiredReplicas = predicted.DesiredReplicas
+		recommendation.DrivingMetric = forecastDrivingMetric
+	}
+}
+
+// forecastReason describes the forecast metric that asks for the most replicas
+// forecastReason 描述要求副本数最多的预测指标
+func forecastReason(recommendation model.ScalingRecommendation) string {
+	var driving model.MetricRecommendation
+	for _, metric := range recommendation.Forecast {
+		if driving.Metric == "" || metric.DesiredReplicas > driving.DesiredReplicas {
+			driving = metric
+		}
+	}
+	return fmt.Sprintf("forecast %s %.2f against target %.2f", driving.Metric, driving.Value, driving.Target)
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// memoryAlias is an alias of the memory store and the indices it points to
+// memoryAlias 内存存储中的别名及其指向的索引
+type memoryAlias struct {
+	indices    map[string]bool
+	writeIndex string
+}
+
+// memoryPIT is a point-in-time: the documents of an index as they were when it was opened
+// memoryPIT point-in-time：打开时索引中文档的快照
+type memoryPIT struct {
+	index   string
+	ids     []string
+	docs    map[string]*memoryDoc
+	expires time.Time
+}
+
+// Refresh does nothing; writes to the memory store are visible immediately
+// Refresh 不执行任何操作；内存存储的写入立即可见
+func (m *MemoryStore) Refresh(indexName string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	_, err := m.getIndex(indexName)
+	return err
+}
+
+// UpdateIndexSettings applies index.blocks.write; other settings have no effect in memory
+// UpdateIndexSettings 应用 index.blocks.write 设置；其他设置在内存存储中无效
+func (m *MemoryStore) UpdateIndexSettings(indexName string, settings map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return err
+	}
+	if value, ok := settings["index.blocks.write"]; ok {
+		index.writeBlocked = fmt.Sprint(value) == "true"
+	}
+	return nil
+}
+
+// GetAliasIndices returns the indices an alias points to in name order, or nil if the alias does not exist
+// GetAliasIndices 按名称顺序返回别名指向的索引，别名不存在时返回 nil
+func (m *MemoryStore) GetAliasIndices(alias string) ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	entry, ok := m.aliases[alias]
+	if !ok {
+		return nil, nil
+	}
+	indices := make([]string, 0, len(entry.indices))
+	for name := range entry.indices {
+		indices = append(indices, name)
+	}
+	sort.Strings(indices)
+	return indices, nil
+}
+
+// UpdateAliases applies add, remove and remove_index actions atomically, like the _aliases API.
+// Alias filters are not supported.
+// UpdateAliases 像 _aliases API 一样原子地执行 add、remove 和 remove_index 操作；不支持别名过滤条件
+func (m *MemoryStore) UpdateAliases(actions []map[string]interface{}) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// Work on copies so a failing action leaves everything unchanged
+	// 在副本上操作，使失败的操作不会留下部分修改
+	aliases := make(map[string]*memoryAlias, len(m.aliases))
+	for name, alias := range m.aliases {
+		indices := make(map[string]bool, len(alias.indices))
+		for index := range alias.indices {
+			indices[index] = true
+		}
+		aliases[name] = &memoryAlias{indices: indices, writeIndex: alias.writeIndex}
+	}
+	removed := make(map[string]bool)
+	exists := func(index string) bool {
+		_, ok := m.indices[index]
+		return ok && !removed[index]
+	}
+
+	for _, action := range actions {
+		for kind, value := range action {
+			body, _ := value.(map[string]interface{})
+			index, _ := body["index"].(string)
+			if !exists(index) {
+				return fmt.Errorf("%w: %s", ErrIndexNotFound, index)
+			}
+
+			switch kind {
+			case "add":
+				name, _ := body["alias"].(string)
+				if name == "" {
+					return fmt.Errorf("%w: alias is required", ErrInvalidRequest)
+				}
+				if _, ok := body["filter"]; ok {
+					return fmt.Errorf("%w: alias filters are not supported by the memory store", ErrInvalidRequest)
+				}
+				alias, ok := aliases[name]
+				if !ok {
+					alias = &memoryAlias{indices: make(map[string]bool)}
+					aliases[name] = alias
+				}
+				alias.indices[index] = true
+				if isWrite, ok := body["is_write_index"].(bool); ok {
+					if isWrite {
+						alias.writeIndex = index
+					} else if alias.writeIndex == index {
+						alias.writeIndex = ""
+					}
+				}
+			case "remove":
+				name, _ := body["alias"].(string)
+				alias, ok := aliases[name]
+				if !ok || !alias.indices[index] {
+					return fmt.Errorf("%w: alias %s does not point to %s", ErrIndexNotFound, name, index)
+				}
+				removeAliasIndex(aliases, name, index)
+			case "remove_index":
+				removed[index] = true
+				for name := range aliases {
+					removeAliasIndex(aliases, name, index)
+				}
+			default:
+				return fmt.Errorf("%w: alias action %s is not supported by the memory store", ErrInvalidRequest, kind)
+			}
+		}
+	}
+
+	for name := range aliases {
+		if exists(name) {
+			return fmt.Errorf("%w: alias %s has the same name as an index", ErrInvalidRequest, name)
+		}
+	}
+
+	for index := range removed {
+		delete(m.indices, index)
+	}
+	m.aliases = aliases
+	return nil
+}
+
+// dropFromAliases removes a deleted index from every alias; the caller must hold the lock
+// dropFromAliases 将已删除的索引从所有别名中移除，调用方需持有锁
+func (m *MemoryStore) dropFromAliases(indexName string) {
+	for name := range m.aliases {
+		removeAliasIndex(m.aliases, name, indexName)
+	}
+}
+
+// removeAliasIndex removes an index from an alias and drops the alias once it points nowhere
+// removeAliasIndex 将索引从别名中移除，别名不再指向任何索引时将其删除
+func removeAliasIndex(aliases map[string]*memoryAlias, name, index string) {
+	alias := aliases[name]
+	delete(alias.indices, index)
+	if alias.writeIndex == index {
+		alias.writeIndex = ""
+	}
+	if len(alias.indices) == 0 {
+		delete(aliases, name)
+	}
+}
+
+// OpenPointInTime snapshots the documents of an index and returns the ID of the snapshot
+// OpenPointInTime 对索引文档做快照并返回快照 ID
+func (m *MemoryStore) OpenPointInTime(indexName, keepAlive string) (string, error) {
+	ttl, err := time.ParseDuration(keepAlive)
+	if err != nil {
+		return "", fmt.Errorf("%w: invalid keep_alive %q", ErrInvalidRequest, keepAlive)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return "", err
+	}
+
+	// Documents are replaced rather than modified on write, so keeping the pointers is a snapshot
+	// 写入时替换而不是修改文档，因此保留指针即构成快照
+	pit := &memoryPIT{
+		index:   index.name,
+		ids:     make([]string, 0, len(index.docs)),
+		docs:    make(map[string]*memoryDoc, len(index.docs)),
+		expires: time.Now().Add(ttl),
+	}
+	for id, doc := range index.docs {
+		pit.ids = append(pit.ids, id)
+		pit.docs[id] = doc
+	}
+	sort.Strings(pit.ids)
+
+	m.nextID++
+	id := fmt.Sprintf("mem-pit-%d", m.nextID)
+	m.pits[id] = pit
+	return id, nil
+}
+
+// ClosePointInTime releases a point-in-time; closing an unknown one is not an error
+// ClosePointInTime 释放 point-in-time；关闭不存在的 point-in-time 不视为错误
+func (m *MemoryStore) ClosePointInTime(pitID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.pits, pitID)
+	return nil
+}
+
+// SearchPointInTime pages through a point-in-time in document order. It supports
+// size, query, _source, keep_alive, search_after and the _shard_doc sort.
+// SearchPointInTime 按文档顺序在 point-in-time 上翻页；支持 size、query、_source、
+// keep_alive、search_after 以及 _shard_doc 排序
+func (m *MemoryStore) SearchPointInTime(body map[string]interface{}) (map[string]interface{}, error) {
+	start := time.Now()
+
+	pitSpec, _ := body["pit"].(map[string]interface{})
+	pitID, _ := pitSpec["id"].(string)
+	query, _ := body["query"].(map[string]interface{})
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+	for _, spec := range toList(body["sort"]) {
+		if sortSpec, ok := spec.(map[string]interface{}); !ok || len(sortSpec) != 1 || sortSpec["_shard_doc"] == nil {
+			return nil, fmt.Errorf("%w: the memory store only sorts point-in-time searches by _shard_doc", ErrInvalidRequest)
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pit, ok := m.pits[pitID]
+	if !ok || time.Now().After(pit.expires) {
+		delete(m.pits, pitID)
+		return nil, fmt.Errorf("%w: point-in-time %s has expired or does not exist", ErrInvalidRequest, pitID)
+	}
+	if keepAlive, ok := pitSpec["keep_alive"].(string); ok {
+		if ttl, err := time.ParseDuration(keepAlive); err == nil {
+			pit.expires = time.Now().Add(ttl)
+		}
+	}
+
+	size := 10
+	if _, ok := body["size"]; ok {
+		size = toInt(body["size"])
+	}
+	position := 0
+	if after := toList(body["search_after"]); len(after) > 0 {
+		position = toInt(after[0]) + 1
+	}
+
+	hitList := make([]interface{}, 0, size)
+	for ; position < len(pit.ids) && len(hitList) < size; position++ {
+		id := pit.ids[position]
+		doc := pit.docs[id]
+		if !matchQuery(doc.source, id, query) {
+			continue
+		}
+		hit := map[string]interface{}{
+			"_index": pit.index,
+			"_id":    id,
+			"_score": nil,
+			"sort":   []interface{}{position},
+		}
+		if source := filterSource(doc.source, body["_source"]); source != nil {
+			hit["_source"] = source
+		}
+		hitList = append(hitList, hit)
+	}
+
+	return map[string]interface{}{
+		"pit_id":    pitID,
+		"took":      time.Since(start).Milliseconds(),
+		"timed_out": false,
+		"hits": map[string]interface{}{
+			"hits": hitList,
+		},
+	}, nil
+}
+
+// Reindex copies every document of source into dest with external versioning: documents
+// whose version in dest is already at least the source version are skipped as conflicts.
+// The copy runs synchronously and the returned task is already completed. Scripts are not
+// supported.
+// Reindex 使用外部版本号将 source 的所有文档复制到 dest：dest 中版本不低于源版本的文档作为冲突跳过；
+// 复制同步执行，返回的任务已完成；不支持脚本
+func (m *MemoryStore) Reindex(source, dest string, script map[string]interface{}) (string, error) {
+	if script != nil {
+		return "", fmt.Errorf("%w: reindex scripts are not supported by the memory store", ErrInvalidRequest)
+	}
+	start := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	src, err := m.getIndex(source)
+	if err != nil {
+		return "", err
+	}
+	dst, err := m.getIndex(dest)
+	if err != nil {
+		return "", err
+	}
+
+	ids := make([]string, 0, len(src.docs))
+	for id := range src.docs {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var created, updated, conflicts int
+	failures := []interface{}{}
+	for _, id := range ids {
+		doc := src.docs[id]
+		if existing, ok := dst.docs[id]; ok && existing.version >= doc.version {
+			conflicts++
+			continue
+		}
+		result, err := dst.put(id, doc.source)
+		if err != nil {
+			failures = append(failures, map[string]interface{}{"id": id, "cause": err.Error()})
+			continue
+		}
+		dst.docs[id].version = doc.version
+		if result.Result == "created" {
+			created++
+		} else {
+			updated++
+		}
+	}
+
+	// Counts are float64 like in a decoded ES response
+	// 计数使用 float64，与解析后的 ES 响应一致
+	status := map[string]interface{}{
+		"total":             float64(len(ids)),
+		"created":           float64(created),
+		"updated":           float64(updated),
+		"version_conflicts": float64(conflicts),
+	}
+	response := map[string]interface{}{"took": time.Since(start).Milliseconds(), "failures": failures}
+	for key, value := range status {
+		response[key] = value
+	}
+
+	m.nextID++
+	taskID := fmt.Sprintf("memory:%d", m.nextID)
+	m.tasks[taskID] = map[string]interface{}{
+		"completed": true,
+		"task":      map[string]interface{}{"action": "indices:data/write/reindex", "status": status},
+		"response":  response,
+	}
+	return taskID, nil
+}
+
+// GetTask returns the status of a task in the shape of the ES _tasks API
+// GetTask 以 ES _tasks API 的格式返回任务状态
+func (m *MemoryStore) GetTask(taskID string) (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	task, ok := m.tasks[taskID]
+	if !ok {
+		return nil, fmt.Errorf("task %s not found", taskID)
+	}
+	return task, nil
+}
+
+// CancelTask does nothing for known tasks since memory store tasks complete synchronously
+// CancelTask 内存存储的任务同步完成，对已知任务不执行任何操作
+func (m *MemoryStore) CancelTask(taskID string) error {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.tasks[taskID]; !ok {
+		return fmt.Errorf("task %s not found", taskID)
+	}
+	return nil
+}
@@ -0,0 +1,188 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// ivfIndex is the inverted file structure of a memory store vector field: a set of
+// k-means centroids and, for every centroid, the documents assigned to it
+// ivfIndex 内存存储向量字段的倒排文件结构：一组 KMeans 聚类中心以及分配到每个中心的文档
+type ivfIndex struct {
+	metric    string
+	centroids [][]float32
+	lists     []map[string]bool
+	// Centroid of every assigned document
+	// 每个已分配文档所属的聚类中心
+	assignments map[string]int
+}
+
+// trainIVF runs Lloyd's k-means over the sample and returns the empty IVF structure
+// together with the final inertia (sum of squared distances to the closest centroid)
+// trainIVF 对样本执行 Lloyd KMeans，返回空的 IVF 结构以及最终惯性（到最近中心距离平方和）
+func trainIVF(ctx context.Context, sample [][]float32, nlist, maxIterations int, metric string, rng *rand.Rand) (*ivfIndex, float64, error) {
+	// Cosine clusters unit vectors so centroids follow the angular distribution
+	// 余弦度量对单位向量聚类，使聚类中心反映角度分布
+	points := sample
+	if metric == "cosine" {
+		points = make([][]float32, len(sample))
+		for i, vector := range sample {
+			points[i] = normalizeVector(vector)
+		}
+	}
+
+	centroids := make([][]float32, nlist)
+	for i, j := range rng.Perm(len(points))[:nlist] {
+		centroids[i] = append([]float32(nil), points[j]...)
+	}
+
+	dims := len(points[0])
+	assign := make([]int, len(points))
+	var inertia float64
+	for iteration := 0; iteration < maxIterations; iteration++ {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+
+		changed := false
+		inertia = 0
+		for i, point := range points {
+			best, distance := nearestCentroid(centroids, point)
+			inertia += distance
+			if best != assign[i] {
+				assign[i] = best
+				changed = true
+			}
+		}
+		if !changed && iteration > 0 {
+			break
+		}
+
+		sums := make([][]float64, nlist)
+		counts := make([]int, nlist)
+		for i := range sums {
+			sums[i] = make([]float64, dims)
+		}
+		for i, point := range points {
+			c := assign[i]
+			counts[c]++
+			for d, v := range point {
+				sums[c][d] += float64(v)
+			}
+		}
+		for c := range centroids {
+			if counts[c] == 0 {
+				// Re-seed empty clusters with a random point
+				// 空簇使用随机点重新初始化
+				centroids[c] = append([]float32(nil), points[rng.Intn(len(points))]...)
+				continue
+			}
+			for d := range centroids[c] {
+				centroids[c][d] = float32(sums[c][d] / float64(counts[c]))
+			}
+		}
+	}
+
+	lists := make([]map[string]bool, nlist)
+	for i := range lists {
+		lists[i] = make(map[string]bool)
+	}
+	return &ivfIndex{
+		metric:      metric,
+		centroids:   centroids,
+		lists:       lists,
+		assignments: make(map[string]int),
+	}, inertia, nil
+}
+
+// add assigns a document to its nearest centroid
+// add 将文档分配到最近的聚类中心
+func (ivf *ivfIndex) add(docID string, vector []float32) {
+	ivf.remove(docID)
+	best, _ := nearestCentroid(ivf.centroids, ivf.prepare(vector))
+	ivf.lists[best][docID] = true
+	ivf.assignments[docID] = best
+}
+
+// remove drops a document from its list
+// remove 将文档从其倒排列表中移除
+func (ivf *ivfIndex) remove(docID string) {
+	if c, ok := ivf.assignments[docID]; ok {
+		delete(ivf.lists[c], docID)
+		delete(ivf.assignments, docID)
+	}
+}
+
+// probe returns the documents of the nprobe lists closest to the query
+// probe 返回距离查询最近的 nprobe 个倒排列表中的文档
+func (ivf *ivfIndex) probe(query []float32, nprobe int) []string {
+	query = ivf.prepare(query)
+
+	distances := make([]float64, len(ivf.centroids))
+	order := make([]int, len(ivf.centroids))
+	for i, centroid := range ivf.centroids {
+		distances[i] = squaredDistance(query, centroid)
+		order[i] = i
+	}
+	sort.Slice(order, func(a, b int) bool {
+		return distances[order[a]] < distances[order[b]]
+	})
+	if nprobe > len(order) {
+		nprobe = len(order)
+	}
+
+	var candidates []string
+	for _, c := range order[:nprobe] {
+		for id := range ivf.lists[c] {
+			candidates = append(candidates, id)
+		}
+	}
+	return candidates
+}
+
+// prepare normalizes vectors for cosine so they live in the same space as the centroids
+// prepare 余弦度量下对向量归一化，使其与聚类中心处于同一空间
+func (ivf *ivfIndex) prepare(vector []float32) []float32 {
+	if ivf.metric == "cosine" {
+		return normalizeVector(vector)
+	}
+	return vector
+}
+
+// nearestCentroid returns the index of and squared distance to the closest centroid
+// nearestCentroid 返回最近聚类中心的下标及其距离平方
+func nearestCentroid(centroids [][]float32, vector []float32) (int, float64) {
+	best, bestDistance := 0, math.MaxFloat64
+	for i, centroid := range centroids {
+		if d := squaredDistance(vector, centroid); d < bestDistance {
+			best, bestDistance = i, d
+		}
+	}
+	return best, bestDistance
+}
+
+// squaredDistance returns the squared euclidean distance of two vectors
+// squaredDistance 返回两个向量的欧氏距离平方
+func squaredDistance(a, b []float32) float64 {
+	return -similarity(a, b, "l2")
+}
+
+// normalizeVector returns a unit length copy of a vector
+// normalizeVector 返回向量的单位长度副本
+func normalizeVector(vector []float32) []float32 {
+	var norm float64
+	for _, v := range vector {
+		norm += float64(v) * float64(v)
+	}
+	normalized := make([]float32, len(vector))
+	if norm == 0 {
+		return normalized
+	}
+	norm = math.Sqrt(norm)
+	for i, v := range vector {
+		normalized[i] = float32(float64(v) / norm)
+	}
+	return normalized
+}
@@ -0,0 +1,1019 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// ErrIndexNotFound is returned by the memory store for operations on a missing index
+// ErrIndexNotFound 内存存储中操作不存在的索引时返回的错误
+var ErrIndexNotFound = errors.New("index not found")
+
+// MemoryStore is a pure Go, in-process vector store implementing VectorStore.
+// It supports flat (exact) kNN and IVF search trained with k-means, so handlers
+// and integration tests can run without Elasticsearch and plugin results can be
+// cross-checked against an exact reference.
+// MemoryStore 纯 Go 实现的进程内向量存储，实现了 VectorStore 接口；
+// 支持精确（flat）kNN 搜索以及基于 KMeans 训练的 IVF 搜索，
+// 使处理器和集成测试无需 Elasticsearch 即可运行，并可与插件结果交叉验证
+type MemoryStore struct {
+	indices map[string]*memoryIndex
+	aliases map[string]*memoryAlias
+	pits    map[string]*memoryPIT
+	tasks   map[string]map[string]interface{}
+	nextID  int64
+	mu      sync.RWMutex
+}
+
+// memoryIndex is a single index of the memory store
+// memoryIndex 内存存储中的单个索引
+type memoryIndex struct {
+	name string
+	// Dense vector fields by name
+	// 按名称索引的 dense_vector 字段
+	vectorFields map[string]*memoryVectorField
+	docs         map[string]*memoryDoc
+	nextID       int64
+	// writeBlocked mirrors the index.blocks.write setting
+	// writeBlocked 对应 index.blocks.write 设置
+	writeBlocked bool
+}
+
+// memoryVectorField describes a dense_vector field and its optional IVF structure
+// memoryVectorField 描述 dense_vector 字段及其可选的 IVF 结构
+type memoryVectorField struct {
+	dims   int
+	metric string // l2, cosine, dot
+	ivf    *ivfIndex
+}
+
+// memoryDoc is a stored document
+// memoryDoc 存储的文档
+type memoryDoc struct {
+	source  map[string]interface{}
+	version int64
+	// Vectors decoded from the source by field name
+	// 从文档中解析出的向量（按字段名）
+	vectors map[string][]float32
+}
+
+// NewMemoryStore creates a new empty memory store
+// NewMemoryStore 创建一个新的空内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		indices: make(map[string]*memoryIndex),
+		aliases: make(map[string]*memoryAlias),
+		pits:    make(map[string]*memoryPIT),
+		tasks:   make(map[string]map[string]interface{}),
+	}
+}
+
+// CreateVectorIndex creates an index with the dense_vector fields of the mapping
+// CreateVectorIndex 根据映射中的 dense_vector 字段创建索引
+func (m *MemoryStore) CreateVectorIndex(indexName string, mapping model.VectorIndexMapping) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.indices[indexName]; ok {
+		return fmt.Errorf("%w: index %s already exists", ErrInvalidRequest, indexName)
+	}
+	if _, ok := m.aliases[indexName]; ok {
+		return fmt.Errorf("%w: %s is already an alias", ErrInvalidRequest, indexName)
+	}
+
+	index := &memoryIndex{
+		name:         indexName,
+		vectorFields: make(map[string]*memoryVectorField),
+		docs:         make(map[string]*memoryDoc),
+	}
+	for name, property := range mapping.Properties {
+		prop, ok := property.(map[string]interface{})
+		if !ok || prop["type"] != "dense_vector" {
+			continue
+		}
+		similarity, _ := prop["similarity"].(string)
+		index.vectorFields[name] = &memoryVectorField{
+			dims:   toInt(prop["dims"]),
+			metric: storeMetric(similarity),
+		}
+	}
+
+	m.indices[indexName] = index
+	return nil
+}
+
+// DeleteIndex deletes an index; deleting a missing index is not an error
+// DeleteIndex 删除索引；删除不存在的索引不视为错误
+func (m *MemoryStore) DeleteIndex(indexName string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.indices, indexName)
+	m.dropFromAliases(indexName)
+	return nil
+}
+
+// ListIndexes lists all indexes in name order
+// ListIndexes 按名称顺序列出所有索引
+func (m *MemoryStore) ListIndexes() ([]string, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	names := make([]string, 0, len(m.indices))
+	for name := range m.indices {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IndexDocument creates or replaces a document
+// IndexDocument 创建或替换文档
+func (m *MemoryStore) IndexDocument(indexName, docID string, document map[string]interface{}) (*model.DocumentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+	return index.put(docID, document)
+}
+
+// BulkIndex indexes many documents, reporting failures per document
+// BulkIndex 批量写入文档，逐个报告失败
+func (m *MemoryStore) BulkIndex(indexName string, docs []model.BulkDocument) (*model.BulkResponse, error) {
+	start := time.Now()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	response := &model.BulkResponse{Items: make([]model.DocumentResult, 0, len(docs))}
+	for _, doc := range docs {
+		result, err := index.put(doc.ID, doc.Document)
+		if err != nil {
+			result = &model.DocumentResult{Index: index.name, ID: doc.ID, Error: err.Error()}
+			response.Errors++
+		}
+		response.Items = append(response.Items, *result)
+	}
+	response.Took = time.Since(start).Milliseconds()
+	return response, nil
+}
+
+// GetDocument retrieves a document in the same shape as the ES _doc API
+// GetDocument 以与 ES _doc API 相同的格式获取文档
+func (m *MemoryStore) GetDocument(indexName, docID string) (map[string]interface{}, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := index.docs[docID]
+	if !ok {
+		return nil, ErrDocumentNotFound
+	}
+
+	return map[string]interface{}{
+		"_index":   index.name,
+		"_id":      docID,
+		"_version": doc.version,
+		"found":    true,
+		"_source":  copySource(doc.source),
+	}, nil
+}
+
+// UpdateDocument merges fields into an existing document
+// UpdateDocument 将字段合并到已有文档中
+func (m *MemoryStore) UpdateDocument(indexName, docID string, fields map[string]interface{}) (*model.DocumentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := index.docs[docID]
+	if !ok {
+		return nil, ErrDocumentNotFound
+	}
+
+	merged := copySource(doc.source)
+	for key, value := range fields {
+		merged[key] = value
+	}
+	result, err := index.put(docID, merged)
+	if err != nil {
+		return nil, err
+	}
+	result.Result = "updated"
+	return result, nil
+}
+
+// DeleteDocument deletes a document by ID
+// DeleteDocument 根据 ID 删除文档
+func (m *MemoryStore) DeleteDocument(indexName, docID string) (*model.DocumentResult, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+	doc, ok := index.docs[docID]
+	if !ok {
+		return nil, ErrDocumentNotFound
+	}
+	if index.writeBlocked {
+		return nil, fmt.Errorf("%w: index %s is blocked for writes", ErrIndexBusy, index.name)
+	}
+
+	index.remove(docID)
+	return &model.DocumentResult{
+		Index:   index.name,
+		ID:      docID,
+		Result:  "deleted",
+		Version: doc.version + 1,
+	}, nil
+}
+
+// Search runs a search body and returns an ES shaped response. It supports the
+// top level knn section, the plugin's ann query, exact vector script_score queries
+// and simple filters (match_all, ids, term, terms, exists, bool).
+// Search 执行搜索请求体并返回 ES 格式的响应；支持顶层 knn、插件的 ann 查询、精确向量 script_score 查询以及
+// 简单过滤条件（match_all、ids、term、terms、exists、bool）
+func (m *MemoryStore) Search(indexName string, query map[string]interface{}) (map[string]interface{}, error) {
+	start := time.Now()
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	size := 10
+	if _, ok := query["size"]; ok {
+		size = toInt(query["size"])
+	}
+	from := toInt(query["from"])
+
+	var hits []memoryHit
+	queryClause, _ := query["query"].(map[string]interface{})
+	knn, hasKNN := query["knn"].(map[string]interface{})
+	ann, filters := extractANN(queryClause)
+
+	switch {
+	case hasKNN:
+		filter, _ := knn["filter"].(map[string]interface{})
+		if queryClause != nil {
+			filters = append(filters, queryClause)
+		}
+		if filter != nil {
+			filters = append(filters, filter)
+		}
+		hits, err = index.vectorSearch(knn["field"], knn["query_vector"], toInt(knn["k"]), 0, filters)
+	case ann != nil:
+		hits, err = index.vectorSearch(ann["field"], ann["vector"], toInt(ann["k"]), toInt(ann["nprobe"]), filters)
+	case queryClause["script_score"] != nil:
+		hits, err = index.scriptScoreSearch(queryClause["script_score"], from+size)
+	default:
+		hits, err = index.filterSearch(queryClause)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	total := len(hits)
+	if from > len(hits) {
+		from = len(hits)
+	}
+	hits = hits[from:]
+	if size < len(hits) {
+		hits = hits[:size]
+	}
+
+	hitList := make([]interface{}, 0, len(hits))
+	var maxScore interface{}
+	for i, hit := range hits {
+		if i == 0 {
+			maxScore = hit.score
+		}
+		item := map[string]interface{}{
+			"_index": index.name,
+			"_id":    hit.id,
+			"_score": hit.score,
+		}
+		if source := filterSource(index.docs[hit.id].source, query["_source"]); source != nil {
+			item["_source"] = source
+		}
+		hitList = append(hitList, item)
+	}
+
+	return map[string]interface{}{
+		"took":      time.Since(start).Milliseconds(),
+		"timed_out": false,
+		"hits": map[string]interface{}{
+			"total":     map[string]interface{}{"value": total, "relation": "eq"},
+			"max_score": maxScore,
+			"hits":      hitList,
+		},
+	}, nil
+}
+
+// BatchSearch runs the kNN queries of a batch search one after another
+// BatchSearch 依次执行批量搜索中的 kNN 查询
+func (m *MemoryStore) BatchSearch(indexName string, req model.BatchSearchRequest) *model.BatchSearchResponse {
+	start := time.Now()
+
+	queries, rescores := buildBatchSearchQueries(req)
+	results := make([]model.BatchSearchResult, len(queries))
+	for i, query := range queries {
+		results[i].Index = i
+		response, err := m.Search(indexName, query)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+
+		// Round trip through JSON so hits look exactly like decoded ES responses
+		// 经过一次 JSON 编解码，使结果与解析后的 ES 响应完全一致
+		var decoded map[string]interface{}
+		data, _ := json.Marshal(response)
+		json.Unmarshal(data, &decoded)
+		rescores[i].Apply(decoded)
+		fillBatchSearchResult(&results[i], decoded)
+	}
+
+	return newBatchSearchResponse(results, time.Since(start))
+}
+
+// Count returns the number of documents matching a query
+// Count 返回匹配查询条件的文档数
+func (m *MemoryStore) Count(indexName string, query map[string]interface{}) (int64, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return 0, err
+	}
+	hits, err := index.filterSearch(query)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(hits)), nil
+}
+
+// TrainIVFIndex clusters the vectors of a field with k-means and assigns every
+// document to its nearest centroid. It accepts the same parameters as the plugin's
+// _ivf/train endpoint plus an optional seed.
+// TrainIVFIndex 使用 KMeans 对字段向量聚类并将每个文档分配到最近的聚类中心；
+// 参数与插件的 _ivf/train 接口相同，另支持可选的 seed
+func (m *MemoryStore) TrainIVFIndex(ctx context.Context, indexName string, params map[string]interface{}) (*model.TrainResult, error) {
+	start := time.Now()
+
+	// Sample under the read lock and cluster without holding the lock, so reads and writes
+	// of other indices are not blocked by k-means
+	// 在读锁下采样，聚类时不持有锁，避免 KMeans 阻塞其他索引的读写
+	m.mu.RLock()
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		m.mu.RUnlock()
+		return nil, err
+	}
+
+	fieldName, _ := params["field"].(string)
+	if fieldName == "" {
+		fieldName = "vector"
+	}
+	field, ok := index.vectorFields[fieldName]
+	if !ok {
+		m.mu.RUnlock()
+		return nil, fmt.Errorf("%w: %s is not a dense_vector field", ErrInvalidRequest, fieldName)
+	}
+
+	nlist := toInt(params["nlist"])
+	if nlist <= 0 {
+		nlist = defaultNList
+	}
+	maxIterations := toInt(params["max_iterations"])
+	if maxIterations <= 0 {
+		maxIterations = defaultTrainMaxIterations
+	}
+	seed := int64(toInt(params["seed"]))
+	if seed == 0 {
+		seed = 1
+	}
+
+	ids := make([]string, 0, len(index.docs))
+	for id, doc := range index.docs {
+		if _, ok := doc.vectors[fieldName]; ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) < nlist {
+		m.mu.RUnlock()
+		return nil, fmt.Errorf("%w: %d vectors are not enough to train nlist=%d", ErrInvalidRequest, len(ids), nlist)
+	}
+
+	// Sort before sampling so the same seed always picks the same vectors
+	// 采样前排序，保证相同 seed 选出相同的向量
+	sort.Strings(ids)
+	rng := rand.New(rand.NewSource(seed))
+	sampleSize := toInt(params["sample_size"])
+	if sampleSize <= 0 || sampleSize > len(ids) {
+		sampleSize = len(ids)
+	}
+	if sampleSize < nlist {
+		sampleSize = nlist
+	}
+	sample := make([][]float32, sampleSize)
+	for i, j := range rng.Perm(len(ids))[:sampleSize] {
+		sample[i] = index.docs[ids[j]].vectors[fieldName]
+	}
+	metric := field.metric
+	m.mu.RUnlock()
+
+	ivf, inertia, err := trainIVF(ctx, sample, nlist, maxIterations, metric, rng)
+	if err != nil {
+		return nil, err
+	}
+
+	// Assign the documents present now, including those written while training
+	// 分配当前存在的文档，包括训练期间写入的文档
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if current, err := m.getIndex(indexName); err != nil || current != index || index.vectorFields[fieldName] != field {
+		return nil, fmt.Errorf("index %s changed while training", indexName)
+	}
+	for id, doc := range index.docs {
+		if vector, ok := doc.vectors[fieldName]; ok {
+			ivf.add(id, vector)
+		}
+	}
+	field.ivf = ivf
+
+	return &model.TrainResult{
+		Success:        true,
+		Message:        "IVF index trained successfully",
+		VectorCount:    sampleSize,
+		NList:          nlist,
+		CentroidCount:  len(ivf.centroids),
+		Inertia:        inertia,
+		TrainingTimeMs: time.Since(start).Milliseconds(),
+	}, nil
+}
+
+// GetIndexStats returns the subset of the ES _stats response used by the manager
+// GetIndexStats 返回管理服务使用的 ES _stats 响应子集
+func (m *MemoryStore) GetIndexStats(indexName string) (map[string]interface{}, error) {
+	docCount, storageBytes, err := m.GetIndexUsage(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	stats := map[string]interface{}{
+		"docs":  map[string]interface{}{"count": docCount},
+		"store": map[string]interface{}{"size_in_bytes": storageBytes},
+	}
+	return map[string]interface{}{
+		"_all": map[string]interface{}{"primaries": stats, "total": stats},
+		"indices": map[string]interface{}{
+			indexName: map[string]interface{}{"primaries": stats, "total": stats},
+		},
+	}, nil
+}
+
+// GetIndexUsage returns the document count and an estimate of the memory used by an index
+// GetIndexUsage 返回索引的文档数以及估算的内存占用
+func (m *MemoryStore) GetIndexUsage(indexName string) (docCount int64, storageBytes int64, err error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	index, err := m.getIndex(indexName)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, doc := range index.docs {
+		if data, err := json.Marshal(doc.source); err == nil {
+			storageBytes += int64(len(data))
+		}
+		for _, vector := range doc.vectors {
+			storageBytes += int64(len(vector) * 4)
+		}
+	}
+	return int64(len(index.docs)), storageBytes, nil
+}
+
+// getIndex looks up an index, resolving an alias to its write index or single index;
+// the caller must hold the lock
+// getIndex 查找索引，别名解析为其写入索引或唯一索引；调用方需持有锁
+func (m *MemoryStore) getIndex(indexName string) (*memoryIndex, error) {
+	if index, ok := m.indices[indexName]; ok {
+		return index, nil
+	}
+	if alias, ok := m.aliases[indexName]; ok {
+		target := alias.writeIndex
+		if target == "" && len(alias.indices) == 1 {
+			for name := range alias.indices {
+				target = name
+			}
+		}
+		if target == "" {
+			return nil, fmt.Errorf("%w: alias %s points to %d indices", ErrInvalidRequest, indexName, len(alias.indices))
+		}
+		if index, ok := m.indices[target]; ok {
+			return index, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s", ErrIndexNotFound, indexName)
+}
+
+// put validates and stores a document, keeping IVF lists up to date
+// put 校验并保存文档，同时维护 IVF 倒排列表
+func (idx *memoryIndex) put(docID string, source map[string]interface{}) (*model.DocumentResult, error) {
+	if idx.writeBlocked {
+		return nil, fmt.Errorf("%w: index %s is blocked for writes", ErrIndexBusy, idx.name)
+	}
+	vectors := make(map[string][]float32)
+	for name, field := range idx.vectorFields {
+		value, ok := source[name]
+		if !ok || value == nil {
+			continue
+		}
+		vector, ok := toVector(value)
+		if !ok {
+			return nil, fmt.Errorf("%w: field %s is not a vector", ErrInvalidRequest, name)
+		}
+		if field.dims > 0 && len(vector) != field.dims {
+			return nil, fmt.Errorf("%w: field %s has dimension %d, expected %d", ErrInvalidRequest, name, len(vector), field.dims)
+		}
+		vectors[name] = vector
+	}
+
+	if docID == "" {
+		idx.nextID++
+		docID = fmt.Sprintf("mem-%d", idx.nextID)
+	}
+
+	result := "created"
+	version := int64(1)
+	if existing, ok := idx.docs[docID]; ok {
+		result = "updated"
+		version = existing.version + 1
+		idx.remove(docID)
+	}
+
+	idx.docs[docID] = &memoryDoc{
+		source:  copySource(source),
+		version: version,
+		vectors: vectors,
+	}
+	for name, vector := range vectors {
+		if ivf := idx.vectorFields[name].ivf; ivf != nil {
+			ivf.add(docID, vector)
+		}
+	}
+
+	return &model.DocumentResult{
+		Index:   idx.name,
+		ID:      docID,
+		Result:  result,
+		Version: version,
+	}, nil
+}
+
+// remove deletes a document and its IVF list entries
+// remove 删除文档及其 IVF 倒排列表项
+func (idx *memoryIndex) remove(docID string) {
+	for _, field := range idx.vectorFields {
+		if field.ivf != nil {
+			field.ivf.remove(docID)
+		}
+	}
+	delete(idx.docs, docID)
+}
+
+// memoryHit is a scored search hit
+// memoryHit 带分数的搜索命中
+type memoryHit struct {
+	id    string
+	score float64
+}
+
+// vectorSearch returns the k best documents for a query vector. With nprobe > 0 and
+// a trained IVF structure only the nprobe closest lists are scanned, otherwise the
+// search is exact.
+// vectorSearch 返回与查询向量最接近的 k 个文档；当 nprobe > 0 且已训练 IVF 时
+// 仅扫描最近的 nprobe 个倒排列表，否则执行精确搜索
+func (idx *memoryIndex) vectorSearch(fieldValue, vectorValue interface{}, k, nprobe int, filters []map[string]interface{}) ([]memoryHit, error) {
+	fieldName, _ := fieldValue.(string)
+	field, ok := idx.vectorFields[fieldName]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s is not a dense_vector field", ErrInvalidRequest, fieldName)
+	}
+	query, ok := toVector(vectorValue)
+	if !ok || (field.dims > 0 && len(query) != field.dims) {
+		return nil, fmt.Errorf("%w: query vector must have dimension %d", ErrInvalidRequest, field.dims)
+	}
+	if k <= 0 {
+		k = 10
+	}
+	for _, filter := range filters {
+		if err := validateQuery(filter); err != nil {
+			return nil, err
+		}
+	}
+
+	var candidates []string
+	if nprobe > 0 && field.ivf != nil {
+		candidates = field.ivf.probe(query, nprobe)
+	} else {
+		candidates = make([]string, 0, len(idx.docs))
+		for id := range idx.docs {
+			candidates = append(candidates, id)
+		}
+	}
+
+	hits := make([]memoryHit, 0, len(candidates))
+	for _, id := range candidates {
+		doc := idx.docs[id]
+		vector, ok := doc.vectors[fieldName]
+		if !ok {
+			continue
+		}
+		matched := true
+		for _, filter := range filters {
+			if matched = matchQuery(doc.source, id, filter); !matched {
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+		hits = append(hits, memoryHit{id: id, score: esScore(similarity(query, vector, field.metric), field.metric)})
+	}
+
+	sortHits(hits)
+	if len(hits) > k {
+		hits = hits[:k]
+	}
+	return hits, nil
+}
+
+// scriptScoreSearch runs the exact vector script_score queries built by exactVectorQuery. The script
+// source is not evaluated: documents matching the inner query are scored exactly with the similarity
+// of the field named in params.field, which ranks them the same way as those scripts.
+// scriptScoreSearch 执行 exactVectorQuery 构建的精确向量 script_score 查询；不解析脚本内容，
+// 而是用 params.field 所指字段的相似度对匹配内部查询的文档精确打分，排序与这些脚本一致
+func (idx *memoryIndex) scriptScoreSearch(body interface{}, k int) ([]memoryHit, error) {
+	clause, _ := body.(map[string]interface{})
+	script, _ := clause["script"].(map[string]interface{})
+	params, _ := script["params"].(map[string]interface{})
+	if params["field"] == nil || params["query_vector"] == nil {
+		return nil, fmt.Errorf("%w: script_score is only supported with params.field and params.query_vector", ErrInvalidRequest)
+	}
+
+	var filters []map[string]interface{}
+	if inner, ok := clause["query"].(map[string]interface{}); ok {
+		filters = append(filters, inner)
+	}
+	return idx.vectorSearch(params["field"], params["query_vector"], k, 0, filters)
+}
+
+// filterSearch returns every document matching a query with a constant score, in ID order
+// filterSearch 以常数分数按 ID 顺序返回所有匹配查询的文档
+func (idx *memoryIndex) filterSearch(query map[string]interface{}) ([]memoryHit, error) {
+	if err := validateQuery(query); err != nil {
+		return nil, err
+	}
+
+	var hits []memoryHit
+	for id, doc := range idx.docs {
+		if matchQuery(doc.source, id, query) {
+			hits = append(hits, memoryHit{id: id, score: 1})
+		}
+	}
+	sortHits(hits)
+	return hits, nil
+}
+
+// extractANN finds the plugin ann query either at the top of the query or inside
+// bool.must, returning the remaining clauses as filters
+// extractANN 在查询顶层或 bool.must 中查找插件的 ann 查询，其余子句作为过滤条件返回
+func extractANN(query map[string]interface{}) (map[string]interface{}, []map[string]interface{}) {
+	if ann, ok := query["ann"].(map[string]interface{}); ok {
+		return ann, nil
+	}
+
+	boolQuery, ok := query["bool"].(map[string]interface{})
+	if !ok {
+		return nil, nil
+	}
+	var ann map[string]interface{}
+	var filters []map[string]interface{}
+	for _, key := range []string{"must", "filter"} {
+		for _, clause := range queryClauses(boolQuery[key]) {
+			if found, ok := clause["ann"].(map[string]interface{}); ok && ann == nil {
+				ann = found
+				continue
+			}
+			filters = append(filters, clause)
+		}
+	}
+	if ann == nil {
+		return nil, nil
+	}
+	if mustNot := queryClauses(boolQuery["must_not"]); len(mustNot) > 0 {
+		filters = append(filters, map[string]interface{}{
+			"bool": map[string]interface{}{"must_not": boolQuery["must_not"]},
+		})
+	}
+	return ann, filters
+}
+
+// validateQuery rejects query types the memory store does not understand
+// validateQuery 拒绝内存存储不支持的查询类型
+func validateQuery(query map[string]interface{}) error {
+	for kind, body := range query {
+		switch kind {
+		case "match_all", "ids", "term", "terms", "exists":
+		case "bool":
+			clause, _ := body.(map[string]interface{})
+			for _, key := range []string{"must", "filter", "must_not", "should"} {
+				for _, sub := range queryClauses(clause[key]) {
+					if err := validateQuery(sub); err != nil {
+						return err
+					}
+				}
+			}
+		default:
+			return fmt.Errorf("%w: query type %s is not supported by the memory store", ErrInvalidRequest, kind)
+		}
+	}
+	return nil
+}
+
+// matchQuery evaluates a validated filter query against a document. An empty query matches everything.
+// matchQuery 针对文档评估已校验的过滤查询，空查询匹配所有文档
+func matchQuery(source map[string]interface{}, id string, query map[string]interface{}) bool {
+	for kind, body := range query {
+		clause, _ := body.(map[string]interface{})
+		switch kind {
+		case "ids":
+			found := false
+			for _, v := range toList(clause["values"]) {
+				if fmt.Sprint(v) == id {
+					found = true
+					break
+				}
+			}
+			if !found {
+				return false
+			}
+		case "term":
+			for field, expected := range clause {
+				if e, ok := expected.(map[string]interface{}); ok {
+					expected = e["value"]
+				}
+				if !valueMatches(source[field], expected) {
+					return false
+				}
+			}
+		case "terms":
+			for field, expected := range clause {
+				found := false
+				for _, v := range toList(expected) {
+					if valueMatches(source[field], v) {
+						found = true
+						break
+					}
+				}
+				if !found {
+					return false
+				}
+			}
+		case "exists":
+			field, _ := clause["field"].(string)
+			if value, ok := source[field]; !ok || value == nil {
+				return false
+			}
+		case "bool":
+			for _, key := range []string{"must", "filter"} {
+				for _, sub := range queryClauses(clause[key]) {
+					if !matchQuery(source, id, sub) {
+						return false
+					}
+				}
+			}
+			for _, sub := range queryClauses(clause["must_not"]) {
+				if matchQuery(source, id, sub) {
+					return false
+				}
+			}
+			if should := queryClauses(clause["should"]); len(should) > 0 {
+				matched := false
+				for _, sub := range should {
+					if matchQuery(source, id, sub) {
+						matched = true
+						break
+					}
+				}
+				if !matched {
+					return false
+				}
+			}
+		}
+	}
+	return true
+}
+
+// toList converts a decoded JSON array or a string slice into a generic list
+// toList 将解析后的 JSON 数组或字符串切片转换为通用列表
+func toList(value interface{}) []interface{} {
+	switch v := value.(type) {
+	case []interface{}:
+		return v
+	case []string:
+		list := make([]interface{}, len(v))
+		for i, item := range v {
+			list[i] = item
+		}
+		return list
+	}
+	return nil
+}
+
+// queryClauses normalizes a bool clause that may be a single query or a list
+// queryClauses 规范化 bool 子句（可能是单个查询或查询列表）
+func queryClauses(value interface{}) []map[string]interface{} {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		return []map[string]interface{}{v}
+	case []interface{}:
+		clauses := make([]map[string]interface{}, 0, len(v))
+		for _, item := range v {
+			if clause, ok := item.(map[string]interface{}); ok {
+				clauses = append(clauses, clause)
+			}
+		}
+		return clauses
+	case []map[string]interface{}:
+		return v
+	}
+	return nil
+}
+
+// valueMatches compares a document value with a term, matching any element of arrays
+// valueMatches 比较文档值与查询词，数组中任一元素相等即匹配
+func valueMatches(value, expected interface{}) bool {
+	if list, ok := value.([]interface{}); ok {
+		for _, item := range list {
+			if valueMatches(item, expected) {
+				return true
+			}
+		}
+		return false
+	}
+	return fmt.Sprint(value) == fmt.Sprint(expected)
+}
+
+// filterSource applies the _source parameter of a search body
+// filterSource 应用搜索请求体中的 _source 参数
+func filterSource(source map[string]interface{}, spec interface{}) map[string]interface{} {
+	switch v := spec.(type) {
+	case nil:
+		return copySource(source)
+	case bool:
+		if v {
+			return copySource(source)
+		}
+		return nil
+	case []interface{}, []string:
+		var fields []string
+		if list, ok := v.([]string); ok {
+			fields = list
+		} else {
+			for _, item := range v.([]interface{}) {
+				fields = append(fields, fmt.Sprint(item))
+			}
+		}
+		filtered := make(map[string]interface{}, len(fields))
+		for _, field := range fields {
+			if value, ok := source[field]; ok {
+				filtered[field] = value
+			}
+		}
+		return filtered
+	}
+	return copySource(source)
+}
+
+// sortHits orders hits by descending score, breaking ties by ID
+// sortHits 按分数降序排列命中结果，分数相同时按 ID 排序
+func sortHits(hits []memoryHit) {
+	sort.Slice(hits, func(i, j int) bool {
+		if hits[i].score != hits[j].score {
+			return hits[i].score > hits[j].score
+		}
+		return hits[i].id < hits[j].id
+	})
+}
+
+// esScore converts a similarity into the score Elasticsearch reports for a metric
+// esScore 将相似度转换为 Elasticsearch 针对该度量返回的分数
+func esScore(sim float64, metric string) float64 {
+	switch metric {
+	case "l2":
+		// similarity is the negative squared distance
+		// similarity 为负的距离平方
+		return 1 / (1 - sim)
+	default:
+		return (1 + sim) / 2
+	}
+}
+
+// storeMetric maps an ES similarity name to the metric names used by the manager
+// storeMetric 将 ES 相似度名称映射为管理服务使用的度量名称
+func storeMetric(similarity string) string {
+	switch strings.ToLower(similarity) {
+	case "l2_norm", "l2":
+		return "l2"
+	case "dot_product", "dot", "max_inner_product":
+		return "dot"
+	default:
+		return "cosine"
+	}
+}
+
+// toVector converts a decoded JSON array or a float slice into a vector
+// toVector 将解析后的 JSON 数组或浮点切片转换为向量
+func toVector(value interface{}) ([]float32, bool) {
+	switch v := value.(type) {
+	case []float32:
+		return v, true
+	case []float64:
+		vector := make([]float32, len(v))
+		for i, f := range v {
+			vector[i] = float32(f)
+		}
+		return vector, true
+	case []interface{}:
+		vector := make([]float32, len(v))
+		for i, item := range v {
+			f, ok := item.(float64)
+			if !ok {
+				return nil, false
+			}
+			vector[i] = float32(f)
+		}
+		return vector, true
+	}
+	return nil, false
+}
+
+// toInt converts a decoded JSON number or Go integer into an int
+// toInt 将解析后的 JSON 数字或 Go 整数转换为 int
+func toInt(value interface{}) int {
+	switch v := value.(type) {
+	case int:
+		return v
+	case int64:
+		return int(v)
+	case float64:
+		return int(v)
+	case json.Number:
+		n, _ := v.Int64()
+		return int(n)
+	}
+	return 0
+}
+
+// copySource makes a shallow copy of a document source
+// copySource 浅拷贝文档内容
+func copySource(source map[string]interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(source))
+	for key, value := range source {
+		copied[key] = value
+	}
+	return copied
+}
@@ -0,0 +1,360 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"es-serverless-manager/internal/model"
+)
+
+// newTestStore creates a memory store with one two-dimensional index and the given documents
+func newTestStore(t *testing.T, indexName, metric string, docs map[string][]float64) *MemoryStore {
+	t.Helper()
+
+	store := NewMemoryStore()
+	mapping := BuildVectorMapping(model.VectorIndexRequest{
+		Dimension:    2,
+		Metric:       metric,
+		FieldMapping: map[string]string{"category": "keyword"},
+	})
+	if err := store.CreateVectorIndex(indexName, mapping); err != nil {
+		t.Fatalf("CreateVectorIndex: %v", err)
+	}
+
+	bulk := make([]model.BulkDocument, 0, len(docs))
+	for id, vector := range docs {
+		bulk = append(bulk, model.BulkDocument{ID: id, Document: map[string]interface{}{
+			"vector":   vector,
+			"category": fmt.Sprintf("c%d", int(vector[0])%2),
+		}})
+	}
+	response, err := store.BulkIndex(indexName, bulk)
+	if err != nil {
+		t.Fatalf("BulkIndex: %v", err)
+	}
+	if response.Errors != 0 {
+		t.Fatalf("BulkIndex reported %d errors: %+v", response.Errors, response.Items)
+	}
+	return store
+}
+
+// hitIDs returns the document IDs of a search response in order
+func hitIDs(t *testing.T, response map[string]interface{}) []string {
+	t.Helper()
+
+	hits, _ := response["hits"].(map[string]interface{})
+	hitList, _ := hits["hits"].([]interface{})
+	ids := make([]string, 0, len(hitList))
+	for _, item := range hitList {
+		hit := item.(map[string]interface{})
+		ids = append(ids, hit["_id"].(string))
+	}
+	return ids
+}
+
+func TestMemoryStoreDocumentLifecycle(t *testing.T) {
+	store := newTestStore(t, "docs", "l2", nil)
+
+	created, err := store.IndexDocument("docs", "a", map[string]interface{}{"vector": []float64{1, 2}, "category": "x"})
+	if err != nil {
+		t.Fatalf("IndexDocument: %v", err)
+	}
+	if created.Result != "created" || created.Version != 1 {
+		t.Fatalf("IndexDocument = %+v, want created version 1", created)
+	}
+
+	updated, err := store.UpdateDocument("docs", "a", map[string]interface{}{"category": "y"})
+	if err != nil {
+		t.Fatalf("UpdateDocument: %v", err)
+	}
+	if updated.Result != "updated" || updated.Version != 2 {
+		t.Fatalf("UpdateDocument = %+v, want updated version 2", updated)
+	}
+
+	doc, err := store.GetDocument("docs", "a")
+	if err != nil {
+		t.Fatalf("GetDocument: %v", err)
+	}
+	source := doc["_source"].(map[string]interface{})
+	if source["category"] != "y" {
+		t.Fatalf("category = %v, want y", source["category"])
+	}
+
+	if _, err := store.IndexDocument("docs", "bad", map[string]interface{}{"vector": []float64{1, 2, 3}}); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("IndexDocument with wrong dimension: err = %v, want ErrInvalidRequest", err)
+	}
+
+	if _, err := store.DeleteDocument("docs", "a"); err != nil {
+		t.Fatalf("DeleteDocument: %v", err)
+	}
+	if _, err := store.GetDocument("docs", "a"); !errors.Is(err, ErrDocumentNotFound) {
+		t.Fatalf("GetDocument after delete: err = %v, want ErrDocumentNotFound", err)
+	}
+	if _, err := store.GetDocument("missing", "a"); !errors.Is(err, ErrIndexNotFound) {
+		t.Fatalf("GetDocument on missing index: err = %v, want ErrIndexNotFound", err)
+	}
+}
+
+func TestMemoryStoreKNNSearch(t *testing.T) {
+	docs := map[string][]float64{
+		"origin": {0, 0},
+		"near":   {1, 0},
+		"mid":    {3, 0},
+		"far":    {10, 0},
+	}
+
+	tests := []struct {
+		name   string
+		metric string
+		query  []float64
+		filter map[string]interface{}
+		want   []string
+	}{
+		{
+			name:   "l2 orders by distance",
+			metric: "l2",
+			query:  []float64{0.9, 0},
+			want:   []string{"near", "origin", "mid"},
+		},
+		{
+			name:   "l2_norm is an alias of l2",
+			metric: "l2_norm",
+			query:  []float64{9, 0},
+			want:   []string{"far", "mid", "near"},
+		},
+		{
+			name:   "filter restricts candidates",
+			metric: "l2",
+			query:  []float64{0.9, 0},
+			filter: map[string]interface{}{"term": map[string]interface{}{"category": "c1"}},
+			want:   []string{"near", "mid"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newTestStore(t, "vectors", tt.metric, docs)
+			knn := map[string]interface{}{
+				"field":          "vector",
+				"query_vector":   tt.query,
+				"k":              3,
+				"num_candidates": 10,
+			}
+			if tt.filter != nil {
+				knn["filter"] = tt.filter
+			}
+
+			response, err := store.Search("vectors", map[string]interface{}{"knn": knn, "size": 3})
+			if err != nil {
+				t.Fatalf("Search: %v", err)
+			}
+			if got := hitIDs(t, response); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("hits = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryStoreIVFMatchesExactSearchWhenProbingAllLists(t *testing.T) {
+	docs := make(map[string][]float64)
+	for i := 0; i < 40; i++ {
+		docs[fmt.Sprintf("doc-%02d", i)] = []float64{float64(i % 8), float64(i / 8)}
+	}
+	store := newTestStore(t, "ivf", "l2", docs)
+
+	result, err := store.TrainIVFIndex(context.Background(), "ivf", map[string]interface{}{"nlist": 4, "seed": 7})
+	if err != nil {
+		t.Fatalf("TrainIVFIndex: %v", err)
+	}
+	if result.CentroidCount != 4 || result.VectorCount != 40 {
+		t.Fatalf("TrainIVFIndex = %+v, want 4 centroids from 40 vectors", result)
+	}
+
+	query := []float64{2.2, 3.1}
+	exact, err := store.Search("ivf", map[string]interface{}{
+		"knn":  map[string]interface{}{"field": "vector", "query_vector": query, "k": 5},
+		"size": 5,
+	})
+	if err != nil {
+		t.Fatalf("exact Search: %v", err)
+	}
+	ann, err := store.Search("ivf", map[string]interface{}{
+		"query": map[string]interface{}{"ann": map[string]interface{}{
+			"field": "vector", "vector": query, "algorithm": "ivf", "nprobe": 4, "k": 5,
+		}},
+		"size": 5,
+	})
+	if err != nil {
+		t.Fatalf("ann Search: %v", err)
+	}
+	if got, want := hitIDs(t, ann), hitIDs(t, exact); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("ann hits = %v, exact hits = %v", got, want)
+	}
+}
+
+func TestMemoryStoreAliasSwap(t *testing.T) {
+	store := newTestStore(t, "products_v1", "l2", map[string][]float64{"a": {1, 1}})
+	if err := store.CreateVectorIndex("products_v2", BuildVectorMapping(model.VectorIndexRequest{Dimension: 2})); err != nil {
+		t.Fatalf("CreateVectorIndex: %v", err)
+	}
+
+	if err := store.UpdateAliases([]map[string]interface{}{
+		{"add": map[string]interface{}{"index": "products_v1", "alias": "products"}},
+	}); err != nil {
+		t.Fatalf("UpdateAliases add: %v", err)
+	}
+	if _, err := store.GetDocument("products", "a"); err != nil {
+		t.Fatalf("GetDocument through alias: %v", err)
+	}
+
+	// A failing action leaves the alias untouched
+	err := store.UpdateAliases([]map[string]interface{}{
+		{"add": map[string]interface{}{"index": "products_v2", "alias": "products"}},
+		{"remove": map[string]interface{}{"index": "missing", "alias": "products"}},
+	})
+	if !errors.Is(err, ErrIndexNotFound) {
+		t.Fatalf("UpdateAliases with missing index: err = %v, want ErrIndexNotFound", err)
+	}
+	if indices, _ := store.GetAliasIndices("products"); fmt.Sprint(indices) != "[products_v1]" {
+		t.Fatalf("alias indices after failed update = %v, want [products_v1]", indices)
+	}
+
+	if err := store.UpdateAliases([]map[string]interface{}{
+		{"add": map[string]interface{}{"index": "products_v2", "alias": "products"}},
+		{"remove_index": map[string]interface{}{"index": "products_v1"}},
+	}); err != nil {
+		t.Fatalf("UpdateAliases swap: %v", err)
+	}
+	if indices, _ := store.GetAliasIndices("products"); fmt.Sprint(indices) != "[products_v2]" {
+		t.Fatalf("alias indices after swap = %v, want [products_v2]", indices)
+	}
+	if names, _ := store.ListIndexes(); fmt.Sprint(names) != "[products_v2]" {
+		t.Fatalf("indexes after swap = %v, want [products_v2]", names)
+	}
+
+	err = store.UpdateAliases([]map[string]interface{}{
+		{"add": map[string]interface{}{"index": "products_v2", "alias": "products_v2"}},
+	})
+	if !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("alias named like an index: err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestMemoryStoreReindexCatchUp(t *testing.T) {
+	store := newTestStore(t, "source", "l2", map[string][]float64{"a": {1, 1}, "b": {2, 2}, "c": {3, 3}})
+	if err := store.CreateVectorIndex("dest", BuildVectorMapping(model.VectorIndexRequest{Dimension: 2})); err != nil {
+		t.Fatalf("CreateVectorIndex: %v", err)
+	}
+
+	taskID, err := store.Reindex("source", "dest", nil)
+	if err != nil {
+		t.Fatalf("Reindex: %v", err)
+	}
+	status, err := store.GetTask(taskID)
+	if err != nil {
+		t.Fatalf("GetTask: %v", err)
+	}
+	if completed, _ := status["completed"].(bool); !completed {
+		t.Fatalf("task %s is not completed: %v", taskID, status)
+	}
+	if processed, total := esTaskProgress(status["task"].(map[string]interface{})["status"].(map[string]interface{})); processed != 3 || total != 3 {
+		t.Fatalf("progress = %d/%d, want 3/3", processed, total)
+	}
+
+	// Only the document written after the first pass is copied again
+	if _, err := store.UpdateDocument("source", "b", map[string]interface{}{"category": "changed"}); err != nil {
+		t.Fatalf("UpdateDocument: %v", err)
+	}
+	taskID, err = store.Reindex("source", "dest", nil)
+	if err != nil {
+		t.Fatalf("second Reindex: %v", err)
+	}
+	status, _ = store.GetTask(taskID)
+	response := status["response"].(map[string]interface{})
+	if response["updated"] != float64(1) || response["version_conflicts"] != float64(2) {
+		t.Fatalf("catch-up response = %v, want 1 updated and 2 version conflicts", response)
+	}
+
+	doc, err := store.GetDocument("dest", "b")
+	if err != nil {
+		t.Fatalf("GetDocument: %v", err)
+	}
+	if doc["_source"].(map[string]interface{})["category"] != "changed" {
+		t.Fatalf("dest document b = %v, want the updated source", doc)
+	}
+
+	if _, err := store.Reindex("source", "dest", map[string]interface{}{"source": "ctx._source.x = 1"}); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("Reindex with script: err = %v, want ErrInvalidRequest", err)
+	}
+}
+
+func TestMemoryStoreWriteBlock(t *testing.T) {
+	store := newTestStore(t, "blocked", "l2", map[string][]float64{"a": {1, 1}})
+
+	if err := store.UpdateIndexSettings("blocked", map[string]interface{}{"index.blocks.write": true}); err != nil {
+		t.Fatalf("UpdateIndexSettings: %v", err)
+	}
+	if _, err := store.IndexDocument("blocked", "b", map[string]interface{}{"vector": []float64{2, 2}}); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("IndexDocument on blocked index: err = %v, want ErrIndexBusy", err)
+	}
+	if _, err := store.DeleteDocument("blocked", "a"); !errors.Is(err, ErrIndexBusy) {
+		t.Fatalf("DeleteDocument on blocked index: err = %v, want ErrIndexBusy", err)
+	}
+
+	if err := store.UpdateIndexSettings("blocked", map[string]interface{}{"index.blocks.write": false}); err != nil {
+		t.Fatalf("UpdateIndexSettings: %v", err)
+	}
+	if _, err := store.IndexDocument("blocked", "b", map[string]interface{}{"vector": []float64{2, 2}}); err != nil {
+		t.Fatalf("IndexDocument after unblocking: %v", err)
+	}
+}
+
+func TestMemoryStorePointInTimeIsASnapshot(t *testing.T) {
+	store := newTestStore(t, "pit", "l2", map[string][]float64{"a": {1, 1}, "b": {2, 2}, "c": {3, 3}})
+
+	pitID, err := store.OpenPointInTime("pit", "1m")
+	if err != nil {
+		t.Fatalf("OpenPointInTime: %v", err)
+	}
+	if _, err := store.IndexDocument("pit", "d", map[string]interface{}{"vector": []float64{4, 4}}); err != nil {
+		t.Fatalf("IndexDocument: %v", err)
+	}
+
+	var ids []string
+	var searchAfter interface{}
+	for {
+		body := map[string]interface{}{
+			"size": 2,
+			"pit":  map[string]interface{}{"id": pitID, "keep_alive": "1m"},
+			"sort": []interface{}{map[string]interface{}{"_shard_doc": "asc"}},
+		}
+		if searchAfter != nil {
+			body["search_after"] = searchAfter
+		}
+		response, err := store.SearchPointInTime(body)
+		if err != nil {
+			t.Fatalf("SearchPointInTime: %v", err)
+		}
+		hitList := response["hits"].(map[string]interface{})["hits"].([]interface{})
+		if len(hitList) == 0 {
+			break
+		}
+		for _, item := range hitList {
+			hit := item.(map[string]interface{})
+			ids = append(ids, hit["_id"].(string))
+			searchAfter = hit["sort"]
+		}
+	}
+	if fmt.Sprint(ids) != "[a b c]" {
+		t.Fatalf("point-in-time documents = %v, want [a b c]", ids)
+	}
+
+	if err := store.ClosePointInTime(pitID); err != nil {
+		t.Fatalf("ClosePointInTime: %v", err)
+	}
+	if _, err := store.SearchPointInTime(map[string]interface{}{"pit": map[string]interface{}{"id": pitID}}); !errors.Is(err, ErrInvalidRequest) {
+		t.Fatalf("SearchPointInTime after close: err = %v, want ErrInvalidRequest", err)
+	}
+}
@@ -2,9 +2,13 @@ package service
 
 import (
 	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 
 	"es-serverless-manager/internal/model"
 )
@@ -72,6 +76,22 @@ func (m *MetadataService) SaveDeploymentStatus(status *model.DeploymentStatus) e
 	return m.db.Save(status).Error
 }
 
+// UpdateDeploymentStatusColumns writes only the named columns of a deployment status, so the columns
+// other writers changed since it was read are kept
+// UpdateDeploymentStatusColumns 仅写入部署状态中指定的列，保留读取之后其他写入方修改的列
+func (m *MetadataService) UpdateDeploymentStatusColumns(status *model.DeploymentStatus, columns ...string) error {
+	return m.db.Model(&model.DeploymentStatus{}).Where("namespace = ?", status.Namespace).Select(columns).Updates(status).Error
+}
+
+// SetDeploymentStatusIf sets the status and its reason only while the recorded status is still from,
+// so a status another writer set in the meantime is not overwritten
+// SetDeploymentStatusIf 仅当记录的状态仍为 from 时设置状态及其原因，避免覆盖其他写入方在此期间设置的状态
+func (m *MetadataService) SetDeploymentStatusIf(namespace, from, status, reason string) error {
+	return m.db.Model(&model.DeploymentStatus{}).
+		Where("namespace = ? AND status = ?", namespace, from).
+		Updates(map[string]interface{}{"status": status, "status_reason": reason}).Error
+}
+
 // GetDeploymentStatus retrieves deployment status metadata
 // GetDeploymentStatus 获取部署状态元数据
 func (m *MetadataService) GetDeploymentStatus(namespace string) (*model.DeploymentStatus, error) {
@@ -111,6 +131,86 @@ func (m *MetadataService) GetIndexMetadata(id string) (*model.IndexMetadata, err
 	return &metadata, nil
 }
 
+// GetIndexMetadataByName retrieves the metadata of a live index by its logical name
+// or by the name of its current backing index
+// GetIndexMetadataByName 根据逻辑索引名或当前底层索引名获取未删除索引的元数据
+func (m *MetadataService) GetIndexMetadataByName(indexName string) (*model.IndexMetadata, error) {
+	var metadata model.IndexMetadata
+	result := m.db.Where("(index_name = ? OR backing_index = ?) AND status <> ?", indexName, indexName, "deleted").First(&metadata)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &metadata, nil
+}
+
+// UpdateIndexUsage applies a document count delta and, when storageBytes is not negative,
+// records the new storage size and carries the difference over to the tenant quota
+// UpdateIndexUsage 更新索引文档数；当 storageBytes 非负时记录新的存储大小并同步到租户配额
+func (m *MetadataService) UpdateIndexUsage(indexName string, docDelta int, storageBytes int64) error {
+	return m.updateIndexUsage(indexName, gorm.Expr("GREATEST(document_count + ?, 0)", docDelta), storageBytes)
+}
+
+// SetIndexUsage records the live document count of an index and, when storageBytes is not negative,
+// its storage size
+// SetIndexUsage 记录索引的实时文档数；当 storageBytes 非负时同时记录其存储大小
+func (m *MetadataService) SetIndexUsage(indexName string, docCount int64, storageBytes int64) error {
+	return m.updateIndexUsage(indexName, docCount, storageBytes)
+}
+
+// updateIndexUsage updates the document count in place and the storage size under a row lock, so
+// concurrent requests neither lose deltas nor charge the tenant twice
+// updateIndexUsage 原地更新文档数，并在行锁下更新存储大小，避免并发请求丢失增量或重复计入租户配额
+func (m *MetadataService) updateIndexUsage(indexName string, documentCount interface{}, storageBytes int64) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		var metadata model.IndexMetadata
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("(index_name = ? OR backing_index = ?) AND status <> ?", indexName, indexName, "deleted").
+			First(&metadata).Error
+		if err != nil {
+			return err
+		}
+
+		updates := map[string]interface{}{
+			"document_count": documentCount,
+			"updated_at":     time.Now(),
+		}
+		if storageBytes >= 0 {
+			previous := parseStorageSize(metadata.StorageSize)
+			updates["storage_size"] = formatStorageSize(storageBytes)
+			if metadata.CreatedBy != "" && storageBytes != previous {
+				if err := m.adjustTenantStorage(tx, metadata.CreatedBy, storageBytes-previous); err != nil {
+					return err
+				}
+			}
+		}
+		return tx.Model(&model.IndexMetadata{}).Where("id = ?", metadata.ID).Updates(updates).Error
+	})
+}
+
+// MarkIndexDeleted marks an index as deleted and releases its storage and index quota
+// MarkIndexDeleted 将索引标记为已删除，并释放其存储和索引配额
+func (m *MetadataService) MarkIndexDeleted(indexName string) error {
+	metadata, err := m.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return err
+	}
+
+	if metadata.CreatedBy != "" {
+		if err := m.adjustTenantStorage(m.db, metadata.CreatedBy, -parseStorageSize(metadata.StorageSize)); err != nil {
+			return err
+		}
+		if err := m.UpdateTenantQuotaUsage(metadata.CreatedBy, false, ""); err != nil {
+			return err
+		}
+	}
+
+	metadata.Status = "deleted"
+	metadata.DocumentCount = 0
+	metadata.StorageSize = "0"
+	metadata.UpdatedAt = time.Now()
+	return m.SaveIndexMetadata(metadata)
+}
+
 // ListIndexMetadata lists all index metadata
 // ListIndexMetadata 列出所有索引元数据
 func (m *MetadataService) ListIndexMetadata() ([]*model.IndexMetadata, error) {
@@ -128,6 +228,40 @@ func (m *MetadataService) DeleteIndexMetadata(id string) error {
 	return m.db.Delete(&model.IndexMetadata{}, "id = ?", id).Error
 }
 
+// SaveIndexAlias saves an index alias
+// SaveIndexAlias 保存索引别名
+func (m *MetadataService) SaveIndexAlias(alias *model.IndexAlias) error {
+	return m.db.Save(alias).Error
+}
+
+// GetIndexAlias retrieves an index alias by name
+// GetIndexAlias 根据名称获取索引别名
+func (m *MetadataService) GetIndexAlias(name string) (*model.IndexAlias, error) {
+	var alias model.IndexAlias
+	result := m.db.Where("alias = ?", name).First(&alias)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &alias, nil
+}
+
+// ListIndexAliases lists all index aliases
+// ListIndexAliases 列出所有索引别名
+func (m *MetadataService) ListIndexAliases() ([]*model.IndexAlias, error) {
+	var aliases []*model.IndexAlias
+	result := m.db.Order("alias").Find(&aliases)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return aliases, nil
+}
+
+// DeleteIndexAlias deletes an index alias
+// DeleteIndexAlias 删除索引别名
+func (m *MetadataService) DeleteIndexAlias(name string) error {
+	return m.db.Delete(&model.IndexAlias{}, "alias = ?", name).Error
+}
+
 // SaveTenantQuota saves tenant quota
 // SaveTenantQuota 保存租户配额
 func (m *MetadataService) SaveTenantQuota(quota *model.TenantQuota) error {
@@ -211,11 +345,252 @@ func (m *MetadataService) UpdateTenantQuotaUsage(tenantID string, increase bool,
 	return m.SaveTenantQuota(quota)
 }
 
+// adjustTenantStorage adds a byte delta to the tenant's current storage usage, locking the quota
+// row when db is a transaction
+// adjustTenantStorage 将字节增量累加到租户的当前存储使用量；db 为事务时锁定配额行
+func (m *MetadataService) adjustTenantStorage(db *gorm.DB, tenantID string, delta int64) error {
+	var quota model.TenantQuota
+	err := db.Clauses(clause.Locking{Strength: "UPDATE"}).Where("tenant_id = ?", tenantID).First(&quota).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		quota = model.TenantQuota{
+			ID:             "quota_" + tenantID,
+			TenantID:       tenantID,
+			MaxIndices:     100,
+			MaxStorage:     "1Ti",
+			CurrentStorage: "0Gi",
+			CreatedAt:      time.Now(),
+		}
+	} else if err != nil {
+		return err
+	}
+
+	current := parseStorageSize(quota.CurrentStorage) + delta
+	if current < 0 {
+		current = 0
+	}
+	quota.CurrentStorage = formatStorageSize(current)
+	quota.UpdatedAt = time.Now()
+	return db.Save(&quota).Error
+}
+
+// SaveOperationTask saves a background operation task
+// SaveOperationTask 保存后台操作任务
+func (m *MetadataService) SaveOperationTask(task *model.OperationTask) error {
+	return m.db.Save(task).Error
+}
+
+// GetOperationTask retrieves a background operation task
+// GetOperationTask 获取后台操作任务
+func (m *MetadataService) GetOperationTask(id string) (*model.OperationTask, error) {
+	var task model.OperationTask
+	result := m.db.Where("id = ?", id).First(&task)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &task, nil
+}
+
+// ListOperationTasks lists background operation tasks, optionally filtered by index
+// ListOperationTasks 列出后台操作任务，可按索引过滤
+func (m *MetadataService) ListOperationTasks(indexName string) ([]*model.OperationTask, error) {
+	var tasks []*model.OperationTask
+	query := m.db.Order("created_at desc")
+	if indexName != "" {
+		query = query.Where("index_name = ?", indexName)
+	}
+	result := query.Find(&tasks)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return tasks, nil
+}
+
+// SaveBenchmarkRun saves a benchmark run
+// SaveBenchmarkRun 保存基准测试记录
+func (m *MetadataService) SaveBenchmarkRun(run *model.BenchmarkRun) error {
+	return m.db.Save(run).Error
+}
+
+// GetBenchmarkRun retrieves a benchmark run of an index
+// GetBenchmarkRun 获取索引的基准测试记录
+func (m *MetadataService) GetBenchmarkRun(indexName, id string) (*model.BenchmarkRun, error) {
+	var run model.BenchmarkRun
+	result := m.db.Where("id = ? AND index_name = ?", id, indexName).First(&run)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return &run, nil
+}
+
+// ListBenchmarkRuns lists the benchmark runs of an index, newest first
+// ListBenchmarkRuns 列出索引的基准测试记录（按时间倒序）
+func (m *MetadataService) ListBenchmarkRuns(indexName string) ([]*model.BenchmarkRun, error) {
+	var runs []*model.BenchmarkRun
+	result := m.db.Where("index_name = ?", indexName).Order("created_at desc").Find(&runs)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+	return runs, nil
+}
+
 // SaveMetrics saves monitoring metrics
 func (m *MetadataService) SaveMetrics(metrics *model.Metrics) error {
 	return m.db.Create(metrics).Error
 }
 
+// ListMetricsRange retrieves the metrics recorded in [from, to) in time order;
+// an empty namespace matches every namespace
+// ListMetricsRange 按时间顺序获取 [from, to) 内记录的指标，命名空间为空时匹配所有命名空间
+func (m *MetadataService) ListMetricsRange(namespace string, from, to time.Time) ([]model.Metrics, error) {
+	var metrics []model.Metrics
+	query := m.db.Where("timestamp >= ? AND timestamp < ?", from, to)
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	err := query.Order("timestamp asc").Find(&metrics).Error
+	return metrics, err
+}
+
+// EarliestMetricsTimestamp returns the time of the oldest raw metrics sample
+// EarliestMetricsTimestamp 返回最早的原始指标样本时间
+func (m *MetadataService) EarliestMetricsTimestamp() (time.Time, bool, error) {
+	var metrics model.Metrics
+	result := m.db.Order("timestamp asc").Limit(1).Find(&metrics)
+	if result.Error != nil || result.RowsAffected == 0 {
+		return time.Time{}, false, result.Error
+	}
+	return metrics.Timestamp, true, nil
+}
+
+// DeleteMetricsBefore deletes raw cluster and pod metrics recorded before cutoff
+// DeleteMetricsBefore 删除 cutoff 之前记录的原始集群指标与 Pod 指标
+func (m *MetadataService) DeleteMetricsBefore(cutoff time.Time) (int64, error) {
+	result := m.db.Where("timestamp < ?", cutoff).Delete(&model.Metrics{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	pods := m.db.Where("timestamp < ?", cutoff).Delete(&model.PodMetrics{})
+	return result.RowsAffected + pods.RowsAffected, pods.Error
+}
+
+// SaveMetricsRollups upserts rollup buckets of a resolution (5m or 1h)
+// SaveMetricsRollups 写入或更新指定粒度（5m 或 1h）的汇总桶
+func (m *MetadataService) SaveMetricsRollups(resolution string, rollups []model.MetricsRollup) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		for _, rollup := range rollups {
+			var err error
+			switch resolution {
+			case "5m":
+				err = tx.Save(&model.MetricsRollup5m{MetricsRollup: rollup}).Error
+			case "1h":
+				err = tx.Save(&model.MetricsRollup1h{MetricsRollup: rollup}).Error
+			default:
+				err = fmt.Errorf("unknown rollup resolution %s", resolution)
+			}
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListMetricsRollups retrieves the rollup buckets starting in [from, to) in time order;
+// an empty namespace matches every namespace
+// ListMetricsRollups 按时间顺序获取起始于 [from, to) 的汇总桶，命名空间为空时匹配所有命名空间
+func (m *MetadataService) ListMetricsRollups(resolution, namespace string, from, to time.Time) ([]model.MetricsRollup, error) {
+	query := m.db.Where("bucket_start >= ? AND bucket_start < ?", from, to)
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	query = query.Order("bucket_start asc")
+
+	var rollups []model.MetricsRollup
+	switch resolution {
+	case "5m":
+		var rows []model.MetricsRollup5m
+		if err := query.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			rollups = append(rollups, row.MetricsRollup)
+		}
+	case "1h":
+		var rows []model.MetricsRollup1h
+		if err := query.Find(&rows).Error; err != nil {
+			return nil, err
+		}
+		for _, row := range rows {
+			rollups = append(rollups, row.MetricsRollup)
+		}
+	default:
+		return nil, fmt.Errorf("unknown rollup resolution %s", resolution)
+	}
+	return rollups, nil
+}
+
+// MetricsRollupBounds returns the first and last bucket start of a rollup resolution
+// MetricsRollupBounds 返回指定粒度汇总桶的最早与最晚起始时间
+func (m *MetadataService) MetricsRollupBounds(resolution string) (first, last time.Time, ok bool, err error) {
+	var table interface{}
+	switch resolution {
+	case "5m":
+		table = &model.MetricsRollup5m{}
+	case "1h":
+		table = &model.MetricsRollup1h{}
+	default:
+		return first, last, false, fmt.Errorf("unknown rollup resolution %s", resolution)
+	}
+
+	var bounds struct {
+		First *time.Time
+		Last  *time.Time
+	}
+	err = m.db.Model(table).Select("MIN(bucket_start) AS first, MAX(bucket_start) AS last").Scan(&bounds).Error
+	if err != nil || bounds.First == nil || bounds.Last == nil {
+		return first, last, false, err
+	}
+	return *bounds.First, *bounds.Last, true, nil
+}
+
+// DeleteMetricsRollupsBefore deletes rollup buckets starting before cutoff
+// DeleteMetricsRollupsBefore 删除起始时间早于 cutoff 的汇总桶
+func (m *MetadataService) DeleteMetricsRollupsBefore(resolution string, cutoff time.Time) (int64, error) {
+	var result *gorm.DB
+	switch resolution {
+	case "5m":
+		result = m.db.Where("bucket_start < ?", cutoff).Delete(&model.MetricsRollup5m{})
+	case "1h":
+		result = m.db.Where("bucket_start < ?", cutoff).Delete(&model.MetricsRollup1h{})
+	default:
+		return 0, fmt.Errorf("unknown rollup resolution %s", resolution)
+	}
+	return result.RowsAffected, result.Error
+}
+
+// SavePodMetrics saves the per pod metrics of one collection
+// SavePodMetrics 保存一次采集的各 Pod 指标
+func (m *MetadataService) SavePodMetrics(pods []model.PodMetrics) error {
+	if len(pods) == 0 {
+		return nil
+	}
+	return m.db.Create(&pods).Error
+}
+
+// GetLatestPodMetrics retrieves the pod metrics of the latest collection for a namespace
+// GetLatestPodMetrics 获取命名空间最近一次采集的各 Pod 指标
+func (m *MetadataService) GetLatestPodMetrics(namespace string) ([]model.PodMetrics, error) {
+	var latest model.PodMetrics
+	result := m.db.Where("namespace = ?", namespace).Order("timestamp desc").First(&latest)
+	if result.Error != nil {
+		return nil, result.Error
+	}
+
+	var pods []model.PodMetrics
+	err := m.db.Where("namespace = ? AND timestamp = ?", namespace, latest.Timestamp).Order("pod_name").Find(&pods).Error
+	return pods, err
+}
+
 // GetLatestMetrics retrieves the latest metrics for a namespace
 func (m *MetadataService) GetLatestMetrics(namespace string) (*model.Metrics, error) {
 	var metrics model.Metrics
@@ -226,3 +601,387 @@ func (m *MetadataService) GetLatestMetrics(namespace string) (*model.Metrics, er
 	}
 	return &metrics, nil
 }
+
+// storageUnits maps Kubernetes style quantity suffixes to bytes
+// storageUnits 将 Kubernetes 风格的容量后缀映射为字节数
+var storageUnits = []struct {
+	suffix string
+	bytes  float64
+}{
+	{"Pi", 1 << 50},
+	{"Ti", 1 << 40},
+	{"Gi", 1 << 30},
+	{"Mi", 1 << 20},
+	{"Ki", 1 << 10},
+	{"P", 1e15},
+	{"T", 1e12},
+	{"G", 1e9},
+	{"M", 1e6},
+	{"K", 1e3},
+}
+
+// parseStorageSize parses a size such as "10Gi" or "512Mi" into bytes
+// parseStorageSize 将 "10Gi"、"512Mi" 等容量字符串解析为字节数
+func parseStorageSize(s string) int64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+
+	multiplier := 1.0
+	for _, unit := range storageUnits {
+		if strings.HasSuffix(s, unit.suffix) {
+			s = strings.TrimSuffix(s, unit.suffix)
+			multiplier = unit.bytes
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0
+	}
+	return int64(value * multiplier)
+}
+
+// formatStorageSize formats bytes using the largest binary unit, e.g. "1.5Gi"
+// formatStorageSize 使用最大的二进制单位格式化字节数，例如 "1.5Gi"
+func formatStorageSize(bytes int64) string {
+	for _, unit := range storageUnits[:5] {
+		if float64(bytes) >= unit.bytes {
+			value := fmt.Sprintf("%.2f", float64(bytes)/unit.bytes)
+			value = strings.TrimRight(strings.TrimRight(value, "0"), ".")
+			return value + unit.suffix
+		}
+	}
+	return strconv.FormatInt(bytes, 10)
+}
+
+// SaveAlertRule saves an alert rule
+// SaveAlertRule 保存告警规则
+func (m *MetadataService) SaveAlertRule(rule *model.AlertRule) error {
+	return m.db.Save(rule).Error
+}
+
+// GetAlertRule retrieves an alert rule by ID
+// GetAlertRule 根据 ID 获取告警规则
+func (m *MetadataService) GetAlertRule(id string) (*model.AlertRule, error) {
+	var rule model.AlertRule
+	if err := m.db.Where("id = ?", id).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListAlertRules lists alert rules; a non-empty tenant lists the rules of that tenant
+// together with the global rules
+// ListAlertRules 列出告警规则；指定租户时返回该租户的规则以及全局规则
+func (m *MetadataService) ListAlertRules(tenantOrgID string) ([]*model.AlertRule, error) {
+	var rules []*model.AlertRule
+	query := m.db.Order("created_at asc")
+	if tenantOrgID != "" {
+		query = query.Where("tenant_org_id = ? OR tenant_org_id = ''", tenantOrgID)
+	}
+	err := query.Find(&rules).Error
+	return rules, err
+}
+
+// DeleteAlertRule deletes an alert rule together with its alerts
+// DeleteAlertRule 删除告警规则及其告警
+func (m *MetadataService) DeleteAlertRule(id string) error {
+	return m.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&model.AlertRule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("rule_id = ?", id).Delete(&model.Alert{}).Error
+	})
+}
+
+// SaveAlertChannel saves a notification channel
+// SaveAlertChannel 保存通知渠道
+func (m *MetadataService) SaveAlertChannel(channel *model.AlertChannel) error {
+	return m.db.Save(channel).Error
+}
+
+// GetAlertChannel retrieves a notification channel by ID
+// GetAlertChannel 根据 ID 获取通知渠道
+func (m *MetadataService) GetAlertChannel(id string) (*model.AlertChannel, error) {
+	var channel model.AlertChannel
+	if err := m.db.Where("id = ?", id).First(&channel).Error; err != nil {
+		return nil, err
+	}
+	return &channel, nil
+}
+
+// ListAlertChannels lists notification channels; a non-empty tenant lists the channels
+// of that tenant together with the global channels
+// ListAlertChannels 列出通知渠道；指定租户时返回该租户的渠道以及全局渠道
+func (m *MetadataService) ListAlertChannels(tenantOrgID string) ([]*model.AlertChannel, error) {
+	var channels []*model.AlertChannel
+	query := m.db.Order("created_at asc")
+	if tenantOrgID != "" {
+		query = query.Where("tenant_org_id = ? OR tenant_org_id = ''", tenantOrgID)
+	}
+	err := query.Find(&channels).Error
+	return channels, err
+}
+
+// DeleteAlertChannel deletes a notification channel
+// DeleteAlertChannel 删除通知渠道
+func (m *MetadataService) DeleteAlertChannel(id string) error {
+	result := m.db.Where("id = ?", id).Delete(&model.AlertChannel{})
+	if result.Error == nil && result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return result.Error
+}
+
+// SaveAlert saves an alert
+// SaveAlert 保存告警
+func (m *MetadataService) SaveAlert(alert *model.Alert) error {
+	return m.db.Save(alert).Error
+}
+
+// DeleteAlert deletes an alert
+// DeleteAlert 删除告警
+func (m *MetadataService) DeleteAlert(id string) error {
+	return m.db.Where("id = ?", id).Delete(&model.Alert{}).Error
+}
+
+// ListActiveAlerts lists the pending and firing alerts
+// ListActiveAlerts 列出 pending 与 firing 状态的告警
+func (m *MetadataService) ListActiveAlerts() ([]*model.Alert, error) {
+	var alerts []*model.Alert
+	err := m.db.Where("state IN ?", []string{"pending", "firing"}).Find(&alerts).Error
+	return alerts, err
+}
+
+// ListUnannouncedResolvedAlerts lists the resolved alerts whose resolution has not been delivered yet
+// ListUnannouncedResolvedAlerts 列出解决通知尚未送达的已解决告警
+func (m *MetadataService) ListUnannouncedResolvedAlerts() ([]*model.Alert, error) {
+	var alerts []*model.Alert
+	err := m.db.Where("state = ? AND notified_state = ?", "resolved", "firing").Find(&alerts).Error
+	return alerts, err
+}
+
+// ListAlerts lists alerts, newest first, filtered by tenant, namespace and states when given
+// ListAlerts 按时间倒序列出告警，可按租户、命名空间与状态过滤
+func (m *MetadataService) ListAlerts(tenantOrgID, namespace string, states []string, limit int) ([]*model.Alert, error) {
+	var alerts []*model.Alert
+	query := m.db.Order("starts_at desc")
+	if tenantOrgID != "" {
+		query = query.Where("tenant_org_id = ?", tenantOrgID)
+	}
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	if len(states) > 0 {
+		query = query.Where("state IN ?", states)
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	err := query.Find(&alerts).Error
+	return alerts, err
+}
+
+// SaveAlertSilence saves an alert silence
+// SaveAlertSilence 保存告警静默
+func (m *MetadataService) SaveAlertSilence(silence *model.AlertSilence) error {
+	return m.db.Save(silence).Error
+}
+
+// GetAlertSilence retrieves an alert silence by ID
+// GetAlertSilence 根据 ID 获取告警静默
+func (m *MetadataService) GetAlertSilence(id string) (*model.AlertSilence, error) {
+	var silence model.AlertSilence
+	if err := m.db.Where("id = ?", id).First(&silence).Error; err != nil {
+		return nil, err
+	}
+	return &silence, nil
+}
+
+// ListAlertSilences lists silences ending after the given time; a non-empty tenant
+// lists the silences of that tenant together with the global silences
+// ListAlertSilences 列出结束时间晚于指定时间的静默；指定租户时返回该租户的静默以及全局静默
+func (m *MetadataService) ListAlertSilences(tenantOrgID string, endsAfter time.Time) ([]*model.AlertSilence, error) {
+	var silences []*model.AlertSilence
+	query := m.db.Where("ends_at > ?", endsAfter).Order("starts_at asc")
+	if tenantOrgID != "" {
+		query = query.Where("tenant_org_id = ? OR tenant_org_id = ''", tenantOrgID)
+	}
+	err := query.Find(&silences).Error
+	return silences, err
+}
+
+// GetAutoscalerConfig retrieves the stored global autoscaler configuration
+// GetAutoscalerConfig 获取已保存的全局自动扩缩容配置
+func (m *MetadataService) GetAutoscalerConfig() (*model.AutoscalerConfig, error) {
+	var config model.AutoscalerConfig
+	if err := m.db.Where("id = ?", autoscalerConfigID).First(&config).Error; err != nil {
+		return nil, err
+	}
+	return &config, nil
+}
+
+// SaveAutoscalerConfig saves the global autoscaler configuration
+// SaveAutoscalerConfig 保存全局自动扩缩容配置
+func (m *MetadataService) SaveAutoscalerConfig(config *model.AutoscalerConfig) error {
+	config.ID = autoscalerConfigID
+	return m.db.Save(config).Error
+}
+
+// SaveScalingPolicy saves a scaling policy; the unique index on user and namespace rejects
+// a second policy for the same scope
+// SaveScalingPolicy 保存扩缩容策略；用户与命名空间上的唯一索引会拒绝同一作用域的第二条策略
+func (m *MetadataService) SaveScalingPolicy(policy *model.ScalingPolicy) error {
+	err := m.db.Save(policy).Error
+	if errors.Is(err, gorm.ErrDuplicatedKey) {
+		return fmt.Errorf("%w: a policy already covers this scope", ErrInvalidRequest)
+	}
+	return err
+}
+
+// GetScalingPolicy retrieves a scaling policy by ID
+// GetScalingPolicy 根据 ID 获取扩缩容策略
+func (m *MetadataService) GetScalingPolicy(id string) (*model.ScalingPolicy, error) {
+	var policy model.ScalingPolicy
+	if err := m.db.Where("id = ?", id).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// ListScalingPolicies lists scaling policies, optionally filtered by user and namespace
+// ListScalingPolicies 列出扩缩容策略，可按用户与命名空间过滤
+func (m *MetadataService) ListScalingPolicies(userID, namespace string) ([]*model.ScalingPolicy, error) {
+	var policies []*model.ScalingPolicy
+	query := m.db.Order("created_at asc")
+	if userID != "" {
+		query = query.Where("user_id = ?", userID)
+	}
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	err := query.Find(&policies).Error
+	return policies, err
+}
+
+// DeleteScalingPolicy deletes a scaling policy
+// DeleteScalingPolicy 删除扩缩容策略
+func (m *MetadataService) DeleteScalingPolicy(id string) error {
+	result := m.db.Where("id = ?", id).Delete(&model.ScalingPolicy{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveScalingSchedule saves a scaling schedule
+// SaveScalingSchedule 保存定时扩缩容计划
+func (m *MetadataService) SaveScalingSchedule(schedule *model.ScalingSchedule) error {
+	return m.db.Save(schedule).Error
+}
+
+// GetScalingSchedule retrieves a scaling schedule by ID
+// GetScalingSchedule 根据 ID 获取定时扩缩容计划
+func (m *MetadataService) GetScalingSchedule(id string) (*model.ScalingSchedule, error) {
+	var schedule model.ScalingSchedule
+	if err := m.db.Where("id = ?", id).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListScalingSchedules lists scaling schedules, optionally filtered by namespace
+// ListScalingSchedules 列出定时扩缩容计划，可按命名空间过滤
+func (m *MetadataService) ListScalingSchedules(namespace string) ([]*model.ScalingSchedule, error) {
+	var schedules []*model.ScalingSchedule
+	query := m.db.Order("created_at asc")
+	if namespace != "" {
+		query = query.Where("namespace = ?", namespace)
+	}
+	err := query.Find(&schedules).Error
+	return schedules, err
+}
+
+// DeleteScalingSchedule deletes a scaling schedule
+// DeleteScalingSchedule 删除定时扩缩容计划
+func (m *MetadataService) DeleteScalingSchedule(id string) error {
+	result := m.db.Where("id = ?", id).Delete(&model.ScalingSchedule{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// SaveAutoscalerDecision saves an autoscaler decision
+// SaveAutoscalerDecision 保存自动扩缩容决策
+func (m *MetadataService) SaveAutoscalerDecision(decision *model.AutoscalerDecision) error {
+	return m.db.Create(decision).Error
+}
+
+// ListAutoscalerDecisions lists the decisions of a namespace within [from, to], newest first;
+// an empty outcome lists every outcome
+// ListAutoscalerDecisions 列出命名空间在 [from, to] 内的决策，最新的在前；outcome 为空时列出全部结果
+func (m *MetadataService) ListAutoscalerDecisions(namespace, outcome string, from, to time.Time, limit int) ([]*model.AutoscalerDecision, error) {
+	var decisions []*model.AutoscalerDecision
+	query := m.db.Where("namespace = ? AND timestamp >= ? AND timestamp <= ?", namespace, from, to)
+	if outcome != "" {
+		query = query.Where("outcome = ?", outcome)
+	}
+	err := query.Order("timestamp desc").Limit(limit).Find(&decisions).Error
+	return decisions, err
+}
+
+// DeleteAutoscalerDecisionsBefore deletes the decisions recorded before cutoff
+// DeleteAutoscalerDecisionsBefore 删除 cutoff 之前记录的决策
+func (m *MetadataService) DeleteAutoscalerDecisionsBefore(cutoff time.Time) (int64, error) {
+	result := m.db.Where("timestamp < ?", cutoff).Delete(&model.AutoscalerDecision{})
+	return result.RowsAffected, result.Error
+}
+
+// SaveLoadForecast saves a load forecast, replacing an earlier forecast of the same series and hour
+// SaveLoadForecast 保存负载预测，覆盖同一序列同一小时较早的预测
+func (m *MetadataService) SaveLoadForecast(forecast *model.LoadForecast) error {
+	return m.db.Save(forecast).Error
+}
+
+// ListLoadForecasts lists the load forecasts of a namespace for the hours in [from, to) in time order
+// ListLoadForecasts 按时间顺序列出命名空间在 [from, to) 内各小时的负载预测
+func (m *MetadataService) ListLoadForecasts(namespace string, from, to time.Time) ([]*model.LoadForecast, error) {
+	var forecasts []*model.LoadForecast
+	err := m.db.Where("namespace = ? AND time >= ? AND time < ?", namespace, from, to).Order("time asc").Find(&forecasts).Error
+	return forecasts, err
+}
+
+// DeleteLoadForecastsBefore deletes the load forecasts of the hours before cutoff
+// DeleteLoadForecastsBefore 删除 cutoff 之前各小时的负载预测
+func (m *MetadataService) DeleteLoadForecastsBefore(cutoff time.Time) (int64, error) {
+	result := m.db.Where("time < ?", cutoff).Delete(&model.LoadForecast{})
+	return result.RowsAffected, result.Error
+}
+
+// SaveScaleEvent saves a scale audit record
+// SaveScaleEvent 保存扩缩容审计记录
+func (m *MetadataService) SaveScaleEvent(event *model.ScaleEvent) error {
+	return m.db.Create(event).Error
+}
+
+// ListScaleEvents lists the scale audit records of a namespace, newest first
+// ListScaleEvents 列出命名空间的扩缩容审计记录，最新的在前
+func (m *MetadataService) ListScaleEvents(namespace string, limit int) ([]*model.ScaleEvent, error) {
+	var events []*model.ScaleEvent
+	err := m.db.Where("namespace = ?", namespace).Order("created_at desc").Limit(limit).Find(&events).Error
+	return events, err
+}
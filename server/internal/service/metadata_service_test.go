@@ -0,0 +1,47 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"es-serverless-manager/internal/model"
+)
+
+// newTestMetadataService returns a metadata service backed by a private in-memory SQLite database
+func newTestMetadataService(t *testing.T) *MetadataService {
+	t.Helper()
+
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{
+		Logger:         logger.Default.LogMode(logger.Silent),
+		TranslateError: true,
+	})
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	// Every connection to ":memory:" is a separate database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("opening test database: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	if err := db.AutoMigrate(
+		&model.TenantContainer{},
+		&model.IndexMetadata{},
+		&model.TenantQuota{},
+		&model.DeploymentStatus{},
+		&model.Metrics{},
+		&model.ScalingPolicy{},
+		&model.ScaleEvent{},
+		&model.OperationTask{},
+		&model.IndexAlias{},
+		&model.BenchmarkRun{},
+	); err != nil {
+		t.Fatalf("migrating test database: %v", err)
+	}
+	return NewMetadataService(db)
+}
@@ -0,0 +1,494 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// maxHistoryBuckets bounds the number of buckets one history query may return
+	// maxHistoryBuckets 单次历史查询可返回的最大桶数
+	maxHistoryBuckets = 10000
+	// rollupGrace leaves time for in-flight samples before a bucket is compacted
+	// rollupGrace 压缩一个桶之前为尚在写入的样本预留的时间
+	rollupGrace = time.Minute
+)
+
+// metricsSeries maps the series names of the history API to the raw metrics fields
+// metricsSeries 将历史 API 的序列名称映射到原始指标字段
+var metricsSeries = map[string]func(m *model.Metrics) float64{
+	"cpu_usage":             func(m *model.Metrics) float64 { return m.CPUUsage },
+	"cpu_usage_max":         func(m *model.Metrics) float64 { return m.CPUUsageMax },
+	"memory_usage":          func(m *model.Metrics) float64 { return m.MemoryUsage },
+	"memory_usage_max":      func(m *model.Metrics) float64 { return m.MemoryUsageMax },
+	"disk_usage":            func(m *model.Metrics) float64 { return m.DiskUsage },
+	"disk_usage_max":        func(m *model.Metrics) float64 { return m.DiskUsageMax },
+	"heap_usage":            func(m *model.Metrics) float64 { return m.HeapUsage },
+	"heap_usage_max":        func(m *model.Metrics) float64 { return m.HeapUsageMax },
+	"qps":                   func(m *model.Metrics) float64 { return m.QPS },
+	"plugin_qps":            func(m *model.Metrics) float64 { return m.PluginQPS },
+	"search_latency_ms":     func(m *model.Metrics) float64 { return m.SearchLatencyMs },
+	"search_latency_p95_ms": func(m *model.Metrics) float64 { return m.SearchLatencyP95Ms },
+	"search_latency_p99_ms": func(m *model.Metrics) float64 { return m.SearchLatencyP99Ms },
+	"indexing_rate":         func(m *model.Metrics) float64 { return m.IndexingRate },
+}
+
+// seriesMetric maps the series read from a measured usage or from the Elasticsearch rates
+// to the scaling metric listed in Metrics.Missing when it could not be measured
+// seriesMetric 将来自使用率或 Elasticsearch 速率的序列映射到无法测量时记入 Metrics.Missing 的扩缩容指标
+var seriesMetric = map[string]string{
+	"cpu_usage":             "cpu_usage",
+	"cpu_usage_max":         "cpu_usage",
+	"memory_usage":          "memory_usage",
+	"memory_usage_max":      "memory_usage",
+	"disk_usage":            "disk_usage",
+	"disk_usage_max":        "disk_usage",
+	"heap_usage":            "heap_usage",
+	"heap_usage_max":        "heap_usage",
+	"qps":                   "qps",
+	"plugin_qps":            "qps",
+	"search_latency_ms":     "qps",
+	"search_latency_p95_ms": "qps",
+	"search_latency_p99_ms": "qps",
+}
+
+// rollupResolutions lists the rollup tables from the finest to the coarsest
+// rollupResolutions 按从细到粗的顺序列出汇总表
+var rollupResolutions = []struct {
+	name     string
+	interval time.Duration
+}{
+	{"5m", 5 * time.Minute},
+	{"1h", time.Hour},
+}
+
+// MetricsRetention configures how long each resolution of metrics is kept
+// MetricsRetention 配置各粒度指标的保留时长
+type MetricsRetention struct {
+	Raw      time.Duration
+	Rollup5m time.Duration
+	Rollup1h time.Duration
+}
+
+// DefaultMetricsRetention keeps raw samples for a week, 5 minute rollups for 30 days
+// and 1 hour rollups for a year
+// DefaultMetricsRetention 原始样本保留一周，5 分钟汇总保留 30 天，1 小时汇总保留一年
+func DefaultMetricsRetention() MetricsRetention {
+	return MetricsRetention{
+		Raw:      7 * 24 * time.Hour,
+		Rollup5m: 30 * 24 * time.Hour,
+		Rollup1h: 365 * 24 * time.Hour,
+	}
+}
+
+// MetricsHistoryService serves metrics time series and compacts old samples into rollups
+// MetricsHistoryService 提供指标时间序列查询，并将旧样本压缩为汇总数据
+type MetricsHistoryService struct {
+	metadataService *MetadataService
+	retention       MetricsRetention
+	ticker          *time.Ticker
+	stopChan        chan struct{}
+}
+
+// NewMetricsHistoryService creates a new metrics history service
+// NewMetricsHistoryService 创建一个新的指标历史服务
+func NewMetricsHistoryService(metadataService *MetadataService, retention MetricsRetention) *MetricsHistoryService {
+	return &MetricsHistoryService{
+		metadataService: metadataService,
+		retention:       retention,
+		stopChan:        make(chan struct{}),
+	}
+}
+
+// Start begins the rollup and retention loop
+// Start 启动汇总与保留清理循环
+func (s *MetricsHistoryService) Start() {
+	s.ticker = time.NewTicker(5 * time.Minute)
+	go func() {
+		s.runRollup()
+		for {
+			select {
+			case <-s.ticker.C:
+				s.runRollup()
+			case <-s.stopChan:
+				s.ticker.Stop()
+				return
+			}
+		}
+	}()
+}
+
+// Stop stops the rollup and retention loop
+// Stop 停止汇总与保留清理循环
+func (s *MetricsHistoryService) Stop() {
+	close(s.stopChan)
+}
+
+func (s *MetricsHistoryService) runRollup() {
+	if err := s.RunRollup(time.Now()); err != nil {
+		log.Printf("Error rolling up metrics: %v", err)
+	}
+}
+
+// seriesSample is one raw sample or rollup bucket of every series at a point in time
+// seriesSample 某一时刻所有序列的一个原始样本或汇总桶
+type seriesSample struct {
+	timestamp time.Time
+	samples   int
+	values    map[string]model.SeriesBucketStat
+}
+
+// Query returns the series of a namespace in [from, to) aggregated into buckets of step.
+// Raw samples are used while they are retained, older ranges are served from the
+// coarsest rollup needed and the part not yet rolled up is filled from finer data.
+// Query 返回命名空间在 [from, to) 内按 step 分桶聚合的序列；原始样本保留期内直接使用原始样本，
+// 更早的范围使用所需的最粗粒度汇总，尚未汇总的部分由更细的数据补齐
+func (s *MetricsHistoryService) Query(namespace string, from, to time.Time, step time.Duration, series []string) (*model.MetricsHistory, error) {
+	if !to.After(from) {
+		return nil, fmt.Errorf("%w: to must be after from", ErrInvalidRequest)
+	}
+	if step <= 0 {
+		return nil, fmt.Errorf("%w: step must be positive", ErrInvalidRequest)
+	}
+	if int64(to.Sub(from)/step) >= maxHistoryBuckets {
+		return nil, fmt.Errorf("%w: range of %s with step %s exceeds %d buckets", ErrInvalidRequest, to.Sub(from), step, maxHistoryBuckets)
+	}
+	if len(series) == 0 {
+		for name := range metricsSeries {
+			series = append(series, name)
+		}
+	}
+	for _, name := range series {
+		if _, ok := metricsSeries[name]; !ok {
+			return nil, fmt.Errorf("%w: unknown series %s", ErrInvalidRequest, name)
+		}
+	}
+
+	now := time.Now()
+	source := "raw"
+	switch {
+	case from.Before(now.Add(-s.retention.Rollup5m)):
+		source = "1h"
+	case from.Before(now.Add(-s.retention.Raw)):
+		source = "5m"
+	}
+
+	var samples []seriesSample
+	cursor := from
+	for i := len(rollupResolutions) - 1; i >= 0; i-- {
+		resolution := rollupResolutions[i]
+		if resolutionRank(resolution.name) > resolutionRank(source) {
+			continue
+		}
+		rollups, err := s.metadataService.ListMetricsRollups(resolution.name, namespace, cursor, to)
+		if err != nil {
+			return nil, err
+		}
+		for _, rollup := range rollups {
+			samples = append(samples, seriesSample{timestamp: rollup.BucketStart, samples: rollup.Samples, values: rollup.Values})
+		}
+		if len(rollups) > 0 {
+			cursor = rollups[len(rollups)-1].BucketStart.Add(resolution.interval)
+		}
+	}
+	if cursor.Before(to) {
+		raw, err := s.metadataService.ListMetricsRange(namespace, cursor, to)
+		if err != nil {
+			return nil, err
+		}
+		for i := range raw {
+			samples = append(samples, rawSeriesSample(&raw[i]))
+		}
+	}
+
+	history := &model.MetricsHistory{
+		Namespace: namespace,
+		From:      from,
+		To:        to,
+		Step:      step.String(),
+		Source:    source,
+		Series:    make(map[string][]model.SeriesPoint, len(series)),
+	}
+	for _, name := range series {
+		history.Series[name] = bucketSeries(samples, name, from, step)
+	}
+	return history, nil
+}
+
+// resolutionRank orders resolutions from raw (0) to the coarsest rollup
+// resolutionRank 将粒度从 raw（0）到最粗的汇总排序
+func resolutionRank(name string) int {
+	for i, resolution := range rollupResolutions {
+		if resolution.name == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// rawSeriesSample wraps a raw sample as a bucket holding a single observation; series
+// that could not be measured are left out
+// rawSeriesSample 将原始样本包装为只含一个观测值的桶；无法测量的序列不包含在内
+func rawSeriesSample(m *model.Metrics) seriesSample {
+	values := make(map[string]model.SeriesBucketStat, len(metricsSeries))
+	for name, field := range metricsSeries {
+		if metric, ok := seriesMetric[name]; ok && m.IsMissing(metric) {
+			continue
+		}
+		v := field(m)
+		values[name] = model.SeriesBucketStat{Avg: v, Max: v, P95: v}
+	}
+	return seriesSample{timestamp: m.Timestamp, samples: 1, values: values}
+}
+
+// bucketSeries aggregates one series into buckets of step starting at from; empty
+// buckets are omitted
+// bucketSeries 将单个序列聚合为从 from 开始、宽度为 step 的桶；空桶不返回
+func bucketSeries(samples []seriesSample, name string, from time.Time, step time.Duration) []model.SeriesPoint {
+	grouped := make(map[int64][]seriesSample)
+	for _, sample := range samples {
+		if _, ok := sample.values[name]; !ok || sample.timestamp.Before(from) {
+			continue
+		}
+		index := int64(sample.timestamp.Sub(from) / step)
+		grouped[index] = append(grouped[index], sample)
+	}
+
+	indexes := make([]int64, 0, len(grouped))
+	for index := range grouped {
+		indexes = append(indexes, index)
+	}
+	sort.Slice(indexes, func(i, j int) bool { return indexes[i] < indexes[j] })
+
+	points := make([]model.SeriesPoint, 0, len(indexes))
+	for _, index := range indexes {
+		stat, count := mergeBucketStats(grouped[index], name)
+		points = append(points, model.SeriesPoint{
+			Timestamp: from.Add(time.Duration(index) * step),
+			Samples:   count,
+			Avg:       stat.Avg,
+			Max:       stat.Max,
+			P95:       stat.P95,
+		})
+	}
+	return points
+}
+
+// mergeBucketStats combines buckets of one series: the average is weighted by the
+// sample counts, the maximum is kept and the p95 is the weighted p95 of the bucket p95s.
+// Buckets without the series are skipped.
+// mergeBucketStats 合并单个序列的多个桶：平均值按样本数加权，保留最大值，p95 取各桶 p95 的加权 p95；
+// 跳过不含该序列的桶
+func mergeBucketStats(samples []seriesSample, name string) (model.SeriesBucketStat, int) {
+	var stat model.SeriesBucketStat
+	var sum float64
+	var count int
+	p95s := make([]weightedSample, 0, len(samples))
+	for _, sample := range samples {
+		value, ok := sample.values[name]
+		if !ok {
+			continue
+		}
+		weight := sample.samples
+		if weight <= 0 {
+			weight = 1
+		}
+		sum += value.Avg * float64(weight)
+		if count == 0 || value.Max > stat.Max {
+			stat.Max = value.Max
+		}
+		count += weight
+		p95s = append(p95s, weightedSample{value: value.P95, weight: int64(weight)})
+	}
+	if count > 0 {
+		stat.Avg = sum / float64(count)
+	}
+	stat.P95 = weightedPercentile(p95s, 0.95)
+	return stat, count
+}
+
+// RunRollup compacts complete buckets of raw samples into 5 minute rollups and 5 minute
+// rollups into 1 hour rollups, then deletes data past its retention. Data is only
+// deleted once it is covered by the next coarser resolution.
+// RunRollup 将完整时间桶内的原始样本压缩为 5 分钟汇总，再将 5 分钟汇总压缩为 1 小时汇总，
+// 随后删除超过保留期的数据；数据只有在被更粗粒度覆盖后才会删除
+func (s *MetricsHistoryService) RunRollup(now time.Time) error {
+	rawCovered, err := s.rollupRaw(now)
+	if err != nil {
+		return fmt.Errorf("raw to 5m rollup failed: %v", err)
+	}
+	covered5m, err := s.rollup5m()
+	if err != nil {
+		return fmt.Errorf("5m to 1h rollup failed: %v", err)
+	}
+
+	cutoff := now.Add(-s.retention.Raw)
+	if rawCovered.Before(cutoff) {
+		cutoff = rawCovered
+	}
+	if deleted, err := s.metadataService.DeleteMetricsBefore(cutoff); err != nil {
+		return fmt.Errorf("failed to delete raw metrics: %v", err)
+	} else if deleted > 0 {
+		log.Printf("Deleted %d raw metrics samples before %s", deleted, cutoff.Format(time.RFC3339))
+	}
+
+	cutoff = now.Add(-s.retention.Rollup5m)
+	if covered5m.Before(cutoff) {
+		cutoff = covered5m
+	}
+	if _, err := s.metadataService.DeleteMetricsRollupsBefore("5m", cutoff); err != nil {
+		return fmt.Errorf("failed to delete 5m rollups: %v", err)
+	}
+	if _, err := s.metadataService.DeleteMetricsRollupsBefore("1h", now.Add(-s.retention.Rollup1h)); err != nil {
+		return fmt.Errorf("failed to delete 1h rollups: %v", err)
+	}
+	return nil
+}
+
+// rollupRaw compacts raw samples into 5 minute buckets and returns the end of the
+// range covered by 5 minute rollups
+// rollupRaw 将原始样本压缩为 5 分钟桶，并返回 5 分钟汇总覆盖范围的结束时间
+func (s *MetricsHistoryService) rollupRaw(now time.Time) (time.Time, error) {
+	const interval = 5 * time.Minute
+
+	start, err := s.rollupStart("5m", interval)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if start.IsZero() {
+		earliest, ok, err := s.metadataService.EarliestMetricsTimestamp()
+		if err != nil || !ok {
+			return time.Time{}, err
+		}
+		start = earliest.Truncate(interval)
+	}
+	end := now.Add(-rollupGrace).Truncate(interval)
+
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(time.Hour) {
+		chunkEnd := chunkStart.Add(time.Hour)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		raw, err := s.metadataService.ListMetricsRange("", chunkStart, chunkEnd)
+		if err != nil {
+			return start, err
+		}
+		samples := make([]namespacedSample, 0, len(raw))
+		for i := range raw {
+			samples = append(samples, namespacedSample{namespace: raw[i].Namespace, seriesSample: rawSeriesSample(&raw[i])})
+		}
+		if err := s.metadataService.SaveMetricsRollups("5m", buildRollups(samples, "5m", interval)); err != nil {
+			return chunkStart, err
+		}
+	}
+	if end.After(start) {
+		return end, nil
+	}
+	return start, nil
+}
+
+// rollup5m compacts 5 minute rollups into 1 hour buckets and returns the end of the
+// range covered by 1 hour rollups
+// rollup5m 将 5 分钟汇总压缩为 1 小时桶，并返回 1 小时汇总覆盖范围的结束时间
+func (s *MetricsHistoryService) rollup5m() (time.Time, error) {
+	first, last, ok, err := s.metadataService.MetricsRollupBounds("5m")
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+	start, err := s.rollupStart("1h", time.Hour)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if start.IsZero() {
+		start = first.Truncate(time.Hour)
+	}
+	// Only hours fully covered by 5 minute rollups are compacted
+	// 仅压缩已被 5 分钟汇总完整覆盖的小时
+	end := last.Add(5 * time.Minute).Truncate(time.Hour)
+
+	for chunkStart := start; chunkStart.Before(end); chunkStart = chunkStart.Add(24 * time.Hour) {
+		chunkEnd := chunkStart.Add(24 * time.Hour)
+		if chunkEnd.After(end) {
+			chunkEnd = end
+		}
+		rollups, err := s.metadataService.ListMetricsRollups("5m", "", chunkStart, chunkEnd)
+		if err != nil {
+			return start, err
+		}
+		samples := make([]namespacedSample, 0, len(rollups))
+		for _, rollup := range rollups {
+			samples = append(samples, namespacedSample{
+				namespace:    rollup.Namespace,
+				seriesSample: seriesSample{timestamp: rollup.BucketStart, samples: rollup.Samples, values: rollup.Values},
+			})
+		}
+		if err := s.metadataService.SaveMetricsRollups("1h", buildRollups(samples, "1h", time.Hour)); err != nil {
+			return chunkStart, err
+		}
+	}
+	if end.After(start) {
+		return end, nil
+	}
+	return start, nil
+}
+
+// rollupStart returns the end of the newest bucket of a resolution, or the zero time
+// when the resolution holds no buckets yet
+// rollupStart 返回指定粒度最新桶的结束时间；尚无数据时返回零值
+func (s *MetricsHistoryService) rollupStart(resolution string, interval time.Duration) (time.Time, error) {
+	_, last, ok, err := s.metadataService.MetricsRollupBounds(resolution)
+	if err != nil || !ok {
+		return time.Time{}, err
+	}
+	return last.Add(interval), nil
+}
+
+// namespacedSample is a series sample together with its namespace
+// namespacedSample 附带命名空间的序列样本
+type namespacedSample struct {
+	namespace string
+	seriesSample
+}
+
+// buildRollups groups samples by namespace and bucket and merges every series
+// buildRollups 按命名空间与时间桶分组样本并合并各序列
+func buildRollups(samples []namespacedSample, resolution string, interval time.Duration) []model.MetricsRollup {
+	type bucketKey struct {
+		namespace string
+		start     int64
+	}
+	grouped := make(map[bucketKey][]seriesSample)
+	var keys []bucketKey
+	for _, sample := range samples {
+		key := bucketKey{namespace: sample.namespace, start: sample.timestamp.Truncate(interval).Unix()}
+		if _, ok := grouped[key]; !ok {
+			keys = append(keys, key)
+		}
+		grouped[key] = append(grouped[key], sample.seriesSample)
+	}
+
+	rollups := make([]model.MetricsRollup, 0, len(keys))
+	for _, key := range keys {
+		bucket := grouped[key]
+		rollup := model.MetricsRollup{
+			ID:          fmt.Sprintf("%s_%s_%d", key.namespace, resolution, key.start),
+			Namespace:   key.namespace,
+			BucketStart: time.Unix(key.start, 0).UTC(),
+			Values:      make(map[string]model.SeriesBucketStat, len(metricsSeries)),
+		}
+		for _, sample := range bucket {
+			rollup.Samples += max(sample.samples, 1)
+		}
+		for name := range metricsSeries {
+			if stat, count := mergeBucketStats(bucket, name); count > 0 {
+				rollup.Values[name] = stat
+			}
+		}
+		rollups = append(rollups, rollup)
+	}
+	return rollups
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestBuildRollupsSkipsMissingSeries(t *testing.T) {
+	start := time.Date(2026, 1, 1, 10, 0, 0, 0, time.UTC)
+	raw := []model.Metrics{
+		{Namespace: "tenant-a", CPUUsage: 40, QPS: 10, Missing: []string{"memory_usage"}, Timestamp: start},
+		{Namespace: "tenant-a", CPUUsage: 0, MemoryUsage: 60, Missing: []string{"cpu_usage", "qps"}, Timestamp: start.Add(time.Minute)},
+	}
+	samples := make([]namespacedSample, 0, len(raw))
+	for i := range raw {
+		samples = append(samples, namespacedSample{namespace: raw[i].Namespace, seriesSample: rawSeriesSample(&raw[i])})
+	}
+
+	rollups := buildRollups(samples, "5m", 5*time.Minute)
+	if len(rollups) != 1 {
+		t.Fatalf("rollups = %d, want 1", len(rollups))
+	}
+	rollup := rollups[0]
+	if rollup.Samples != 2 {
+		t.Fatalf("Samples = %d, want 2", rollup.Samples)
+	}
+	// Each series only averages the samples that measured it
+	for name, want := range map[string]float64{"cpu_usage": 40, "memory_usage": 60, "qps": 10, "search_latency_ms": 0} {
+		if got := rollup.Values[name].Avg; got != want {
+			t.Errorf("%s avg = %v, want %v", name, got, want)
+		}
+	}
+	if _, ok := rollup.Values["disk_usage"]; !ok {
+		t.Errorf("disk_usage measured by both samples is missing from the rollup")
+	}
+
+	points := bucketSeries([]seriesSample{rawSeriesSample(&raw[1])}, "cpu_usage", start, time.Hour)
+	if len(points) != 0 {
+		t.Errorf("cpu_usage points of a sample missing it = %v, want none", points)
+	}
+}
@@ -1,174 +1,393 @@
 package service
 
 import (
-	"encoding/json"
+	"context"
+	"errors"
 	"fmt"
 	"log"
-	"os/exec"
-	"strings"
+	"math"
+	"math/rand"
+	"slices"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"es-serverless-manager/internal/model"
 )
 
+// MonitoringConfig controls how often and how concurrently tenant clusters are collected
+// MonitoringConfig 控制租户集群的采集频率与并发度
+type MonitoringConfig struct {
+	Interval      time.Duration // 采集间隔
+	Workers       int           // 并发采集的命名空间数
+	TenantTimeout time.Duration // 单个命名空间的采集期限
+	Jitter        time.Duration // 每个周期开始前的最大随机延迟
+}
+
+// DefaultMonitoringConfig returns the default monitoring configuration
+// DefaultMonitoringConfig 返回默认的监控配置
+func DefaultMonitoringConfig() MonitoringConfig {
+	return MonitoringConfig{
+		Interval:      30 * time.Second,
+		Workers:       8,
+		TenantTimeout: 20 * time.Second,
+		Jitter:        3 * time.Second,
+	}
+}
+
 // MonitoringService handles container monitoring
 // MonitoringService 处理容器监控
 type MonitoringService struct {
 	metadataService *MetadataService
+	inspector       ClusterInspector
+	esStats         *ESStatsCollector
+	metrics         *PrometheusExporter
+	config          MonitoringConfig
 	ticker          *time.Ticker
+
+	// running is set while a cycle is in flight, so ticks that would overlap it are skipped
+	// running 在周期执行期间被置位，与之重叠的触发会被跳过
+	running atomic.Bool
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
 }
 
 // NewMonitoringService creates a new monitoring service
 // NewMonitoringService 创建一个新的监控服务
-func NewMonitoringService(metadataService *MetadataService) *MonitoringService {
+func NewMonitoringService(metadataService *MetadataService, inspector ClusterInspector, esStats *ESStatsCollector, metrics *PrometheusExporter, config MonitoringConfig) *MonitoringService {
+	defaults := DefaultMonitoringConfig()
+	if config.Interval <= 0 {
+		config.Interval = defaults.Interval
+	}
+	if config.Workers <= 0 {
+		config.Workers = defaults.Workers
+	}
+	if config.TenantTimeout <= 0 {
+		config.TenantTimeout = defaults.TenantTimeout
+	}
+	if config.Jitter < 0 || config.Jitter >= config.Interval {
+		config.Jitter = 0
+	}
 	return &MonitoringService{
 		metadataService: metadataService,
-		ticker:          time.NewTicker(30 * time.Second),
+		inspector:       inspector,
+		esStats:         esStats,
+		metrics:         metrics,
+		config:          config,
+		ticker:          time.NewTicker(config.Interval),
 	}
 }
 
 // Start begins the monitoring loop
 // Start 启动监控循环
 func (ms *MonitoringService) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	ms.cancel = cancel
+
+	ms.wg.Add(1)
 	go func() {
-		for range ms.ticker.C {
-			ms.collectMetrics()
+		defer ms.wg.Done()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ms.ticker.C:
+			}
+
+			if !ms.running.CompareAndSwap(false, true) {
+				log.Printf("Skipping monitoring cycle: previous cycle still running")
+				ms.metrics.ObserveMonitoringSkippedCycle()
+				continue
+			}
+			ms.wg.Add(1)
+			go func() {
+				defer ms.wg.Done()
+				defer ms.running.Store(false)
+				ms.runCycle(ctx)
+			}()
 		}
 	}()
 }
 
-// Stop stops the monitoring loop
-// Stop 停止监控循环
+// Stop stops the monitoring loop and waits for the in-flight cycle to return
+// Stop 停止监控循环并等待进行中的周期返回
 func (ms *MonitoringService) Stop() {
 	ms.ticker.Stop()
+	if ms.cancel != nil {
+		ms.cancel()
+	}
+	ms.wg.Wait()
+}
+
+// runCycle waits a random jitter, so collections of several managers do not line up, then collects
+// runCycle 等待一个随机延迟，避免多个管理器的采集同时发生，然后执行采集
+func (ms *MonitoringService) runCycle(ctx context.Context) {
+	if ms.config.Jitter > 0 {
+		timer := time.NewTimer(time.Duration(rand.Int63n(int64(ms.config.Jitter))))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+	ms.collectMetrics(ctx)
 }
 
-// collectMetrics collects metrics from all namespaces
-// collectMetrics 收集所有命名空间的指标
-func (ms *MonitoringService) collectMetrics() {
+// collectMetrics collects metrics from all namespaces with a bounded pool of workers
+// collectMetrics 使用有界的工作协程池收集所有命名空间的指标
+func (ms *MonitoringService) collectMetrics(ctx context.Context) {
+	start := time.Now()
+	defer func() {
+		ms.metrics.ObserveMonitoringCycle(ms.config.Interval, time.Since(start))
+	}()
+
 	// Get list of namespaces with ES clusters
 	// 获取具有 ES 集群的命名空间列表
-	cmd := exec.Command("kubectl", "get", "namespaces", "-l", "es-cluster=true", "-o", "jsonpath={.items[*].metadata.name}")
-	out, err := cmd.CombinedOutput()
+	listCtx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	namespaces, err := ms.inspector.ListClusterNamespaces(listCtx)
+	cancel()
+	if errors.Is(err, ErrClusterInspectorDisabled) {
+		// Already reported at startup
+		// 启动时已报告
+		return
+	}
 	if err != nil {
 		log.Printf("Error getting namespaces: %v", err)
 		return
 	}
 
-	namespaces := strings.Fields(string(out))
+	ms.esStats.Retain(namespaces)
+	ms.metrics.RetainTenants(namespaces)
+
+	workers := ms.config.Workers
+	if workers > len(namespaces) {
+		workers = len(namespaces)
+	}
+	jobs := make(chan string)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ns := range jobs {
+				ms.collectNamespace(ctx, ns)
+			}
+		}()
+	}
+
+dispatch:
 	for _, ns := range namespaces {
-		containerMetrics, err := ms.getContainerMetricsForNamespace(ns)
-		if err != nil {
-			log.Printf("Error getting container metrics for namespace %s: %v", ns, err)
-			continue
+		select {
+		case jobs <- ns:
+		case <-ctx.Done():
+			break dispatch
 		}
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// collectNamespace collects, stores and exports the metrics and health of one namespace within
+// the per tenant deadline, so a hung cluster only delays its own worker
+// collectNamespace 在单租户期限内采集、保存并导出一个命名空间的指标与健康状态，使卡住的集群只会拖慢自己的工作协程
+func (ms *MonitoringService) collectNamespace(ctx context.Context, ns string) {
+	ctx, cancel := context.WithTimeout(ctx, ms.config.TenantTimeout)
+	defer cancel()
+
+	health := ms.checkClusterHealth(ctx, ns)
+
+	containerMetrics, err := ms.getContainerMetricsForNamespace(ctx, ns)
+	if err != nil {
+		log.Printf("Error getting container metrics for namespace %s: %v", ns, err)
+		ms.metrics.ObserveNamespaceCollection(ns, err)
+		ms.updateDeploymentStatusWithMetrics(ns, nil, health)
+		return
+	}
+
+	// Save container metrics to metadata service
+	// 将容器指标保存到元数据服务
+	err = ms.saveContainerMetricsToMetadataService(containerMetrics)
+	if err != nil {
+		log.Printf("Error saving container metrics to metadata service for namespace %s: %v", ns, err)
+	}
+	ms.metrics.ObserveNamespaceCollection(ns, err)
+
+	// Update deployment status with latest metrics and health
+	// 使用最新指标与健康状态更新部署状态
+	deployment := ms.updateDeploymentStatusWithMetrics(ns, containerMetrics, health)
+	ms.exportTenantMetrics(ns, metricsFromContainer(containerMetrics), deployment)
+}
+
+// clusterHealthCheck is the Kubernetes and Elasticsearch health of a cluster read in one cycle
+// clusterHealthCheck 一个周期内读取的集群 Kubernetes 与 Elasticsearch 健康状态
+type clusterHealthCheck struct {
+	statefulSet *StatefulSetStatus
+	es          *model.ESClusterHealth
+	shards      []model.ESShard
+	checkedAt   time.Time
+}
+
+// checkClusterHealth reads the StatefulSet readiness, _cluster/health and _cat/shards of a cluster
+// checkClusterHealth 读取集群的 StatefulSet 就绪情况、_cluster/health 与 _cat/shards
+func (ms *MonitoringService) checkClusterHealth(ctx context.Context, namespace string) *clusterHealthCheck {
+	// Health gets half of the tenant deadline so a hung Elasticsearch still leaves time for metrics
+	// 健康检查占用单租户期限的一半，使卡住的 Elasticsearch 仍为指标采集留出时间
+	ctx, cancel := context.WithTimeout(ctx, ms.config.TenantTimeout/2)
+	defer cancel()
+
+	check := &clusterHealthCheck{checkedAt: time.Now()}
+	sts, err := ms.inspector.GetStatefulSet(ctx, namespace, esStatefulSetName)
+	if err != nil {
+		log.Printf("Warning: error getting statefulset for namespace %s: %v", namespace, err)
+	} else {
+		check.statefulSet = sts
+	}
+
+	health, err := ms.esStats.ClusterHealth(ctx, namespace)
+	if err != nil {
+		log.Printf("Warning: error getting cluster health for namespace %s: %v", namespace, err)
+		check.es = &model.ESClusterHealth{Status: "unreachable"}
+		return check
+	}
+	check.es = health
 
-		// Save container metrics to metadata service
-		// 将容器指标保存到元数据服务
-		err = ms.saveContainerMetricsToMetadataService(containerMetrics)
+	// Shards are only listed when the cluster reports some that are not started
+	// 仅当集群报告存在未启动的分片时才列出分片
+	if health.UnassignedShards+health.RelocatingShards+health.InitializingShards > 0 {
+		shards, err := ms.esStats.Shards(ctx, namespace)
 		if err != nil {
-			log.Printf("Error saving container metrics to metadata service for namespace %s: %v", ns, err)
+			log.Printf("Warning: error getting shards for namespace %s: %v", namespace, err)
+		} else {
+			check.shards = unhealthyShards(shards)
 		}
+	}
+	return check
+}
 
-		// Update deployment status with latest metrics
-		// 使用最新指标更新部署状态
-		ms.updateDeploymentStatusWithMetrics(ns, containerMetrics)
+// exportTenantMetrics publishes the latest metrics of a tenant cluster to the Prometheus exporter,
+// labelled with the tenant organization and user of its deployment
+// exportTenantMetrics 将租户集群的最新指标发布到 Prometheus 导出器，并附带其部署的租户组织与用户标签
+func (ms *MonitoringService) exportTenantMetrics(namespace string, metrics *model.Metrics, deployment *model.DeploymentStatus) {
+	if deployment == nil {
+		ms.metrics.SetTenantMetrics("", namespace, "", metrics)
+		return
 	}
+	ms.metrics.SetTenantMetrics(deployment.TenantOrgID, namespace, deployment.User, metrics)
 }
 
-// updateDeploymentStatusWithMetrics updates deployment status with latest metrics
-// updateDeploymentStatusWithMetrics 使用最新指标更新部署状态
-func (ms *MonitoringService) updateDeploymentStatusWithMetrics(namespace string, metrics *model.ContainerMetrics) {
+// updateDeploymentStatusWithMetrics updates deployment status with latest metrics and health and
+// returns it, or nil when the namespace has no deployment status. metrics is nil when they could not
+// be collected, in which case only the health is updated.
+// updateDeploymentStatusWithMetrics 使用最新指标与健康状态更新部署状态并返回该状态；命名空间没有部署状态时返回 nil。
+// 无法采集指标时 metrics 为 nil，此时仅更新健康状态
+func (ms *MonitoringService) updateDeploymentStatusWithMetrics(namespace string, metrics *model.ContainerMetrics, health *clusterHealthCheck) *model.DeploymentStatus {
 	// Get current deployment status
 	// 获取当前部署状态
 	deployment, err := ms.metadataService.GetDeploymentStatus(namespace)
 	if err != nil {
 		// If deployment status doesn't exist, that's okay - we'll skip updating it
 		// 如果部署状态不存在，没关系 - 我们将跳过更新它
-		return
+		return nil
 	}
 
-	// Update deployment status with latest metrics
-	// 使用最新指标更新部署状态
-	deployment.CPUUsage = metrics.CPUUsage
-	deployment.MemoryUsage = metrics.MemoryUsage
-	deployment.DiskUsage = metrics.DiskUsage
-	deployment.QPS = metrics.QPS
+	if metrics != nil {
+		// Update deployment status with latest metrics
+		// 使用最新指标更新部署状态
+		deployment.CPUUsage = metrics.CPUUsage
+		deployment.MemoryUsage = metrics.MemoryUsage
+		deployment.DiskUsage = metrics.DiskUsage
+		deployment.QPS = metrics.QPS
+
+		// Update load based on the busiest pod, so a single hot node is not averaged away;
+		// usages that could not be measured are left out
+		// 根据最繁忙的 Pod 更新负载，避免单个热点节点被平均值掩盖；无法测量的使用率不参与判断
+		cpuKnown := !slices.Contains(metrics.Missing, "cpu_usage")
+		memoryKnown := !slices.Contains(metrics.Missing, "memory_usage")
+		switch {
+		case (cpuKnown && metrics.CPUUsageMax > 80) || (memoryKnown && metrics.MemoryUsageMax > 80):
+			deployment.Load = "high_load"
+		case (cpuKnown || memoryKnown) && (!cpuKnown || metrics.CPUUsageMax < 20) && (!memoryKnown || metrics.MemoryUsageMax < 20):
+			deployment.Load = "low_load"
+		default:
+			deployment.Load = "normal"
+		}
+	}
 	deployment.UpdatedAt = time.Now()
 
-	// Update status based on metrics
-	// 根据指标更新状态
-	if metrics.CPUUsage > 80 || metrics.MemoryUsage > 80 {
-		deployment.Status = "high_load"
-	} else if metrics.CPUUsage < 20 && metrics.MemoryUsage < 20 {
-		deployment.Status = "low_load"
-	} else {
-		deployment.Status = "normal"
+	// Derive the overall status from Kubernetes and Elasticsearch health; a healthy cluster reports its load
+	// 根据 Kubernetes 与 Elasticsearch 健康状态得出整体状态；健康的集群报告其负载
+	if health.statefulSet != nil {
+		deployment.ReadyReplicas = health.statefulSet.ReadyReplicas
 	}
-
-	// Save updated deployment status
-	// 保存更新后的部署状态
-	err = ms.metadataService.SaveDeploymentStatus(deployment)
-	if err != nil {
+	deployment.ESHealth = *health.es
+	deployment.UnhealthyShards = health.shards
+	deployment.HealthCheckedAt = &health.checkedAt
+	previous := deployment.Status
+	status, reason := DeriveClusterStatus(health.statefulSet, health.es, previous)
+	if status == "running" && deployment.Load != "" {
+		status = deployment.Load
+	}
+	deployment.Status = status
+	deployment.StatusReason = reason
+
+	// Write only the columns owned by monitoring, so replicas a scale recorded meanwhile are kept, and
+	// change the status only if nobody else changed it since it was read
+	// 仅写入监控负责的列，以保留期间扩缩容记录的副本数；状态仅在读取后未被他人修改时才更新
+	columns := []string{"ready_replicas", "es_status", "es_number_of_nodes", "es_active_shards", "es_relocating_shards",
+		"es_initializing_shards", "es_unassigned_shards", "es_number_of_pending_tasks", "unhealthy_shards",
+		"health_checked_at", "updated_at"}
+	if metrics != nil {
+		columns = append(columns, "cpu_usage", "memory_usage", "disk_usage", "qps", "load")
+	}
+	if err := ms.metadataService.UpdateDeploymentStatusColumns(deployment, columns...); err != nil {
 		log.Printf("Error updating deployment status for namespace %s: %v", namespace, err)
 	}
+	if err := ms.metadataService.SetDeploymentStatusIf(namespace, previous, status, reason); err != nil {
+		log.Printf("Error updating deployment status for namespace %s: %v", namespace, err)
+	}
+	return deployment
 }
 
 // getContainerMetricsForNamespace gets detailed container metrics for a specific namespace
 // getContainerMetricsForNamespace 获取特定命名空间的详细容器指标
-func (ms *MonitoringService) getContainerMetricsForNamespace(namespace string) (*model.ContainerMetrics, error) {
-	// Get CPU and memory usage
-	// 获取 CPU 和内存使用情况
-	cpu, memory, err := ms.getResourceUsage(namespace)
-	if err != nil {
-		return nil, fmt.Errorf("error getting resource usage: %v", err)
-	}
-
-	// Get disk usage
-	// 获取磁盘使用情况
-	disk, err := ms.getDiskUsage(namespace)
+func (ms *MonitoringService) getContainerMetricsForNamespace(ctx context.Context, namespace string) (*model.ContainerMetrics, error) {
+	// Get resource limits and requests
+	// 获取资源限制和请求
+	resourceLimits, resourceRequests, err := ms.getResourceLimitsAndRequests(ctx, namespace)
 	if err != nil {
-		log.Printf("Warning: error getting disk usage for namespace %s: %v", namespace, err)
-		disk = 0.0
+		log.Printf("Warning: error getting resource limits and requests for namespace %s: %v", namespace, err)
 	}
 
-	// Get QPS (in a real implementation, this would come from Elasticsearch)
-	// 获取 QPS（在实际实现中，这将来自 Elasticsearch）
-	qps := ms.getQPS(namespace)
-
-	// Get plugin QPS
-	// 获取插件 QPS
-	pluginQPS := ms.getPluginQPS(namespace)
-
-	// Get startup metrics
-	// 获取启动指标
-	startupCPU, startupMemory, startupDisk, err := ms.getStartupMetrics(namespace)
+	// Get search rates and latencies from Elasticsearch counters
+	// 从 Elasticsearch 计数器获取搜索速率与延迟
+	search, err := ms.esStats.Collect(ctx, namespace)
 	if err != nil {
-		log.Printf("Warning: error getting startup metrics for namespace %s: %v", namespace, err)
-		startupCPU, startupMemory, startupDisk = 0.0, 0.0, 0.0
+		log.Printf("Warning: error getting search stats for namespace %s: %v", namespace, err)
+		search = &SearchStats{}
 	}
 
-	// Get resource limits and requests
-	// 获取资源限制和请求
-	resourceLimits, resourceRequests, err := ms.getResourceLimitsAndRequests(namespace)
+	// Get per pod usage
+	// 获取各 Pod 的使用情况
+	now := time.Now()
+	pods, err := ms.getPodMetrics(ctx, namespace, resourceLimits, resourceRequests, search, now)
 	if err != nil {
-		log.Printf("Warning: error getting resource limits and requests for namespace %s: %v", namespace, err)
+		return nil, fmt.Errorf("error getting resource usage: %v", err)
 	}
 
 	containerMetrics := &model.ContainerMetrics{
-		ID:            fmt.Sprintf("container_metrics_%s_%d", namespace, time.Now().UnixNano()),
-		Namespace:     namespace,
-		ContainerName: "elasticsearch",
-		CPUUsage:      cpu,
-		MemoryUsage:   memory,
-		DiskUsage:     disk,
-		QPS:           qps,
-		StartupCPU:    startupCPU,
-		StartupMemory: startupMemory,
-		StartupDisk:   startupDisk,
-		PluginQPS:     pluginQPS,
-		Timestamp:     time.Now(),
-		Status:        "running",
+		ID:                 fmt.Sprintf("container_metrics_%s_%d", namespace, now.UnixNano()),
+		Namespace:          namespace,
+		ContainerName:      "elasticsearch",
+		QPS:                search.QPS,
+		PluginQPS:          search.PluginQPS,
+		SearchLatencyMs:    search.AvgLatencyMs,
+		SearchLatencyP95Ms: search.P95LatencyMs,
+		SearchLatencyP99Ms: search.P99LatencyMs,
+		Indices:            search.Indices,
+		Pods:               pods,
+		Timestamp:          now,
+		Status:             "running",
 		ResourceLimits: model.ResourceLimits{
 			CPU:    resourceLimits.CPU,
 			Memory: resourceLimits.Memory,
@@ -178,185 +397,198 @@ func (ms *MonitoringService) getContainerMetricsForNamespace(namespace string) (
 			Memory: resourceRequests.Memory,
 		},
 	}
-
-	return containerMetrics, nil
-}
-
-// getResourceUsage gets CPU and memory usage for a namespace
-// getResourceUsage 获取命名空间的 CPU 和内存使用情况
-func (ms *MonitoringService) getResourceUsage(namespace string) (cpu float64, memory float64, err error) {
-	// Get pod metrics using kubectl top
-	// 使用 kubectl top 获取 pod 指标
-	cmd := exec.Command("kubectl", "top", "pods", "-n", namespace, "--no-headers")
-	out, err := cmd.CombinedOutput()
-	if err != nil {
-		return 0, 0, err
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	if len(lines) == 0 || lines[0] == "" {
-		return 0, 0, nil
-	}
-
-	// Parse the first pod's metrics (in a real implementation, you would aggregate all pods)
-	// 解析第一个 pod 的指标（在实际实现中，您将聚合所有 pod）
-	fields := strings.Fields(lines[0])
-	if len(fields) < 3 {
-		return 0, 0, fmt.Errorf("unexpected output format")
-	}
-
-	// Parse CPU usage (e.g., "100m" -> 0.1)
-	// 解析 CPU 使用情况（例如 "100m" -> 0.1）
-	cpuStr := strings.TrimSuffix(fields[1], "m")
-	if cpuVal, err := parseFloat(cpuStr); err == nil {
-		cpu = cpuVal / 1000 // Convert millicores to cores
+	aggregatePodMetrics(containerMetrics, pods)
+	// Rates need two collections of the counters, so the first one after a start or an
+	// Elasticsearch error has none
+	// 速率需要两次计数器采集，因此启动或 Elasticsearch 出错后的首次采集没有速率
+	if search.Interval == 0 {
+		containerMetrics.Missing = append(containerMetrics.Missing, "qps")
 	}
 
-	// Parse memory usage (e.g., "100Mi" -> 100)
-	// 解析内存使用情况（例如 "100Mi" -> 100）
-	memStr := strings.TrimSuffix(fields[2], "Mi")
-	if memVal, err := parseFloat(memStr); err == nil {
-		memory = memVal // In MB
-	}
+	// Startup metrics are taken from the current collection
+	// 启动指标取自本次采集
+	containerMetrics.StartupCPU = containerMetrics.CPUUsage
+	containerMetrics.StartupMemory = containerMetrics.MemoryUsage
+	containerMetrics.StartupDisk = containerMetrics.DiskUsage
 
-	return cpu, memory, nil
+	return containerMetrics, nil
 }
 
-// getDiskUsage gets disk usage for a namespace
-// getDiskUsage 获取命名空间的磁盘使用情况
-func (ms *MonitoringService) getDiskUsage(namespace string) (float64, error) {
-	// Get PVC usage
-	// 获取 PVC 使用情况
-	cmd := exec.Command("kubectl", "exec", "-n", namespace, "elasticsearch-0", "--", "df", "-h", "/usr/share/elasticsearch/data")
-	out, err := cmd.CombinedOutput()
+// getPodMetrics combines the Kubernetes pod usage with the Elasticsearch node stats
+// of each pod, which include the disk usage of its data volume. Usage percentages are
+// relative to the container limits, or the requests when no limit is set; a usage
+// without a known capacity or node stats is listed in the pod's Missing metrics.
+// getPodMetrics 合并各 Pod 的 Kubernetes 资源使用量与对应 Elasticsearch 节点统计（含数据卷磁盘使用量）；
+// 使用率相对于容器 limit 计算，未设置 limit 时相对于 request；容量或节点统计未知的使用率记入该 Pod 的 Missing
+func (ms *MonitoringService) getPodMetrics(ctx context.Context, namespace string, limits model.ResourceLimits, requests model.ResourceRequests, search *SearchStats, now time.Time) ([]model.PodMetrics, error) {
+	usages, err := ms.inspector.ListPodMetrics(ctx, namespace)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
 
-	lines := strings.Split(strings.TrimSpace(string(out)), "\n")
-	if len(lines) < 2 {
-		return 0, fmt.Errorf("unexpected df output")
+	cpuCapacity, _ := parseCPU(firstNonEmpty(limits.CPU, requests.CPU))
+	memoryCapacity := parseStorageSize(firstNonEmpty(limits.Memory, requests.Memory))
+
+	pods := make([]model.PodMetrics, 0, len(usages))
+	for _, usage := range usages {
+		pod := model.PodMetrics{
+			ID:          fmt.Sprintf("pod_metrics_%s_%s_%d", namespace, usage.Name, now.UnixNano()),
+			Namespace:   namespace,
+			PodName:     usage.Name,
+			CPUCores:    usage.CPUCores,
+			MemoryBytes: usage.MemoryBytes,
+			Timestamp:   now,
+		}
+		measure := func(metric string, value, capacity float64) float64 {
+			percent, ok := percentOf(value, capacity)
+			if !ok {
+				pod.Missing = append(pod.Missing, metric)
+			}
+			return percent
+		}
+		pod.CPUUsage = measure("cpu_usage", usage.CPUCores, cpuCapacity)
+		pod.MemoryUsage = measure("memory_usage", float64(usage.MemoryBytes), float64(memoryCapacity))
+
+		// A pod without node stats has unknown disk and heap capacities
+		// 没有节点统计的 Pod 的磁盘与堆容量未知
+		node := search.Nodes[usage.Name]
+		pod.DiskUsedBytes = node.DiskUsedBytes
+		pod.DiskCapacityBytes = node.DiskTotalBytes
+		pod.DiskUsage = measure("disk_usage", float64(node.DiskUsedBytes), float64(node.DiskTotalBytes))
+		pod.HeapUsedBytes = node.HeapUsedBytes
+		pod.HeapMaxBytes = node.HeapMaxBytes
+		pod.HeapUsage = measure("heap_usage", float64(node.HeapUsedBytes), float64(node.HeapMaxBytes))
+		pod.SearchRate = node.SearchRate
+		pod.IndexingRate = node.IndexingRate
+		pods = append(pods, pod)
 	}
+	return pods, nil
+}
 
-	// Parse disk usage percentage (e.g., "75%" -> 75)
-	// 解析磁盘使用百分比（例如 "75%" -> 75）
-	fields := strings.Fields(lines[1])
-	if len(fields) < 5 {
-		return 0, fmt.Errorf("unexpected df output format")
+// aggregatePodMetrics derives the cluster averages, maximums and totals from the pods.
+// Usages are averaged over the pods that measured them; a usage missing on any pod is
+// missing for the cluster, since its maximum is unknown.
+// aggregatePodMetrics 根据各 Pod 指标计算集群的平均值、最大值与合计值；使用率按测得它的 Pod 求平均，
+// 任一 Pod 缺失的使用率在集群级别也视为缺失，因为其最大值未知
+func aggregatePodMetrics(metrics *model.ContainerMetrics, pods []model.PodMetrics) {
+	if len(pods) == 0 {
+		return
 	}
 
-	percentStr := strings.TrimSuffix(fields[4], "%")
-	if percent, err := parseFloat(percentStr); err == nil {
-		return percent, nil
+	usages := []struct {
+		metric   string
+		value    func(pod *model.PodMetrics) float64
+		avg, max *float64
+	}{
+		{"cpu_usage", func(pod *model.PodMetrics) float64 { return pod.CPUUsage }, &metrics.CPUUsage, &metrics.CPUUsageMax},
+		{"memory_usage", func(pod *model.PodMetrics) float64 { return pod.MemoryUsage }, &metrics.MemoryUsage, &metrics.MemoryUsageMax},
+		{"disk_usage", func(pod *model.PodMetrics) float64 { return pod.DiskUsage }, &metrics.DiskUsage, &metrics.DiskUsageMax},
+		{"heap_usage", func(pod *model.PodMetrics) float64 { return pod.HeapUsage }, &metrics.HeapUsage, &metrics.HeapUsageMax},
 	}
-
-	return 0, fmt.Errorf("unable to parse disk usage")
-}
-
-// getQPS gets QPS for a namespace (mock implementation)
-// getQPS 获取命名空间的 QPS（模拟实现）
-func (ms *MonitoringService) getQPS(namespace string) float64 {
-	// In a real implementation, this would query Elasticsearch for QPS metrics
-	// For now, we'll return a mock value
-	// 在实际实现中，这将查询 Elasticsearch 的 QPS 指标
-	// 目前，我们返回一个模拟值
-	return 100.0 + float64(time.Now().UnixNano()%1000)/100.0
-}
-
-// getPluginQPS gets plugin QPS for a namespace (mock implementation)
-// getPluginQPS 获取命名空间的插件 QPS（模拟实现）
-func (ms *MonitoringService) getPluginQPS(namespace string) float64 {
-	// In a real implementation, this would query the Elasticsearch plugin for QPS metrics
-	// For now, we'll return a mock value
-	// 在实际实现中，这将查询 Elasticsearch 插件的 QPS 指标
-	// 目前，我们返回一个模拟值
-	return 50.0 + float64(time.Now().UnixNano()%500)/100.0
-}
-
-// getStartupMetrics gets startup metrics for a namespace
-// getStartupMetrics 获取命名空间的启动指标
-func (ms *MonitoringService) getStartupMetrics(namespace string) (cpu float64, memory float64, disk float64, err error) {
-	// In a real implementation, this would get the metrics from when the container was started
-	// For now, we'll return mock values based on current metrics
-	// 在实际实现中，这将获取容器启动时的指标
-	// 目前，我们根据当前指标返回模拟值
-	cpu, memory, err = ms.getResourceUsage(namespace)
-	if err != nil {
-		return 0, 0, 0, err
+	for _, usage := range usages {
+		var sum float64
+		measured := 0
+		for i := range pods {
+			if slices.Contains(pods[i].Missing, usage.metric) {
+				continue
+			}
+			value := usage.value(&pods[i])
+			sum += value
+			*usage.max = math.Max(*usage.max, value)
+			measured++
+		}
+		if measured > 0 {
+			*usage.avg = sum / float64(measured)
+		}
+		if measured < len(pods) {
+			metrics.Missing = append(metrics.Missing, usage.metric)
+		}
 	}
 
-	disk, err = ms.getDiskUsage(namespace)
-	if err != nil {
-		return cpu, memory, 0, nil
+	for _, pod := range pods {
+		metrics.CPUCores += pod.CPUCores
+		metrics.MemoryBytes += pod.MemoryBytes
+		metrics.DiskBytes += pod.DiskUsedBytes
+		metrics.IndexingRate += pod.IndexingRate
 	}
-
-	return cpu, memory, disk, nil
 }
 
 // getResourceLimitsAndRequests gets resource limits and requests for a namespace
 // getResourceLimitsAndRequests 获取命名空间的资源限制和请求
-func (ms *MonitoringService) getResourceLimitsAndRequests(namespace string) (model.ResourceLimits, model.ResourceRequests, error) {
+func (ms *MonitoringService) getResourceLimitsAndRequests(ctx context.Context, namespace string) (model.ResourceLimits, model.ResourceRequests, error) {
 	// Get resource limits and requests from the statefulset
 	// 从 StatefulSet 获取资源限制和请求
-	cmd := exec.Command("kubectl", "-n", namespace, "get", "sts/elasticsearch", "-o", "jsonpath={.spec.template.spec.containers[0].resources}")
-	out, err := cmd.CombinedOutput()
+	sts, err := ms.inspector.GetStatefulSet(ctx, namespace, esStatefulSetName)
 	if err != nil {
 		return model.ResourceLimits{}, model.ResourceRequests{}, err
 	}
 
-	// Parse the JSON output
-	// 解析 JSON 输出
-	var resources map[string]map[string]string
-	if err := json.Unmarshal(out, &resources); err != nil {
-		return model.ResourceLimits{}, model.ResourceRequests{}, err
-	}
-
-	limits := model.ResourceLimits{}
-	requests := model.ResourceRequests{}
-
-	if limitsMap, ok := resources["limits"]; ok {
-		limits.CPU = limitsMap["cpu"]
-		limits.Memory = limitsMap["memory"]
-	}
-
-	if requestsMap, ok := resources["requests"]; ok {
-		requests.CPU = requestsMap["cpu"]
-		requests.Memory = requestsMap["memory"]
-	}
-
-	return limits, requests, nil
+	return sts.Limits, sts.Requests, nil
 }
 
 // saveContainerMetricsToMetadataService saves container metrics to the metadata service
 // saveContainerMetricsToMetadataService 将容器指标保存到元数据服务
 func (ms *MonitoringService) saveContainerMetricsToMetadataService(metrics *model.ContainerMetrics) error {
-	// Convert ContainerMetrics to regular Metrics for backward compatibility
-	// 将 ContainerMetrics 转换为常规 Metrics 以实现向后兼容性
-	regularMetrics := &model.Metrics{
-		ID:          metrics.ID,
-		Namespace:   metrics.Namespace,
-		CPUUsage:    metrics.CPUUsage,
-		MemoryUsage: metrics.MemoryUsage,
-		DiskUsage:   metrics.DiskUsage,
-		QPS:         metrics.QPS,
-		Timestamp:   metrics.Timestamp,
-	}
-
 	// Save metrics to metadata service
 	// 将指标保存到元数据服务
-	err := ms.metadataService.SaveMetrics(regularMetrics)
+	err := ms.metadataService.SaveMetrics(metricsFromContainer(metrics))
 	if err != nil {
 		return fmt.Errorf("failed to save metrics to metadata service: %v", err)
 	}
+	if err := ms.metadataService.SavePodMetrics(metrics.Pods); err != nil {
+		return fmt.Errorf("failed to save pod metrics to metadata service: %v", err)
+	}
 
 	log.Printf("Successfully saved container metrics to metadata service for namespace %s", metrics.Namespace)
 	return nil
 }
 
-// parseFloat is a helper function to parse float64 from string
-// parseFloat 是一个辅助函数，用于从字符串解析 float64
-func parseFloat(s string) (float64, error) {
-	var val float64
-	_, err := fmt.Sscanf(s, "%f", &val)
-	return val, err
+// metricsFromContainer converts ContainerMetrics to regular Metrics for backward compatibility
+// metricsFromContainer 将 ContainerMetrics 转换为常规 Metrics 以实现向后兼容性
+func metricsFromContainer(metrics *model.ContainerMetrics) *model.Metrics {
+	return &model.Metrics{
+		ID:                 metrics.ID,
+		Namespace:          metrics.Namespace,
+		CPUUsage:           metrics.CPUUsage,
+		MemoryUsage:        metrics.MemoryUsage,
+		DiskUsage:          metrics.DiskUsage,
+		HeapUsage:          metrics.HeapUsage,
+		CPUUsageMax:        metrics.CPUUsageMax,
+		MemoryUsageMax:     metrics.MemoryUsageMax,
+		DiskUsageMax:       metrics.DiskUsageMax,
+		HeapUsageMax:       metrics.HeapUsageMax,
+		CPUCores:           metrics.CPUCores,
+		MemoryBytes:        metrics.MemoryBytes,
+		DiskBytes:          metrics.DiskBytes,
+		IndexingRate:       metrics.IndexingRate,
+		PodCount:           len(metrics.Pods),
+		QPS:                metrics.QPS,
+		PluginQPS:          metrics.PluginQPS,
+		SearchLatencyMs:    metrics.SearchLatencyMs,
+		SearchLatencyP95Ms: metrics.SearchLatencyP95Ms,
+		SearchLatencyP99Ms: metrics.SearchLatencyP99Ms,
+		Indices:            metrics.Indices,
+		Missing:            metrics.Missing,
+		Timestamp:          metrics.Timestamp,
+	}
+}
+
+// percentOf returns value as a percentage of capacity; ok is false when the capacity is unknown
+// percentOf 返回 value 占 capacity 的百分比；容量未知时 ok 为 false
+func percentOf(value, capacity float64) (percent float64, ok bool) {
+	if capacity <= 0 {
+		return 0, false
+	}
+	return value / capacity * 100, true
+}
+
+// firstNonEmpty returns the first non-empty string
+// firstNonEmpty 返回第一个非空字符串
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
 }
@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// newStubTenantES serves the node and index stats read by ESStatsCollector for a
+// cluster whose nodes are named after the given pods
+func newStubTenantES(t *testing.T, disks map[string][2]int64) *httptest.Server {
+	t.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasPrefix(r.URL.Path, "/_nodes/stats"):
+			nodes := make(map[string]interface{})
+			for name, disk := range disks {
+				nodes["id-"+name] = map[string]interface{}{
+					"name": name,
+					"jvm":  map[string]interface{}{"mem": map[string]interface{}{"heap_used_in_bytes": 256, "heap_max_in_bytes": 1024}},
+					"fs":   map[string]interface{}{"total": map[string]interface{}{"total_in_bytes": disk[0], "free_in_bytes": disk[1]}},
+				}
+			}
+			json.NewEncoder(w).Encode(map[string]interface{}{"nodes": nodes})
+		case r.URL.Path == "/_stats/search":
+			json.NewEncoder(w).Encode(map[string]interface{}{"indices": map[string]interface{}{}})
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMonitoringServiceCollectsFromInspector(t *testing.T) {
+	inspector := NewFakeClusterInspector()
+	inspector.AddCluster("tenant-a", 2)
+	inspector.StatefulSets["tenant-a"].Limits.CPU = "2"
+	inspector.StatefulSets["tenant-a"].Requests.Memory = "4Gi"
+	inspector.Pods["tenant-a"] = []PodUsage{
+		{Name: "elasticsearch-0", CPUCores: 1, MemoryBytes: 1 << 30},
+		{Name: "elasticsearch-1", CPUCores: 0.5, MemoryBytes: 3 << 30},
+	}
+
+	es := newStubTenantES(t, map[string][2]int64{
+		"elasticsearch-0": {1000, 750},
+		"elasticsearch-1": {1000, 250},
+	})
+	ms := NewMonitoringService(nil, inspector, NewESStatsCollector(es.URL), nil, DefaultMonitoringConfig())
+	defer ms.ticker.Stop()
+
+	metrics, err := ms.getContainerMetricsForNamespace(context.Background(), "tenant-a")
+	if err != nil {
+		t.Fatalf("getContainerMetricsForNamespace: %v", err)
+	}
+
+	if metrics.ResourceLimits.CPU != "2" || metrics.ResourceRequests.Memory != "4Gi" {
+		t.Fatalf("resources = %+v / %+v, want the StatefulSet resources", metrics.ResourceLimits, metrics.ResourceRequests)
+	}
+	if len(metrics.Pods) != 2 {
+		t.Fatalf("pods = %d, want 2", len(metrics.Pods))
+	}
+
+	approx := func(name string, got, want float64) {
+		t.Helper()
+		if math.Abs(got-want) > 1e-9 {
+			t.Errorf("%s = %v, want %v", name, got, want)
+		}
+	}
+	// CPU is relative to the limit, memory to the request since no limit is set
+	approx("CPUUsage", metrics.CPUUsage, 37.5)
+	approx("CPUUsageMax", metrics.CPUUsageMax, 50)
+	approx("MemoryUsage", metrics.MemoryUsage, 50)
+	approx("MemoryUsageMax", metrics.MemoryUsageMax, 75)
+	// Disk usage comes from the data path of the Elasticsearch node running in each pod
+	approx("DiskUsage", metrics.DiskUsage, 50)
+	approx("DiskUsageMax", metrics.DiskUsageMax, 75)
+	approx("HeapUsage", metrics.HeapUsage, 25)
+	if metrics.DiskBytes != 1000 {
+		t.Errorf("DiskBytes = %d, want 1000", metrics.DiskBytes)
+	}
+	// The first collection has no counters to compute rates from
+	if want := []string{"qps"}; !reflect.DeepEqual(metrics.Missing, want) {
+		t.Errorf("Missing = %v, want %v", metrics.Missing, want)
+	}
+}
+
+func TestMonitoringServiceReportsUnknownCapacityAsMissing(t *testing.T) {
+	inspector := NewFakeClusterInspector()
+	inspector.AddCluster("tenant-a", 2)
+	inspector.StatefulSets["tenant-a"].Limits.Memory = "4Gi"
+	inspector.Pods["tenant-a"] = []PodUsage{
+		{Name: "elasticsearch-0", CPUCores: 1, MemoryBytes: 1 << 30},
+		{Name: "elasticsearch-1", CPUCores: 1, MemoryBytes: 3 << 30},
+	}
+
+	// elasticsearch-1 has no Elasticsearch node stats
+	es := newStubTenantES(t, map[string][2]int64{"elasticsearch-0": {1000, 900}})
+	ms := NewMonitoringService(nil, inspector, NewESStatsCollector(es.URL), nil, DefaultMonitoringConfig())
+	defer ms.ticker.Stop()
+
+	metrics, err := ms.getContainerMetricsForNamespace(context.Background(), "tenant-a")
+	if err != nil {
+		t.Fatalf("getContainerMetricsForNamespace: %v", err)
+	}
+
+	// Neither a CPU limit nor a request is set, and disk and heap are unknown for one pod
+	if want := []string{"cpu_usage", "disk_usage", "heap_usage", "qps"}; !reflect.DeepEqual(metrics.Missing, want) {
+		t.Fatalf("Missing = %v, want %v", metrics.Missing, want)
+	}
+	if want := []string{"cpu_usage", "disk_usage", "heap_usage"}; !reflect.DeepEqual(metrics.Pods[1].Missing, want) {
+		t.Fatalf("pod Missing = %v, want %v", metrics.Pods[1].Missing, want)
+	}
+	if metrics.CPUUsage != 0 || metrics.MemoryUsageMax != 75 {
+		t.Fatalf("CPUUsage = %v, MemoryUsageMax = %v, want 0 and 75", metrics.CPUUsage, metrics.MemoryUsageMax)
+	}
+	// Averages only cover the pods that measured the usage
+	if metrics.DiskUsage != 10 || metrics.DiskUsageMax != 10 {
+		t.Fatalf("DiskUsage = %v/%v, want 10/10", metrics.DiskUsage, metrics.DiskUsageMax)
+	}
+
+	stored := metricsFromContainer(metrics)
+	if !stored.IsMissing("cpu_usage") || stored.IsMissing("memory_usage") {
+		t.Fatalf("stored Missing = %v", stored.Missing)
+	}
+}
+
+func TestMonitoringServiceHandlesEmptyNamespace(t *testing.T) {
+	inspector := NewFakeClusterInspector()
+	es := newStubTenantES(t, nil)
+	ms := NewMonitoringService(nil, inspector, NewESStatsCollector(es.URL), nil, DefaultMonitoringConfig())
+	defer ms.ticker.Stop()
+
+	// A namespace without a StatefulSet still reports its (empty) pod list
+	metrics, err := ms.getContainerMetricsForNamespace(context.Background(), "missing")
+	if err != nil {
+		t.Fatalf("getContainerMetricsForNamespace: %v", err)
+	}
+	if len(metrics.Pods) != 0 || metrics.CPUUsage != 0 {
+		t.Fatalf("metrics for empty namespace = %+v", metrics)
+	}
+}
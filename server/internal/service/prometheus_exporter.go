@@ -0,0 +1,359 @@
+package service
+
+import (
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"es-serverless-manager/internal/model"
+)
+
+var (
+	// httpDurationBuckets are the latency buckets of API requests, in seconds
+	// httpDurationBuckets API 请求延迟的分桶（秒）
+	httpDurationBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+	// terraformDurationBuckets are the duration buckets of Terraform runs, in seconds
+	// terraformDurationBuckets Terraform 执行耗时的分桶（秒）
+	terraformDurationBuckets = []float64{1, 5, 10, 30, 60, 120, 300, 600, 1200}
+	// monitoringCycleBuckets are the duration buckets of monitoring cycles, in seconds
+	// monitoringCycleBuckets 监控周期耗时的分桶（秒）
+	monitoringCycleBuckets = []float64{0.5, 1, 2.5, 5, 10, 20, 30, 60, 120}
+)
+
+// tenantExtraSeries are the tenant gauges exported besides the history series
+// tenantExtraSeries 除历史序列外额外导出的租户指标
+var tenantExtraSeries = map[string]func(m *model.Metrics) float64{
+	"cpu_cores":    func(m *model.Metrics) float64 { return m.CPUCores },
+	"memory_bytes": func(m *model.Metrics) float64 { return float64(m.MemoryBytes) },
+	"disk_bytes":   func(m *model.Metrics) float64 { return float64(m.DiskBytes) },
+	"pod_count":    func(m *model.Metrics) float64 { return float64(m.PodCount) },
+}
+
+// PrometheusExporter keeps the manager's internal metrics and the latest metrics of
+// every tenant cluster in a Prometheus registry and serves them with promhttp.
+// A nil exporter ignores every observation.
+// PrometheusExporter 在 Prometheus 注册表中保存管理器内部指标以及每个租户集群的最新指标，并通过 promhttp 输出；
+// nil 导出器会忽略所有观测
+type PrometheusExporter struct {
+	registry *prometheus.Registry
+	handler  http.Handler
+
+	httpRequests        *prometheus.CounterVec
+	httpDuration        *prometheus.HistogramVec
+	terraformDuration   *prometheus.HistogramVec
+	terraformFailures   *prometheus.CounterVec
+	autoscalerDecisions *prometheus.CounterVec
+	monitoringCycles    prometheus.Histogram
+	monitoringSkipped   prometheus.Counter
+	monitoringFailures  *prometheus.CounterVec
+
+	mu                 sync.Mutex
+	monitoringInterval time.Duration
+	lastCycleEnd       time.Time
+	tenants            map[string]tenantSample        // 以命名空间为键
+	collections        map[string]namespaceCollection // 以命名空间为键
+}
+
+// namespaceCollection tracks when a namespace was first collected and last collected successfully
+// namespaceCollection 记录命名空间首次采集与最近一次成功采集的时间
+type namespaceCollection struct {
+	firstSeen   time.Time
+	lastSuccess time.Time
+}
+
+// tenantSample is the latest metrics of a tenant cluster with its labels
+// tenantSample 租户集群的最新指标及其标签
+type tenantSample struct {
+	tenantOrgID string
+	user        string
+	metrics     model.Metrics
+}
+
+// NewPrometheusExporter creates a new Prometheus exporter
+// NewPrometheusExporter 创建一个新的 Prometheus 导出器
+func NewPrometheusExporter() *PrometheusExporter {
+	e := &PrometheusExporter{
+		registry: prometheus.NewRegistry(),
+		httpRequests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "es_manager_http_requests_total",
+			Help: "HTTP requests handled by the manager API.",
+		}, []string{"method", "route", "status"}),
+		httpDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "es_manager_http_request_duration_seconds",
+			Help:    "Latency of HTTP requests handled by the manager API.",
+			Buckets: httpDurationBuckets,
+		}, []string{"method", "route"}),
+		terraformDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "es_manager_terraform_run_duration_seconds",
+			Help:    "Duration of Terraform commands run for tenant clusters.",
+			Buckets: terraformDurationBuckets,
+		}, []string{"command", "result"}),
+		terraformFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "es_manager_terraform_failures_total",
+			Help: "Terraform commands that failed.",
+		}, []string{"command"}),
+		autoscalerDecisions: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "es_manager_autoscaler_decisions_total",
+			Help: "Autoscaler evaluations by outcome and driving metric.",
+		}, []string{"namespace", "decision", "metric"}),
+		monitoringCycles: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Name:    "es_manager_monitoring_cycle_duration_seconds",
+			Help:    "Duration of monitoring collection cycles.",
+			Buckets: monitoringCycleBuckets,
+		}),
+		monitoringSkipped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "es_manager_monitoring_skipped_cycles_total",
+			Help: "Monitoring cycles skipped because the previous cycle was still running.",
+		}),
+		monitoringFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "es_manager_monitoring_namespace_failures_total",
+			Help: "Failed metric collections per namespace.",
+		}, []string{"namespace"}),
+		tenants:     make(map[string]tenantSample),
+		collections: make(map[string]namespaceCollection),
+	}
+
+	e.registry.MustRegister(
+		e.httpRequests,
+		e.httpDuration,
+		e.terraformDuration,
+		e.terraformFailures,
+		e.autoscalerDecisions,
+		e.monitoringCycles,
+		e.monitoringSkipped,
+		e.monitoringFailures,
+		newMonitoringCollector(e),
+	)
+	e.handler = promhttp.HandlerFor(e.registry, promhttp.HandlerOpts{})
+	return e
+}
+
+// ObserveHTTPRequest records a handled API request; route is the route template
+// ObserveHTTPRequest 记录一次已处理的 API 请求；route 为路由模板
+func (e *PrometheusExporter) ObserveHTTPRequest(method, route string, status int, duration time.Duration) {
+	if e == nil {
+		return
+	}
+	e.httpRequests.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+	e.httpDuration.WithLabelValues(method, route).Observe(duration.Seconds())
+}
+
+// ObserveTerraformRun records the duration and outcome of a Terraform command
+// ObserveTerraformRun 记录一次 Terraform 命令的耗时与结果
+func (e *PrometheusExporter) ObserveTerraformRun(command string, duration time.Duration, err error) {
+	if e == nil {
+		return
+	}
+	result := "success"
+	if err != nil {
+		result = "failure"
+		e.terraformFailures.WithLabelValues(command).Inc()
+	}
+	e.terraformDuration.WithLabelValues(command, result).Observe(duration.Seconds())
+}
+
+// ObserveAutoscalerDecision counts one autoscaler evaluation of a namespace with the metric that
+// drove it, which is empty when no recommendation was made
+// ObserveAutoscalerDecision 统计一次命名空间的自动扩缩容评估及决定它的指标；未给出建议时指标为空
+func (e *PrometheusExporter) ObserveAutoscalerDecision(namespace, decision, metric string) {
+	if e == nil {
+		return
+	}
+	e.autoscalerDecisions.WithLabelValues(namespace, decision, metric).Inc()
+}
+
+// ObserveMonitoringCycle records a finished monitoring cycle; the lag exported at
+// scrape time is the time since this cycle beyond the collection interval
+// ObserveMonitoringCycle 记录一次完成的监控周期；抓取时导出的滞后为距该周期结束超出采集间隔的时间
+func (e *PrometheusExporter) ObserveMonitoringCycle(interval, duration time.Duration) {
+	if e == nil {
+		return
+	}
+	e.monitoringCycles.Observe(duration.Seconds())
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.monitoringInterval = interval
+	e.lastCycleEnd = time.Now()
+}
+
+// ObserveMonitoringSkippedCycle counts a monitoring cycle skipped because the previous one was still running
+// ObserveMonitoringSkippedCycle 统计一次因上一周期仍在运行而被跳过的监控周期
+func (e *PrometheusExporter) ObserveMonitoringSkippedCycle() {
+	if e == nil {
+		return
+	}
+	e.monitoringSkipped.Inc()
+}
+
+// ObserveNamespaceCollection records the outcome of collecting one namespace; the lag exported at
+// scrape time is the time since its last successful collection beyond the collection interval
+// ObserveNamespaceCollection 记录一个命名空间的采集结果；抓取时导出的滞后为距其最近一次成功采集超出采集间隔的时间
+func (e *PrometheusExporter) ObserveNamespaceCollection(namespace string, err error) {
+	if e == nil {
+		return
+	}
+	if err != nil {
+		e.monitoringFailures.WithLabelValues(namespace).Inc()
+	}
+
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	collection, ok := e.collections[namespace]
+	if !ok {
+		collection.firstSeen = now
+	}
+	if err == nil {
+		collection.lastSuccess = now
+	}
+	e.collections[namespace] = collection
+}
+
+// SetTenantMetrics replaces the exported metrics of a tenant cluster
+// SetTenantMetrics 替换租户集群的导出指标
+func (e *PrometheusExporter) SetTenantMetrics(tenantOrgID, namespace, user string, metrics *model.Metrics) {
+	if e == nil || metrics == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.tenants[namespace] = tenantSample{tenantOrgID: tenantOrgID, user: user, metrics: *metrics}
+}
+
+// RetainTenants drops the tenant clusters that are no longer monitored
+// RetainTenants 移除不再被监控的租户集群
+func (e *PrometheusExporter) RetainTenants(namespaces []string) {
+	if e == nil {
+		return
+	}
+	keep := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		keep[ns] = true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for ns := range e.tenants {
+		if !keep[ns] {
+			delete(e.tenants, ns)
+		}
+	}
+	for ns := range e.collections {
+		if !keep[ns] {
+			delete(e.collections, ns)
+		}
+	}
+}
+
+// ServeHTTP writes every metric in the Prometheus exposition format
+// ServeHTTP 以 Prometheus 格式输出全部指标
+func (e *PrometheusExporter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.handler.ServeHTTP(w, r)
+}
+
+// monitoringCollector exports the monitoring lag and the latest tenant metrics, which
+// are computed from the exporter state at scrape time
+// monitoringCollector 导出监控滞后与租户最新指标，这些值在抓取时根据导出器状态计算
+type monitoringCollector struct {
+	exporter     *PrometheusExporter
+	lag          *prometheus.Desc
+	lastCycle    *prometheus.Desc
+	namespaceLag *prometheus.Desc
+	tenant       map[string]*prometheus.Desc
+	tenantSeries map[string]func(m *model.Metrics) float64
+}
+
+func newMonitoringCollector(e *PrometheusExporter) *monitoringCollector {
+	c := &monitoringCollector{
+		exporter: e,
+		lag: prometheus.NewDesc("es_manager_monitoring_lag_seconds",
+			"Time the monitoring loop is behind its collection interval.", nil, nil),
+		lastCycle: prometheus.NewDesc("es_manager_monitoring_last_cycle_timestamp_seconds",
+			"Unix time of the last finished monitoring cycle.", nil, nil),
+		namespaceLag: prometheus.NewDesc("es_manager_monitoring_namespace_lag_seconds",
+			"Time since the last successful collection of a namespace beyond the collection interval.", []string{"namespace"}, nil),
+		tenant:       make(map[string]*prometheus.Desc),
+		tenantSeries: make(map[string]func(m *model.Metrics) float64),
+	}
+	for _, series := range []map[string]func(m *model.Metrics) float64{metricsSeries, tenantExtraSeries} {
+		for name, value := range series {
+			c.tenantSeries[name] = value
+			c.tenant[name] = prometheus.NewDesc("es_tenant_"+name,
+				"Tenant cluster "+name+" collected by the monitoring loop.", []string{"tenant_org_id", "namespace", "user"}, nil)
+		}
+	}
+	return c
+}
+
+func (c *monitoringCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.lag
+	ch <- c.lastCycle
+	ch <- c.namespaceLag
+	for _, desc := range c.tenant {
+		ch <- desc
+	}
+}
+
+// Collect snapshots the exporter state under its lock and sends the metrics afterwards,
+// so a slow scrape never blocks the monitoring loop
+// Collect 在持锁时复制导出器状态，释放锁后再发送指标，避免缓慢的抓取阻塞监控循环
+func (c *monitoringCollector) Collect(ch chan<- prometheus.Metric) {
+	e := c.exporter
+	e.mu.Lock()
+	interval := e.monitoringInterval
+	lastCycleEnd := e.lastCycleEnd
+	collections := make(map[string]namespaceCollection, len(e.collections))
+	for ns, collection := range e.collections {
+		collections[ns] = collection
+	}
+	tenants := make(map[string]tenantSample, len(e.tenants))
+	for ns, tenant := range e.tenants {
+		tenants[ns] = tenant
+	}
+	e.mu.Unlock()
+
+	now := time.Now()
+	lagSince := func(since time.Time) float64 {
+		lag := now.Sub(since) - interval
+		if lag < 0 {
+			lag = 0
+		}
+		return lag.Seconds()
+	}
+
+	if !lastCycleEnd.IsZero() {
+		ch <- prometheus.MustNewConstMetric(c.lag, prometheus.GaugeValue, lagSince(lastCycleEnd))
+		ch <- prometheus.MustNewConstMetric(c.lastCycle, prometheus.GaugeValue, float64(lastCycleEnd.UnixNano())/1e9)
+	}
+
+	for ns, collection := range collections {
+		since := collection.lastSuccess
+		if since.IsZero() {
+			since = collection.firstSeen
+		}
+		ch <- prometheus.MustNewConstMetric(c.namespaceLag, prometheus.GaugeValue, lagSince(since), ns)
+	}
+
+	names := make([]string, 0, len(c.tenantSeries))
+	for name := range c.tenantSeries {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for ns, tenant := range tenants {
+		for _, name := range names {
+			// Series that could not be measured are left out instead of exported as 0
+			// 无法测量的序列不导出，而不是导出为 0
+			if metric, ok := seriesMetric[name]; ok && tenant.metrics.IsMissing(metric) {
+				continue
+			}
+			ch <- prometheus.MustNewConstMetric(c.tenant[name], prometheus.GaugeValue,
+				c.tenantSeries[name](&tenant.metrics), tenant.tenantOrgID, ns, tenant.user)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package service
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func scrapeExporter(t *testing.T, e *PrometheusExporter) string {
+	t.Helper()
+
+	recorder := httptest.NewRecorder()
+	e.ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+	body, err := io.ReadAll(recorder.Result().Body)
+	if err != nil {
+		t.Fatalf("reading scrape: %v", err)
+	}
+	return string(body)
+}
+
+func TestPrometheusExporterServesMetrics(t *testing.T) {
+	e := NewPrometheusExporter()
+	e.ObserveHTTPRequest("GET", "/api/v1/clusters", 200, 20*time.Millisecond)
+	e.ObserveTerraformRun("apply", time.Second, errors.New("boom"))
+	e.ObserveNamespaceCollection("tenant-a", nil)
+	e.SetTenantMetrics("org-1", "tenant-a", "alice", &model.Metrics{
+		CPUUsage:    42,
+		MemoryUsage: 60,
+		PodCount:    3,
+		Missing:     []string{"memory_usage"},
+	})
+
+	body := scrapeExporter(t, e)
+	for _, want := range []string{
+		`es_manager_http_requests_total{method="GET",route="/api/v1/clusters",status="200"} 1`,
+		`es_manager_terraform_failures_total{command="apply"} 1`,
+		`es_manager_monitoring_namespace_lag_seconds{namespace="tenant-a"} `,
+		`es_tenant_cpu_usage{namespace="tenant-a",tenant_org_id="org-1",user="alice"} 42`,
+		`es_tenant_pod_count{namespace="tenant-a",tenant_org_id="org-1",user="alice"} 3`,
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("scrape is missing %q", want)
+		}
+	}
+	// A series that could not be measured is not exported as 0
+	if strings.Contains(body, "es_tenant_memory_usage{") {
+		t.Errorf("scrape exports the missing memory_usage series")
+	}
+	// The cycle lag is only known after the first cycle
+	if strings.Contains(body, "es_manager_monitoring_lag_seconds ") {
+		t.Errorf("scrape exports the cycle lag before any cycle")
+	}
+
+	e.RetainTenants(nil)
+	if body := scrapeExporter(t, e); strings.Contains(body, "es_tenant_cpu_usage{") {
+		t.Errorf("scrape still exports a tenant that is no longer monitored")
+	}
+}
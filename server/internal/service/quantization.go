@@ -0,0 +1,60 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+)
+
+// hnswDefaultM is the default number of HNSW neighbours per vector in Elasticsearch
+// hnswDefaultM Elasticsearch 中 HNSW 每个向量的默认邻居数
+const hnswDefaultM = 16
+
+// NormalizeQuantization validates a quantization mode and returns its canonical name
+// (none or int8). An empty mode means none.
+// NormalizeQuantization 校验量化方式并返回规范名称（none 或 int8），空值表示 none
+func NormalizeQuantization(quantization string) (string, error) {
+	switch strings.ToLower(quantization) {
+	case "", "none", "float":
+		return "none", nil
+	case "int8":
+		return "int8", nil
+	case "binary", "bbq":
+		// bbq_hnsw needs Elasticsearch 8.16, the clusters run 8.15
+		// bbq_hnsw 需要 Elasticsearch 8.16，而集群运行的是 8.15
+		return "", fmt.Errorf("%w: binary quantization is not supported by Elasticsearch 8.15, use int8", ErrInvalidRequest)
+	default:
+		return "", fmt.Errorf("%w: unknown quantization %s (expected none or int8)", ErrInvalidRequest, quantization)
+	}
+}
+
+// esIndexOptionsType maps a quantization mode to the dense_vector index_options type
+// esIndexOptionsType 将量化方式映射为 dense_vector 的 index_options 类型
+func esIndexOptionsType(quantization string) string {
+	switch quantization {
+	case "int8":
+		return "int8_hnsw"
+	default:
+		return "hnsw"
+	}
+}
+
+// EstimateVectorMemory estimates the off-heap memory Elasticsearch needs to keep the
+// vectors and HNSW graph of an index in the page cache, following the sizing rules
+// in the Elasticsearch kNN tuning guide
+// EstimateVectorMemory 按照 Elasticsearch kNN 调优指南中的估算方法，
+// 估算将索引的向量和 HNSW 图保留在页缓存中所需的堆外内存
+func EstimateVectorMemory(vectorCount int64, dims int, quantization string) int64 {
+	graph := int64(hnswDefaultM) * 4
+	return vectorCount * (quantizedVectorSize(dims, quantization) + graph)
+}
+
+// quantizedVectorSize returns the bytes of one vector as searched by the index
+// quantizedVectorSize 返回索引检索时单个向量的字节数
+func quantizedVectorSize(dims int, quantization string) int64 {
+	switch quantization {
+	case "int8":
+		return int64(dims) + 4
+	default:
+		return int64(dims) * 4
+	}
+}
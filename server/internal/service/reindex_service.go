@@ -0,0 +1,397 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// Painless scripts used to transform vectors while reindexing
+// 重建索引时用于转换向量的 painless 脚本
+const (
+	truncateVectorScript  = `def v = ctx._source[params.field]; if (v != null && v.size() > params.dims) { ctx._source[params.field] = new ArrayList(v.subList(0, params.dims)); }`
+	padVectorScript       = `def v = ctx._source[params.field]; if (v != null) { while (v.size() < params.dims) { v.add(0.0); } }`
+	normalizeVectorScript = `def v = ctx._source[params.field]; if (v != null) { double n = 0; for (def x : v) { n += x * x; } n = Math.sqrt(n); if (n > 0) { for (int i = 0; i < v.size(); i++) { v[i] = v[i] / n; } } }`
+)
+
+// ReindexService rebuilds vector indexes into versioned backing indices behind an alias
+// ReindexService 将向量索引重建为带版本的底层索引，并通过别名对外提供服务
+type ReindexService struct {
+	store           VectorStore
+	metadataService *MetadataService
+	taskService     *TaskService
+}
+
+// NewReindexService creates a new reindex service
+// NewReindexService 创建一个新的重建索引服务
+func NewReindexService(store VectorStore, metadataService *MetadataService, taskService *TaskService) *ReindexService {
+	return &ReindexService{
+		store:           store,
+		metadataService: metadataService,
+		taskService:     taskService,
+	}
+}
+
+// StartReindex copies a logical index into a new versioned backing index (name_vN) and
+// atomically points the logical name at it once the copy has finished
+// StartReindex 将逻辑索引复制到新的版本化底层索引（name_vN），复制完成后原子地将逻辑名指向新索引
+func (r *ReindexService) StartReindex(indexName string, req model.ReindexRequest) (*model.OperationTask, error) {
+	metadata, err := r.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Status != "active" {
+		return nil, fmt.Errorf("%w: %s is %s", ErrIndexBusy, indexName, metadata.Status)
+	}
+
+	// Unset fields keep the current definition
+	// 未设置的字段沿用当前定义
+	spec := model.VectorIndexRequest{
+		IndexName:    indexName,
+		TenantID:     metadata.CreatedBy,
+		Dimension:    req.Dimension,
+		Metric:       req.Metric,
+		IVFParams:    req.IVFParams,
+		FieldMapping: req.FieldMapping,
+	}
+	if spec.Dimension <= 0 {
+		spec.Dimension = metadata.Dimension
+	}
+	if spec.Metric == "" {
+		spec.Metric = metadata.Metric
+	}
+	if len(spec.IVFParams) == 0 {
+		spec.IVFParams = map[string]int{
+			"nlist":  metadata.IVFParams.NList,
+			"nprobe": metadata.IVFParams.NProbe,
+		}
+	}
+	spec.Quantization = req.Quantization
+	if spec.Quantization == "" {
+		spec.Quantization = metadata.Quantization
+	}
+	if spec.Quantization, err = NormalizeQuantization(spec.Quantization); err != nil {
+		return nil, err
+	}
+
+	// An alias cannot share its name with an index, so a physical index that still owns the
+	// logical name can only be replaced, never kept
+	// 别名不能与索引同名，因此仍占用逻辑名的物理索引只能被替换，无法保留
+	if (metadata.BackingIndex == "" || metadata.BackingIndex == indexName) && !req.DeleteOld {
+		return nil, fmt.Errorf("%w: %s is a physical index that the alias replaces, set delete_old", ErrInvalidRequest, indexName)
+	}
+
+	script, err := buildReindexScript(req, metadata.Dimension, spec.Dimension)
+	if err != nil {
+		return nil, err
+	}
+
+	task, err := r.taskService.CreateTask("reindex", indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.Status = "reindexing"
+	metadata.UpdatedAt = time.Now()
+	if err := r.metadataService.SaveIndexMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	r.taskService.RunTask(task, func(ctx context.Context, progress func(processed, total int64)) (map[string]interface{}, error) {
+		result, err := r.reindex(ctx, metadata, spec, script, req, progress)
+		if err != nil {
+			// The logical name still points at the old index, so it stays usable
+			// 逻辑名仍指向旧索引，因此索引保持可用
+			metadata.Status = "active"
+			metadata.UpdatedAt = time.Now()
+			r.metadataService.SaveIndexMetadata(metadata)
+		}
+		return result, err
+	})
+
+	return task, nil
+}
+
+// reindex performs the copy and alias swap for StartReindex
+// reindex 执行 StartReindex 的数据复制和别名切换
+func (r *ReindexService) reindex(ctx context.Context, metadata *model.IndexMetadata, spec model.VectorIndexRequest, script map[string]interface{}, req model.ReindexRequest, progress func(processed, total int64)) (map[string]interface{}, error) {
+	indexName := metadata.IndexName
+	source := metadata.BackingIndex
+	if source == "" {
+		source = indexName
+	}
+	version := metadata.Version
+	if version < 1 {
+		version = 1
+	}
+	dest := fmt.Sprintf("%s_v%d", indexName, version+1)
+
+	result := map[string]interface{}{
+		"source":  source,
+		"dest":    dest,
+		"version": version + 1,
+	}
+
+	if err := r.store.CreateVectorIndex(dest, BuildVectorMapping(spec)); err != nil {
+		return result, fmt.Errorf("failed to create index %s: %w", dest, err)
+	}
+
+	// Remove the half-built index and lift the write block if anything fails before the alias swap
+	// 如果在切换别名前失败，删除未完成的新索引并解除写入阻止
+	swapped := false
+	blocked := false
+	defer func() {
+		if swapped {
+			return
+		}
+		if err := r.store.DeleteIndex(dest); err != nil {
+			log.Printf("Warning: Failed to clean up index %s: %v", dest, err)
+		}
+		if blocked {
+			r.store.UpdateIndexSettings(source, map[string]interface{}{"index.blocks.write": false})
+		}
+	}()
+	blockWrites := func() error {
+		if err := r.store.UpdateIndexSettings(source, map[string]interface{}{"index.blocks.write": true}); err != nil {
+			return fmt.Errorf("failed to block writes on %s: %w", source, err)
+		}
+		blocked = true
+		return nil
+	}
+
+	if req.BlockWrites {
+		if err := blockWrites(); err != nil {
+			return result, err
+		}
+	}
+
+	response, err := r.copyDocuments(ctx, source, dest, script, progress)
+	if err != nil {
+		return result, err
+	}
+	result["es_task_id"] = response["es_task_id"]
+	result["took"] = response["took"]
+
+	// Writes that landed during the copy are caught up by a second pass under a write block,
+	// which only copies documents whose version changed
+	// 复制期间的写入由写入阻止下的第二轮补齐，该轮只复制版本发生变化的文档
+	if !blocked {
+		if err := blockWrites(); err != nil {
+			return result, err
+		}
+		response, err := r.copyDocuments(ctx, source, dest, script, nil)
+		if err != nil {
+			return result, fmt.Errorf("failed to catch up writes: %w", err)
+		}
+		result["catch_up_updated"] = response["updated"]
+		result["catch_up_created"] = response["created"]
+	}
+
+	if err := r.store.Refresh(source); err != nil {
+		return result, fmt.Errorf("failed to refresh %s: %w", source, err)
+	}
+	if err := r.store.Refresh(dest); err != nil {
+		return result, fmt.Errorf("failed to refresh %s: %w", dest, err)
+	}
+	sourceCount, err := r.store.Count(source, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to count documents in %s: %w", source, err)
+	}
+	destCount, err := r.store.Count(dest, nil)
+	if err != nil {
+		return result, fmt.Errorf("failed to count documents in %s: %w", dest, err)
+	}
+	result["source_count"] = sourceCount
+	result["dest_count"] = destCount
+	if sourceCount != destCount {
+		return result, fmt.Errorf("document count mismatch: %s has %d, %s has %d", source, sourceCount, dest, destCount)
+	}
+
+	// Swap the alias atomically. A physical index that still owns the logical name
+	// has to be removed in the same request, since an alias cannot share its name.
+	// 原子地切换别名。如果逻辑名仍是物理索引，需要在同一请求中删除它，因为别名不能与索引同名
+	actions := []map[string]interface{}{
+		{"add": map[string]interface{}{"index": dest, "alias": indexName}},
+	}
+	oldDeleted := false
+	if source == indexName && req.DeleteOld {
+		actions = append(actions, map[string]interface{}{"remove_index": map[string]interface{}{"index": source}})
+		oldDeleted = true
+	} else {
+		actions = append(actions, map[string]interface{}{"remove": map[string]interface{}{"index": source, "alias": indexName}})
+	}
+	if err := r.store.UpdateAliases(actions); err != nil {
+		return result, fmt.Errorf("failed to swap alias: %w", err)
+	}
+	swapped = true
+
+	if !oldDeleted {
+		if req.DeleteOld {
+			if err := r.store.DeleteIndex(source); err != nil {
+				log.Printf("Warning: Failed to delete old index %s: %v", source, err)
+			} else {
+				oldDeleted = true
+			}
+		}
+		if !oldDeleted {
+			r.store.UpdateIndexSettings(source, map[string]interface{}{"index.blocks.write": false})
+		}
+	}
+	result["old_index_deleted"] = oldDeleted
+
+	metadata.Version = version + 1
+	metadata.BackingIndex = dest
+	metadata.Dimension = spec.Dimension
+	metadata.Metric = spec.Metric
+	metadata.Quantization = spec.Quantization
+	metadata.IVFParams = model.IVFParams{
+		NList:  spec.IVFParams["nlist"],
+		NProbe: spec.IVFParams["nprobe"],
+	}
+	metadata.DocumentCount = int(destCount)
+	metadata.Status = "active"
+	metadata.UpdatedAt = time.Now()
+	if err := r.metadataService.SaveIndexMetadata(metadata); err != nil {
+		return result, fmt.Errorf("alias swapped but failed to save metadata: %w", err)
+	}
+
+	if _, size, err := r.store.GetIndexUsage(dest); err == nil {
+		r.metadataService.UpdateIndexUsage(indexName, 0, size)
+	}
+
+	log.Printf("Reindexed %s from %s to %s (%d documents)", indexName, source, dest, destCount)
+	return result, nil
+}
+
+// copyDocuments reindexes source into dest and waits for the ES task to finish
+// copyDocuments 将 source 重建到 dest 并等待 ES 任务完成
+func (r *ReindexService) copyDocuments(ctx context.Context, source, dest string, script map[string]interface{}, progress func(processed, total int64)) (map[string]interface{}, error) {
+	esTaskID, err := r.store.Reindex(source, dest, script)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start reindex: %w", err)
+	}
+
+	response, err := r.taskService.WaitForESTask(ctx, esTaskID, progress)
+	if err != nil {
+		return nil, err
+	}
+	if response == nil {
+		response = map[string]interface{}{}
+	}
+	response["es_task_id"] = esTaskID
+	return response, nil
+}
+
+// buildReindexScript returns the painless script applied while copying documents
+// buildReindexScript 返回复制文档时使用的 painless 脚本
+func buildReindexScript(req model.ReindexRequest, oldDim, newDim int) (map[string]interface{}, error) {
+	if req.Script != "" {
+		return map[string]interface{}{"source": req.Script, "lang": "painless"}, nil
+	}
+
+	var source string
+	switch req.VectorTransform {
+	case "":
+		if oldDim != newDim {
+			return nil, fmt.Errorf("%w: dimension changes from %d to %d, set vector_transform or script", ErrInvalidRequest, oldDim, newDim)
+		}
+		return nil, nil
+	case "truncate":
+		if newDim > oldDim {
+			return nil, fmt.Errorf("%w: truncate needs a smaller dimension (%d -> %d)", ErrInvalidRequest, oldDim, newDim)
+		}
+		source = truncateVectorScript
+	case "pad":
+		if newDim < oldDim {
+			return nil, fmt.Errorf("%w: pad needs a larger dimension (%d -> %d)", ErrInvalidRequest, oldDim, newDim)
+		}
+		source = padVectorScript
+	case "normalize":
+		if newDim != oldDim {
+			return nil, fmt.Errorf("%w: normalize cannot change the dimension (%d -> %d)", ErrInvalidRequest, oldDim, newDim)
+		}
+		source = normalizeVectorScript
+	default:
+		return nil, fmt.Errorf("%w: unknown vector_transform %q", ErrInvalidRequest, req.VectorTransform)
+	}
+
+	return map[string]interface{}{
+		"source": source,
+		"lang":   "painless",
+		"params": map[string]interface{}{"field": "vector", "dims": newDim},
+	}, nil
+}
+
+// DeleteIndexVersions deletes every physical index behind a logical index: the indices the alias
+// points at, every name_vN version kept by earlier reindexes and the original physical index.
+// It returns the deleted indices.
+// DeleteIndexVersions 删除逻辑索引背后的所有物理索引：别名指向的索引、之前重建保留的所有 name_vN 版本以及原始物理索引，
+// 返回被删除的索引
+func DeleteIndexVersions(store VectorStore, metadataService *MetadataService, indexName string) ([]string, error) {
+	// The name may be a backing index, so resolve it to its logical index first
+	// 传入的名称可能是底层索引，先解析为其逻辑索引
+	logical := indexName
+	if metadata, err := metadataService.GetIndexMetadataByName(indexName); err == nil {
+		logical = metadata.IndexName
+	}
+
+	targets := make(map[string]bool)
+	aliased, err := store.GetAliasIndices(logical)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve alias %s: %w", logical, err)
+	}
+	for _, index := range aliased {
+		targets[index] = true
+	}
+
+	names, err := store.ListIndexes()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list indexes: %w", err)
+	}
+	for _, name := range names {
+		if name == logical {
+			targets[name] = true
+			continue
+		}
+		if !isIndexVersion(logical, name) {
+			continue
+		}
+		// A separately managed index that merely looks like a version is left alone
+		// 仅名称形似版本、但单独管理的索引不做删除
+		if other, err := metadataService.GetIndexMetadataByName(name); err == nil && other.IndexName != logical {
+			continue
+		}
+		targets[name] = true
+	}
+
+	// Deleting the last index behind the alias removes the alias as well
+	// 删除别名背后的最后一个索引时，别名也会随之删除
+	deleted := make([]string, 0, len(targets))
+	for index := range targets {
+		deleted = append(deleted, index)
+	}
+	sort.Strings(deleted)
+	for _, index := range deleted {
+		if err := store.DeleteIndex(index); err != nil {
+			return nil, fmt.Errorf("failed to delete index %s: %w", index, err)
+		}
+	}
+	return deleted, nil
+}
+
+// isIndexVersion reports whether index is a name_vN version of the logical index name
+// isIndexVersion 判断 index 是否为逻辑索引 name 的 name_vN 版本
+func isIndexVersion(name, index string) bool {
+	suffix, ok := strings.CutPrefix(index, name+"_v")
+	if !ok {
+		return false
+	}
+	version, err := strconv.Atoi(suffix)
+	return err == nil && version > 0
+}
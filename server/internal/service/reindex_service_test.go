@@ -0,0 +1,57 @@
+package service
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestDeleteIndexVersionsAfterReindex(t *testing.T) {
+	store := newTestStore(t, "docs", "cosine", map[string][]float64{"a": {1, 0}, "b": {0, 1}})
+	unrelated := BuildVectorMapping(model.VectorIndexRequest{Dimension: 2})
+	if err := store.CreateVectorIndex("docs_vectors", unrelated); err != nil {
+		t.Fatalf("CreateVectorIndex: %v", err)
+	}
+
+	metadataService := newTestMetadataService(t)
+	tasks := NewTaskService(metadataService, store)
+	tasks.pollInterval = time.Millisecond
+	reindexer := NewReindexService(store, metadataService, tasks)
+
+	metadata := &model.IndexMetadata{ID: "idx_docs", IndexName: "docs", Dimension: 2, Metric: "cosine", Status: "active", Version: 1}
+	if err := metadataService.SaveIndexMetadata(metadata); err != nil {
+		t.Fatalf("SaveIndexMetadata: %v", err)
+	}
+	spec := model.VectorIndexRequest{IndexName: "docs", Dimension: 2, Metric: "cosine"}
+
+	// The first reindex replaces the physical index, the second keeps docs_v2 around
+	if _, err := reindexer.reindex(context.Background(), metadata, spec, nil, model.ReindexRequest{DeleteOld: true}, nil); err != nil {
+		t.Fatalf("first reindex: %v", err)
+	}
+	if _, err := reindexer.reindex(context.Background(), metadata, spec, nil, model.ReindexRequest{}, nil); err != nil {
+		t.Fatalf("second reindex: %v", err)
+	}
+	if names, _ := store.ListIndexes(); !reflect.DeepEqual(names, []string{"docs_v2", "docs_v3", "docs_vectors"}) {
+		t.Fatalf("indexes after reindex = %v", names)
+	}
+
+	deleted, err := DeleteIndexVersions(store, metadataService, "docs")
+	if err != nil {
+		t.Fatalf("DeleteIndexVersions: %v", err)
+	}
+	if !reflect.DeepEqual(deleted, []string{"docs_v2", "docs_v3"}) {
+		t.Errorf("deleted = %v, want [docs_v2 docs_v3]", deleted)
+	}
+	if names, _ := store.ListIndexes(); !reflect.DeepEqual(names, []string{"docs_vectors"}) {
+		t.Errorf("indexes after delete = %v, want [docs_vectors]", names)
+	}
+	if indices, _ := store.GetAliasIndices("docs"); len(indices) != 0 {
+		t.Errorf("alias docs still points at %v", indices)
+	}
+	if err := store.CreateVectorIndex("docs", unrelated); err != nil {
+		t.Errorf("re-creating docs: %v", err)
+	}
+}
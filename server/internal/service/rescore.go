@@ -0,0 +1,165 @@
+package service
+
+import (
+	"math"
+	"sort"
+)
+
+// KNNRescore rescores the hits of an oversampled kNN search with the full precision vectors.
+// Elasticsearch 8.15 has no knn.rescore_vector option, so quantized indexes fetch more
+// candidates and the manager restores the exact order before cutting back to the requested size.
+// KNNRescore 使用全精度向量对过采样的 kNN 搜索结果重新打分。Elasticsearch 8.15 不支持 knn.rescore_vector，
+// 因此量化索引会获取更多候选结果，由管理服务恢复精确顺序后再截取到请求的数量
+type KNNRescore struct {
+	field  string
+	vector []float32
+	metric string
+	size   int
+	// Whether the vector field has to be dropped from the returned _source again
+	// 是否需要再从返回的 _source 中去掉向量字段
+	stripSource bool
+	stripField  bool
+}
+
+// OversampleKNNQuery widens the kNN section of a search body by the oversample factor and makes
+// sure the vectors are returned. A rescore_vector option set by the caller overrides oversample
+// and is removed from the body. It returns nil when the body is left unchanged.
+// OversampleKNNQuery 按过采样倍数扩大搜索请求体中的 kNN 部分并确保返回向量；调用方设置的 rescore_vector
+// 优先于 oversample 并会从请求体中移除。请求体未被修改时返回 nil
+func OversampleKNNQuery(query map[string]interface{}, metric string, oversample float64) *KNNRescore {
+	knn, ok := query["knn"].(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	if option, set := knn["rescore_vector"]; set {
+		delete(knn, "rescore_vector")
+		if spec, ok := option.(map[string]interface{}); ok {
+			if value, ok := spec["oversample"].(float64); ok {
+				oversample = value
+			}
+		}
+	}
+	if oversample <= 1 {
+		return nil
+	}
+
+	field, _ := knn["field"].(string)
+	vector, ok := toVector(knn["query_vector"])
+	if field == "" || !ok {
+		return nil
+	}
+
+	k := toInt(knn["k"])
+	if k <= 0 {
+		k = 10
+	}
+	size := 10
+	if _, set := query["size"]; set {
+		size = toInt(query["size"])
+	}
+	if size > k {
+		size = k
+	}
+	widened := int(math.Ceil(float64(k) * oversample))
+	knn["k"] = widened
+	if toInt(knn["num_candidates"]) < widened {
+		knn["num_candidates"] = widened
+	}
+	query["size"] = widened
+
+	rescore := &KNNRescore{
+		field:  field,
+		vector: vector,
+		metric: storeMetric(esSimilarity(metric)),
+		size:   size,
+	}
+	switch source := query["_source"].(type) {
+	case bool:
+		if !source {
+			query["_source"] = []interface{}{field}
+			rescore.stripSource = true
+		}
+	case []interface{}:
+		if !containsField(source, field) {
+			query["_source"] = append(source, field)
+			rescore.stripField = true
+		}
+	case []string:
+		fields := make([]interface{}, 0, len(source)+1)
+		for _, item := range source {
+			fields = append(fields, item)
+		}
+		if !containsField(fields, field) {
+			fields = append(fields, field)
+			rescore.stripField = true
+		}
+		query["_source"] = fields
+	}
+	return rescore
+}
+
+// Apply reorders the hits of a search response by their exact score, cuts them back to the
+// requested size and removes the vectors the caller did not ask for. A nil rescore does nothing.
+// Apply 按精确分数重新排列搜索结果，截取到请求的数量并去掉调用方未请求的向量；rescore 为 nil 时不做任何处理
+func (r *KNNRescore) Apply(response map[string]interface{}) {
+	if r == nil {
+		return
+	}
+	hits, _ := response["hits"].(map[string]interface{})
+	hitList, _ := hits["hits"].([]interface{})
+	if hits == nil {
+		return
+	}
+
+	type scoredHit struct {
+		hit   map[string]interface{}
+		score float64
+	}
+	scored := make([]scoredHit, 0, len(hitList))
+	for _, item := range hitList {
+		hit, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		score, _ := hit["_score"].(float64)
+		source, _ := hit["_source"].(map[string]interface{})
+		if vector, ok := toVector(source[r.field]); ok {
+			score = esScore(similarity(r.vector, vector, r.metric), r.metric)
+			hit["_score"] = score
+		}
+		switch {
+		case r.stripSource:
+			delete(hit, "_source")
+		case r.stripField && source != nil:
+			delete(source, r.field)
+		}
+		scored = append(scored, scoredHit{hit: hit, score: score})
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > r.size {
+		scored = scored[:r.size]
+	}
+
+	rescored := make([]interface{}, len(scored))
+	for i, item := range scored {
+		rescored[i] = item.hit
+	}
+	hits["hits"] = rescored
+	if len(scored) > 0 {
+		hits["max_score"] = scored[0].score
+	}
+}
+
+// containsField reports whether a _source field list names field
+// containsField 判断 _source 字段列表中是否包含 field
+func containsField(fields []interface{}, field string) bool {
+	for _, item := range fields {
+		if name, ok := item.(string); ok && name == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package service
+
+import (
+	"fmt"
+	"testing"
+
+	"es-serverless-manager/internal/model"
+)
+
+func TestOversampleKNNQueryRescoresWithoutRescoreVector(t *testing.T) {
+	store := newTestStore(t, "quantized", "l2", map[string][]float64{
+		"a": {1, 0}, "b": {2, 0}, "c": {3, 0}, "d": {4, 0}, "e": {5, 0},
+	})
+
+	query := map[string]interface{}{
+		"knn": map[string]interface{}{
+			"field":          "vector",
+			"query_vector":   []interface{}{4.2, 0.0},
+			"k":              2.0,
+			"num_candidates": 2.0,
+			"rescore_vector": map[string]interface{}{"oversample": 2.0},
+		},
+		"_source": false,
+	}
+	rescore := OversampleKNNQuery(query, "l2_norm", 0)
+	if rescore == nil {
+		t.Fatal("OversampleKNNQuery returned nil for a rescore_vector query")
+	}
+	knn := query["knn"].(map[string]interface{})
+	if _, set := knn["rescore_vector"]; set {
+		t.Fatal("rescore_vector was sent to the store")
+	}
+	if knn["k"] != 4 || knn["num_candidates"] != 4 || query["size"] != 4 {
+		t.Fatalf("widened query = %v, want k, num_candidates and size 4", query)
+	}
+
+	response, err := store.Search("quantized", query)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	rescore.Apply(response)
+
+	if got := hitIDs(t, response); fmt.Sprint(got) != "[d e]" {
+		t.Fatalf("rescored hits = %v, want [d e]", got)
+	}
+	for _, item := range response["hits"].(map[string]interface{})["hits"].([]interface{}) {
+		if _, ok := item.(map[string]interface{})["_source"]; ok {
+			t.Errorf("hit %v kept the _source the caller turned off", item)
+		}
+	}
+}
+
+func TestBatchSearchOversampleCutsBackToK(t *testing.T) {
+	store := newTestStore(t, "quantized", "l2", map[string][]float64{
+		"a": {1, 0}, "b": {2, 0}, "c": {3, 0}, "d": {4, 0}, "e": {5, 0},
+	})
+
+	response := store.BatchSearch("quantized", model.BatchSearchRequest{
+		Vectors:    [][]float32{{1.1, 0}},
+		K:          2,
+		Source:     []string{"category"},
+		Oversample: 2,
+		Metric:     "l2",
+	})
+	if response.Errors != 0 {
+		t.Fatalf("BatchSearch errors: %+v", response.Results)
+	}
+	hits := response.Results[0].Hits
+	if len(hits) != 2 || hits[0]["_id"] != "a" || hits[1]["_id"] != "b" {
+		t.Fatalf("hits = %v, want a and b", hits)
+	}
+	source, _ := hits[0]["_source"].(map[string]interface{})
+	if _, ok := source["vector"]; ok || source["category"] == nil {
+		t.Errorf("_source = %v, want only the requested category", source)
+	}
+}
@@ -0,0 +1,319 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+// ErrTaskFinished is returned when cancelling a task that is no longer running
+// ErrTaskFinished 取消已结束的任务时返回的错误
+var ErrTaskFinished = errors.New("task already finished")
+
+// ErrTaskNotStarted is returned when cancelling a task that has nothing to cancel yet
+// ErrTaskNotStarted 取消尚无可取消对象的任务时返回的错误
+var ErrTaskNotStarted = errors.New("task has not started yet")
+
+// TaskService tracks long running background operations
+// TaskService 跟踪长时间运行的后台操作
+type TaskService struct {
+	metadataService *MetadataService
+	store           VectorStore
+	pollInterval    time.Duration
+	// Cancel functions of tasks running inside this process
+	// 本进程内运行的任务的取消函数
+	cancels map[string]context.CancelFunc
+	mu      sync.Mutex
+}
+
+// TaskFunc is the body of a locally executed task. It reports progress through
+// the progress callback and returns the task result.
+// TaskFunc 本地执行任务的主体，通过 progress 回调报告进度并返回任务结果
+type TaskFunc func(ctx context.Context, progress func(processed, total int64)) (map[string]interface{}, error)
+
+// NewTaskService creates a new task service
+// NewTaskService 创建一个新的任务服务
+func NewTaskService(metadataService *MetadataService, store VectorStore) *TaskService {
+	return &TaskService{
+		metadataService: metadataService,
+		store:           store,
+		pollInterval:    2 * time.Second,
+		cancels:         make(map[string]context.CancelFunc),
+	}
+}
+
+// CreateTask creates and persists a new pending task
+// CreateTask 创建并保存一个待执行的任务
+func (t *TaskService) CreateTask(taskType, indexName string) (*model.OperationTask, error) {
+	task := &model.OperationTask{
+		ID:        fmt.Sprintf("task_%s_%d", taskType, time.Now().UnixNano()),
+		Type:      taskType,
+		IndexName: indexName,
+		Status:    "pending",
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := t.metadataService.SaveOperationTask(task); err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// GetTask retrieves a task by ID
+// GetTask 根据 ID 获取任务
+func (t *TaskService) GetTask(id string) (*model.OperationTask, error) {
+	return t.metadataService.GetOperationTask(id)
+}
+
+// ListTasks lists tasks, optionally filtered by index
+// ListTasks 列出任务，可按索引过滤
+func (t *TaskService) ListTasks(indexName string) ([]*model.OperationTask, error) {
+	return t.metadataService.ListOperationTasks(indexName)
+}
+
+// CancelTask requests cancellation of a running task
+// CancelTask 请求取消正在运行的任务
+func (t *TaskService) CancelTask(id string) (*model.OperationTask, error) {
+	task, err := t.metadataService.GetOperationTask(id)
+	if err != nil {
+		return nil, err
+	}
+
+	if task.FinishedAt != nil {
+		return task, ErrTaskFinished
+	}
+
+	t.mu.Lock()
+	cancel, local := t.cancels[id]
+	t.mu.Unlock()
+	if local {
+		cancel()
+		return task, nil
+	}
+
+	if task.ESTaskID == "" {
+		return task, ErrTaskNotStarted
+	}
+
+	// The poll loop records the cancelled state once ES reports completion
+	// 当 ES 报告任务完成时，轮询循环会记录取消状态
+	if err := t.store.CancelTask(task.ESTaskID); err != nil {
+		return task, err
+	}
+	return task, nil
+}
+
+// RunTask executes fn in the background as the given task, recording progress and result
+// RunTask 在后台以指定任务执行 fn，并记录进度和结果
+func (t *TaskService) RunTask(task *model.OperationTask, fn TaskFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	t.mu.Lock()
+	t.cancels[task.ID] = cancel
+	t.mu.Unlock()
+
+	task.Status = "running"
+	task.UpdatedAt = time.Now()
+	if err := t.metadataService.SaveOperationTask(task); err != nil {
+		log.Printf("Error saving task %s: %v", task.ID, err)
+	}
+
+	go func() {
+		defer func() {
+			t.mu.Lock()
+			delete(t.cancels, task.ID)
+			t.mu.Unlock()
+			cancel()
+		}()
+
+		// Persist progress at most once per poll interval
+		// 每个轮询间隔最多保存一次进度
+		var mu sync.Mutex
+		lastSaved := time.Time{}
+		progress := func(processed, total int64) {
+			mu.Lock()
+			defer mu.Unlock()
+			task.Processed = processed
+			task.Total = total
+			if total > 0 {
+				task.Progress = float64(processed) / float64(total) * 100
+			}
+			if time.Since(lastSaved) < t.pollInterval {
+				return
+			}
+			lastSaved = time.Now()
+			task.UpdatedAt = lastSaved
+			if err := t.metadataService.SaveOperationTask(task); err != nil {
+				log.Printf("Error saving task %s: %v", task.ID, err)
+			}
+		}
+
+		result, err := fn(ctx, progress)
+
+		mu.Lock()
+		defer mu.Unlock()
+		switch {
+		case errors.Is(err, context.Canceled):
+			t.finishTask(task, "cancelled", "cancelled by user", result)
+		case err != nil:
+			t.finishTask(task, "failed", err.Error(), result)
+		default:
+			t.finishTask(task, "completed", "", result)
+		}
+	}()
+}
+
+// TrackESTask polls an Elasticsearch task until it completes and records its progress.
+// onFinish is called with the ES task response, which may be nil, once the task has succeeded,
+// failed or been cancelled, since a task that stopped partway may still have changed documents.
+// TrackESTask 轮询 Elasticsearch 任务直到完成并记录进度；任务成功、失败或被取消后以 ES 任务响应（可能为 nil）调用 onFinish，
+// 因为中途停止的任务也可能已修改了文档
+func (t *TaskService) TrackESTask(task *model.OperationTask, esTaskID string, onFinish func(response map[string]interface{})) {
+	task.ESTaskID = esTaskID
+	task.Status = "running"
+	task.UpdatedAt = time.Now()
+	if err := t.metadataService.SaveOperationTask(task); err != nil {
+		log.Printf("Error saving task %s: %v", task.ID, err)
+	}
+
+	go func() {
+		progress := func(processed, total int64) {
+			task.Processed = processed
+			task.Total = total
+			if total > 0 {
+				task.Progress = float64(processed) / float64(total) * 100
+			}
+			task.UpdatedAt = time.Now()
+			if err := t.metadataService.SaveOperationTask(task); err != nil {
+				log.Printf("Error saving task %s: %v", task.ID, err)
+			}
+		}
+
+		response, err := t.WaitForESTask(context.Background(), esTaskID, progress)
+		var cancelled *ESTaskCancelledError
+		switch {
+		case errors.As(err, &cancelled):
+			t.finishTask(task, "cancelled", cancelled.Reason, response)
+		case err != nil:
+			t.finishTask(task, "failed", err.Error(), response)
+		default:
+			t.finishTask(task, "completed", "", response)
+		}
+		if onFinish != nil {
+			onFinish(response)
+		}
+	}()
+}
+
+// ESTaskCancelledError is returned when an Elasticsearch task was cancelled
+// ESTaskCancelledError Elasticsearch 任务被取消时返回的错误
+type ESTaskCancelledError struct {
+	Reason string
+}
+
+func (e *ESTaskCancelledError) Error() string {
+	return "ES task cancelled: " + e.Reason
+}
+
+// WaitForESTask polls an Elasticsearch bulk-by-scroll task (reindex, delete-by-query, ...)
+// until it completes and returns its response. If ctx is cancelled the ES task is cancelled too.
+// WaitForESTask 轮询 Elasticsearch bulk-by-scroll 任务直到完成并返回其响应；ctx 取消时同时取消 ES 任务
+func (t *TaskService) WaitForESTask(ctx context.Context, esTaskID string, progress func(processed, total int64)) (map[string]interface{}, error) {
+	ticker := time.NewTicker(t.pollInterval)
+	defer ticker.Stop()
+
+	failures := 0
+	for {
+		select {
+		case <-ctx.Done():
+			if err := t.store.CancelTask(esTaskID); err != nil {
+				log.Printf("Error cancelling ES task %s: %v", esTaskID, err)
+			}
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+
+		status, err := t.store.GetTask(esTaskID)
+		if err != nil {
+			// Tolerate transient errors before giving up on the task
+			// 容忍短暂错误，连续失败多次后才放弃
+			failures++
+			log.Printf("Error polling ES task %s: %v", esTaskID, err)
+			if failures >= 5 {
+				return nil, err
+			}
+			continue
+		}
+		failures = 0
+
+		if inner, ok := status["task"].(map[string]interface{}); ok {
+			if taskStatus, ok := inner["status"].(map[string]interface{}); ok && progress != nil {
+				progress(esTaskProgress(taskStatus))
+			}
+		}
+
+		if completed, _ := status["completed"].(bool); !completed {
+			continue
+		}
+
+		if errObj, ok := status["error"].(map[string]interface{}); ok {
+			reason, _ := errObj["reason"].(string)
+			return nil, fmt.Errorf("ES task failed: %s", reason)
+		}
+
+		response, _ := status["response"].(map[string]interface{})
+		if canceled, _ := response["canceled"].(string); canceled != "" {
+			return response, &ESTaskCancelledError{Reason: canceled}
+		}
+		if failureList, _ := response["failures"].([]interface{}); len(failureList) > 0 {
+			return response, fmt.Errorf("%d failures, first: %v", len(failureList), failureList[0])
+		}
+
+		return response, nil
+	}
+}
+
+// esTaskProgress extracts processed and total counts from a bulk-by-scroll task status
+// esTaskProgress 从 bulk-by-scroll 任务状态中提取已处理数和总数
+func esTaskProgress(status map[string]interface{}) (processed, total int64) {
+	getInt64 := func(key string) int64 {
+		if v, ok := status[key].(float64); ok {
+			return int64(v)
+		}
+		return 0
+	}
+
+	processed = getInt64("created") + getInt64("updated") + getInt64("deleted") +
+		getInt64("noops") + getInt64("version_conflicts")
+	return processed, getInt64("total")
+}
+
+// FailTask marks a task that could not be started as failed
+// FailTask 将无法启动的任务标记为失败
+func (t *TaskService) FailTask(task *model.OperationTask, err error) {
+	t.finishTask(task, "failed", err.Error(), nil)
+}
+
+// finishTask marks a task as finished with the given status
+// finishTask 以指定状态结束任务
+func (t *TaskService) finishTask(task *model.OperationTask, status, errMsg string, result map[string]interface{}) {
+	now := time.Now()
+	task.Status = status
+	task.Error = errMsg
+	task.Result = result
+	task.UpdatedAt = now
+	task.FinishedAt = &now
+	if status == "completed" {
+		task.Progress = 100
+	}
+
+	if err := t.metadataService.SaveOperationTask(task); err != nil {
+		log.Printf("Error saving task %s: %v", task.ID, err)
+	}
+	log.Printf("Task %s (%s on %s) finished with status %s", task.ID, task.Type, task.IndexName, status)
+}
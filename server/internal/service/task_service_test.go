@@ -0,0 +1,71 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+// stubTaskStore reports a fixed status for every ES task
+type stubTaskStore struct {
+	*MemoryStore
+	status map[string]interface{}
+}
+
+func (s *stubTaskStore) GetTask(taskID string) (map[string]interface{}, error) {
+	return s.status, nil
+}
+
+func TestTrackESTaskCallsOnFinishForEveryOutcome(t *testing.T) {
+	cases := []struct {
+		name   string
+		status map[string]interface{}
+		want   string
+	}{
+		{"succeeded", map[string]interface{}{
+			"completed": true,
+			"response":  map[string]interface{}{"deleted": float64(10)},
+		}, "completed"},
+		{"failed", map[string]interface{}{
+			"completed": true,
+			"response":  map[string]interface{}{"deleted": float64(4), "failures": []interface{}{"shard failure"}},
+		}, "failed"},
+		{"cancelled", map[string]interface{}{
+			"completed": true,
+			"response":  map[string]interface{}{"deleted": float64(2), "canceled": "by user request"},
+		}, "cancelled"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			metadata := newTestMetadataService(t)
+			tasks := NewTaskService(metadata, &stubTaskStore{MemoryStore: NewMemoryStore(), status: c.status})
+			tasks.pollInterval = time.Millisecond
+
+			task, err := tasks.CreateTask("delete_by_query", "docs")
+			if err != nil {
+				t.Fatalf("CreateTask: %v", err)
+			}
+			finished := make(chan map[string]interface{}, 1)
+			tasks.TrackESTask(task, "node:1", func(response map[string]interface{}) {
+				finished <- response
+			})
+
+			select {
+			case response := <-finished:
+				if response["deleted"] != c.status["response"].(map[string]interface{})["deleted"] {
+					t.Errorf("onFinish response = %v", response)
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("onFinish was not called")
+			}
+
+			stored, err := tasks.GetTask(task.ID)
+			if err != nil {
+				t.Fatalf("GetTask: %v", err)
+			}
+			if stored.Status != c.want {
+				t.Errorf("status = %s, want %s", stored.Status, c.want)
+			}
+		})
+	}
+}
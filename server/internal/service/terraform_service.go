@@ -1,11 +1,13 @@
 package service
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"text/template"
+	"time"
 
 	"es-serverless-manager/internal/model"
 )
@@ -14,13 +16,15 @@ import (
 // TerraformManager 处理租户集群的 Terraform 操作
 type TerraformManager struct {
 	BaseDir string
+	metrics *PrometheusExporter
 }
 
 // NewTerraformManager creates a new TerraformManager
 // NewTerraformManager 创建一个新的 TerraformManager
-func NewTerraformManager(baseDir string) *TerraformManager {
+func NewTerraformManager(baseDir string, metrics *PrometheusExporter) *TerraformManager {
 	return &TerraformManager{
 		BaseDir: baseDir,
+		metrics: metrics,
 	}
 }
 
@@ -49,6 +53,12 @@ output "namespace" {
 // CreateCluster creates a new cluster using Terraform
 // CreateCluster 使用 Terraform 创建新集群
 func (m *TerraformManager) CreateCluster(config model.TenantConfig) error {
+	return m.CreateClusterContext(context.Background(), config)
+}
+
+// CreateClusterContext creates or updates a cluster using Terraform; Terraform is interrupted when ctx ends
+// CreateClusterContext 使用 Terraform 创建或更新集群；ctx 结束时中断 Terraform
+func (m *TerraformManager) CreateClusterContext(ctx context.Context, config model.TenantConfig) error {
 	// Check if terraform is installed
 	// 检查 Terraform 是否安装
 	if _, err := exec.LookPath("terraform"); err != nil {
@@ -81,13 +91,13 @@ func (m *TerraformManager) CreateCluster(config model.TenantConfig) error {
 
 	// Initialize Terraform
 	// 初始化 Terraform
-	if err := m.runTerraform(tenantDir, "init"); err != nil {
+	if err := m.runTerraform(ctx, tenantDir, "init"); err != nil {
 		return fmt.Errorf("terraform init failed: %w", err)
 	}
 
 	// Apply Terraform
 	// 应用 Terraform 配置
-	if err := m.runTerraform(tenantDir, "apply", "-auto-approve"); err != nil {
+	if err := m.runTerraform(ctx, tenantDir, "apply", "-auto-approve"); err != nil {
 		return fmt.Errorf("terraform apply failed: %w", err)
 	}
 
@@ -113,7 +123,7 @@ func (m *TerraformManager) DeleteCluster(namespace string) error {
 
 	// Destroy Terraform
 	// 销毁 Terraform 资源
-	if err := m.runTerraform(tenantDir, "destroy", "-auto-approve"); err != nil {
+	if err := m.runTerraform(context.Background(), tenantDir, "destroy", "-auto-approve"); err != nil {
 		return fmt.Errorf("terraform destroy failed: %w", err)
 	}
 
@@ -122,7 +132,10 @@ func (m *TerraformManager) DeleteCluster(namespace string) error {
 	return os.RemoveAll(tenantDir)
 }
 
-func (m *TerraformManager) runTerraform(dir string, args ...string) error {
+// runTerraform runs a Terraform command; when ctx ends Terraform gets an interrupt, so it can
+// release the state lock, and is killed if it has not exited a minute later
+// runTerraform 执行 Terraform 命令；ctx 结束时向 Terraform 发送中断信号以便其释放状态锁，一分钟后仍未退出则将其终止
+func (m *TerraformManager) runTerraform(ctx context.Context, dir string, args ...string) error {
 	// Check if terraform is installed
 	// 检查 Terraform 是否安装
 	_, err := exec.LookPath("terraform")
@@ -130,9 +143,15 @@ func (m *TerraformManager) runTerraform(dir string, args ...string) error {
 		return fmt.Errorf("terraform not found in PATH")
 	}
 
-	cmd := exec.Command("terraform", args...)
+	cmd := exec.CommandContext(ctx, "terraform", args...)
+	cmd.Cancel = func() error { return cmd.Process.Signal(os.Interrupt) }
+	cmd.WaitDelay = time.Minute
 	cmd.Dir = dir
 	cmd.Stdout = os.Stdout
 	cmd.Stderr = os.Stderr
-	return cmd.Run()
+
+	start := time.Now()
+	err = cmd.Run()
+	m.metrics.ObserveTerraformRun(args[0], time.Since(start), err)
+	return err
 }
@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"es-serverless-manager/internal/model"
+)
+
+const (
+	// defaultTrainSamplesPerCentroid sizes the default training sample relative to nlist
+	// defaultTrainSamplesPerCentroid 默认训练样本数相对于 nlist 的倍数
+	defaultTrainSamplesPerCentroid = 50
+	// defaultTrainMaxIterations matches the SimpleKMeansTrainer default in the plugin
+	// defaultTrainMaxIterations 与插件中 SimpleKMeansTrainer 的默认值一致
+	defaultTrainMaxIterations = 100
+	// defaultNList is used when neither the request nor the index defines nlist
+	// defaultNList 请求和索引均未定义 nlist 时使用的默认值
+	defaultNList = 100
+)
+
+// TrainingService triggers IVF training in the Elasticsearch plugin and records its results
+// TrainingService 触发 Elasticsearch 插件中的 IVF 训练并记录训练结果
+type TrainingService struct {
+	store           VectorStore
+	metadataService *MetadataService
+	taskService     *TaskService
+}
+
+// NewTrainingService creates a new training service
+// NewTrainingService 创建一个新的训练服务
+func NewTrainingService(store VectorStore, metadataService *MetadataService, taskService *TaskService) *TrainingService {
+	return &TrainingService{
+		store:           store,
+		metadataService: metadataService,
+		taskService:     taskService,
+	}
+}
+
+// StartTraining validates the request and trains the index as a tracked background task.
+// The index status moves to building while training runs and back to active afterwards.
+// StartTraining 校验请求并以可跟踪的后台任务训练索引；训练期间索引状态为 building，完成后恢复为 active
+func (t *TrainingService) StartTraining(indexName string, req model.TrainRequest) (*model.OperationTask, error) {
+	metadata, err := t.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Status != "active" {
+		return nil, fmt.Errorf("%w: %s is %s", ErrIndexBusy, indexName, metadata.Status)
+	}
+
+	if req.Field == "" {
+		req.Field = "vector"
+	}
+	if req.NList <= 0 {
+		req.NList = metadata.IVFParams.NList
+	}
+	if req.NList <= 0 {
+		req.NList = defaultNList
+	}
+	if req.SampleSize <= 0 {
+		req.SampleSize = req.NList * defaultTrainSamplesPerCentroid
+	}
+	if req.MaxIterations <= 0 {
+		req.MaxIterations = defaultTrainMaxIterations
+	}
+	if req.SampleSize < req.NList {
+		return nil, fmt.Errorf("%w: sample_size %d is smaller than nlist %d", ErrInvalidRequest, req.SampleSize, req.NList)
+	}
+
+	// K-means needs at least one vector per centroid
+	// KMeans 至少需要每个聚类中心一个向量
+	count, err := t.store.Count(indexName, map[string]interface{}{
+		"exists": map[string]interface{}{"field": req.Field},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count vectors: %w", err)
+	}
+	if count < int64(req.NList) {
+		return nil, fmt.Errorf("%w: index has %d vectors, training needs at least nlist=%d", ErrInvalidRequest, count, req.NList)
+	}
+
+	task, err := t.taskService.CreateTask("train", indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	metadata.Status = "building"
+	metadata.UpdatedAt = time.Now()
+	if err := t.metadataService.SaveIndexMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	t.taskService.RunTask(task, func(ctx context.Context, progress func(processed, total int64)) (map[string]interface{}, error) {
+		return t.train(ctx, metadata, req, progress)
+	})
+
+	return task, nil
+}
+
+// train calls the plugin and stores the training results on the index metadata
+// train 调用插件并将训练结果保存到索引元数据
+func (t *TrainingService) train(ctx context.Context, metadata *model.IndexMetadata, req model.TrainRequest, progress func(processed, total int64)) (map[string]interface{}, error) {
+	start := time.Now()
+	params := map[string]interface{}{
+		"field":          req.Field,
+		"nlist":          req.NList,
+		"metric":         metadata.Metric,
+		"sample_size":    req.SampleSize,
+		"max_iterations": req.MaxIterations,
+	}
+
+	// After a reindex the logical name is an alias, while the plugin keys the IVF index by the
+	// concrete index that searches resolve to
+	// 重建后逻辑名是一个别名，而插件按搜索解析到的具体索引保存 IVF 索引
+	target := metadata.BackingIndex
+	if target == "" {
+		target = metadata.IndexName
+	}
+	result, err := t.store.TrainIVFIndex(ctx, target, params)
+	elapsed := time.Since(start)
+
+	metadata.Status = "active"
+	metadata.UpdatedAt = time.Now()
+	if err != nil {
+		if saveErr := t.metadataService.SaveIndexMetadata(metadata); saveErr != nil {
+			log.Printf("Error restoring status of index %s: %v", metadata.IndexName, saveErr)
+		}
+		return map[string]interface{}{"elapsed_ms": elapsed.Milliseconds()}, err
+	}
+
+	if result.TrainingTimeMs == 0 {
+		result.TrainingTimeMs = elapsed.Milliseconds()
+	}
+	progress(int64(result.VectorCount), int64(result.VectorCount))
+
+	trainedAt := time.Now()
+	metadata.IVFParams.NList = req.NList
+	metadata.CentroidCount = result.CentroidCount
+	metadata.TrainingVectorCount = result.VectorCount
+	metadata.TrainingTimeMs = result.TrainingTimeMs
+	metadata.TrainingInertia = result.Inertia
+	metadata.TrainedAt = &trainedAt
+	if err := t.metadataService.SaveIndexMetadata(metadata); err != nil {
+		return nil, fmt.Errorf("training finished but failed to save metadata: %w", err)
+	}
+
+	log.Printf("Trained IVF index %s: %d centroids from %d vectors in %dms", metadata.IndexName, result.CentroidCount, result.VectorCount, result.TrainingTimeMs)
+	return map[string]interface{}{
+		"centroid_count":   result.CentroidCount,
+		"vector_count":     result.VectorCount,
+		"nlist":            req.NList,
+		"inertia":          result.Inertia,
+		"training_time_ms": result.TrainingTimeMs,
+		"message":          result.Message,
+	}, nil
+}
+
+// GetTrainingStatus returns the training results of an index and its latest training task
+// GetTrainingStatus 返回索引的训练结果及最近一次训练任务
+func (t *TrainingService) GetTrainingStatus(indexName string) (map[string]interface{}, error) {
+	metadata, err := t.metadataService.GetIndexMetadataByName(indexName)
+	if err != nil {
+		return nil, err
+	}
+
+	status := map[string]interface{}{
+		"index_name":            metadata.IndexName,
+		"status":                metadata.Status,
+		"nlist":                 metadata.IVFParams.NList,
+		"centroid_count":        metadata.CentroidCount,
+		"training_vector_count": metadata.TrainingVectorCount,
+		"training_time_ms":      metadata.TrainingTimeMs,
+		"training_inertia":      metadata.TrainingInertia,
+		"trained_at":            metadata.TrainedAt,
+	}
+
+	tasks, err := t.taskService.ListTasks(metadata.IndexName)
+	if err == nil {
+		for _, task := range tasks {
+			if task.Type == "train" {
+				status["latest_task"] = task
+				break
+			}
+		}
+	}
+
+	return status, nil
+}
@@ -0,0 +1,47 @@
+package service
+
+import (
+	"context"
+
+	"es-serverless-manager/internal/model"
+)
+
+// VectorStore is the set of vector index operations shared by the Elasticsearch
+// backend and the embedded in-process store, including the aliases, point-in-time
+// paging, settings and reindex tasks used by export, reindex and benchmarks.
+// VectorStore Elasticsearch 后端与内嵌进程内存储共用的向量索引操作集合，
+// 包括导出、重建和基准测试使用的别名、point-in-time 翻页、索引设置与重建任务
+type VectorStore interface {
+	CreateVectorIndex(indexName string, mapping model.VectorIndexMapping) error
+	DeleteIndex(indexName string) error
+	ListIndexes() ([]string, error)
+	IndexDocument(indexName, docID string, document map[string]interface{}) (*model.DocumentResult, error)
+	BulkIndex(indexName string, docs []model.BulkDocument) (*model.BulkResponse, error)
+	GetDocument(indexName, docID string) (map[string]interface{}, error)
+	UpdateDocument(indexName, docID string, fields map[string]interface{}) (*model.DocumentResult, error)
+	DeleteDocument(indexName, docID string) (*model.DocumentResult, error)
+	Search(indexName string, query map[string]interface{}) (map[string]interface{}, error)
+	BatchSearch(indexName string, req model.BatchSearchRequest) *model.BatchSearchResponse
+	Count(indexName string, query map[string]interface{}) (int64, error)
+	TrainIVFIndex(ctx context.Context, indexName string, params map[string]interface{}) (*model.TrainResult, error)
+	GetIndexStats(indexName string) (map[string]interface{}, error)
+	GetIndexUsage(indexName string) (docCount int64, storageBytes int64, err error)
+	Refresh(indexName string) error
+	UpdateIndexSettings(indexName string, settings map[string]interface{}) error
+
+	GetAliasIndices(alias string) ([]string, error)
+	UpdateAliases(actions []map[string]interface{}) error
+
+	OpenPointInTime(indexName, keepAlive string) (string, error)
+	ClosePointInTime(pitID string) error
+	SearchPointInTime(body map[string]interface{}) (map[string]interface{}, error)
+
+	Reindex(source, dest string, script map[string]interface{}) (string, error)
+	GetTask(taskID string) (map[string]interface{}, error)
+	CancelTask(taskID string) error
+}
+
+var (
+	_ VectorStore = (*ESService)(nil)
+	_ VectorStore = (*MemoryStore)(nil)
+)
@@ -1,9 +1,15 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"log"
+	"net/http"
 	"os"
+	"os/signal"
+	"strconv"
+	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"gorm.io/driver/postgres"
@@ -42,7 +48,9 @@ func main() {
 		dbHost, dbUser, dbPassword, dbName, dbPort)
 
 	// 连接数据库
-	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{})
+	// TranslateError maps unique violations to gorm.ErrDuplicatedKey
+	// TranslateError 将唯一约束冲突转换为 gorm.ErrDuplicatedKey
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{TranslateError: true})
 	if err != nil {
 		// 如果连接失败，记录严重错误并退出，因为 MetadataService 强依赖数据库
 		log.Fatalf("Failed to connect to database: %v", err)
@@ -56,6 +64,22 @@ func main() {
 		&model.TenantQuota{},
 		&model.DeploymentStatus{},
 		&model.Metrics{},
+		&model.PodMetrics{},
+		&model.MetricsRollup5m{},
+		&model.MetricsRollup1h{},
+		&model.AlertRule{},
+		&model.AlertChannel{},
+		&model.Alert{},
+		&model.AlertSilence{},
+		&model.AutoscalerConfig{},
+		&model.ScalingPolicy{},
+		&model.AutoscalerDecision{},
+		&model.ScalingSchedule{},
+		&model.LoadForecast{},
+		&model.ScaleEvent{},
+		&model.OperationTask{},
+		&model.IndexAlias{},
+		&model.BenchmarkRun{},
 	)
 	if err != nil {
 		log.Fatalf("Failed to auto migrate database: %v", err)
@@ -65,18 +89,58 @@ func main() {
 	// 初始化核心服务：元数据服务
 	metadataService := service.NewMetadataService(db)
 
+	// Prometheus Exporter
+	// Prometheus 指标导出器：记录管理器内部指标并转出租户集群指标
+	prometheusExporter := service.NewPrometheusExporter()
+
 	// Terraform Manager (assuming templates are in ./terraform/tenants or similar)
 	// Terraform 管理器初始化，确保目录存在
 	terraformDir := "./terraform/tenants"
 	if err := os.MkdirAll(terraformDir, 0755); err != nil {
 		log.Printf("Warning: Failed to create terraform directory: %v", err)
 	}
-	terraformManager := service.NewTerraformManager(terraformDir)
+	terraformManager := service.NewTerraformManager(terraformDir, prometheusExporter)
+
+	// Cluster Inspector
+	// Kubernetes 资源检查器：KUBE_INSPECTOR=fake 时使用内存实现，便于本地运行；
+	// 没有可用的 Kubernetes 配置时禁用依赖集群的检查，服务照常启动
+	var clusterInspector service.ClusterInspector
+	if os.Getenv("KUBE_INSPECTOR") == "fake" {
+		log.Println("Using fake Kubernetes cluster inspector")
+		clusterInspector = service.NewFakeClusterInspector()
+	} else if kubeInspector, err := service.NewKubeInspectorFromEnv(); err != nil {
+		log.Printf("Warning: Failed to configure Kubernetes client, cluster monitoring and autoscaling are disabled: %v", err)
+		clusterInspector = service.DisabledClusterInspector{}
+	} else {
+		clusterInspector = kubeInspector
+	}
 
 	// Background Services
 	// 初始化后台服务：监控服务和自动扩缩容服务
-	monitoringService := service.NewMonitoringService(metadataService)
-	autoscalerService := service.NewAutoscalerService(metadataService)
+	// TENANT_ES_URL_TEMPLATE 为租户 ES 地址模板，{namespace} 会被替换为命名空间
+	esStatsCollector := service.NewESStatsCollector(os.Getenv("TENANT_ES_URL_TEMPLATE"))
+	// MONITORING_INTERVAL / MONITORING_WORKERS / MONITORING_TENANT_TIMEOUT / MONITORING_JITTER 控制监控采集的间隔、并发度、单租户期限与随机延迟
+	monitoringConfig := service.DefaultMonitoringConfig()
+	monitoringConfig.Interval = envDuration("MONITORING_INTERVAL", monitoringConfig.Interval)
+	monitoringConfig.Workers = envInt("MONITORING_WORKERS", monitoringConfig.Workers)
+	monitoringConfig.TenantTimeout = envDuration("MONITORING_TENANT_TIMEOUT", monitoringConfig.TenantTimeout)
+	monitoringConfig.Jitter = envDuration("MONITORING_JITTER", monitoringConfig.Jitter)
+	monitoringService := service.NewMonitoringService(metadataService, clusterInspector, esStatsCollector, prometheusExporter, monitoringConfig)
+	// Cluster Scaler
+	// 集群扩缩容：手动与自动扩缩容共用的 Terraform 路径
+	clusterScaler := service.NewClusterScaler(metadataService, terraformManager)
+	// METRICS_RAW_RETENTION / METRICS_5M_RETENTION / METRICS_1H_RETENTION 为各粒度指标的保留时长（如 168h）
+	retention := service.DefaultMetricsRetention()
+	retention.Raw = envDuration("METRICS_RAW_RETENTION", retention.Raw)
+	retention.Rollup5m = envDuration("METRICS_5M_RETENTION", retention.Rollup5m)
+	retention.Rollup1h = envDuration("METRICS_1H_RETENTION", retention.Rollup1h)
+	metricsHistoryService := service.NewMetricsHistoryService(metadataService, retention)
+	// Load Forecaster
+	// 负载预测：从指标历史学习日/周季节性，供预测式扩缩容使用
+	loadForecaster := service.NewLoadForecaster(metadataService, metricsHistoryService)
+	autoscalerService := service.NewAutoscalerService(metadataService, clusterInspector, clusterScaler, loadForecaster, prometheusExporter)
+	// ALERT_EVALUATION_INTERVAL 为告警规则评估间隔（如 30s）
+	alertService := service.NewAlertService(metadataService, esStatsCollector, service.NewAlertNotifier(), envDuration("ALERT_EVALUATION_INTERVAL", 30*time.Second))
 
 	// ES Service
 	// ES 服务配置
@@ -86,6 +150,53 @@ func main() {
 	}
 	esService := service.NewESService(esURL)
 
+	// Vector Store
+	// 向量存储：VECTOR_STORE=memory 时使用内嵌的纯 Go 存储，无需 Elasticsearch
+	var vectorStore service.VectorStore = esService
+	if os.Getenv("VECTOR_STORE") == "memory" {
+		log.Println("Using embedded in-memory vector store")
+		vectorStore = service.NewMemoryStore()
+	}
+
+	// Embedding Service
+	// 文本向量编码服务：哈希编码器始终可用，配置 EMBEDDING_API_URL 时注册兼容 OpenAI 的编码器
+	embeddingService := service.NewEmbeddingService(envInt("EMBEDDING_BATCH_SIZE", 64), envInt("EMBEDDING_CACHE_SIZE", 10000))
+	hashingEncoder, err := service.NewHashingEncoder(envInt("HASHING_ENCODER_DIMENSION", 256))
+	if err != nil {
+		log.Fatalf("Failed to configure hashing encoder: %v", err)
+	}
+	embeddingService.Register(hashingEncoder)
+	if apiURL := os.Getenv("EMBEDDING_API_URL"); apiURL != "" {
+		embeddingModel := os.Getenv("EMBEDDING_MODEL")
+		if embeddingModel == "" {
+			embeddingModel = "text-embedding-3-small"
+		}
+		// EMBEDDING_DIMENSION defaults to the native dimension of the model
+		// EMBEDDING_DIMENSION 默认为模型的原生维度
+		openAIEncoder, err := service.NewOpenAIEncoder("openai", apiURL, os.Getenv("EMBEDDING_API_KEY"), embeddingModel, envInt("EMBEDDING_DIMENSION", 0))
+		if err != nil {
+			log.Fatalf("Failed to configure embedding encoder: %v", err)
+		}
+		embeddingService.Register(openAIEncoder)
+	}
+
+	// Task Service
+	// 后台任务服务
+	taskService := service.NewTaskService(metadataService, vectorStore)
+
+	// Export Service
+	// 导出服务：导出文件目录从环境变量读取
+	exportDir := os.Getenv("EXPORT_DIR")
+	if exportDir == "" {
+		exportDir = "./exports"
+	}
+	exportService := service.NewExportService(vectorStore, taskService, exportDir)
+	reindexService := service.NewReindexService(vectorStore, metadataService, taskService)
+	aliasService := service.NewAliasService(vectorStore, metadataService)
+	trainingService := service.NewTrainingService(vectorStore, metadataService, taskService)
+	benchmarkService := service.NewBenchmarkService(vectorStore, metadataService, taskService, exportService)
+	capacityService := service.NewCapacityService()
+
 	// Start Background Services
 	// 启动后台服务
 	log.Println("Starting monitoring service...")
@@ -94,19 +205,43 @@ func main() {
 	log.Println("Starting autoscaler service...")
 	autoscalerService.Start()
 
-	// Ensure clean shutdown of background services
-	// 注册延迟关闭函数，确保服务优雅停止
-	defer func() {
+	log.Println("Starting metrics rollup service...")
+	metricsHistoryService.Start()
+
+	log.Println("Starting alert evaluation service...")
+	alertService.Start()
+
+	// stopServices stops the background services, waiting for their in-flight work; it is
+	// called explicitly because log.Fatalf and os.Exit skip deferred calls
+	// stopServices 停止后台服务并等待进行中的工作完成；由于 log.Fatalf 与 os.Exit 会跳过 defer，需显式调用
+	stopServices := func() {
 		log.Println("Stopping monitoring service...")
 		monitoringService.Stop()
 		log.Println("Stopping autoscaler service...")
 		autoscalerService.Stop()
-	}()
+		log.Println("Stopping metrics rollup service...")
+		metricsHistoryService.Stop()
+		log.Println("Stopping alert evaluation service...")
+		alertService.Stop()
+		log.Println("Waiting for running cluster scales...")
+		clusterScaler.Wait()
+	}
 
 	// Initialize Handlers
 	// 初始化 HTTP 处理函数
-	clusterHandler := handler.NewClusterHandler(metadataService, terraformManager)
-	vectorHandler := handler.NewVectorHandler(esService)
+	clusterHandler := handler.NewClusterHandler(metadataService, terraformManager, capacityService, clusterInspector, clusterScaler)
+	vectorHandler := handler.NewVectorHandler(vectorStore, metadataService, taskService, exportService, embeddingService)
+	taskHandler := handler.NewTaskHandler(taskService)
+	reindexHandler := handler.NewReindexHandler(reindexService)
+	aliasHandler := handler.NewAliasHandler(aliasService)
+	trainingHandler := handler.NewTrainingHandler(trainingService)
+	embeddingHandler := handler.NewEmbeddingHandler(embeddingService)
+	capacityHandler := handler.NewCapacityHandler(capacityService)
+	benchmarkHandler := handler.NewBenchmarkHandler(benchmarkService)
+	metricsHandler := handler.NewMetricsHandler(metricsHistoryService)
+	prometheusHandler := handler.NewPrometheusHandler(prometheusExporter)
+	alertHandler := handler.NewAlertHandler(alertService)
+	autoscalerHandler := handler.NewAutoscalerHandler(autoscalerService)
 
 	// Setup Router
 	// 设置 Gin 路由
@@ -118,7 +253,7 @@ func main() {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Content-Length, Accept-Encoding, X-CSRF-Token, Authorization, accept, origin, Cache-Control, X-Requested-With")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, DELETE")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "POST, OPTIONS, GET, PUT, PATCH, DELETE")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -127,19 +262,30 @@ func main() {
 
 		c.Next()
 	})
+	r.Use(prometheusHandler.Middleware())
 
 	// Health Check
 	// 健康检查接口
 	r.GET("/health", handler.HandleHealth)
 
+	// Prometheus Metrics
+	// Prometheus 指标抓取接口
+	r.GET("/metrics", prometheusHandler.Metrics)
+
 	// Cluster Routes
 	// 集群管理相关路由
 	clusters := r.Group("/clusters")
 	{
-		clusters.POST("", clusterHandler.CreateCluster)      // 创建集群
-		clusters.GET("", clusterHandler.ListClusters)        // 获取集群列表
-		clusters.DELETE("", clusterHandler.DeleteCluster)    // 删除集群
-		clusters.POST("/scale", clusterHandler.ScaleCluster) // 扩缩容集群
+		clusters.POST("", clusterHandler.CreateCluster)                                         // 创建集群
+		clusters.GET("", clusterHandler.ListClusters)                                           // 获取集群列表
+		clusters.DELETE("", clusterHandler.DeleteCluster)                                       // 删除集群
+		clusters.POST("/scale", clusterHandler.ScaleCluster)                                    // 扩缩容集群
+		clusters.GET("/:namespace", clusterHandler.GetCluster)                                  // 获取集群详情
+		clusters.GET("/:namespace/metrics", metricsHandler.GetMetricsHistory)                   // 获取集群指标历史
+		clusters.GET("/:namespace/autoscaler/decisions", autoscalerHandler.ListDecisions)       // 获取自动扩缩容决策
+		clusters.GET("/:namespace/autoscaler/schedule", autoscalerHandler.ListScheduledChanges) // 获取即将到来的定时变更
+		clusters.GET("/:namespace/autoscaler/forecast", autoscalerHandler.GetForecast)          // 获取负载预测
+		clusters.GET("/:namespace/scale-events", clusterHandler.ListScaleEvents)                // 获取扩缩容审计记录
 	}
 
 	// Vector Routes
@@ -150,11 +296,100 @@ func main() {
 		vectors.GET("", vectorHandler.ListVectorIndexes)    // 获取索引列表
 		vectors.DELETE("", vectorHandler.DeleteVectorIndex) // 删除索引
 
+		// Alias management
+		// 索引别名管理
+		vectors.POST("/aliases", aliasHandler.CreateAlias)          // 创建别名
+		vectors.GET("/aliases", aliasHandler.ListAliases)           // 获取别名列表
+		vectors.GET("/aliases/:alias", aliasHandler.GetAlias)       // 获取别名详情
+		vectors.PUT("/aliases/:alias", aliasHandler.UpdateAlias)    // 更新别名
+		vectors.DELETE("/aliases/:alias", aliasHandler.DeleteAlias) // 删除别名
+
 		// Operations on specific index
 		// 特定索引的操作
-		vectors.POST("/:index_name/doc", vectorHandler.IndexDocument)  // 插入文档
-		vectors.POST("/:index_name/search", vectorHandler.Search)      // 搜索
-		vectors.GET("/:index_name/stats", vectorHandler.GetIndexStats) // 获取统计信息
+		vectors.POST("/:index_name/doc", vectorHandler.IndexDocument)                       // 插入文档
+		vectors.POST("/:index_name/bulk", vectorHandler.BulkIndex)                          // 批量写入文档
+		vectors.GET("/:index_name/doc/:doc_id", vectorHandler.GetDocument)                  // 获取文档
+		vectors.PATCH("/:index_name/doc/:doc_id", vectorHandler.UpdateDocument)             // 部分更新文档
+		vectors.DELETE("/:index_name/doc/:doc_id", vectorHandler.DeleteDocument)            // 删除文档
+		vectors.POST("/:index_name/delete_by_query", vectorHandler.DeleteByQuery)           // 按查询删除文档
+		vectors.POST("/:index_name/search", vectorHandler.Search)                           // 搜索
+		vectors.POST("/:index_name/search/batch", vectorHandler.BatchSearch)                // 批量搜索
+		vectors.POST("/:index_name/export", vectorHandler.ExportIndex)                      // 流式导出索引
+		vectors.POST("/:index_name/export/jobs", vectorHandler.StartExportJob)              // 导出索引到服务器文件
+		vectors.POST("/:index_name/reindex", reindexHandler.ReindexVectorIndex)             // 无停机重建索引
+		vectors.POST("/:index_name/train", trainingHandler.TrainIndex)                      // 训练 IVF 索引
+		vectors.GET("/:index_name/train", trainingHandler.GetTrainingStatus)                // 获取训练状态
+		vectors.POST("/:index_name/benchmarks", benchmarkHandler.StartBenchmark)            // 启动基准测试
+		vectors.GET("/:index_name/benchmarks", benchmarkHandler.ListBenchmarks)             // 列出基准测试记录
+		vectors.GET("/:index_name/benchmarks/:benchmark_id", benchmarkHandler.GetBenchmark) // 获取基准测试记录
+		vectors.GET("/:index_name/stats", vectorHandler.GetIndexStats)                      // 获取统计信息
+	}
+
+	// Task Routes
+	// 后台任务相关路由
+	tasks := r.Group("/tasks")
+	{
+		tasks.GET("", taskHandler.ListTasks)                   // 获取任务列表
+		tasks.GET("/:task_id", taskHandler.GetTask)            // 获取任务详情
+		tasks.POST("/:task_id/cancel", taskHandler.CancelTask) // 取消任务
+	}
+
+	// Embedding Routes
+	// 文本向量编码相关路由
+	embeddings := r.Group("/embeddings")
+	{
+		embeddings.GET("/encoders", embeddingHandler.ListEncoders) // 列出编码器
+		embeddings.POST("", embeddingHandler.Embed)                // 编码文本
+	}
+
+	// Capacity Routes
+	// 容量规划相关路由
+	capacity := r.Group("/capacity")
+	{
+		capacity.POST("/estimate", capacityHandler.Estimate) // 估算资源
+	}
+
+	// Alert Routes
+	// 告警规则、通知渠道与静默相关路由
+	alerts := r.Group("/alerts")
+	{
+		alerts.GET("", alertHandler.ListAlerts) // 获取告警列表
+
+		alerts.POST("/rules", alertHandler.CreateRule)       // 创建告警规则
+		alerts.GET("/rules", alertHandler.ListRules)         // 获取告警规则列表
+		alerts.GET("/rules/:id", alertHandler.GetRule)       // 获取告警规则
+		alerts.PUT("/rules/:id", alertHandler.UpdateRule)    // 更新告警规则
+		alerts.DELETE("/rules/:id", alertHandler.DeleteRule) // 删除告警规则
+
+		alerts.POST("/channels", alertHandler.CreateChannel)        // 创建通知渠道
+		alerts.GET("/channels", alertHandler.ListChannels)          // 获取通知渠道列表
+		alerts.PUT("/channels/:id", alertHandler.UpdateChannel)     // 更新通知渠道
+		alerts.DELETE("/channels/:id", alertHandler.DeleteChannel)  // 删除通知渠道
+		alerts.POST("/channels/:id/test", alertHandler.TestChannel) // 发送测试通知
+
+		alerts.POST("/silences", alertHandler.CreateSilence)       // 创建静默
+		alerts.GET("/silences", alertHandler.ListSilences)         // 获取静默列表
+		alerts.DELETE("/silences/:id", alertHandler.ExpireSilence) // 结束静默
+	}
+
+	// Autoscaler Routes
+	// 自动扩缩容配置与策略相关路由
+	autoscaler := r.Group("/autoscaler")
+	{
+		autoscaler.GET("/config", autoscalerHandler.GetConfig)    // 获取全局配置
+		autoscaler.PUT("/config", autoscalerHandler.UpdateConfig) // 更新全局配置
+
+		autoscaler.POST("/policies", autoscalerHandler.CreatePolicy)       // 创建扩缩容策略
+		autoscaler.GET("/policies", autoscalerHandler.ListPolicies)        // 获取扩缩容策略列表
+		autoscaler.GET("/policies/:id", autoscalerHandler.GetPolicy)       // 获取扩缩容策略
+		autoscaler.PUT("/policies/:id", autoscalerHandler.UpdatePolicy)    // 更新扩缩容策略
+		autoscaler.DELETE("/policies/:id", autoscalerHandler.DeletePolicy) // 删除扩缩容策略
+
+		autoscaler.POST("/schedules", autoscalerHandler.CreateSchedule)       // 创建定时扩缩容计划
+		autoscaler.GET("/schedules", autoscalerHandler.ListSchedules)         // 获取定时扩缩容计划列表
+		autoscaler.GET("/schedules/:id", autoscalerHandler.GetSchedule)       // 获取定时扩缩容计划
+		autoscaler.PUT("/schedules/:id", autoscalerHandler.UpdateSchedule)    // 更新定时扩缩容计划
+		autoscaler.DELETE("/schedules/:id", autoscalerHandler.DeleteSchedule) // 删除定时扩缩容计划
 	}
 
 	// Start Server
@@ -163,8 +398,58 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
-	log.Printf("Starting server on port %s", port)
-	if err := r.Run(":" + port); err != nil {
-		log.Fatalf("Failed to run server: %v", err)
+	srv := &http.Server{
+		Addr:    ":" + port,
+		Handler: r,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		log.Printf("Starting server on port %s", port)
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serveErr:
+		log.Printf("Failed to run server: %v", err)
+		exitCode = 1
+	case <-ctx.Done():
+		// Stop accepting requests and let the in-flight ones finish
+		// 停止接收新请求并等待进行中的请求完成
+		log.Println("Shutting down server...")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), envDuration("SHUTDOWN_TIMEOUT", 30*time.Second))
+		if err := srv.Shutdown(shutdownCtx); err != nil {
+			log.Printf("Error shutting down server: %v", err)
+		}
+		cancel()
+	}
+
+	stopServices()
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
+}
+
+// envInt reads an integer environment variable, falling back to def when unset or invalid
+// envInt 读取整数环境变量，未设置或无效时使用默认值
+func envInt(name string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(name))
+	if err != nil {
+		return def
+	}
+	return value
+}
+
+// envDuration reads a duration environment variable such as "168h", falling back to def when unset or invalid
+// envDuration 读取时长环境变量（如 "168h"），未设置或无效时使用默认值
+func envDuration(name string, def time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(name))
+	if err != nil || value <= 0 {
+		return def
 	}
+	return value
 }